@@ -2,19 +2,22 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/game-apps/internal/api/http"
+	apihttp "github.com/game-apps/internal/api/http"
 	"github.com/game-apps/internal/api/websocket"
 	"github.com/game-apps/internal/config"
 	"github.com/game-apps/internal/middleware"
+	"github.com/game-apps/internal/notify"
 	"github.com/game-apps/internal/repository/mysql"
 	"github.com/game-apps/internal/repository/postgres"
 	"github.com/game-apps/internal/repository/redis"
@@ -26,12 +29,20 @@ import (
 	"github.com/game-apps/pkg/cache"
 	"github.com/game-apps/pkg/database"
 	"github.com/game-apps/pkg/logger"
+	"github.com/game-apps/pkg/origins"
+	"github.com/game-apps/pkg/ratelimit"
+	"github.com/game-apps/pkg/retry"
+	"github.com/game-apps/pkg/worker"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 func main() {
+	// -migrate 只执行数据库迁移后退出，用于关闭 auto_migrate 的部署单独执行迁移
+	migrateOnly := flag.Bool("migrate", false, "执行数据库迁移后退出")
+	flag.Parse()
+
 	// 加载配置
 	cfg, err := config.Load("")
 	if err != nil {
@@ -58,64 +69,91 @@ func main() {
 	defer logger.Sync()
 
 	log := logger.Get()
-	log.Info("应用启动", zap.Any("config", cfg))
+	log.Info("应用启动", zap.Any("config", cfg.Redacted()))
+
+	// 依赖服务可能比本服务晚就绪（如容器编排中同时启动），连接失败时按退避策略重试
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), cfg.Startup.Timeout)
+	defer cancelStartup()
+	startupPolicy := retry.Policy{
+		MaxAttempts:    cfg.Startup.MaxAttempts,
+		InitialBackoff: cfg.Startup.InitialBackoff,
+		MaxBackoff:     cfg.Startup.MaxBackoff,
+	}
+	logRetry := func(target string) func(attempt int, err error, wait time.Duration) {
+		return func(attempt int, err error, wait time.Duration) {
+			log.Warn("连接"+target+"失败，稍后重试",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", startupPolicy.MaxAttempts),
+				zap.Duration("wait", wait),
+				zap.Error(err),
+			)
+		}
+	}
 
 	// 连接数据库
 	var db *gorm.DB
-	if cfg.Database.Driver == "mysql" {
-		db, err = database.Connect(database.Config{
-			Driver:          cfg.Database.Driver,
-			MySQLConfig: database.MySQLConfig{
-				Host:      cfg.Database.MySQL.Host,
-				Port:      cfg.Database.MySQL.Port,
-				User:      cfg.Database.MySQL.User,
-				Password:  cfg.Database.MySQL.Password,
-				DBName:    cfg.Database.MySQL.DBName,
-				Charset:   cfg.Database.MySQL.Charset,
-				ParseTime: cfg.Database.MySQL.ParseTime,
-				Loc:       cfg.Database.MySQL.Loc,
-			},
-			MaxOpenConns:    cfg.Database.MySQL.MaxOpenConns,
-			MaxIdleConns:    cfg.Database.MySQL.MaxIdleConns,
-			ConnMaxLifetime: cfg.Database.MySQL.ConnMaxLifetime,
-		})
-	} else {
-		db, err = database.Connect(database.Config{
-			Driver:          cfg.Database.Driver,
-			PostgresConfig: database.PostgresConfig{
-				Host:     cfg.Database.Postgres.Host,
-				Port:     cfg.Database.Postgres.Port,
-				User:     cfg.Database.Postgres.User,
-				Password: cfg.Database.Postgres.Password,
-				DBName:   cfg.Database.Postgres.DBName,
-				SSLMode:  cfg.Database.Postgres.SSLMode,
-			},
-			MaxOpenConns:    cfg.Database.Postgres.MaxOpenConns,
-			MaxIdleConns:    cfg.Database.Postgres.MaxIdleConns,
-			ConnMaxLifetime: cfg.Database.Postgres.ConnMaxLifetime,
-		})
-	}
+	err = retry.Do(startupCtx, startupPolicy, func(ctx context.Context) error {
+		var err error
+		if cfg.Database.Driver == "mysql" {
+			db, err = database.Connect(database.Config{
+				Driver:          cfg.Database.Driver,
+				MySQLConfig: database.MySQLConfig{
+					Host:      cfg.Database.MySQL.Host,
+					Port:      cfg.Database.MySQL.Port,
+					User:      cfg.Database.MySQL.User,
+					Password:  cfg.Database.MySQL.Password,
+					DBName:    cfg.Database.MySQL.DBName,
+					Charset:   cfg.Database.MySQL.Charset,
+					ParseTime: cfg.Database.MySQL.ParseTime,
+					Loc:       cfg.Database.MySQL.Loc,
+				},
+				MaxOpenConns:    cfg.Database.MySQL.MaxOpenConns,
+				MaxIdleConns:    cfg.Database.MySQL.MaxIdleConns,
+				ConnMaxLifetime: cfg.Database.MySQL.ConnMaxLifetime,
+			})
+		} else {
+			db, err = database.Connect(database.Config{
+				Driver:          cfg.Database.Driver,
+				PostgresConfig: database.PostgresConfig{
+					Host:     cfg.Database.Postgres.Host,
+					Port:     cfg.Database.Postgres.Port,
+					User:     cfg.Database.Postgres.User,
+					Password: cfg.Database.Postgres.Password,
+					DBName:   cfg.Database.Postgres.DBName,
+					SSLMode:  cfg.Database.Postgres.SSLMode,
+				},
+				MaxOpenConns:    cfg.Database.Postgres.MaxOpenConns,
+				MaxIdleConns:    cfg.Database.Postgres.MaxIdleConns,
+				ConnMaxLifetime: cfg.Database.Postgres.ConnMaxLifetime,
+			})
+		}
+		return err
+	}, logRetry("数据库"))
 	if err != nil {
 		log.Fatal("连接数据库失败", zap.Error(err))
 	}
 	log.Info("数据库连接成功")
 
-	// 自动迁移
-	if err := autoMigrate(db); err != nil {
-		log.Fatal("数据库迁移失败", zap.Error(err))
+	// 自动迁移，持有数据库咨询锁，多个实例同时启动时只有一个实例执行迁移，其他实例等待
+	if *migrateOnly || cfg.Database.AutoMigrate {
+		if err := database.WithMigrationLock(context.Background(), db, cfg.Database.MigrateLockTimeout, autoMigrate); err != nil {
+			log.Fatal("数据库迁移失败", zap.Error(err))
+		}
+		log.Info("数据库迁移完成")
+	} else {
+		log.Info("未开启自动迁移，跳过数据库迁移")
+	}
+	if *migrateOnly {
+		return
 	}
 
 	// 连接 Redis
-	redisClient, err := cache.NewClient(
-		cfg.Redis.Addr,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-		cfg.Redis.PoolSize,
-		cfg.Redis.MinIdleConns,
-		cfg.Redis.DialTimeout,
-		cfg.Redis.ReadTimeout,
-		cfg.Redis.WriteTimeout,
-	)
+	var redisClient *cache.Client
+	err = retry.Do(startupCtx, startupPolicy, func(ctx context.Context) error {
+		var err error
+		redisClient, err = cache.NewClient(redisOptions(cfg.Redis))
+		return err
+	}, logRetry("Redis"))
 	if err != nil {
 		log.Fatal("连接 Redis 失败", zap.Error(err))
 	}
@@ -125,21 +163,30 @@ func main() {
 	var userRepo user.UserRepository
 	var userProfileRepo user.UserProfileRepository
 	var userStatsRepo user.UserStatsRepository
+	var usernameHistoryRepo user.UsernameHistoryRepository
+	var gameHistoryRepo user.GameHistoryRepository
 	var roomRepo game.RoomRepository
 	var roomPlayerRepo game.RoomPlayerRepository
+	var eventRepo game.EventRepository
 
 	if cfg.Database.Driver == "mysql" {
 		userRepo = mysql.NewUserRepository(db)
 		userProfileRepo = mysql.NewUserProfileRepository(db)
 		userStatsRepo = mysql.NewUserStatsRepository(db)
+		usernameHistoryRepo = mysql.NewUsernameHistoryRepository(db)
+		gameHistoryRepo = mysql.NewGameHistoryRepository(db)
 		roomRepo = mysql.NewRoomRepository(db)
 		roomPlayerRepo = mysql.NewRoomPlayerRepository(db)
+		eventRepo = mysql.NewEventRepository(db)
 	} else {
 		userRepo = postgres.NewUserRepository(db)
 		userProfileRepo = postgres.NewUserProfileRepository(db)
 		userStatsRepo = postgres.NewUserStatsRepository(db)
+		usernameHistoryRepo = postgres.NewUsernameHistoryRepository(db)
+		gameHistoryRepo = postgres.NewGameHistoryRepository(db)
 		roomRepo = postgres.NewRoomRepository(db)
 		roomPlayerRepo = postgres.NewRoomPlayerRepository(db)
+		eventRepo = postgres.NewEventRepository(db)
 	}
 
 	redisRepo := redis.NewRepository(redisClient)
@@ -147,59 +194,215 @@ func main() {
 	redisRoomRepo := redis.NewRoomRepository(redisRepo)
 	onlineUserRepo := redis.NewOnlineUserRepository(redisRepo)
 	lockRepo := redis.NewLockRepository(redisRepo)
+	// 本实例的 WebSocket 连接记录，启动时清理上次运行和已停止实例留下的记录，退出时删除本实例的记录
+	connectionRepo := redis.NewConnectionRepository(redisRepo, redis.NewInstanceID(), game.ConnectionTTL)
+	if err := connectionRepo.ResetConnections(context.Background()); err != nil {
+		log.Warn("清理连接记录失败", zap.Error(err))
+	}
 
 	// 初始化服务
-	jwtService := utils.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.ExpirationHours,
-		cfg.JWT.RefreshExpirationHours,
-	)
+	var jwtService *utils.JWTService
+	if cfg.JWT.Algorithm == "HS256" {
+		jwtService = utils.NewJWTService(
+			cfg.JWT.Secret,
+			cfg.JWT.ExpirationHours,
+			cfg.JWT.RefreshExpirationHours,
+			cfg.JWT.Issuer,
+			cfg.JWT.Audience,
+			cfg.JWT.Leeway,
+		)
+	} else {
+		jwtService, err = utils.NewAsymmetricJWTService(
+			cfg.JWT.Algorithm,
+			cfg.JWT.PrivateKeyPath,
+			cfg.JWT.PublicKeyPath,
+			cfg.JWT.KeyID,
+			cfg.JWT.ExpirationHours,
+			cfg.JWT.RefreshExpirationHours,
+			cfg.JWT.Issuer,
+			cfg.JWT.Audience,
+			cfg.JWT.Leeway,
+		)
+		if err != nil {
+			log.Fatal("初始化 JWT 服务失败", zap.Error(err))
+		}
+	}
 
-	authService := user.NewAuthService(
+	// 跨域来源白名单，HTTP 与 WebSocket 共用
+	allowedOrigins, err := origins.NewMatcher(cfg.Server.AllowedOrigins)
+	if err != nil {
+		log.Fatal("解析跨域来源白名单失败", zap.Error(err))
+	}
+
+	// 初始化 WebSocket Hub
+	wsHub := websocket.NewHub(log, websocket.HubOptions{
+		WriteTimeout:   cfg.WebSocket.WriteTimeout,
+		SendBufferSize: cfg.WebSocket.SendBufferSize,
+		OverflowPolicy: cfg.WebSocket.OverflowPolicy,
+		MaxOverflows:   cfg.WebSocket.MaxOverflows,
+
+		ReadBufferSize:  cfg.WebSocket.ReadBufferSize,
+		WriteBufferSize: cfg.WebSocket.WriteBufferSize,
+		MaxMessageSize:  cfg.WebSocket.MaxMessageSize,
+		PingInterval:    cfg.WebSocket.PingInterval,
+		PongWait:        cfg.WebSocket.PongWait,
+
+		MessageRate:       cfg.WebSocket.MessageRate,
+		MessageBurst:      cfg.WebSocket.MessageBurst,
+		MaxRateViolations: cfg.WebSocket.MaxRateViolations,
+
+		StateCoalesceInterval:   cfg.WebSocket.StateCoalesceInterval,
+		StateCoalesceByGameType: cfg.WebSocket.StateCoalesceByGameType,
+
+		CloseOnTokenExpiry: cfg.WebSocket.CloseOnTokenExpiry,
+		AllowReauth:        cfg.WebSocket.AllowReauth,
+
+
+		Origins: allowedOrigins,
+
+		DuplicatePolicy: cfg.WebSocket.DuplicatePolicy,
+	})
+	go wsHub.Run()
+
+	profileService := user.NewProfileService(
 		userRepo,
 		userProfileRepo,
-		userStatsRepo,
-		sessionRepo,
-		jwtService,
+		usernameHistoryRepo,
 		log,
 	)
 
-	profileService := user.NewProfileService(
+	statsService := user.NewStatsService(
 		userRepo,
-		userProfileRepo,
+		userStatsRepo,
+		gameHistoryRepo,
 		log,
+		redisClient,
 	)
 
-	statsService := user.NewStatsService(
+	userService := user.NewUserService(
+		userRepo,
 		userStatsRepo,
+		onlineUserRepo,
 		log,
 	)
 
+	roomTypeDefaults := make(map[string]game.RoomDefaults, len(cfg.Game.Room.Types))
+	for gameType, typeCfg := range cfg.Game.Room.Types {
+		roomTypeDefaults[gameType] = game.RoomDefaults{
+			MaxPlayers:     typeCfg.MaxPlayers,
+			MinPlayers:     typeCfg.MinPlayers,
+			DefaultTimeout: typeCfg.DefaultTimeout,
+		}
+	}
+
+	turnPolicy := game.TurnPolicy{
+		TimeLimit:       cfg.Game.Room.TurnTimeLimit,
+		TimeLimitByType: make(map[string]time.Duration, len(cfg.Game.Room.Types)),
+	}
+	for gameType, typeCfg := range cfg.Game.Room.Types {
+		if typeCfg.TurnTimeLimit > 0 {
+			turnPolicy.TimeLimitByType[strings.ToLower(gameType)] = typeCfg.TurnTimeLimit
+		}
+	}
+
+	readyPolicy := game.ReadyPolicy{
+		OwnerAutoReady:  cfg.Game.Room.OwnerAutoReady,
+		RequireAllReady: cfg.Game.Room.RequireAllReady,
+	}
+	eventRetention := game.EventRetention{
+		MaxLength: cfg.Game.Events.MaxLength,
+		TTL:       cfg.Game.Events.TTL,
+	}
+	jsonLimits := utils.JSONLimits{
+		MaxDepth: cfg.Game.JSON.MaxDepth,
+		MaxBytes: cfg.Game.JSON.MaxBytes,
+	}
 	roomService := game.NewRoomService(
 		roomRepo,
 		roomPlayerRepo,
 		redisRoomRepo,
 		lockRepo,
+		wsHub,
 		log,
-		cfg.Game.Room.MaxPlayers,
-		cfg.Game.Room.DefaultTimeout,
+		game.RoomDefaults{
+			MaxPlayers:     cfg.Game.Room.MaxPlayers,
+			MinPlayers:     cfg.Game.Room.MinPlayers,
+			DefaultTimeout: cfg.Game.Room.DefaultTimeout,
+		},
+		roomTypeDefaults,
+		"game:events",
+		cfg.Game.Room.AllowMultiRoom,
+		readyPolicy,
+		wsHub,
+		eventRetention,
+		userRepo,
+		jsonLimits,
 	)
 
 	sessionService := game.NewSessionService(
 		sessionRepo,
 		onlineUserRepo,
+		userRepo,
+		wsHub,
 		log,
 		cfg.Game.Session.HeartbeatInterval,
 		cfg.Game.Session.Timeout,
+		cfg.Game.Session.TimeoutByRole,
+		cfg.Game.Session.Mode,
+		cfg.Game.Session.ConflictPolicy,
 	)
 
 	processService := game.NewProcessService(
 		roomRepo,
+		roomPlayerRepo,
+		eventRepo,
 		redisRoomRepo,
 		lockRepo,
+		wsHub,
 		log,
 		"game:events",
+		readyPolicy,
+		turnPolicy,
+		eventRetention,
+		jsonLimits,
+		game.AFKPolicy{GracePeriod: cfg.Game.Room.AFKGracePeriod},
+		connectionRepo,
 	)
+	// 断线玩家超过宽限期标记为暂离，重连后恢复
+	wsHub.SetConnectionListener(processService)
+
+	// 将 Redis 广播的游戏事件推送给本实例上的房间成员，并断开被踢下线用户在本实例上的连接
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+	go processService.ForwardEvents(eventsCtx, wsHub)
+
+	authService := user.NewAuthService(
+		userRepo,
+		userProfileRepo,
+		userStatsRepo,
+		sessionRepo,
+		onlineUserRepo,
+		jwtService,
+		processService,
+		roomService,
+		log,
+		utils.PasswordPolicy{
+			RequireClasses: cfg.Password.RequireClasses,
+			MinScore:       cfg.Password.MinScore,
+		},
+		sessionService,
+	)
+	if err := user.RegisterSessionMetrics(onlineUserRepo); err != nil {
+		log.Warn("注册会话指标失败", zap.Error(err))
+	}
+
+	// 启动后台任务
+	workers := worker.NewManager(log)
+	workers.Register(game.NewOutboxRelay(eventRepo, redisClient, log), game.OutboxRelayInterval)
+	workers.Register(game.NewTurnTimeoutChecker(processService), game.TurnTimeoutInterval)
+	workers.Register(game.NewConnectionHeartbeat(connectionRepo, wsHub), game.ConnectionHeartbeatInterval)
+	workers.Start(context.Background())
+	defer workers.Stop()
 
 	// 初始化管理服务
 	// 获取项目根目录（假设配置文件在项目根目录）
@@ -210,25 +413,74 @@ func main() {
 		configBasePath = filepath.Dir(filepath.Dir(wd))
 	}
 
-	configService := admin.NewConfigService(configBasePath)
-	adminUserService := admin.NewUserService(db, cfg.Database.Driver)
-	systemService := admin.NewSystemService(configBasePath)
+	var configStore admin.ConfigStore
+	if cfg.ConfigStore.Driver == "redis" {
+		configStore = admin.NewRedisConfigStore(redisClient)
+	} else {
+		configStore = admin.NewFileConfigStore(configBasePath)
+	}
 
-	// 初始化 HTTP 处理器
-	userHandler := http.NewUserHandler(authService, profileService, statsService)
-	gameHandler := http.NewGameHandler(roomService, sessionService, processService)
-	adminHandler := http.NewAdminHandler(configService, adminUserService, systemService, authService)
+	configService := admin.NewConfigService(configStore, log)
+	// Redis 连接参数变化时重建连接
+	configService.OnReload(func(old, updated *config.Config) {
+		if old == nil || old.Redis == updated.Redis {
+			return
+		}
+		err := redisClient.Reconnect(redisOptions(updated.Redis))
+		if err != nil {
+			log.Error("重新连接 Redis 失败，继续使用原连接", zap.Error(err))
+			return
+		}
+		log.Info("已使用新配置重新连接 Redis", zap.String("addr", updated.Redis.Addr))
+	})
+	systemService := admin.NewSystemService(configStore)
 
-	// 初始化 WebSocket Hub
-	wsHub := websocket.NewHub(log)
-	go wsHub.Run()
+	// 初始化通知渠道，已启用渠道的服务商无效时拒绝启动
+	systemConfig, err := systemService.GetSystemConfig(context.Background())
+	if err != nil {
+		log.Fatal("加载系统配置失败", zap.Error(err))
+	}
+	notifier, err := notify.New(systemConfig.Notification.NotifyConfig(), nil)
+	if err != nil {
+		log.Fatal("初始化通知渠道失败", zap.Error(err))
+	}
+	log.Info("通知渠道已初始化",
+		zap.Bool("email", notifier.Enabled(notify.ChannelEmail)),
+		zap.Bool("sms", notifier.Enabled(notify.ChannelSMS)),
+		zap.Bool("push", notifier.Enabled(notify.ChannelPush)),
+	)
+	adminUserService := admin.NewUserService(db, cfg.Database.Driver, jwtService, log, notifier)
+	roomStatsService := admin.NewRoomStatsService(db)
+	adminGameService := admin.NewGameService(roomRepo, processService, log)
+	announcementService := admin.NewAnnouncementService(roomPlayerRepo, wsHub, log)
+	adminCacheService := admin.NewCacheService(redisClient, log)
+
+	// 初始化 HTTP 处理器
+	userHandler := apihttp.NewUserHandler(authService, profileService, statsService, userService)
+	gameHandler := apihttp.NewGameHandler(roomService, sessionService, processService)
+	adminHandler := apihttp.NewAdminHandler(configService, adminUserService, systemService, roomStatsService, adminGameService, announcementService, authService, adminCacheService, statsService)
 
 	// 设置路由
 	router := gin.Default()
-	http.SetupRoutes(router, userHandler, gameHandler, adminHandler, jwtService, log)
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("设置可信代理失败", zap.Error(err))
+	}
+	rateLimiter := ratelimit.NewLimiter(redisClient, cfg.RateLimit.FailOpen)
+	rateLimiters := apihttp.RateLimiters{
+		Login:        middleware.RateLimitMiddleware(rateLimiter, "login", cfg.RateLimit.Login.Limit, cfg.RateLimit.Login.Window, log),
+		Register:     middleware.RateLimitMiddleware(rateLimiter, "register", cfg.RateLimit.Register.Limit, cfg.RateLimit.Register.Window, log),
+		Availability: middleware.RateLimitMiddleware(rateLimiter, "availability", cfg.RateLimit.Availability.Limit, cfg.RateLimit.Availability.Window, log),
+	}
+	apihttp.SetupRoutes(router, userHandler, gameHandler, adminHandler, jwtService, rateLimiters, workers, allowedOrigins, middleware.MetricsOptions{
+		StatusBuckets: cfg.Monitoring.MetricsStatusBuckets,
+	}, middleware.LoggingOptions{
+		SkipPaths:     cfg.Log.Request.SkipPaths,
+		DebugPaths:    cfg.Log.Request.DebugPaths,
+		SlowThreshold: cfg.Log.Request.SlowThreshold,
+	}, db, redisClient, log)
 
 	// WebSocket 路由
-	router.GET("/ws", websocket.HandleWebSocket(wsHub, jwtService, log))
+	router.GET("/ws", websocket.HandleWebSocket(wsHub, jwtService, authService, log))
 
 	// 创建 HTTP 服务器
 	httpServer := &http.Server{
@@ -270,6 +522,11 @@ func main() {
 	<-quit
 
 	log.Info("正在关闭服务器...")
+	workers.Stop()
+	wsHub.CloseAll(websocket.CloseReasonShutdown)
+	if err := connectionRepo.ResetConnections(context.Background()); err != nil {
+		log.Warn("清理连接记录失败", zap.Error(err))
+	}
 
 	// 优雅关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -286,13 +543,90 @@ func main() {
 
 // autoMigrate 自动迁移数据库
 func autoMigrate(db *gorm.DB) error {
+	if err := prepareRoomPlayerPositions(db); err != nil {
+		return fmt.Errorf("整理房间玩家位置失败: %w", err)
+	}
+
 	return db.AutoMigrate(
 		&model.User{},
 		&model.UserProfile{},
 		&model.UserStats{},
+		&model.UsernameHistory{},
 		&model.Room{},
 		&model.RoomPlayer{},
 		&model.Session{},
+		&model.OutboxEvent{},
+		&model.GameHistory{},
 	)
 }
 
+// redisOptions 将 Redis 配置转换为缓存客户端的连接参数
+func redisOptions(cfg config.RedisConfig) cache.Options {
+	return cache.Options{
+		Addr:            cfg.Addr,
+		Password:        cfg.Password,
+		DB:              cfg.DB,
+		PoolSize:        cfg.PoolSize,
+		MinIdleConns:    cfg.MinIdleConns,
+		MaxRetries:      cfg.MaxRetries,
+		DialTimeout:     cfg.DialTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		PoolTimeout:     cfg.PoolTimeout,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+		CommandTimeout:  cfg.CommandTimeout,
+	}
+}
+
+// roomPositionIndex 房间内位置唯一索引
+const roomPositionIndex = "idx_room_players_room_position"
+
+// prepareRoomPlayerPositions 创建位置唯一索引前整理历史数据：
+// 已离开的玩家和观战者位置置空，在场玩家的重复位置按加入顺序重新分配
+func prepareRoomPlayerPositions(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&model.RoomPlayer{}) || m.HasIndex(&model.RoomPlayer{}, roomPositionIndex) {
+		return nil
+	}
+	if err := m.AlterColumn(&model.RoomPlayer{}, "Position"); err != nil {
+		return err
+	}
+
+	inactive := db.Where("left_at IS NOT NULL")
+	if m.HasColumn(&model.RoomPlayer{}, "IsSpectator") {
+		inactive = inactive.Or("is_spectator = ?", true)
+	}
+	if err := db.Model(&model.RoomPlayer{}).Where(inactive).Update("position", nil).Error; err != nil {
+		return err
+	}
+
+	var roomIDs []uint
+	if err := db.Model(&model.RoomPlayer{}).
+		Where("position IS NOT NULL").
+		Group("room_id, position").
+		Having("COUNT(*) > 1").
+		Pluck("room_id", &roomIDs).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[uint]bool, len(roomIDs))
+	for _, roomID := range roomIDs {
+		if seen[roomID] {
+			continue
+		}
+		seen[roomID] = true
+
+		var players []*model.RoomPlayer
+		if err := db.Where("room_id = ? AND position IS NOT NULL", roomID).
+			Order("position ASC, joined_at ASC, id ASC").
+			Find(&players).Error; err != nil {
+			return err
+		}
+		for i, player := range players {
+			if err := db.Model(&model.RoomPlayer{}).Where("id = ?", player.ID).Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
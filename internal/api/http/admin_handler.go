@@ -2,6 +2,7 @@ package http
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/game-apps/internal/service/admin"
@@ -14,7 +15,12 @@ type AdminHandler struct {
 	configService  *admin.ConfigService
 	userService    *admin.UserService
 	systemService  *admin.SystemService
+	roomStatsService *admin.RoomStatsService
+	gameService    *admin.GameService
+	announcementService *admin.AnnouncementService
 	authService    *user.AuthService
+	cacheService   *admin.CacheService
+	statsService   *user.StatsService
 }
 
 // NewAdminHandler 创建管理处理器
@@ -22,23 +28,35 @@ func NewAdminHandler(
 	configService *admin.ConfigService,
 	userService *admin.UserService,
 	systemService *admin.SystemService,
+	roomStatsService *admin.RoomStatsService,
+	gameService *admin.GameService,
+	announcementService *admin.AnnouncementService,
 	authService *user.AuthService,
+	cacheService *admin.CacheService,
+	statsService *user.StatsService,
 ) *AdminHandler {
 	return &AdminHandler{
-		configService: configService,
-		userService:   userService,
-		systemService: systemService,
-		authService:   authService,
+		configService:    configService,
+		userService:      userService,
+		systemService:    systemService,
+		roomStatsService: roomStatsService,
+		gameService:      gameService,
+		announcementService: announcementService,
+		authService:      authService,
+		cacheService:     cacheService,
+		statsService:     statsService,
 	}
 }
 
-// AdminLogin 管理登录（复用用户登录逻辑）
+// AdminLogin 管理登录（复用用户登录逻辑），以 admin 受众登录，非管理员账号被拒绝
 func (h *AdminHandler) AdminLogin(c *gin.Context) {
 	var req user.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
+	req.ClientType = utils.ClientTypeAdmin
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
 
 	resp, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
@@ -61,7 +79,7 @@ func (h *AdminHandler) AdminLogin(c *gin.Context) {
 			"username": userInfo.Username,
 			"email":    userInfo.Email,
 			"nickname": userInfo.Nickname,
-			"role":     "admin", // TODO: 从数据库获取实际角色
+			"role":     userInfo.Role,
 			"status":   userInfo.Status,
 		},
 	})
@@ -88,6 +106,37 @@ func (h *AdminHandler) GetConfig(c *gin.Context) {
 	})
 }
 
+// GetEffectiveConfig 获取服务实际生效的配置
+func (h *AdminHandler) GetEffectiveConfig(c *gin.Context) {
+	service := c.Param("service")
+	if service == "" {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "服务类型不能为空"))
+		return
+	}
+
+	effective, err := h.configService.GetEffectiveConfig(c.Request.Context(), service)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, gin.H{
+		"service": service,
+		"config":  effective,
+	})
+}
+
+// GetFeatureFlags 获取当前生效的功能开关
+func (h *AdminHandler) GetFeatureFlags(c *gin.Context) {
+	flags, err := h.configService.GetFeatureFlags(c.Request.Context())
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, flags)
+}
+
 // UpdateConfig 更新服务配置
 func (h *AdminHandler) UpdateConfig(c *gin.Context) {
 	service := c.Param("service")
@@ -99,8 +148,7 @@ func (h *AdminHandler) UpdateConfig(c *gin.Context) {
 	var req struct {
 		Content string `json:"content" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -112,6 +160,42 @@ func (h *AdminHandler) UpdateConfig(c *gin.Context) {
 	Success(c, nil)
 }
 
+// ListConfigBackups 列出配置的备份版本
+func (h *AdminHandler) ListConfigBackups(c *gin.Context) {
+	service := c.Param("service")
+	if service == "" {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "服务类型不能为空"))
+		return
+	}
+
+	versions, err := h.configService.ListBackups(c.Request.Context(), service)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, versions)
+}
+
+// RestoreConfigBackup 从备份恢复配置，可通过 version 查询参数指定版本，默认恢复最新的备份
+func (h *AdminHandler) RestoreConfigBackup(c *gin.Context) {
+	service := c.Param("service")
+	if service == "" {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "服务类型不能为空"))
+		return
+	}
+
+	content, err := h.configService.RestoreBackup(c.Request.Context(), GetUserID(c), service, c.Query("version"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, gin.H{
+		"content": content,
+	})
+}
+
 // ValidateConfig 验证配置
 func (h *AdminHandler) ValidateConfig(c *gin.Context) {
 	service := c.Param("service")
@@ -123,16 +207,20 @@ func (h *AdminHandler) ValidateConfig(c *gin.Context) {
 	var req struct {
 		Content string `json:"content" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
-	err := h.configService.ValidateConfig(service, req.Content)
+	// deep=true 时按配置结构校验，返回所有具体问题；能定位时附带行号和列号
+	configErrors, err := h.configService.CheckConfig(service, req.Content, c.Query("deep") == "true")
 	if err != nil {
+		Error(c, err)
+		return
+	}
+	if len(configErrors) > 0 {
 		Success(c, gin.H{
 			"valid":  false,
-			"errors": []string{err.Error()},
+			"errors": configErrors,
 		})
 		return
 	}
@@ -150,7 +238,19 @@ func (h *AdminHandler) ReloadConfig(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现配置热重载功能
+	// 本服务支持热更新部分配置（如 Redis 连接），其他服务需要重启
+	if service == "backend" {
+		if err := h.configService.ReloadBackend(c.Request.Context(), GetUserID(c)); err != nil {
+			Error(c, err)
+			return
+		}
+		Success(c, gin.H{
+			"message": "配置已重新加载，Redis 连接等支持热更新的配置已生效，其他配置需要重启",
+		})
+		return
+	}
+
+	// TODO: 实现其他服务的配置热重载
 	// 这通常需要向服务发送信号或通过管理接口触发重载
 	Success(c, gin.H{
 		"message": "配置重新加载请求已提交，服务可能需要重启才能生效",
@@ -159,19 +259,7 @@ func (h *AdminHandler) ReloadConfig(c *gin.Context) {
 
 // GetUserList 获取用户列表
 func (h *AdminHandler) GetUserList(c *gin.Context) {
-	page := 1
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	pageSize := 10
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
-		}
-	}
+	page, pageSize := GetPageQuery(c)
 
 	keyword := c.Query("keyword")
 	status := c.Query("status")
@@ -214,6 +302,24 @@ func (h *AdminHandler) GetUserDetail(c *gin.Context) {
 	Success(c, user)
 }
 
+// ImpersonateUser 模拟登录用户
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的用户ID"))
+		return
+	}
+
+	resp, err := h.userService.ImpersonateUser(c.Request.Context(), GetUserID(c), uint(id))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
 // UpdateUser 更新用户信息
 func (h *AdminHandler) UpdateUser(c *gin.Context) {
 	idStr := c.Param("id")
@@ -224,8 +330,7 @@ func (h *AdminHandler) UpdateUser(c *gin.Context) {
 	}
 
 	var req admin.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -249,8 +354,7 @@ func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
 	var req struct {
 		Status string `json:"status" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -273,11 +377,32 @@ func (h *AdminHandler) GetSystemConfig(c *gin.Context) {
 	Success(c, config)
 }
 
+// GetPublicConfig 获取公开的系统配置（无需认证）
+func (h *AdminHandler) GetPublicConfig(c *gin.Context) {
+	config, err := h.systemService.GetPublicConfig(c.Request.Context())
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, config)
+}
+
+// GetServerTime 获取服务器时间（无需认证）
+func (h *AdminHandler) GetServerTime(c *gin.Context) {
+	serverTime, err := h.systemService.GetServerTime(c.Request.Context())
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, serverTime)
+}
+
 // UpdateSystemConfig 更新系统配置
 func (h *AdminHandler) UpdateSystemConfig(c *gin.Context) {
 	var config admin.SystemConfig
-	if err := c.ShouldBindJSON(&config); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &config) {
 		return
 	}
 
@@ -315,8 +440,7 @@ func (h *AdminHandler) UpdateSystemConfigCategory(c *gin.Context) {
 	}
 
 	var data map[string]interface{}
-	if err := c.ShouldBindJSON(&data); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &data) {
 		return
 	}
 
@@ -328,3 +452,144 @@ func (h *AdminHandler) UpdateSystemConfigCategory(c *gin.Context) {
 	Success(c, nil)
 }
 
+
+// GetRoomStats 获取房间聚合统计
+func (h *AdminHandler) GetRoomStats(c *gin.Context) {
+	stats, err := h.roomStatsService.GetRoomStats(c.Request.Context())
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, stats)
+}
+
+// GetInactiveUsers 获取 since 之后没有再进行对局的用户，since 支持 RFC3339 或 YYYY-MM-DD
+func (h *AdminHandler) GetInactiveUsers(c *gin.Context) {
+	sinceStr := c.Query("since")
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		if since, err = time.ParseInLocation("2006-01-02", sinceStr, time.Local); err != nil {
+			Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的时间参数 since"))
+			return
+		}
+	}
+	page, pageSize := GetPageQuery(c)
+
+	users, err := h.statsService.ListInactiveUsers(c.Request.Context(), since, page, pageSize)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, users)
+}
+
+// GetLiveGames 获取进行中的游戏列表
+func (h *AdminHandler) GetLiveGames(c *gin.Context) {
+	page, pageSize := GetPageQuery(c)
+
+	games, err := h.gameService.ListLiveGames(c.Request.Context(), page, pageSize)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, games)
+}
+
+// ForceEndGame 强制结束游戏
+func (h *AdminHandler) ForceEndGame(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	if err := h.gameService.ForceEndGame(c.Request.Context(), GetUserID(c), uint(id)); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// ListCacheKeys 按模式分页查看缓存键
+func (h *AdminHandler) ListCacheKeys(c *gin.Context) {
+	var cursor uint64
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(cursorStr, 10, 64); err != nil {
+			Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的游标"))
+			return
+		}
+	}
+	count, _ := strconv.Atoi(c.Query("count"))
+
+	page, err := h.cacheService.ListKeys(c.Request.Context(), c.Query("pattern"), cursor, count)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, page)
+}
+
+// DeleteCacheKeys 按模式清理缓存键
+func (h *AdminHandler) DeleteCacheKeys(c *gin.Context) {
+	result, err := h.cacheService.DeleteKeys(c.Request.Context(), GetUserID(c), c.Query("pattern"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, result)
+}
+
+// ListLocks 分页列出当前被持有的分布式锁
+func (h *AdminHandler) ListLocks(c *gin.Context) {
+	var cursor uint64
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(cursorStr, 10, 64); err != nil {
+			Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的游标"))
+			return
+		}
+	}
+	count, _ := strconv.Atoi(c.Query("count"))
+
+	page, err := h.cacheService.ListLocks(c.Request.Context(), cursor, count)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, page)
+}
+
+// ReleaseLock 强制释放卡住的锁
+func (h *AdminHandler) ReleaseLock(c *gin.Context) {
+	if err := h.cacheService.ReleaseLock(c.Request.Context(), GetUserID(c), c.Param("resource")); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// CreateAnnouncement 发布系统公告
+func (h *AdminHandler) CreateAnnouncement(c *gin.Context) {
+	var req admin.AnnouncementRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	announcement, err := h.announcementService.Announce(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, announcement)
+}
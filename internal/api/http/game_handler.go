@@ -39,8 +39,7 @@ func (h *GameHandler) CreateRoom(c *gin.Context) {
 	}
 
 	var req game.CreateRoomRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -53,6 +52,9 @@ func (h *GameHandler) CreateRoom(c *gin.Context) {
 	Success(c, resp)
 }
 
+// RejoinHeader 开启幂等加入房间的请求头
+const RejoinHeader = "X-Rejoin"
+
 // JoinRoom 加入房间
 func (h *GameHandler) JoinRoom(c *gin.Context) {
 	userID := GetUserID(c)
@@ -62,11 +64,15 @@ func (h *GameHandler) JoinRoom(c *gin.Context) {
 	}
 
 	var req game.JoinRoomRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
+	// 也可以通过请求头开启重复加入时返回当前状态
+	if c.GetHeader(RejoinHeader) == "true" {
+		req.Rejoin = true
+	}
+
 	resp, err := h.roomService.JoinRoom(c.Request.Context(), userID, &req)
 	if err != nil {
 		Error(c, err)
@@ -99,6 +105,161 @@ func (h *GameHandler) LeaveRoom(c *gin.Context) {
 	Success(c, nil)
 }
 
+// UpdateRoomSettings 更新房间设置
+func (h *GameHandler) UpdateRoomSettings(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	var req game.UpdateRoomSettingsRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	room, err := h.roomService.UpdateSettings(c.Request.Context(), userID, uint(roomID), req.Settings)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, room)
+}
+
+// Rematch 再来一局
+func (h *GameHandler) Rematch(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	room, err := h.roomService.Rematch(c.Request.Context(), userID, uint(roomID))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, room)
+}
+
+// SetReady 设置准备状态
+func (h *GameHandler) SetReady(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	var req game.SetReadyRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.roomService.SetReady(c.Request.Context(), userID, uint(roomID), req.Ready); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// JoinWaitlist 加入房间候补队列
+func (h *GameHandler) JoinWaitlist(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	var req game.JoinWaitlistRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.roomService.JoinWaitlist(c.Request.Context(), userID, req.RoomCode)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
+// LeaveWaitlist 离开房间候补队列
+func (h *GameHandler) LeaveWaitlist(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	if err := h.roomService.LeaveWaitlist(c.Request.Context(), userID, uint(roomID)); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// SwitchRole 切换玩家/观战者身份
+func (h *GameHandler) SwitchRole(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	var req game.SwitchRoleRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	room, err := h.roomService.SwitchRole(c.Request.Context(), userID, uint(roomID), req.ToSpectator)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, room)
+}
+
 // GetRoom 获取房间信息
 func (h *GameHandler) GetRoom(c *gin.Context) {
 	roomIDStr := c.Param("id")
@@ -127,21 +288,20 @@ func (h *GameHandler) ListRooms(c *gin.Context) {
 		}
 	}
 
-	limit := 20
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
+	page, pageSize := GetPageQuery(c)
 
-	offset := 0
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	// 携带 cursor 参数（可为空，表示第一页）时使用游标分页，否则沿用页码分页
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		rooms, err := h.roomService.ListRoomsByCursor(c.Request.Context(), status, cursor, pageSize)
+		if err != nil {
+			Error(c, err)
+			return
 		}
+		Success(c, rooms)
+		return
 	}
 
-	rooms, err := h.roomService.ListRooms(c.Request.Context(), status, limit, offset)
+	rooms, err := h.roomService.ListRooms(c.Request.Context(), status, page, pageSize)
 	if err != nil {
 		Error(c, err)
 		return
@@ -165,7 +325,7 @@ func (h *GameHandler) StartGame(c *gin.Context) {
 		return
 	}
 
-	if err := h.processService.StartGame(c.Request.Context(), uint(roomID)); err != nil {
+	if err := h.processService.StartGame(c.Request.Context(), uint(roomID), userID); err != nil {
 		Error(c, err)
 		return
 	}
@@ -173,6 +333,105 @@ func (h *GameHandler) StartGame(c *gin.Context) {
 	Success(c, nil)
 }
 
+// SubmitMove 提交游戏操作
+func (h *GameHandler) SubmitMove(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	var req game.SubmitMoveRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.processService.SubmitMove(c.Request.Context(), uint(roomID), userID, &req)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
+// BatchPresence 批量查询用户在线状态
+func (h *GameHandler) BatchPresence(c *gin.Context) {
+	var req struct {
+		UserIDs []uint `json:"user_ids" binding:"required"`
+	}
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	presence, err := h.sessionService.BatchPresence(c.Request.Context(), req.UserIDs)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, presence)
+}
+
+// ListOnlineUsers 分页获取在线用户
+func (h *GameHandler) ListOnlineUsers(c *gin.Context) {
+	page, pageSize := GetPageQuery(c)
+
+	users, err := h.sessionService.ListOnlineUsers(c.Request.Context(), page, pageSize)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, users)
+}
+
+// GetRoomEvents 获取房间内序号大于 since 的事件，只有房间成员（玩家或观战者）和管理员可以查看
+func (h *GameHandler) GetRoomEvents(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	roomID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的房间ID"))
+		return
+	}
+
+	if !h.sessionService.IsAdmin(c.Request.Context(), userID) {
+		if err := h.processService.CheckRoomMember(c.Request.Context(), uint(roomID), userID); err != nil {
+			Error(c, err)
+			return
+		}
+	}
+
+	var since int64
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的事件游标"))
+			return
+		}
+	}
+
+	events, err := h.processService.EventsSince(c.Request.Context(), uint(roomID), since)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, events)
+}
+
 // GetGameState 获取游戏状态
 func (h *GameHandler) GetGameState(c *gin.Context) {
 	roomIDStr := c.Param("id")
@@ -1,17 +1,25 @@
 package http
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/game-apps/internal/middleware"
 	"github.com/game-apps/internal/utils"
 )
 
 // Response 统一响应格式
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    interface{}       `json:"data,omitempty"`
+	TraceID string            `json:"trace_id,omitempty"` // 请求 ID，与响应头 X-Request-ID 一致
+	Fields  map[string]string `json:"fields,omitempty"`   // 字段级校验错误
+	Reason  string            `json:"reason,omitempty"`   // 细分原因，同一错误码下区分不同情况
+
+	Retryable bool `json:"retryable,omitempty"` // 瞬时错误，客户端可安全重试
 }
 
 // Success 成功响应
@@ -20,20 +28,29 @@ func Success(c *gin.Context, data interface{}) {
 		Code:    0,
 		Message: "success",
 		Data:    data,
+		TraceID: middleware.GetRequestID(c),
 	})
 }
 
 // Error 错误响应
 func Error(c *gin.Context, err error) {
 	if appErr, ok := err.(*utils.AppError); ok {
+		if appErr.Retryable && appErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
 		c.JSON(appErr.HTTPStatus(), Response{
-			Code:    appErr.Code,
-			Message: appErr.Message,
+			Code:      appErr.Code,
+			Message:   appErr.Message,
+			TraceID:   middleware.GetRequestID(c),
+			Fields:    appErr.Fields,
+			Reason:    appErr.Reason,
+			Retryable: appErr.Retryable,
 		})
 	} else {
 		c.JSON(http.StatusInternalServerError, Response{
 			Code:    utils.ErrCodeInternal,
 			Message: err.Error(),
+			TraceID: middleware.GetRequestID(c),
 		})
 	}
 }
@@ -50,3 +67,9 @@ func GetUserID(c *gin.Context) uint {
 	return 0
 }
 
+// GetPageQuery 从查询参数获取分页参数（page、page_size），非法值交由 utils.Paginate 规范化
+func GetPageQuery(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.Query("page"))
+	pageSize, _ = strconv.Atoi(c.Query("page_size"))
+	return page, pageSize
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/middleware"
+	"github.com/game-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestResponseTraceID(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware())
+	router.GET("/ok", func(c *gin.Context) { Success(c, gin.H{"ok": true}) })
+	router.GET("/invalid", func(c *gin.Context) {
+		Error(c, utils.NewValidationError("参数错误", map[string]string{"username": "不能为空"}))
+	})
+	router.GET("/internal", func(c *gin.Context) { Error(c, errors.New("boom")) })
+
+	tests := []struct {
+		name       string
+		path       string
+		requestID  string
+		wantStatus int
+		wantFields map[string]string
+	}{
+		{"成功响应", "/ok", "", http.StatusOK, nil},
+		{"沿用客户端请求 ID", "/ok", "client-id-1", http.StatusOK, nil},
+		{"校验错误带字段", "/invalid", "", http.StatusBadRequest, map[string]string{"username": "不能为空"}},
+		{"内部错误", "/internal", "", http.StatusInternalServerError, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			header := w.Header().Get(middleware.RequestIDHeader)
+			if header == "" || resp.TraceID != header {
+				t.Errorf("trace_id = %q, header = %q", resp.TraceID, header)
+			}
+			if tt.requestID != "" && header != tt.requestID {
+				t.Errorf("header = %q, want %q", header, tt.requestID)
+			}
+			if len(resp.Fields) != len(tt.wantFields) {
+				t.Errorf("fields = %v, want %v", resp.Fields, tt.wantFields)
+			}
+			for k, v := range tt.wantFields {
+				if resp.Fields[k] != v {
+					t.Errorf("fields[%s] = %q, want %q", k, resp.Fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorRetryAfter(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+		wantRetryable  bool
+	}{
+		{"可重试错误", &utils.AppError{Code: utils.ErrCodeInternal, Message: "繁忙", Retryable: true, RetryAfter: 1500 * time.Millisecond}, http.StatusServiceUnavailable, "2", true},
+		{"不可重试错误", utils.NewError(utils.ErrCodeInternal, "失败"), http.StatusInternalServerError, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) { Error(c, tt.err) })
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			var resp Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Retryable != tt.wantRetryable {
+				t.Errorf("retryable = %v, want %v", resp.Retryable, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		Error(c, utils.NewError(utils.ErrCodeConflict, "房间已满").WithReason("room_full"))
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != utils.ErrCodeConflict || resp.Reason != "room_full" {
+		t.Errorf("response = code %d reason %q, want %d room_full", resp.Code, resp.Reason, utils.ErrCodeConflict)
+	}
+}
+
+func TestGetCurrentUserWithoutClaims(t *testing.T) {
+	// 未经过认证中间件时不会访问用户服务
+	h := NewUserHandler(nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/user/me", h.GetCurrentUser)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/me", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
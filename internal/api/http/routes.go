@@ -1,13 +1,26 @@
 package http
 
 import (
+	"runtime"
+
 	"github.com/gin-gonic/gin"
 	"github.com/game-apps/internal/middleware"
 	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
+	"github.com/game-apps/pkg/origins"
+	"github.com/game-apps/pkg/worker"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// RateLimiters 各接口的限流中间件
+type RateLimiters struct {
+	Login        gin.HandlerFunc
+	Register     gin.HandlerFunc
+	Availability gin.HandlerFunc // 用户名/邮箱可用性检查，防止枚举
+}
+
 // SetupRoutes 设置路由
 func SetupRoutes(
 	router *gin.Engine,
@@ -15,16 +28,35 @@ func SetupRoutes(
 	gameHandler *GameHandler,
 	adminHandler *AdminHandler,
 	jwtService *utils.JWTService,
+	rateLimiters RateLimiters,
+	workers *worker.Manager,
+	allowedOrigins *origins.Matcher,
+	metricsOptions middleware.MetricsOptions,
+	loggingOptions middleware.LoggingOptions,
+	db *gorm.DB,
+	cacheClient *cache.Client,
 	logger *zap.Logger,
 ) {
+	registerJSONTagNames()
+
 	// 全局中间件
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.RecoveryMiddleware(logger))
-	router.Use(middleware.LoggingMiddleware(logger))
-	router.Use(middleware.MetricsMiddleware())
+	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.LoggingMiddleware(logger, loggingOptions))
+	router.Use(middleware.MetricsMiddleware(metricsOptions))
 
 	// 健康检查
 	router.GET("/health", healthCheck)
 	router.GET("/ready", readyCheck)
+	// 详细健康检查包含协程数和连接池状态，仅管理员可见
+	router.GET("/health/detail",
+		middleware.AuthMiddleware(jwtService, userHandler.authService, logger),
+		middleware.RequireRevocationCheck(),
+		middleware.RequireAudience(utils.ClientTypeAdmin),
+		middleware.AdminMiddleware(gameHandler.sessionService),
+		healthDetail(workers, db, cacheClient, logger),
+	)
 
 	// Metrics
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -32,68 +64,124 @@ func SetupRoutes(
 	// API v1
 	v1 := router.Group("/api/v1")
 	{
+		// 公开配置（不需要认证）
+		v1.GET("/config/public", adminHandler.GetPublicConfig)
+
+		// 服务器时间，客户端用于校准倒计时（不需要认证）
+		v1.GET("/time", adminHandler.GetServerTime)
+
 		// 用户相关（不需要认证）
 		user := v1.Group("/user")
 		{
-			user.POST("/register", userHandler.Register)
-			user.POST("/login", userHandler.Login)
+			user.POST("/register", rateLimiters.Register, userHandler.Register)
+			user.POST("/login", rateLimiters.Login, userHandler.Login)
+			user.GET("/check-username", rateLimiters.Availability, userHandler.CheckUsername)
+			user.GET("/check-email", rateLimiters.Availability, userHandler.CheckEmail)
 			user.POST("/refresh", userHandler.RefreshToken)
+			user.GET("/:id/stats", userHandler.GetPublicStats)
 		}
 
 		// 需要认证的用户接口
 		authUser := v1.Group("/user")
-		authUser.Use(middleware.AuthMiddleware(jwtService))
+		authUser.Use(middleware.AuthMiddleware(jwtService, userHandler.authService, logger))
 		{
 			authUser.POST("/logout", userHandler.Logout)
+			authUser.POST("/logout-all", middleware.RequireRevocationCheck(), middleware.ForbidImpersonation(), userHandler.LogoutAll)
+			authUser.DELETE("/account", middleware.RequireRevocationCheck(), middleware.ForbidImpersonation(), userHandler.DeleteAccount)
+			authUser.GET("/me", userHandler.GetCurrentUser)
 			authUser.GET("/profile", userHandler.GetProfile)
 			authUser.PUT("/profile", userHandler.UpdateProfile)
+			authUser.PUT("/username", middleware.RequireRevocationCheck(), middleware.ForbidImpersonation(), userHandler.ChangeUsername)
 			authUser.GET("/stats", userHandler.GetStats)
+			authUser.GET("/history", userHandler.GetHistory)
+			authUser.POST("/presence", gameHandler.BatchPresence)
+		}
+
+		// 大厅相关（需要认证）
+		lobby := v1.Group("/lobby")
+		lobby.Use(middleware.AuthMiddleware(jwtService, userHandler.authService, logger))
+		{
+			lobby.POST("/users", userHandler.GetLobbyUsers)
 		}
 
 		// 游戏相关（需要认证）
 		game := v1.Group("/game")
-		game.Use(middleware.AuthMiddleware(jwtService))
+		game.Use(middleware.AuthMiddleware(jwtService, userHandler.authService, logger))
 		{
 			// 房间管理
 			game.POST("/rooms", gameHandler.CreateRoom)
 			game.POST("/rooms/join", gameHandler.JoinRoom)
+			game.POST("/rooms/waitlist", gameHandler.JoinWaitlist)
+			game.DELETE("/rooms/:id/waitlist", gameHandler.LeaveWaitlist)
 			game.DELETE("/rooms/:id", gameHandler.LeaveRoom)
 			game.GET("/rooms/:id", gameHandler.GetRoom)
 			game.GET("/rooms", gameHandler.ListRooms)
+			game.PUT("/rooms/:id/settings", gameHandler.UpdateRoomSettings)
+			game.POST("/rooms/:id/rematch", gameHandler.Rematch)
+			game.PUT("/rooms/:id/ready", gameHandler.SetReady)
+			game.POST("/rooms/:id/switch-role", gameHandler.SwitchRole)
 
 			// 游戏进程
 			game.POST("/rooms/:id/start", gameHandler.StartGame)
 			game.GET("/rooms/:id/state", gameHandler.GetGameState)
+			game.GET("/rooms/:id/events", gameHandler.GetRoomEvents)
+			game.POST("/rooms/:id/moves", gameHandler.SubmitMove)
+			game.GET("/online-users", gameHandler.ListOnlineUsers)
 		}
 
 		// 管理接口
 		admin := v1.Group("/admin")
 		{
 			// 管理登录（不需要认证）
-			admin.POST("/auth/login", adminHandler.AdminLogin)
+			admin.POST("/auth/login", rateLimiters.Login, adminHandler.AdminLogin)
 
 			// 需要认证和管理员权限的接口
 			adminAuth := admin.Group("")
-			adminAuth.Use(middleware.AuthMiddleware(jwtService))
-			adminAuth.Use(middleware.AdminMiddleware())
+			adminAuth.Use(middleware.AuthMiddleware(jwtService, userHandler.authService, logger))
+			adminAuth.Use(middleware.RequireRevocationCheck())
+			adminAuth.Use(middleware.RequireAudience(utils.ClientTypeAdmin))
+			adminAuth.Use(middleware.ForbidImpersonation())
+			adminAuth.Use(middleware.AdminMiddleware(gameHandler.sessionService))
 			{
 				// 配置管理
 				adminAuth.GET("/config/:service", adminHandler.GetConfig)
+				adminAuth.GET("/config/:service/effective", adminHandler.GetEffectiveConfig)
 				adminAuth.PUT("/config/:service", adminHandler.UpdateConfig)
 				adminAuth.POST("/config/:service/validate", adminHandler.ValidateConfig)
+				adminAuth.GET("/features", adminHandler.GetFeatureFlags)
 				adminAuth.POST("/config/:service/reload", adminHandler.ReloadConfig)
+				adminAuth.GET("/config/:service/backups", adminHandler.ListConfigBackups)
+				adminAuth.POST("/config/:service/restore-backup", adminHandler.RestoreConfigBackup)
 
 				// 用户管理
 				adminAuth.GET("/users", adminHandler.GetUserList)
 				adminAuth.GET("/users/:id", adminHandler.GetUserDetail)
 				adminAuth.PUT("/users/:id", adminHandler.UpdateUser)
 				adminAuth.PUT("/users/:id/status", adminHandler.UpdateUserStatus)
+				adminAuth.POST("/users/:id/impersonate", adminHandler.ImpersonateUser)
 
 				// 系统配置
 				adminAuth.GET("/system/config", adminHandler.GetSystemConfig)
 				adminAuth.PUT("/system/config", adminHandler.UpdateSystemConfig)
 				adminAuth.GET("/system/config/:category", adminHandler.GetSystemConfigCategory)
 				adminAuth.PUT("/system/config/:category", adminHandler.UpdateSystemConfigCategory)
+
+				// 统计
+				adminAuth.GET("/stats/rooms", adminHandler.GetRoomStats)
+				adminAuth.GET("/stats/inactive-users", adminHandler.GetInactiveUsers)
+
+				// 进行中的游戏
+				adminAuth.GET("/games", adminHandler.GetLiveGames)
+				adminAuth.POST("/games/:id/force-end", adminHandler.ForceEndGame)
+
+				// 系统公告
+				adminAuth.POST("/announcements", adminHandler.CreateAnnouncement)
+
+				// 缓存键排障
+				adminAuth.GET("/cache/keys", adminHandler.ListCacheKeys)
+				adminAuth.DELETE("/cache/keys", adminHandler.DeleteCacheKeys)
+				adminAuth.GET("/locks", adminHandler.ListLocks)
+				adminAuth.DELETE("/locks/:resource", adminHandler.ReleaseLock)
 			}
 		}
 	}
@@ -106,6 +194,76 @@ func healthCheck(c *gin.Context) {
 	})
 }
 
+// DBPoolStats 数据库连接池状态
+type DBPoolStats struct {
+	MaxOpen           int   `json:"max_open"`
+	Open              int   `json:"open"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// CachePoolStats Redis 连接池状态
+type CachePoolStats struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	StaleConns uint32 `json:"stale_conns"`
+}
+
+// healthDetail 详细健康检查，包含各后台任务的运行状态、协程数和连接池状态，用于排查泄漏和连接耗尽
+func healthDetail(workers *worker.Manager, db *gorm.DB, cacheClient *cache.Client, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses := workers.Health()
+		status := "healthy"
+		for _, s := range statuses {
+			if !s.Healthy {
+				status = "degraded"
+				break
+			}
+		}
+
+		resp := gin.H{
+			"status":     status,
+			"workers":    statuses,
+			"goroutines": runtime.NumGoroutine(),
+		}
+
+		if sqlDB, err := db.DB(); err != nil {
+			logger.Warn("获取数据库连接池失败", zap.Error(err))
+		} else {
+			stats := sqlDB.Stats()
+			resp["db_pool"] = DBPoolStats{
+				MaxOpen:           stats.MaxOpenConnections,
+				Open:              stats.OpenConnections,
+				InUse:             stats.InUse,
+				Idle:              stats.Idle,
+				WaitCount:         stats.WaitCount,
+				WaitDurationMs:    stats.WaitDuration.Milliseconds(),
+				MaxIdleClosed:     stats.MaxIdleClosed,
+				MaxLifetimeClosed: stats.MaxLifetimeClosed,
+			}
+		}
+
+		stats := cacheClient.Client().PoolStats()
+		resp["cache_pool"] = CachePoolStats{
+			Hits:       stats.Hits,
+			Misses:     stats.Misses,
+			Timeouts:   stats.Timeouts,
+			TotalConns: stats.TotalConns,
+			IdleConns:  stats.IdleConns,
+			StaleConns: stats.StaleConns,
+		}
+
+		c.JSON(200, resp)
+	}
+}
+
 // readyCheck 就绪检查
 func readyCheck(c *gin.Context) {
 	c.JSON(200, gin.H{
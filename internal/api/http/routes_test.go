@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/pkg/cache"
+	"github.com/game-apps/pkg/worker"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHealthDetail(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxOpenConns(8)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.RunT(t)
+	cacheClient, err := cache.NewClient(cache.Options{
+		Addr:         mr.Addr(),
+		PoolSize:     10,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cacheClient.Close() })
+
+	router := gin.New()
+	router.GET("/health/detail", healthDetail(worker.NewManager(zap.NewNop()), db, cacheClient, zap.NewNop()))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/detail", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var resp struct {
+		Status     string          `json:"status"`
+		Goroutines int             `json:"goroutines"`
+		DBPool     *DBPoolStats    `json:"db_pool"`
+		CachePool  *CachePoolStats `json:"cache_pool"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "healthy" || resp.Goroutines <= 0 {
+		t.Errorf("status = %q, goroutines = %d", resp.Status, resp.Goroutines)
+	}
+	if resp.DBPool == nil || resp.DBPool.MaxOpen != 8 || resp.DBPool.InUse > resp.DBPool.Open {
+		t.Errorf("db_pool = %+v, want max_open 8", resp.DBPool)
+	}
+	// 创建客户端时 Ping 过一次，连接池中至少有一个连接
+	if resp.CachePool == nil || resp.CachePool.TotalConns == 0 || resp.CachePool.IdleConns > resp.CachePool.TotalConns {
+		t.Errorf("cache_pool = %+v, want at least one connection", resp.CachePool)
+	}
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/game-apps/internal/service/user"
 	"github.com/game-apps/internal/utils"
@@ -11,6 +13,7 @@ type UserHandler struct {
 	authService   *user.AuthService
 	profileService *user.ProfileService
 	statsService   *user.StatsService
+	userService    *user.UserService
 }
 
 // NewUserHandler 创建用户处理器
@@ -18,19 +21,26 @@ func NewUserHandler(
 	authService *user.AuthService,
 	profileService *user.ProfileService,
 	statsService *user.StatsService,
+	userService *user.UserService,
 ) *UserHandler {
 	return &UserHandler{
 		authService:    authService,
 		profileService: profileService,
 		statsService:   statsService,
+		userService:    userService,
 	}
 }
 
 // Register 用户注册
 func (h *UserHandler) Register(c *gin.Context) {
 	var req user.RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	// 管理端令牌只能通过管理登录接口获取
+	if req.ClientType == utils.ClientTypeAdmin {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "不支持的客户端类型"))
 		return
 	}
 
@@ -46,11 +56,18 @@ func (h *UserHandler) Register(c *gin.Context) {
 // Login 用户登录
 func (h *UserHandler) Login(c *gin.Context) {
 	var req user.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
+	// 管理端令牌只能通过管理登录接口获取
+	if req.ClientType == utils.ClientTypeAdmin {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "不支持的客户端类型"))
+		return
+	}
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	resp, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
 		Error(c, err)
@@ -63,8 +80,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 // RefreshToken 刷新令牌
 func (h *UserHandler) RefreshToken(c *gin.Context) {
 	var req user.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -93,6 +109,65 @@ func (h *UserHandler) Logout(c *gin.Context) {
 	Success(c, nil)
 }
 
+// LogoutAll 登出所有设备
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	if err := h.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// DeleteAccount 注销账号
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	var req user.DeleteAccountRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.authService.DeleteAccount(c.Request.Context(), userID, req.Password); err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// CheckUsername 检查用户名是否可用
+func (h *UserHandler) CheckUsername(c *gin.Context) {
+	resp, err := h.authService.IsUsernameAvailable(c.Request.Context(), c.Query("u"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
+// CheckEmail 检查邮箱是否可用
+func (h *UserHandler) CheckEmail(c *gin.Context) {
+	resp, err := h.authService.IsEmailAvailable(c.Request.Context(), c.Query("e"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
 // GetProfile 获取用户资料
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID := GetUserID(c)
@@ -119,8 +194,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	var req user.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Error(c, utils.NewError(utils.ErrCodeInvalidInput, err.Error()))
+	if !BindJSON(c, &req) {
 		return
 	}
 
@@ -132,6 +206,28 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	Success(c, nil)
 }
 
+// ChangeUsername 修改用户名
+func (h *UserHandler) ChangeUsername(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	var req user.ChangeUsernameRequest
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	u, err := h.profileService.ChangeUsername(c.Request.Context(), userID, req.Username)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, u)
+}
+
 // GetStats 获取用户统计
 func (h *UserHandler) GetStats(c *gin.Context) {
 	userID := GetUserID(c)
@@ -149,3 +245,74 @@ func (h *UserHandler) GetStats(c *gin.Context) {
 	Success(c, resp)
 }
 
+// GetHistory 获取当前用户的对局记录
+func (h *UserHandler) GetHistory(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	page, pageSize := GetPageQuery(c)
+	history, err := h.statsService.GetHistory(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, history)
+}
+
+// GetPublicStats 获取其他用户的公开统计
+func (h *UserHandler) GetPublicStats(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		Error(c, utils.NewError(utils.ErrCodeInvalidInput, "无效的用户ID"))
+		return
+	}
+
+	resp, err := h.statsService.GetPublicStats(c.Request.Context(), uint(id))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
+// GetCurrentUser 获取当前登录用户的身份信息（比 /profile 更轻量）
+func (h *UserHandler) GetCurrentUser(c *gin.Context) {
+	value, _ := c.Get("claims")
+	claims, ok := value.(*utils.JWTClaims)
+	if !ok {
+		Error(c, utils.NewError(utils.ErrCodeUnauthorized, "未授权"))
+		return
+	}
+
+	resp, err := h.userService.GetCurrentUser(c.Request.Context(), claims)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, resp)
+}
+
+// GetLobbyUsers 批量获取大厅展示所需的用户资料、等级和在线状态
+func (h *UserHandler) GetLobbyUsers(c *gin.Context) {
+	var req struct {
+		UserIDs []uint `json:"user_ids" binding:"required"`
+	}
+	if !BindJSON(c, &req) {
+		return
+	}
+
+	users, err := h.userService.GetLobbyUsers(c.Request.Context(), req.UserIDs)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	Success(c, users)
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerJSONTagNames 校验错误使用 JSON 字段名，与请求体字段保持一致
+func registerJSONTagNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// BindJSON 绑定并校验 JSON 请求体，失败时写入参数错误响应并返回 false，调用方直接返回即可
+func BindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		Error(c, bindError(err))
+		return false
+	}
+	return true
+}
+
+// bindError 将请求绑定错误转换为带字段信息的参数错误
+func bindError(err error) *utils.AppError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields[fieldErr.Field()] = fieldErrorMessage(fieldErr)
+		}
+		return utils.NewValidationError("请求参数校验失败", fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return utils.NewValidationError("请求参数校验失败", map[string]string{
+			typeErr.Field: fmt.Sprintf("类型错误，应为 %s", typeErr.Type.String()),
+		})
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return utils.NewError(utils.ErrCodeInvalidInput, "请求体不是合法的 JSON")
+	}
+
+	return utils.NewError(utils.ErrCodeInvalidInput, "请求参数格式错误")
+}
+
+// fieldErrorMessage 生成单个字段的校验错误描述
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	isString := fieldErr.Kind() == reflect.String
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "不能为空"
+	case "email":
+		return "邮箱格式不正确"
+	case "min":
+		if isString {
+			return fmt.Sprintf("长度不能少于 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能小于 %s", fieldErr.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("长度不能超过 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能大于 %s", fieldErr.Param())
+	case "len":
+		return fmt.Sprintf("长度必须为 %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("必须是以下之一: %s", fieldErr.Param())
+	default:
+		return "格式不正确"
+	}
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/game-apps/internal/service/user"
+	"github.com/game-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindError(t *testing.T) {
+	registerJSONTagNames()
+
+	router := gin.New()
+	router.POST("/register", func(c *gin.Context) {
+		var req user.RegisterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			Error(c, bindError(err))
+			return
+		}
+		Success(c, nil)
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantFields map[string]string
+	}{
+		{
+			name:     "两个字段校验失败",
+			body:     `{"username":"alice","email":"not-an-email"}`,
+			wantCode: utils.ErrCodeInvalidInput,
+			wantFields: map[string]string{
+				"email":    "邮箱格式不正确",
+				"password": "不能为空",
+			},
+		},
+		{
+			name:       "字段类型错误",
+			body:       `{"username":123,"email":"a@example.com","password":"x"}`,
+			wantCode:   utils.ErrCodeInvalidInput,
+			wantFields: map[string]string{"username": "类型错误，应为 string"},
+		},
+		{
+			name:     "非法 JSON",
+			body:     `{"username":`,
+			wantCode: utils.ErrCodeInvalidInput,
+		},
+		{
+			name: "校验通过",
+			body: `{"username":"alice","email":"a@example.com","password":"x"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var resp Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d, body = %s", resp.Code, tt.wantCode, w.Body.String())
+			}
+			if len(resp.Fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %v, want %v", resp.Fields, tt.wantFields)
+			}
+			for field, message := range tt.wantFields {
+				if resp.Fields[field] != message {
+					t.Errorf("fields[%q] = %q, want %q", field, resp.Fields[field], message)
+				}
+			}
+		})
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	registerJSONTagNames()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantBound  bool
+		wantStatus int
+	}{
+		{"绑定成功", `{"room_code":"ABC123"}`, true, http.StatusOK},
+		{"缺少必填字段", `{}`, false, http.StatusBadRequest},
+		{"非法 JSON", `not json`, false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				bound bool
+				req   struct {
+					RoomCode string `json:"room_code" binding:"required"`
+				}
+			)
+			router := gin.New()
+			router.POST("/", func(c *gin.Context) {
+				if bound = BindJSON(c, &req); !bound {
+					return
+				}
+				Success(c, req.RoomCode)
+			})
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httpReq)
+
+			if bound != tt.wantBound || w.Code != tt.wantStatus {
+				t.Fatalf("BindJSON() = %v, status = %d, want %v, %d", bound, w.Code, tt.wantBound, tt.wantStatus)
+			}
+			if tt.wantBound && req.RoomCode != "ABC123" {
+				t.Errorf("room_code = %q", req.RoomCode)
+			}
+			if !tt.wantBound {
+				var resp Response
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != utils.ErrCodeInvalidInput {
+					t.Errorf("body = %s, want invalid input error", w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// retainedMessage 保留的广播消息，在有效期内补发给新连接的客户端
+type retainedMessage struct {
+	message   *outbound
+	expiresAt time.Time
+}
+
+// BroadcastRetained 广播消息，并在 ttl 内补发给之后连接的客户端
+func (h *Hub) BroadcastRetained(message interface{}, ttl time.Duration) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("序列化消息失败", zap.Error(err))
+		return
+	}
+
+	outgoing := newOutbound(data)
+	if ttl > 0 {
+		now := time.Now()
+		h.retainedMu.Lock()
+		h.retained = append(pruneRetained(h.retained, now), retainedMessage{message: outgoing, expiresAt: now.Add(ttl)})
+		h.retainedMu.Unlock()
+	}
+
+	h.broadcast <- outgoing
+}
+
+// BroadcastToUsers 发送消息给多个用户
+func (h *Hub) BroadcastToUsers(userIDs []uint, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("序列化消息失败", zap.Error(err))
+		return
+	}
+	h.sendToUsers(userIDs, newOutbound(data))
+}
+
+// replayRetained 向新连接的客户端补发未过期的保留消息
+func (h *Hub) replayRetained(client *Client) {
+	h.retainedMu.Lock()
+	h.retained = pruneRetained(h.retained, time.Now())
+	messages := make([]*outbound, 0, len(h.retained))
+	for _, msg := range h.retained {
+		messages = append(messages, msg.message)
+	}
+	h.retainedMu.Unlock()
+
+	for _, message := range messages {
+		h.sendMessage(client, message)
+	}
+}
+
+// pruneRetained 移除已过期的保留消息
+func pruneRetained(messages []retainedMessage, now time.Time) []retainedMessage {
+	kept := messages[:0]
+	for _, msg := range messages {
+		if now.Before(msg.expiresAt) {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// receiveType 等待客户端收到一条消息并返回其类型
+func receiveType(t *testing.T, client *Client) string {
+	t.Helper()
+	select {
+	case data := <-client.Send:
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data.data, &msg); err != nil {
+			t.Fatalf("解析消息失败: %v", err)
+		}
+		return msg.Type
+	case <-time.After(time.Second):
+		return ""
+	}
+}
+
+func TestBroadcastRetained(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{})
+	go hub.Run()
+
+	clients := make([]*Client, 0, 3)
+	for userID := uint(1); userID <= 3; userID++ {
+		client := &Client{Hub: hub, Send: make(chan *outbound, 8), UserID: userID}
+		hub.register <- client
+		clients = append(clients, client)
+	}
+
+	hub.BroadcastRetained(map[string]interface{}{"type": "announcement"}, time.Minute)
+	hub.BroadcastRetained(map[string]interface{}{"type": "notice"}, 0)
+
+	for _, client := range clients {
+		if got := receiveType(t, client); got != "announcement" {
+			t.Errorf("用户 %d 收到 %q，期望 announcement", client.UserID, got)
+		}
+		if got := receiveType(t, client); got != "notice" {
+			t.Errorf("用户 %d 收到 %q，期望 notice", client.UserID, got)
+		}
+	}
+
+	// 之后连接的客户端只补发保留期内的消息
+	late := &Client{Hub: hub, Send: make(chan *outbound, 8), UserID: 4}
+	hub.register <- late
+	if got := receiveType(t, late); got != "announcement" {
+		t.Errorf("新连接收到 %q，期望补发 announcement", got)
+	}
+	select {
+	case data := <-late.Send:
+		t.Errorf("新连接收到未保留的消息 %s", data.data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPruneRetained(t *testing.T) {
+	now := time.Now()
+	messages := []retainedMessage{
+		{message: newOutbound([]byte("expired")), expiresAt: now.Add(-time.Second)},
+		{message: newOutbound([]byte("active")), expiresAt: now.Add(time.Second)},
+	}
+	kept := pruneRetained(messages, now)
+	if len(kept) != 1 || string(kept[0].message.data) != "active" {
+		t.Errorf("pruneRetained() = %v", kept)
+	}
+}
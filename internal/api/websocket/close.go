@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 应用自定义关闭码（4000-4999）
+const (
+	CloseUnauthorized    = 4001 // 认证失败或令牌过期
+	CloseSessionReplaced = 4002 // 被踢下线或被新连接替换
+)
+
+// CloseReason 服务端主动断开连接的原因，随关闭帧发送给客户端
+type CloseReason struct {
+	Code   int    // WebSocket 关闭码
+	Reason string // 简短的原因标识
+	Retry  bool   // 客户端是否应该重连
+}
+
+// 预定义的关闭原因
+var (
+	CloseReasonNormal       = CloseReason{Code: websocket.CloseNormalClosure, Reason: "normal", Retry: true}
+	CloseReasonShutdown     = CloseReason{Code: websocket.CloseGoingAway, Reason: "server_shutdown", Retry: true}
+	CloseReasonRateLimited  = CloseReason{Code: websocket.ClosePolicyViolation, Reason: "rate_limited", Retry: false}
+	CloseReasonOverflow     = CloseReason{Code: websocket.CloseTryAgainLater, Reason: "send_overflow", Retry: true}
+	CloseReasonSlowConsumer = CloseReason{Code: websocket.ClosePolicyViolation, Reason: "slow_consumer", Retry: true}
+	CloseReasonKicked       = CloseReason{Code: CloseSessionReplaced, Reason: "kicked", Retry: false}
+	CloseReasonReplaced     = CloseReason{Code: CloseSessionReplaced, Reason: "replaced", Retry: false}
+	CloseReasonDuplicate    = CloseReason{Code: CloseSessionReplaced, Reason: "duplicate_session", Retry: false}
+	CloseReasonUnauthorized = CloseReason{Code: CloseUnauthorized, Reason: "unauthorized", Retry: false}
+)
+
+// payload 关闭帧内容，控制帧负载不能超过 125 字节，只包含简短的 JSON
+func (r CloseReason) payload() []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"reason": r.Reason,
+		"retry":  r.Retry,
+	})
+	return websocket.FormatCloseMessage(r.Code, string(data))
+}
+
+// setCloseReason 记录断开原因，已有原因时保留第一次设置的值
+func (c *Client) setCloseReason(reason CloseReason) {
+	c.closeReason.CompareAndSwap(nil, &reason)
+}
+
+// writeClose 发送关闭帧，每个连接只发送一次
+// WriteControl 可与其他写操作并发调用
+func (c *Client) writeClose() {
+	c.closeOnce.Do(func() {
+		reason := CloseReasonNormal
+		if r := c.closeReason.Load(); r != nil {
+			reason = *r
+		}
+		deadline := time.Now().Add(c.Hub.options.WriteTimeout)
+		c.Conn.WriteControl(websocket.CloseMessage, reason.payload(), deadline)
+	})
+}
+
+// closeClient 以指定原因断开客户端
+func (h *Hub) closeClient(client *Client, reason CloseReason) {
+	client.setCloseReason(reason)
+	h.removeClient(client)
+}
+
+// CloseAll 以指定原因断开所有客户端，用于服务关闭
+func (h *Hub) CloseAll(reason CloseReason) {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for _, client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.closeClient(client, reason)
+	}
+}
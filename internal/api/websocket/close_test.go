@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestCloseReasonPayload(t *testing.T) {
+	reasons := []CloseReason{
+		CloseReasonNormal,
+		CloseReasonShutdown,
+		CloseReasonRateLimited,
+		CloseReasonOverflow,
+		CloseReasonKicked,
+		CloseReasonUnauthorized,
+	}
+	for _, reason := range reasons {
+		t.Run(reason.Reason, func(t *testing.T) {
+			payload := reason.payload()
+			// 控制帧负载不能超过 125 字节
+			if len(payload) > 125 {
+				t.Fatalf("payload 长度 %d 超过 125 字节", len(payload))
+			}
+
+			code := int(payload[0])<<8 | int(payload[1])
+			if code != reason.Code {
+				t.Errorf("close code = %d, want %d", code, reason.Code)
+			}
+			var body struct {
+				Reason string `json:"reason"`
+				Retry  bool   `json:"retry"`
+			}
+			if err := json.Unmarshal(payload[2:], &body); err != nil {
+				t.Fatalf("解析关闭原因失败: %v", err)
+			}
+			if body.Reason != reason.Reason || body.Retry != reason.Retry {
+				t.Errorf("payload = %+v, want reason %q retry %v", body, reason.Reason, reason.Retry)
+			}
+		})
+	}
+}
+
+// readCloseError 读取消息直到连接关闭，返回关闭错误
+func readCloseError(t *testing.T, conn *websocket.Conn) *websocket.CloseError {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			closeErr, ok := err.(*websocket.CloseError)
+			if !ok {
+				t.Fatalf("ReadMessage() error = %v, want close frame", err)
+			}
+			return closeErr
+		}
+	}
+}
+
+func TestDisconnectCloseReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    HubOptions
+		disconnect func(hub *Hub, conn *websocket.Conn)
+		want       CloseReason
+	}{
+		{
+			name:    "被踢下线",
+			options: HubOptions{},
+			disconnect: func(hub *Hub, conn *websocket.Conn) {
+				hub.KickUser(1, "duplicate login")
+			},
+			want: CloseReasonKicked,
+		},
+		{
+			name:    "服务关闭",
+			options: HubOptions{},
+			disconnect: func(hub *Hub, conn *websocket.Conn) {
+				hub.CloseAll(CloseReasonShutdown)
+			},
+			want: CloseReasonShutdown,
+		},
+		{
+			name:    "消息持续超限",
+			options: HubOptions{MessageRate: 1, MessageBurst: 1, MaxRateViolations: 2},
+			disconnect: func(hub *Hub, conn *websocket.Conn) {
+				for i := 0; i < 5; i++ {
+					conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ping"}`))
+				}
+			},
+			want: CloseReasonRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), tt.options)
+			conn := startPumps(t, hub)
+			waitForClient(t, hub, 1)
+
+			tt.disconnect(hub, conn)
+
+			closeErr := readCloseError(t, conn)
+			if closeErr.Code != tt.want.Code {
+				t.Errorf("close code = %d, want %d", closeErr.Code, tt.want.Code)
+			}
+			var body struct {
+				Reason string `json:"reason"`
+				Retry  bool   `json:"retry"`
+			}
+			if err := json.Unmarshal([]byte(closeErr.Text), &body); err != nil {
+				t.Fatalf("解析关闭原因失败: %v", err)
+			}
+			if body.Reason != tt.want.Reason || body.Retry != tt.want.Retry {
+				t.Errorf("reason = %+v, want %q retry %v", body, tt.want.Reason, tt.want.Retry)
+			}
+		})
+	}
+}
+
+// waitForClient 等待用户连接注册到 Hub
+func waitForClient(t *testing.T, hub *Hub, userID uint) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		hub.mu.RLock()
+		_, ok := hub.clients[userID]
+		hub.mu.RUnlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("用户 %d 未注册", userID)
+}
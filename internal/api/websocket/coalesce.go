@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pendingRoomState 等待合并发送的房间状态
+type pendingRoomState struct {
+	userIDs []uint
+	message *outbound
+}
+
+// coalesceInterval 获取游戏类型的状态合并间隔，未单独配置时使用全局间隔
+func (h *Hub) coalesceInterval(gameType string) time.Duration {
+	if interval, ok := h.options.StateCoalesceByGameType[strings.ToLower(gameType)]; ok {
+		return interval
+	}
+	return h.options.StateCoalesceInterval
+}
+
+// BroadcastRoomState 向房间玩家发送游戏状态
+// 配置了合并间隔时，同一房间在间隔内的多次状态更新只发送最新的一次
+// 聊天等离散事件不应通过此方法发送
+func (h *Hub) BroadcastRoomState(roomID uint, gameType string, userIDs []uint, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("序列化消息失败", zap.Error(err))
+		return
+	}
+	outgoing := newOutbound(data)
+
+	interval := h.coalesceInterval(gameType)
+	if interval <= 0 {
+		h.sendToUsers(userIDs, outgoing)
+		return
+	}
+
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	if pending, ok := h.pendingStates[roomID]; ok {
+		pending.userIDs = userIDs
+		pending.message = outgoing
+		return
+	}
+
+	h.pendingStates[roomID] = &pendingRoomState{userIDs: userIDs, message: outgoing}
+	time.AfterFunc(interval, func() {
+		h.flushRoomState(roomID)
+	})
+}
+
+// flushRoomState 发送房间最新的待发送状态
+func (h *Hub) flushRoomState(roomID uint) {
+	h.stateMu.Lock()
+	pending, ok := h.pendingStates[roomID]
+	delete(h.pendingStates, roomID)
+	h.stateMu.Unlock()
+
+	if ok {
+		h.sendToUsers(pending.userIDs, pending.message)
+	}
+}
+
+// sendToUsers 发送已序列化的消息给多个用户，所有连接共用同一份编码结果
+func (h *Hub) sendToUsers(userIDs []uint, message *outbound) {
+	for _, userID := range userIDs {
+		h.mu.RLock()
+		client, ok := h.clients[userID]
+		h.mu.RUnlock()
+
+		if ok {
+			h.sendMessage(client, message)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestBroadcastRoomStateCoalesce(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  HubOptions
+		gameType string
+		wantMsgs []int // 收到的消息中的 seq
+	}{
+		{
+			name:     "间隔内的多次更新合并为最新一次",
+			options:  HubOptions{StateCoalesceInterval: 50 * time.Millisecond},
+			gameType: "chess",
+			wantMsgs: []int{3},
+		},
+		{
+			name:     "未配置间隔时逐条发送",
+			options:  HubOptions{},
+			gameType: "chess",
+			wantMsgs: []int{1, 2, 3},
+		},
+		{
+			name:     "游戏类型关闭合并",
+			options:  HubOptions{StateCoalesceInterval: 50 * time.Millisecond, StateCoalesceByGameType: map[string]time.Duration{"poker": 0}},
+			gameType: "Poker",
+			wantMsgs: []int{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), tt.options)
+			client := newLobbyClient(hub, 1)
+
+			for seq := 1; seq <= 3; seq++ {
+				hub.BroadcastRoomState(10, tt.gameType, []uint{1, 2}, map[string]interface{}{"type": "game_state", "seq": seq})
+			}
+
+			var got []int
+			timeout := time.After(200 * time.Millisecond)
+		collect:
+			for {
+				select {
+				case data := <-client.Send:
+					var msg struct {
+						Seq int `json:"seq"`
+					}
+					if err := json.Unmarshal(data.data, &msg); err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, msg.Seq)
+				case <-timeout:
+					break collect
+				}
+			}
+
+			if len(got) != len(tt.wantMsgs) {
+				t.Fatalf("received %v, want %v", got, tt.wantMsgs)
+			}
+			for i := range got {
+				if got[i] != tt.wantMsgs[i] {
+					t.Errorf("received %v, want %v", got, tt.wantMsgs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// 通过 Sec-WebSocket-Protocol 协商的消息编码子协议
+const (
+	SubprotocolJSON    = "json"    // JSON 文本帧（默认）
+	SubprotocolMsgpack = "msgpack" // MessagePack 二进制帧
+)
+
+// Codec 消息编解码器，消息统一为 {"type": ..., "data": ...} 结构
+type Codec interface {
+	Name() string                                       // 对应的子协议名
+	MessageType() int                                   // 使用的 WebSocket 帧类型
+	Encode(v interface{}) ([]byte, error)               // 编码消息
+	Decode(data []byte) (map[string]interface{}, error) // 解码客户端消息
+}
+
+// codecs 服务端支持的编解码器，按优先级排列
+var codecs = []Codec{msgpackCodec{}, jsonCodec{}}
+
+// subprotocols 升级连接时声明支持的子协议，按优先级排列
+func subprotocols() []string {
+	names := make([]string, len(codecs))
+	for i, codec := range codecs {
+		names[i] = codec.Name()
+	}
+	return names
+}
+
+// codecFor 根据协商结果选择编解码器，未协商时使用 JSON
+func codecFor(subprotocol string) Codec {
+	for _, codec := range codecs {
+		if codec.Name() == subprotocol {
+			return codec
+		}
+	}
+	return jsonCodec{}
+}
+
+// negotiatedCodec 获取升级后连接协商的编解码器
+func negotiatedCodec(conn *websocket.Conn) Codec {
+	return codecFor(conn.Subprotocol())
+}
+
+// jsonCodec JSON 文本编解码
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string     { return SubprotocolJSON }
+func (jsonCodec) MessageType() int { return websocket.TextMessage }
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte) (map[string]interface{}, error) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// msgpackHandle MessagePack 编解码选项，字符串按 str 类型编码，解码时 map 统一为 map[string]interface{}
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}()
+
+// msgpackCodec MessagePack 二进制编解码，消息内容与 JSON 一致，整数保持整数编码
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string     { return SubprotocolMsgpack }
+func (msgpackCodec) MessageType() int { return websocket.BinaryMessage }
+
+// Encode 先按 JSON 规则序列化（保留 json 标签和自定义序列化），再转换为 MessagePack
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	value, err := jsonValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(value); err != nil {
+		return nil, fmt.Errorf("编码消息失败: %w", err)
+	}
+	return out, nil
+}
+
+func (msgpackCodec) Decode(data []byte) (map[string]interface{}, error) {
+	var msg map[string]interface{}
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// jsonValue 将消息转换为 JSON 对应的通用结构，[]byte 和 json.RawMessage 视为已序列化的 JSON
+// 数字按是否为整数转换为 int64、uint64 或 float64
+func jsonValue(v interface{}) (interface{}, error) {
+	var data []byte
+	switch m := v.(type) {
+	case []byte:
+		data = m
+	case json.RawMessage:
+		data = m
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("消息不是有效的 JSON: %w", err)
+	}
+	return normalizeNumbers(value), nil
+}
+
+// normalizeNumbers 将 json.Number 替换为具体的数值类型
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+		return v.String()
+	}
+	return value
+}
+
+// outbound 待发送的消息，保存 JSON 序列化结果，并按编解码器缓存编码后的帧
+// 同一条消息发送给多个连接时，每种编码只执行一次
+type outbound struct {
+	data []byte // JSON 序列化结果
+
+	mu     sync.Mutex
+	frames map[string]encodedFrame
+}
+
+// encodedFrame 缓存的编码结果
+type encodedFrame struct {
+	data []byte
+	err  error
+}
+
+// newOutbound 包装已序列化的 JSON 消息
+func newOutbound(data []byte) *outbound {
+	return &outbound{data: data}
+}
+
+// frame 获取连接协商的帧格式，首次请求某种编码时转换并缓存
+func (m *outbound) frame(c Codec) ([]byte, error) {
+	if c.Name() == SubprotocolJSON {
+		return m.data, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.frames[c.Name()]; ok {
+		return f.data, f.err
+	}
+	data, err := c.Encode(json.RawMessage(m.data))
+	if m.frames == nil {
+		m.frames = make(map[string]encodedFrame)
+	}
+	m.frames[c.Name()] = encodedFrame{data: data, err: err}
+	return data, err
+}
@@ -0,0 +1,203 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	type payload struct {
+		Type string                 `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	message := payload{
+		Type: "game_move",
+		Data: map[string]interface{}{
+			"room_id": 42,
+			"seq":     int64(1) << 40,
+			"ratio":   0.5,
+			"move":    map[string]interface{}{"from": "e2", "to": "e4"},
+			"tags":    []string{"opening"},
+			"ok":      true,
+			"note":    nil,
+		},
+	}
+
+	// 解码后整数保持整数，小数保持小数，与 JSON 的语义一致
+	want := map[string]interface{}{
+		"type": "game_move",
+		"data": map[string]interface{}{
+			"room_id": int64(42),
+			"seq":     int64(1) << 40,
+			"ratio":   0.5,
+			"move":    map[string]interface{}{"from": "e2", "to": "e4"},
+			"tags":    []interface{}{"opening"},
+			"ok":      true,
+			"note":    nil,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		codec       Codec
+		messageType int
+	}{
+		{"JSON", jsonCodec{}, websocket.TextMessage},
+		{"MessagePack", msgpackCodec{}, websocket.BinaryMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.codec.MessageType() != tt.messageType {
+				t.Errorf("MessageType() = %d, want %d", tt.codec.MessageType(), tt.messageType)
+			}
+
+			data, err := tt.codec.Encode(message)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := tt.codec.Decode(data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			// JSON 解码的数字为 float64，统一按 JSON 规则比较
+			if tt.codec.Name() == SubprotocolJSON {
+				gotValue, err := jsonValue(got)
+				if err != nil {
+					t.Fatalf("jsonValue() error = %v", err)
+				}
+				got = gotValue.(map[string]interface{})
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecodeInvalid(t *testing.T) {
+	if _, err := (msgpackCodec{}).Decode([]byte{0xc1}); err == nil {
+		t.Errorf("Decode() 应拒绝无效的 MessagePack 数据")
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	tests := []struct {
+		subprotocol string
+		want        string
+	}{
+		{SubprotocolMsgpack, SubprotocolMsgpack},
+		{SubprotocolJSON, SubprotocolJSON},
+		{"", SubprotocolJSON},
+		{"protobuf", SubprotocolJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subprotocol, func(t *testing.T) {
+			if got := codecFor(tt.subprotocol).Name(); got != tt.want {
+				t.Errorf("codecFor(%q) = %q, want %q", tt.subprotocol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiatedBinaryFraming(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16})
+	go hub.Run()
+
+	upgrader := newUpgrader(hub.options)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{Hub: hub, Conn: conn, Send: make(chan *outbound, hub.options.SendBufferSize), UserID: 1, codec: negotiatedCodec(conn)}
+		hub.register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolMsgpack}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if conn.Subprotocol() != SubprotocolMsgpack {
+		t.Fatalf("Subprotocol() = %q, want %q", conn.Subprotocol(), SubprotocolMsgpack)
+	}
+	waitForClient(t, hub, 1)
+
+	hub.SendToUser(1, map[string]interface{}{"type": "hello", "data": map[string]interface{}{"n": 1}})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messageType != websocket.BinaryMessage {
+		t.Fatalf("messageType = %d, want binary", messageType)
+	}
+	msg, err := msgpackCodec{}.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if msg["type"] != "hello" {
+		t.Errorf("message = %v, want type hello", msg)
+	}
+}
+
+// countingCodec 统计编码次数的编解码器
+type countingCodec struct {
+	msgpackCodec
+	encodes *int
+}
+
+func (c countingCodec) Encode(v interface{}) ([]byte, error) {
+	*c.encodes++
+	return c.msgpackCodec.Encode(v)
+}
+
+func TestOutboundFrame(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{"type": "room_state", "data": map[string]interface{}{"room_id": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := newOutbound(data)
+
+	frame, err := message.frame(jsonCodec{})
+	if err != nil || string(frame) != string(data) {
+		t.Errorf("JSON 帧应直接使用序列化结果, got %q, err = %v", frame, err)
+	}
+
+	encodes := 0
+	codec := countingCodec{encodes: &encodes}
+	first, err := message.frame(codec)
+	if err != nil {
+		t.Fatalf("frame() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := message.frame(codec)
+		if err != nil || string(again) != string(first) {
+			t.Fatalf("缓存的帧不一致, err = %v", err)
+		}
+	}
+	if encodes != 1 {
+		t.Errorf("同一条消息编码了 %d 次, want 1", encodes)
+	}
+
+	decoded, err := codec.Decode(first)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if decoded["type"] != "room_state" {
+		t.Errorf("decoded = %#v", decoded)
+	}
+}
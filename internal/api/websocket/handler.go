@@ -1,22 +1,53 @@
 package websocket
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/origins"
 	"go.uber.org/zap"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // 允许跨域，生产环境应该检查来源
-	},
+// newUpgrader 根据 Hub 配置创建连接升级器
+func newUpgrader(options HubOptions) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  options.ReadBufferSize,
+		WriteBufferSize: options.WriteBufferSize,
+		Subprotocols:    subprotocols(),
+		CheckOrigin: func(r *http.Request) bool {
+			return checkOrigin(r, options.Origins)
+		},
+	}
+}
+
+// checkOrigin 检查连接来源：未携带 Origin（非浏览器客户端）或同源时放行，否则需在白名单中
+func checkOrigin(r *http.Request, matcher *origins.Matcher) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return matcher.Allow(origin)
+}
+
+// TokenRevocationChecker 令牌吊销检查
+type TokenRevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error)
 }
 
 // HandleWebSocket WebSocket 处理器
-func HandleWebSocket(hub *Hub, jwtService *utils.JWTService, logger *zap.Logger) gin.HandlerFunc {
+// 与 HTTP 接口一样拒绝已吊销的令牌；连接会长期存在，吊销检查失败时拒绝连接而不是放行
+func HandleWebSocket(hub *Hub, jwtService *utils.JWTService, revocationChecker TokenRevocationChecker, logger *zap.Logger) gin.HandlerFunc {
+	upgrader := newUpgrader(hub.options)
+
 	return func(c *gin.Context) {
 		// 从查询参数获取 Token
 		token := c.Query("token")
@@ -38,6 +69,35 @@ func HandleWebSocket(hub *Hub, jwtService *utils.JWTService, logger *zap.Logger)
 			return
 		}
 
+		// 检查令牌是否已被吊销（如登出所有设备）
+		if revocationChecker != nil {
+			revoked, err := revocationChecker.IsTokenRevoked(c.Request.Context(), claims)
+			if err != nil {
+				logger.Warn("令牌吊销检查失败，拒绝 WebSocket 连接", zap.Error(err), zap.Uint("user_id", claims.UserID))
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"code":    utils.ErrCodeInternal,
+					"message": "服务暂时不可用，请稍后重试",
+				})
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    utils.ErrCodeUnauthorized,
+					"message": "认证令牌已失效",
+				})
+				return
+			}
+		}
+
+		// reject 策略下用户已有连接时拒绝新连接
+		if hub.options.DuplicatePolicy == DuplicatePolicyReject && hub.hasClient(claims.UserID) {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    utils.ErrCodeConflict,
+				"message": "已有连接在线，请先关闭其他连接",
+			})
+			return
+		}
+
 		// 升级连接
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
@@ -49,9 +109,15 @@ func HandleWebSocket(hub *Hub, jwtService *utils.JWTService, logger *zap.Logger)
 		client := &Client{
 			Hub:      hub,
 			Conn:     conn,
-			Send:     make(chan []byte, 256),
+			Send:     make(chan *outbound, hub.options.SendBufferSize),
 			UserID:   claims.UserID,
 			Username: claims.Username,
+			codec:    negotiatedCodec(conn),
+
+			validator:     jwtService,
+			revocation:    revocationChecker,
+			tokenExpiry:   tokenExpiryOf(claims),
+			expiryUpdates: make(chan time.Time, 1),
 		}
 
 		// 注册客户端
@@ -3,29 +3,118 @@ package websocket
 import (
 	"encoding/json"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/game-apps/pkg/origins"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
 
+// 发送缓冲区满时的处理策略
+const (
+	OverflowPolicyDisconnect = "disconnect"  // 立即断开连接
+	OverflowPolicyDropOldest = "drop_oldest" // 丢弃最旧的待发送消息，持续积压后断开
+	OverflowPolicyWarn       = "warn"        // 丢弃新消息并告警，持续积压后以违反策略为由断开
+)
+
+// 同一用户建立新连接时的处理策略
+const (
+	DuplicatePolicyReplace = "replace" // 通知并断开旧连接，由新连接接管
+	DuplicatePolicyReject  = "reject"  // 拒绝新连接，保留旧连接
+)
+
+// HubOptions Hub 配置
+type HubOptions struct {
+	WriteTimeout   time.Duration // 单条消息写超时
+	SendBufferSize int           // 每个连接的发送缓冲区大小
+	OverflowPolicy string        // 发送缓冲区满时的处理策略
+	MaxOverflows   int           // 连续溢出多少次后断开连接（drop_oldest 和 warn 策略）
+
+	ReadBufferSize  int           // 升级连接时的读缓冲区大小
+	WriteBufferSize int           // 升级连接时的写缓冲区大小
+	MaxMessageSize  int64         // 单条消息最大字节数
+	PingInterval    time.Duration // 服务端发送 ping 的间隔，需小于 PongWait
+	PongWait        time.Duration // 等待客户端 pong 的超时时间
+
+	MessageRate       float64 // 每个连接每秒允许的消息数，<= 0 表示不限流
+	MessageBurst      int     // 允许的突发消息数
+	MaxRateViolations int     // 短时间内超限多少次后断开连接，<= 0 表示不断开
+
+	StateCoalesceInterval   time.Duration            // 房间状态合并发送间隔，<= 0 表示不合并
+	StateCoalesceByGameType map[string]time.Duration // 按游戏类型（小写）覆盖合并间隔
+
+	CloseOnTokenExpiry bool // 令牌过期时主动断开连接
+	AllowReauth        bool // 允许通过 reauth 消息续期连接
+
+	Origins *origins.Matcher // 允许的跨域来源，与 HTTP CORS 共用
+
+	// 同一用户建立新连接时的处理策略，每个实例上每个用户最多保留一个连接：
+	// replace 时旧连接在发送关闭帧后（最多 WriteTimeout）由服务端断开，新连接的数量不会累积
+	DuplicatePolicy string
+}
+
 // Hub WebSocket 连接中心
 type Hub struct {
 	clients    map[uint]*Client
-	broadcast  chan []byte
+	broadcast  chan *outbound
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
 	logger     *zap.Logger
+	options    HubOptions
+
+	stateMu       sync.Mutex
+	pendingStates map[uint]*pendingRoomState // 按房间等待合并发送的状态
+
+	retainedMu sync.Mutex
+	retained   []retainedMessage // 补发给新连接的保留消息（如系统公告）
+
+	listener ConnectionListener // 连接状态监听器，由 mu 保护
 }
 
 // NewHub 创建 Hub
-func NewHub(logger *zap.Logger) *Hub {
+func NewHub(logger *zap.Logger, options HubOptions) *Hub {
+	if options.WriteTimeout <= 0 {
+		options.WriteTimeout = 10 * time.Second
+	}
+	if options.SendBufferSize <= 0 {
+		options.SendBufferSize = 256
+	}
+	if options.OverflowPolicy == "" {
+		options.OverflowPolicy = OverflowPolicyDisconnect
+	}
+	if options.MaxOverflows <= 0 {
+		options.MaxOverflows = 1
+	}
+	if options.ReadBufferSize <= 0 {
+		options.ReadBufferSize = 1024
+	}
+	if options.WriteBufferSize <= 0 {
+		options.WriteBufferSize = 1024
+	}
+	if options.MaxMessageSize <= 0 {
+		options.MaxMessageSize = 64 * 1024
+	}
+	if options.PongWait <= 0 {
+		options.PongWait = 60 * time.Second
+	}
+	if options.PingInterval <= 0 || options.PingInterval >= options.PongWait {
+		options.PingInterval = options.PongWait * 9 / 10
+	}
+	if options.DuplicatePolicy == "" {
+		options.DuplicatePolicy = DuplicatePolicyReplace
+	}
+
 	return &Hub{
 		clients:    make(map[uint]*Client),
-		broadcast:  make(chan []byte, 256),
+		broadcast:  make(chan *outbound, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		logger:     logger,
+		options:    options,
+
+		pendingStates: make(map[uint]*pendingRoomState),
 	}
 }
 
@@ -34,35 +123,135 @@ func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client.UserID] = client
-			h.mu.Unlock()
+			if !h.addClient(client) {
+				continue
+			}
 			h.logger.Info("客户端已连接", zap.Uint("user_id", client.UserID))
+			h.replayRetained(client)
+			h.notifyConnected(client.UserID)
 
 		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				close(client.Send)
-			}
-			h.mu.Unlock()
+			h.removeClient(client)
 			h.logger.Info("客户端已断开", zap.Uint("user_id", client.UserID))
 
 		case message := <-h.broadcast:
+			var slow []*Client
 			h.mu.RLock()
 			for _, client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client.UserID)
+				if !h.enqueue(client, message) {
+					slow = append(slow, client)
 				}
 			}
 			h.mu.RUnlock()
+
+			for _, client := range slow {
+				h.evictSlowConsumer(client)
+			}
 		}
 	}
 }
 
+// enqueue 将消息放入客户端发送缓冲区，返回 false 表示客户端积压过多应断开
+// 调用方需持有 h.mu 读锁，保证发送期间 Send 不会被关闭
+func (h *Hub) enqueue(client *Client, message *outbound) bool {
+	sendQueueDepth.Observe(float64(len(client.Send)))
+	select {
+	case client.Send <- message:
+		client.overflows.Store(0)
+		return true
+	default:
+	}
+
+	overflows := int(client.overflows.Add(1))
+	h.logger.Warn("客户端发送缓冲区已满",
+		zap.Uint("user_id", client.UserID),
+		zap.Int("overflows", overflows),
+		zap.Int("queue_depth", len(client.Send)),
+		zap.String("policy", h.options.OverflowPolicy),
+	)
+
+	if h.options.OverflowPolicy == OverflowPolicyDisconnect || overflows >= h.options.MaxOverflows {
+		return false
+	}
+	slowConsumerTotal.WithLabelValues(slowConsumerDropped).Inc()
+	if h.options.OverflowPolicy == OverflowPolicyWarn {
+		return true
+	}
+
+	// 丢弃最旧的一条消息，为新消息腾出空间
+	select {
+	case <-client.Send:
+	default:
+	}
+	select {
+	case client.Send <- message:
+	default:
+	}
+	return true
+}
+
+// addClient 注册客户端，返回是否注册成功。同一用户已有连接时按重复连接策略处理：
+// replace 策略向旧连接发送 session_replaced 通知后以 replaced 原因关闭，大厅订阅转移到新连接；
+// reject 策略以 duplicate_session 原因关闭新连接（用于兜底升级前检查之后的并发连接）
+// 被关闭连接的发送通道在锁内关闭，之后的 removeClient 不会再次关闭
+func (h *Hub) addClient(client *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old, ok := h.clients[client.UserID]
+	if !ok || old == client {
+		h.clients[client.UserID] = client
+		return true
+	}
+
+	if h.options.DuplicatePolicy == DuplicatePolicyReject {
+		client.setCloseReason(CloseReasonDuplicate)
+		close(client.Send)
+		h.logger.Info("用户已有连接，拒绝新连接", zap.Uint("user_id", client.UserID))
+		return false
+	}
+
+	// 通知在关闭发送通道前入队，写协程会先发送通知再发送关闭帧
+	if data, err := json.Marshal(map[string]interface{}{
+		"type": "session_replaced",
+		"data": map[string]interface{}{"reason": "账号在新的连接中登录"},
+	}); err == nil {
+		select {
+		case old.Send <- newOutbound(data):
+		default:
+		}
+	}
+	old.setCloseReason(CloseReasonReplaced)
+	close(old.Send)
+	client.inheritLobbies(old)
+	h.clients[client.UserID] = client
+	h.logger.Info("连接已被新连接替换", zap.Uint("user_id", client.UserID))
+	return true
+}
+
+// hasClient 用户当前是否有已注册的连接
+func (h *Hub) hasClient(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.clients[userID]
+	return ok
+}
+
+// removeClient 移除客户端并关闭其发送通道，用户因此没有可用连接时通知监听器
+func (h *Hub) removeClient(client *Client) {
+	h.mu.Lock()
+	current, ok := h.clients[client.UserID]
+	removed := ok && current == client
+	if removed {
+		delete(h.clients, client.UserID)
+		close(client.Send)
+	}
+	h.mu.Unlock()
+
+	if removed {
+		h.notifyDisconnected(client.UserID)
+	}
+}
+
 // Broadcast 广播消息
 func (h *Hub) Broadcast(message interface{}) {
 	data, err := json.Marshal(message)
@@ -70,7 +259,7 @@ func (h *Hub) Broadcast(message interface{}) {
 		h.logger.Error("序列化消息失败", zap.Error(err))
 		return
 	}
-	h.broadcast <- data
+	h.broadcast <- newOutbound(data)
 }
 
 // SendToUser 发送消息给指定用户
@@ -83,38 +272,108 @@ func (h *Hub) SendToUser(userID uint, message interface{}) {
 		return
 	}
 
+	h.sendToClient(client, message)
+}
+
+// sendToClient 发送消息给指定连接，连接已被替换或断开时忽略
+func (h *Hub) sendToClient(client *Client, message interface{}) {
 	data, err := json.Marshal(message)
 	if err != nil {
 		h.logger.Error("序列化消息失败", zap.Error(err))
 		return
 	}
 
-	select {
-	case client.Send <- data:
-	default:
-		close(client.Send)
-		h.mu.Lock()
-		delete(h.clients, userID)
-		h.mu.Unlock()
+	h.sendMessage(client, newOutbound(data))
+}
+
+// sendMessage 发送已序列化的消息给指定连接，连接已被替换或断开时忽略
+func (h *Hub) sendMessage(client *Client, message *outbound) {
+	h.mu.RLock()
+	current, registered := h.clients[client.UserID]
+	if !registered || current != client {
+		h.mu.RUnlock()
+		return
+	}
+	ok := h.enqueue(client, message)
+	h.mu.RUnlock()
+
+	if !ok {
+		h.evictSlowConsumer(client)
+	}
+}
+
+// evictSlowConsumer 断开发送缓冲区持续积压的客户端，warn 策略以违反策略为由断开，其他策略提示稍后重连
+func (h *Hub) evictSlowConsumer(client *Client) {
+	reason := CloseReasonOverflow
+	if h.options.OverflowPolicy == OverflowPolicyWarn {
+		reason = CloseReasonSlowConsumer
+	}
+	slowConsumerTotal.WithLabelValues(slowConsumerClosed).Inc()
+	h.logger.Warn("客户端消费过慢，断开连接",
+		zap.Uint("user_id", client.UserID),
+		zap.String("reason", reason.Reason),
+	)
+	h.closeClient(client, reason)
+}
+
+// KickUser 通知用户被踢下线并断开其连接
+func (h *Hub) KickUser(userID uint, reason string) {
+	h.mu.RLock()
+	client, ok := h.clients[userID]
+	h.mu.RUnlock()
+
+	if !ok {
+		return
 	}
+
+	h.SendToUser(userID, map[string]interface{}{
+		"type": "kicked",
+		"data": map[string]interface{}{"reason": reason},
+	})
+	client.setCloseReason(CloseReasonKicked)
+	h.unregister <- client
 }
 
 // Client WebSocket 客户端
 type Client struct {
 	Hub      *Hub
 	Conn     *websocket.Conn
-	Send     chan []byte
+	Send     chan *outbound
 	UserID   uint
 	Username string
+
+	codec Codec // 协商的消息编解码器，默认为 JSON 文本
+
+	overflows atomic.Int32 // 连续发送溢出次数
+
+	closeReason atomic.Pointer[CloseReason] // 服务端主动断开的原因
+	closeOnce   sync.Once
+
+	validator     TokenValidator         // 重新认证时验证新令牌
+	revocation    TokenRevocationChecker // 重新认证时检查新令牌是否已吊销
+	tokenExpiry   time.Time              // 连接令牌的过期时间，零值表示不过期
+	expiryUpdates chan time.Time         // 重新认证后的新过期时间，由写协程消费
+
+	lobbyMu sync.Mutex
+	lobbies map[string]struct{} // 订阅的游戏类型大厅，与房间成员关系无关
 }
 
 // ReadPump 读取消息
 func (c *Client) ReadPump() {
 	defer func() {
+		c.writeClose()
 		c.Hub.unregister <- c
 		c.Conn.Close()
 	}()
 
+	limiter := newRateLimiter(c.Hub.options)
+
+	c.Conn.SetReadLimit(c.Hub.options.MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(c.Hub.options.PongWait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(c.Hub.options.PongWait))
+	})
+
 	for {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
@@ -124,13 +383,35 @@ func (c *Client) ReadPump() {
 			break
 		}
 
+		// 消息限流，超限消息丢弃，持续超限则断开连接
+		allowed, abusive := limiter.check(time.Now())
+		if abusive {
+			c.Hub.logger.Warn("客户端消息持续超限，断开连接", zap.Uint("user_id", c.UserID))
+			c.setCloseReason(CloseReasonRateLimited)
+			break
+		}
+		if !allowed {
+			c.Hub.sendToClient(c, map[string]interface{}{
+				"type": "rate_limited",
+				"data": map[string]interface{}{"message": "消息发送过于频繁"},
+			})
+			continue
+		}
+
 		// 处理消息
-		var msg map[string]interface{}
-		if err := json.Unmarshal(message, &msg); err != nil {
+		msg, err := c.codec.Decode(message)
+		if err != nil {
 			c.Hub.logger.Error("解析消息失败", zap.Error(err))
 			continue
 		}
 
+		if c.handleLobbyMessage(msg) {
+			continue
+		}
+		if c.handleReauthMessage(msg) {
+			continue
+		}
+
 		// 这里可以添加消息处理逻辑
 		c.Hub.logger.Info("收到消息", zap.Any("message", msg))
 	}
@@ -138,20 +419,50 @@ func (c *Client) ReadPump() {
 
 // WritePump 写入消息
 func (c *Client) WritePump() {
-	defer c.Conn.Close()
+	ticker := time.NewTicker(c.Hub.options.PingInterval)
+	expiry := c.newExpiryTimer()
+	defer func() {
+		ticker.Stop()
+		if expiry != nil {
+			expiry.Stop()
+		}
+		c.Conn.Close()
+	}()
 
 	for {
 		select {
 		case message, ok := <-c.Send:
 			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.writeClose()
 				return
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			data, err := message.frame(c.codec)
+			if err != nil {
+				c.Hub.logger.Error("编码消息失败", zap.Error(err), zap.String("codec", c.codec.Name()))
+				continue
+			}
+
+			c.Conn.SetWriteDeadline(time.Now().Add(c.Hub.options.WriteTimeout))
+			if err := c.Conn.WriteMessage(c.codec.MessageType(), data); err != nil {
 				c.Hub.logger.Error("写入消息失败", zap.Error(err))
 				return
 			}
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(c.Hub.options.WriteTimeout))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case expiresAt := <-c.expiryUpdates:
+			resetExpiryTimer(expiry, expiresAt)
+
+		case <-expiryChan(expiry):
+			c.Hub.logger.Info("连接令牌已过期，断开连接", zap.Uint("user_id", c.UserID))
+			c.setCloseReason(CloseReasonUnauthorized)
+			c.writeClose()
+			return
 		}
 	}
 }
@@ -0,0 +1,527 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestEnqueueOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  HubOptions
+		messages []string
+		wantOK   []bool
+		wantLast string // 缓冲区中剩余的消息
+	}{
+		{
+			name:     "缓冲区满立即断开",
+			options:  HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyDisconnect},
+			messages: []string{"a", "b"},
+			wantOK:   []bool{true, false},
+			wantLast: "a",
+		},
+		{
+			name:     "丢弃最旧的消息",
+			options:  HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyDropOldest, MaxOverflows: 3},
+			messages: []string{"a", "b", "c"},
+			wantOK:   []bool{true, true, true},
+			wantLast: "c",
+		},
+		{
+			name:     "持续积压后断开",
+			options:  HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyDropOldest, MaxOverflows: 3},
+			messages: []string{"a", "b", "c", "d"},
+			wantOK:   []bool{true, true, true, false},
+			wantLast: "c",
+		},
+		{
+			name:     "告警策略丢弃新消息",
+			options:  HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyWarn, MaxOverflows: 3},
+			messages: []string{"a", "b", "c"},
+			wantOK:   []bool{true, true, true},
+			wantLast: "a",
+		},
+		{
+			name:     "告警策略持续积压后断开",
+			options:  HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyWarn, MaxOverflows: 3},
+			messages: []string{"a", "b", "c", "d"},
+			wantOK:   []bool{true, true, true, false},
+			wantLast: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), tt.options)
+			client := &Client{Hub: hub, Send: make(chan *outbound, hub.options.SendBufferSize), UserID: 1}
+
+			for i, msg := range tt.messages {
+				if got := hub.enqueue(client, newOutbound([]byte(msg))); got != tt.wantOK[i] {
+					t.Fatalf("enqueue(%q) = %v, want %v", msg, got, tt.wantOK[i])
+				}
+			}
+			if got := string((<-client.Send).data); got != tt.wantLast {
+				t.Errorf("queued message = %q, want %q", got, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestEnqueueResetsOverflows(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 1, OverflowPolicy: OverflowPolicyDropOldest, MaxOverflows: 2})
+	client := &Client{Hub: hub, Send: make(chan *outbound, 1), UserID: 1}
+
+	// 每次溢出后客户端都消费了消息，连续溢出次数不会累积到上限
+	for i := 0; i < 5; i++ {
+		hub.enqueue(client, newOutbound([]byte("a")))
+		if !hub.enqueue(client, newOutbound([]byte("b"))) {
+			t.Fatalf("round %d: enqueue() = false, want true", i)
+		}
+		<-client.Send
+	}
+}
+
+func TestWritePumpSlowClientTimeout(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{WriteTimeout: 50 * time.Millisecond, SendBufferSize: 4})
+	done := make(chan struct{})
+	clients := make(chan *Client, 1)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{Hub: hub, Conn: conn, Send: make(chan *outbound, hub.options.SendBufferSize), UserID: 1, codec: negotiatedCodec(conn)}
+		clients <- client
+		go func() {
+			client.WritePump()
+			close(done)
+		}()
+	}))
+	defer server.Close()
+
+	// 客户端建立连接后从不读取，服务端写入最终因写超时失败
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := <-clients
+	payload := newOutbound([]byte(strings.Repeat("x", 1<<20)))
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case client.Send <- payload:
+		case <-done:
+			return
+		case <-deadline:
+			t.Fatal("WritePump did not return for a slow client")
+		}
+	}
+}
+
+func TestNewHubOptionDefaults(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          HubOptions
+		wantPingInterval time.Duration
+		wantPongWait     time.Duration
+		wantReadLimit    int64
+	}{
+		{"使用配置值", HubOptions{PingInterval: 5 * time.Second, PongWait: 10 * time.Second, MaxMessageSize: 512}, 5 * time.Second, 10 * time.Second, 512},
+		{"未配置时使用默认值", HubOptions{}, 54 * time.Second, 60 * time.Second, 64 * 1024},
+		{"ping 间隔不小于 pong 等待时间时修正", HubOptions{PingInterval: 20 * time.Second, PongWait: 10 * time.Second}, 9 * time.Second, 10 * time.Second, 64 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := NewHub(zap.NewNop(), tt.options).options
+			if options.PingInterval != tt.wantPingInterval || options.PongWait != tt.wantPongWait || options.MaxMessageSize != tt.wantReadLimit {
+				t.Errorf("options = ping %v, pong %v, read limit %d", options.PingInterval, options.PongWait, options.MaxMessageSize)
+			}
+		})
+	}
+
+	upgrader := newUpgrader(NewHub(zap.NewNop(), HubOptions{ReadBufferSize: 2048, WriteBufferSize: 4096}).options)
+	if upgrader.ReadBufferSize != 2048 || upgrader.WriteBufferSize != 4096 {
+		t.Errorf("upgrader buffers = %d/%d, want 2048/4096", upgrader.ReadBufferSize, upgrader.WriteBufferSize)
+	}
+}
+
+// startPumps 启动一个服务端运行读写协程的测试服务器，返回客户端连接
+func startPumps(t *testing.T, hub *Hub) *websocket.Conn {
+	t.Helper()
+	return startPumpsWith(t, hub, nil)
+}
+
+// startPumpsWith 同 startPumps，setup 非空时在启动读写协程前修改服务端客户端
+func startPumpsWith(t *testing.T, hub *Hub, setup func(client *Client)) *websocket.Conn {
+	t.Helper()
+	go hub.Run()
+
+	upgrader := newUpgrader(hub.options)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{Hub: hub, Conn: conn, Send: make(chan *outbound, hub.options.SendBufferSize), UserID: 1, codec: negotiatedCodec(conn)}
+		if setup != nil {
+			setup(client)
+		}
+		hub.register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestPumpsApplyConfig(t *testing.T) {
+	t.Run("按 ping 间隔发送 ping", func(t *testing.T) {
+		hub := NewHub(zap.NewNop(), HubOptions{PingInterval: 20 * time.Millisecond, PongWait: time.Second})
+		conn := startPumps(t, hub)
+
+		pings := make(chan struct{}, 8)
+		conn.SetPingHandler(func(string) error {
+			pings <- struct{}{}
+			return nil
+		})
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-pings:
+		case <-time.After(time.Second):
+			t.Fatal("no ping received")
+		}
+	})
+
+	t.Run("超过消息大小限制时断开", func(t *testing.T) {
+		hub := NewHub(zap.NewNop(), HubOptions{MaxMessageSize: 16})
+		conn := startPumps(t, hub)
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 64))); err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, err := conn.ReadMessage()
+		if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Errorf("ReadMessage() error = %v, want close 1009", err)
+		}
+	})
+
+	t.Run("未收到 pong 时断开", func(t *testing.T) {
+		hub := NewHub(zap.NewNop(), HubOptions{PingInterval: 20 * time.Millisecond, PongWait: 50 * time.Millisecond})
+		conn := startPumps(t, hub)
+		// 客户端忽略 ping，不回复 pong
+		conn.SetPingHandler(func(string) error { return nil })
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		if netErr, ok := err.(net.Error); err == nil || (ok && netErr.Timeout()) {
+			t.Errorf("ReadMessage() error = %v, want connection closed by server", err)
+		}
+	})
+}
+
+func TestRegisterReplacesExistingClient(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16})
+	go hub.Run()
+
+	upgrader := newUpgrader(hub.options)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{Hub: hub, Conn: conn, Send: make(chan *outbound, hub.options.SendBufferSize), UserID: 1, codec: negotiatedCodec(conn)}
+		hub.register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+
+	dial := func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	first := dial()
+	waitForClient(t, hub, 1)
+	hub.mu.RLock()
+	firstClient := hub.clients[1]
+	hub.mu.RUnlock()
+
+	second := dial()
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.mu.RLock()
+		replaced := hub.clients[1] != firstClient
+		hub.mu.RUnlock()
+		if replaced {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("第二个连接未注册")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 旧连接以 replaced 原因关闭
+	if closeErr := readCloseError(t, first); closeErr.Code != CloseReasonReplaced.Code || !strings.Contains(closeErr.Text, CloseReasonReplaced.Reason) {
+		t.Errorf("close = %d %q, want %d %q", closeErr.Code, closeErr.Text, CloseReasonReplaced.Code, CloseReasonReplaced.Reason)
+	}
+
+	// 旧连接注销不影响新连接
+	hub.unregister <- firstClient
+	hub.SendToUser(1, map[string]string{"type": "hello"})
+	second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := second.ReadMessage()
+	if err != nil {
+		t.Fatalf("新连接读取消息失败: %v", err)
+	}
+	if !strings.Contains(string(data), "hello") {
+		t.Errorf("message = %s, want hello", data)
+	}
+}
+
+func TestAddClientDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		wantAdded  bool
+		wantActive string // 注册后保留的连接
+	}{
+		{"默认替换旧连接", "", true, "new"},
+		{"替换旧连接", DuplicatePolicyReplace, true, "new"},
+		{"拒绝新连接", DuplicatePolicyReject, false, "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), HubOptions{DuplicatePolicy: tt.policy})
+			old := newLobbyClient(hub, 1)
+			old.SubscribeLobby("chess")
+			client := &Client{Hub: hub, Send: make(chan *outbound, 8), UserID: 1}
+
+			if added := hub.addClient(client); added != tt.wantAdded {
+				t.Fatalf("addClient() = %v, want %v", added, tt.wantAdded)
+			}
+			active, closed := client, old
+			if tt.wantActive == "old" {
+				active, closed = old, client
+			}
+			if hub.clients[1] != active {
+				t.Fatalf("hub 中保留了错误的连接")
+			}
+
+			// 被关闭的连接先收到通知（仅替换时），随后发送通道关闭
+			var messages []string
+			for data := range closed.Send {
+				messages = append(messages, string(data.data))
+			}
+			if tt.wantAdded {
+				if len(messages) != 1 || !strings.Contains(messages[0], "session_replaced") {
+					t.Errorf("旧连接收到 %v, want session_replaced", messages)
+				}
+				if r := closed.closeReason.Load(); r == nil || *r != CloseReasonReplaced {
+					t.Errorf("close reason = %v, want replaced", r)
+				}
+				if !client.InLobby("chess") {
+					t.Errorf("新连接应接管旧连接的大厅订阅")
+				}
+			} else {
+				if len(messages) != 0 {
+					t.Errorf("被拒绝的连接收到 %v", messages)
+				}
+				if r := closed.closeReason.Load(); r == nil || *r != CloseReasonDuplicate {
+					t.Errorf("close reason = %v, want duplicate_session", r)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleWebSocketDuplicateReject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16, DuplicatePolicy: DuplicatePolicyReject})
+	go hub.Run()
+
+	router := gin.New()
+	router.GET("/ws", HandleWebSocket(hub, jwtService, nil, zap.NewNop()))
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + issueToken(t, jwtService, 1)
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { first.Close() })
+	waitForClient(t, hub, 1)
+
+	// 已有连接在线时新连接被拒绝，旧连接不受影响
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("重复连接应被拒绝")
+	}
+	if resp == nil || resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %v", resp)
+	}
+	hub.SendToUser(1, map[string]string{"type": "hello"})
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, data, err := first.ReadMessage(); err != nil || !strings.Contains(string(data), "hello") {
+		t.Errorf("旧连接读取消息 = %s, %v", data, err)
+	}
+}
+
+func TestHandleWebSocketDuplicateReplace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+	// 默认策略为 replace
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16})
+	go hub.Run()
+
+	router := gin.New()
+	router.GET("/ws", HandleWebSocket(hub, jwtService, nil, zap.NewNop()))
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + issueToken(t, jwtService, 1)
+
+	// 同一用户反复连接，每个新连接都替换并断开上一个连接，Hub 中始终只有一个连接
+	var previous *websocket.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("第 %d 个连接失败: %v", i+1, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		if previous != nil {
+			if closeErr := readCloseError(t, previous); closeErr.Code != CloseReasonReplaced.Code {
+				t.Fatalf("旧连接关闭码 = %d, want %d", closeErr.Code, CloseReasonReplaced.Code)
+			}
+		} else {
+			waitForClient(t, hub, 1)
+		}
+		previous = conn
+	}
+
+	hub.mu.RLock()
+	registered := len(hub.clients)
+	hub.mu.RUnlock()
+	if registered != 1 {
+		t.Errorf("Hub 中的连接数 = %d, want 1", registered)
+	}
+	hub.SendToUser(1, map[string]string{"type": "hello"})
+	previous.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, data, err := previous.ReadMessage(); err != nil || !strings.Contains(string(data), "hello") {
+		t.Errorf("最新连接读取消息 = %s, %v", data, err)
+	}
+}
+
+// stubRevocationChecker 返回固定结果的吊销检查
+type stubRevocationChecker struct {
+	revoked bool
+	err     error
+}
+
+func (c stubRevocationChecker) IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error) {
+	return c.revoked, c.err
+}
+
+func TestHandleWebSocketRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+
+	tests := []struct {
+		name       string
+		checker    TokenRevocationChecker
+		wantStatus int // 为 0 表示连接成功
+	}{
+		{"未吊销", stubRevocationChecker{}, 0},
+		{"令牌已吊销", stubRevocationChecker{revoked: true}, http.StatusUnauthorized},
+		{"吊销检查失败时拒绝", stubRevocationChecker{err: errors.New("redis down")}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16})
+			go hub.Run()
+
+			router := gin.New()
+			router.GET("/ws", HandleWebSocket(hub, jwtService, tt.checker, zap.NewNop()))
+			server := httptest.NewServer(router)
+			t.Cleanup(server.Close)
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + issueToken(t, jwtService, 1)
+
+			conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Dial() error = %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("连接应被拒绝")
+			}
+			if resp == nil || resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %v", tt.wantStatus, resp)
+			}
+			if hub.hasClient(1) {
+				t.Error("被拒绝的连接不应注册到 Hub")
+			}
+		})
+	}
+}
+
+func TestSlowConsumerEviction(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 2, OverflowPolicy: OverflowPolicyWarn, MaxOverflows: 2})
+	client := &Client{Hub: hub, Send: make(chan *outbound, 2), UserID: 1}
+	hub.clients[1] = client
+	dropped := testutil.ToFloat64(slowConsumerTotal.WithLabelValues(slowConsumerDropped))
+	closed := testutil.ToFloat64(slowConsumerTotal.WithLabelValues(slowConsumerClosed))
+
+	// 客户端不消费消息：填满缓冲区后丢弃一条，再次溢出时断开
+	for i := 0; i < 4; i++ {
+		hub.SendToUser(1, map[string]int{"n": i})
+	}
+
+	if r := client.closeReason.Load(); r == nil || *r != CloseReasonSlowConsumer {
+		t.Fatalf("close reason = %v, want slow_consumer", r)
+	}
+	if got := testutil.ToFloat64(slowConsumerTotal.WithLabelValues(slowConsumerDropped)) - dropped; got != 1 {
+		t.Errorf("dropped = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(slowConsumerTotal.WithLabelValues(slowConsumerClosed)) - closed; got != 1 {
+		t.Errorf("closed = %v, want 1", got)
+	}
+}
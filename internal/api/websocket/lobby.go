@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// 大厅订阅消息类型
+const (
+	MessageTypeSubscribeLobby   = "subscribe_lobby"
+	MessageTypeUnsubscribeLobby = "unsubscribe_lobby"
+)
+
+// lobbyKey 规范化大厅名（游戏类型）
+func lobbyKey(gameType string) string {
+	return strings.ToLower(strings.TrimSpace(gameType))
+}
+
+// SubscribeLobby 订阅游戏类型大厅
+func (c *Client) SubscribeLobby(gameType string) {
+	c.lobbyMu.Lock()
+	defer c.lobbyMu.Unlock()
+	if c.lobbies == nil {
+		c.lobbies = make(map[string]struct{})
+	}
+	c.lobbies[lobbyKey(gameType)] = struct{}{}
+}
+
+// UnsubscribeLobby 取消订阅游戏类型大厅
+func (c *Client) UnsubscribeLobby(gameType string) {
+	c.lobbyMu.Lock()
+	defer c.lobbyMu.Unlock()
+	delete(c.lobbies, lobbyKey(gameType))
+}
+
+// InLobby 是否订阅了指定游戏类型大厅
+func (c *Client) InLobby(gameType string) bool {
+	c.lobbyMu.Lock()
+	defer c.lobbyMu.Unlock()
+	_, ok := c.lobbies[lobbyKey(gameType)]
+	return ok
+}
+
+// inheritLobbies 接管被替换连接的大厅订阅，重连后无需重新订阅
+func (c *Client) inheritLobbies(old *Client) {
+	old.lobbyMu.Lock()
+	lobbies := make([]string, 0, len(old.lobbies))
+	for lobby := range old.lobbies {
+		lobbies = append(lobbies, lobby)
+	}
+	old.lobbyMu.Unlock()
+
+	for _, lobby := range lobbies {
+		c.SubscribeLobby(lobby)
+	}
+}
+
+// BroadcastToLobby 向订阅了指定游戏类型大厅的客户端广播消息
+func (h *Hub) BroadcastToLobby(gameType string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		h.logger.Error("序列化消息失败", zap.Error(err))
+		return
+	}
+
+	outgoing := newOutbound(data)
+	var slow []*Client
+	h.mu.RLock()
+	for _, client := range h.clients {
+		if client.InLobby(gameType) && !h.enqueue(client, outgoing) {
+			slow = append(slow, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range slow {
+		h.closeClient(client, CloseReasonOverflow)
+	}
+}
+
+// handleLobbyMessage 处理大厅订阅消息，返回是否已处理
+func (c *Client) handleLobbyMessage(msg map[string]interface{}) bool {
+	msgType, _ := msg["type"].(string)
+	if msgType != MessageTypeSubscribeLobby && msgType != MessageTypeUnsubscribeLobby {
+		return false
+	}
+
+	data, _ := msg["data"].(map[string]interface{})
+	gameType, _ := data["game_type"].(string)
+	if lobbyKey(gameType) == "" {
+		c.Hub.logger.Warn("大厅订阅缺少游戏类型", zap.Uint("user_id", c.UserID))
+		return true
+	}
+
+	if msgType == MessageTypeSubscribeLobby {
+		c.SubscribeLobby(gameType)
+	} else {
+		c.UnsubscribeLobby(gameType)
+	}
+	return true
+}
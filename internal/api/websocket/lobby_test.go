@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newLobbyClient 创建注册到 Hub 的测试客户端（不建立真实连接）
+func newLobbyClient(hub *Hub, userID uint) *Client {
+	client := &Client{Hub: hub, Send: make(chan *outbound, 8), UserID: userID}
+	hub.clients[userID] = client
+	return client
+}
+
+func TestBroadcastToLobby(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{})
+	poker := newLobbyClient(hub, 1)
+	chess := newLobbyClient(hub, 2)
+	idle := newLobbyClient(hub, 3)
+
+	poker.handleLobbyMessage(map[string]interface{}{
+		"type": MessageTypeSubscribeLobby,
+		"data": map[string]interface{}{"game_type": "Poker"},
+	})
+	chess.handleLobbyMessage(map[string]interface{}{
+		"type": MessageTypeSubscribeLobby,
+		"data": map[string]interface{}{"game_type": "chess"},
+	})
+
+	hub.BroadcastToLobby("poker", map[string]interface{}{"type": "lobby_room_created"})
+
+	select {
+	case data := <-poker.Send:
+		var msg map[string]interface{}
+		if err := json.Unmarshal(data.data, &msg); err != nil || msg["type"] != "lobby_room_created" {
+			t.Errorf("poker subscriber got %s", data.data)
+		}
+	default:
+		t.Error("poker subscriber got no message")
+	}
+	for name, client := range map[string]*Client{"chess": chess, "idle": idle} {
+		select {
+		case data := <-client.Send:
+			t.Errorf("%s client got unexpected message %s", name, data.data)
+		default:
+		}
+	}
+
+	// 取消订阅后不再收到大厅消息
+	poker.handleLobbyMessage(map[string]interface{}{
+		"type": MessageTypeUnsubscribeLobby,
+		"data": map[string]interface{}{"game_type": "poker"},
+	})
+	hub.BroadcastToLobby("poker", map[string]interface{}{"type": "lobby_room_closed"})
+	select {
+	case data := <-poker.Send:
+		t.Errorf("unsubscribed client got %s", data.data)
+	default:
+	}
+}
+
+func TestHandleLobbyMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         map[string]interface{}
+		wantHandled bool
+	}{
+		{"订阅消息", map[string]interface{}{"type": MessageTypeSubscribeLobby, "data": map[string]interface{}{"game_type": "go"}}, true},
+		{"缺少游戏类型", map[string]interface{}{"type": MessageTypeSubscribeLobby}, true},
+		{"其他消息", map[string]interface{}{"type": "chat"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{Hub: NewHub(zap.NewNop(), HubOptions{}), UserID: 1}
+			if got := client.handleLobbyMessage(tt.msg); got != tt.wantHandled {
+				t.Errorf("handleLobbyMessage() = %v, want %v", got, tt.wantHandled)
+			}
+		})
+	}
+}
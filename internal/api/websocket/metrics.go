@@ -0,0 +1,30 @@
+package websocket
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	sendQueueDepth = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "websocket_send_queue_depth",
+			Help:    "Number of messages already queued on a connection when a new message is enqueued",
+			Buckets: []float64{0, 1, 4, 16, 64, 128, 256, 512, 1024},
+		},
+	)
+
+	slowConsumerTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_slow_consumer_total",
+			Help: "Total number of messages dropped and connections closed because the send buffer was full",
+		},
+		[]string{"action"},
+	)
+)
+
+// 慢消费者处理动作标签
+const (
+	slowConsumerDropped = "dropped" // 丢弃了一条消息
+	slowConsumerClosed  = "closed"  // 断开了连接
+)
@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/game-apps/pkg/origins"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	matcher, err := origins.NewMatcher([]string{"https://example.com", "https://*.game.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		matcher *origins.Matcher
+		origin  string
+		want    bool
+	}{
+		{"非浏览器客户端", matcher, "", true},
+		{"同源请求", nil, "http://api.local", true},
+		{"精确匹配", matcher, "https://example.com", true},
+		{"通配子域名", matcher, "https://eu.game.example.com", true},
+		{"后缀相同的其他域名", matcher, "https://evilgame.example.com", false},
+		{"未配置的来源", matcher, "https://attacker.com", false},
+		{"未配置白名单", nil, "https://example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://api.local/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := checkOrigin(req, tt.matcher); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
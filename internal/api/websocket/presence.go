@@ -0,0 +1,47 @@
+package websocket
+
+// ConnectionListener 用户连接状态变化的监听接口，用于标记断线玩家暂离等
+// 回调在独立的协程中执行，不阻塞 Hub
+type ConnectionListener interface {
+	UserConnected(userID uint)
+	UserDisconnected(userID uint)
+}
+
+// SetConnectionListener 设置连接状态监听器，listener 为 nil 时取消监听
+// 同一用户的连接被新连接替换时不视为断开
+func (h *Hub) SetConnectionListener(listener ConnectionListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listener = listener
+}
+
+// ConnectedUserIDs 返回本实例上已建立连接的用户，供心跳刷新跨实例的连接记录
+func (h *Hub) ConnectedUserIDs() []uint {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	userIDs := make([]uint, 0, len(h.clients))
+	for userID := range h.clients {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// notifyConnected 通知用户已建立连接
+func (h *Hub) notifyConnected(userID uint) {
+	h.mu.RLock()
+	listener := h.listener
+	h.mu.RUnlock()
+	if listener != nil {
+		go listener.UserConnected(userID)
+	}
+}
+
+// notifyDisconnected 通知用户已没有可用连接
+func (h *Hub) notifyDisconnected(userID uint) {
+	h.mu.RLock()
+	listener := h.listener
+	h.mu.RUnlock()
+	if listener != nil {
+		go listener.UserDisconnected(userID)
+	}
+}
@@ -0,0 +1,68 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recordingListener 按顺序记录连接状态变化
+type recordingListener struct {
+	events chan string
+}
+
+func (l *recordingListener) UserConnected(userID uint) {
+	l.events <- "connected"
+}
+
+func (l *recordingListener) UserDisconnected(userID uint) {
+	l.events <- "disconnected"
+}
+
+func TestConnectionListener(t *testing.T) {
+	hub := NewHub(zap.NewNop(), HubOptions{SendBufferSize: 16})
+	listener := &recordingListener{events: make(chan string, 8)}
+	hub.SetConnectionListener(listener)
+	go hub.Run()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-listener.events:
+			if got != want {
+				t.Fatalf("event = %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("未收到 %s 通知", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case got := <-listener.events:
+			t.Fatalf("unexpected event %s", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	first := &Client{Hub: hub, Send: make(chan *outbound, 16), UserID: 1}
+	hub.register <- first
+	expect("connected")
+
+	// 被新连接替换的旧连接注销时不视为断开
+	second := &Client{Hub: hub, Send: make(chan *outbound, 16), UserID: 1}
+	hub.register <- second
+	expect("connected")
+	hub.unregister <- first
+	expectNone()
+	if got := hub.ConnectedUserIDs(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("ConnectedUserIDs() = %v, want [1]", got)
+	}
+
+	hub.unregister <- second
+	expect("disconnected")
+	if got := hub.ConnectedUserIDs(); len(got) != 0 {
+		t.Errorf("断开后 ConnectedUserIDs() = %v, want []", got)
+	}
+}
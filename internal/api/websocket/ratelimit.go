@@ -0,0 +1,80 @@
+package websocket
+
+import "time"
+
+// violationWindow 超过该时间没有再超限，则重新累计超限次数
+const violationWindow = 10 * time.Second
+
+// tokenBucket 令牌桶限流器，仅在单个连接的读协程中使用，无需加锁
+type tokenBucket struct {
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket 创建令牌桶，rate <= 0 时返回 nil 表示不限流
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow 尝试取出一个令牌
+func (b *tokenBucket) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter 连接消息限流，记录持续超限的次数
+type rateLimiter struct {
+	bucket        *tokenBucket
+	maxViolations int
+	violations    int
+	lastViolation time.Time
+}
+
+// newRateLimiter 根据 Hub 配置创建连接限流器
+func newRateLimiter(options HubOptions) *rateLimiter {
+	return &rateLimiter{
+		bucket:        newTokenBucket(options.MessageRate, options.MessageBurst),
+		maxViolations: options.MaxRateViolations,
+	}
+}
+
+// check 检查消息是否允许处理；第二个返回值表示是否因持续超限应断开连接
+func (l *rateLimiter) check(now time.Time) (allowed bool, abusive bool) {
+	if l.bucket.allow(now) {
+		return true, false
+	}
+
+	if now.Sub(l.lastViolation) > violationWindow {
+		l.violations = 0
+	}
+	l.violations++
+	l.lastViolation = now
+
+	return false, l.maxViolations > 0 && l.violations >= l.maxViolations
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterCheck(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name          string
+		options       HubOptions
+		offsets       []time.Duration // 每条消息相对开始时间的到达时间
+		wantAllowed   int
+		wantAbusiveAt int // 第几条消息（从 1 开始）触发断开，0 表示不断开
+	}{
+		{
+			name:        "突发在容量内全部通过",
+			options:     HubOptions{MessageRate: 1, MessageBurst: 5},
+			offsets:     []time.Duration{0, 0, 0, 0, 0},
+			wantAllowed: 5,
+		},
+		{
+			name:        "超出容量的消息被限流",
+			options:     HubOptions{MessageRate: 1, MessageBurst: 3},
+			offsets:     []time.Duration{0, 0, 0, 0, 0},
+			wantAllowed: 3,
+		},
+		{
+			name:        "令牌随时间补充",
+			options:     HubOptions{MessageRate: 10, MessageBurst: 1},
+			offsets:     []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond},
+			wantAllowed: 3,
+		},
+		{
+			name:          "持续超限时断开",
+			options:       HubOptions{MessageRate: 1, MessageBurst: 1, MaxRateViolations: 3},
+			offsets:       []time.Duration{0, 0, 0, 0, 0},
+			wantAllowed:   1,
+			wantAbusiveAt: 4,
+		},
+		{
+			name:        "超限间隔较长时重新累计",
+			options:     HubOptions{MessageRate: 0.01, MessageBurst: 1, MaxRateViolations: 2},
+			offsets:     []time.Duration{0, time.Second, time.Second + violationWindow + time.Second},
+			wantAllowed: 1,
+		},
+		{
+			name:        "未配置速率时不限流",
+			options:     HubOptions{},
+			offsets:     []time.Duration{0, 0, 0, 0, 0, 0, 0, 0},
+			wantAllowed: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter(tt.options)
+			if limiter.bucket != nil {
+				limiter.bucket.last = start
+			}
+
+			allowed, abusiveAt := 0, 0
+			for i, offset := range tt.offsets {
+				ok, abusive := limiter.check(start.Add(offset))
+				if ok {
+					allowed++
+				}
+				if abusive && abusiveAt == 0 {
+					abusiveAt = i + 1
+				}
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed = %d, want %d", allowed, tt.wantAllowed)
+			}
+			if abusiveAt != tt.wantAbusiveAt {
+				t.Errorf("abusive at message %d, want %d", abusiveAt, tt.wantAbusiveAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// MessageTypeReauth 重新认证消息类型，data.token 为新的访问令牌
+const MessageTypeReauth = "reauth"
+
+// TokenValidator 令牌验证接口
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*utils.JWTClaims, error)
+}
+
+// tokenExpiryOf 获取令牌过期时间，未设置过期时间时返回零值
+func tokenExpiryOf(claims *utils.JWTClaims) time.Time {
+	if claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
+
+// newExpiryTimer 创建令牌过期定时器，未开启过期断开或令牌不过期时返回 nil
+func (c *Client) newExpiryTimer() *time.Timer {
+	if !c.Hub.options.CloseOnTokenExpiry || c.tokenExpiry.IsZero() {
+		return nil
+	}
+	return time.NewTimer(time.Until(c.tokenExpiry))
+}
+
+// expiryChan 获取定时器通道，定时器为 nil 时返回永不触发的通道
+func expiryChan(timer *time.Timer) <-chan time.Time {
+	if timer == nil {
+		return nil
+	}
+	return timer.C
+}
+
+// resetExpiryTimer 按新的过期时间重置定时器，新令牌不过期时停止定时器
+func resetExpiryTimer(timer *time.Timer, expiresAt time.Time) {
+	if timer == nil {
+		return
+	}
+	// 先停止并排空已触发的定时器，避免重置后立即读到旧的触发值
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	if !expiresAt.IsZero() {
+		timer.Reset(time.Until(expiresAt))
+	}
+}
+
+// handleReauthMessage 处理重新认证消息，返回是否已处理
+// 新令牌必须属于同一用户且未被吊销，验证通过后以新令牌的过期时间续期连接
+// 新令牌已被吊销时以 unauthorized 原因断开连接，吊销检查失败时拒绝续期
+func (c *Client) handleReauthMessage(msg map[string]interface{}) bool {
+	msgType, _ := msg["type"].(string)
+	if msgType != MessageTypeReauth {
+		return false
+	}
+
+	if !c.Hub.options.AllowReauth || c.validator == nil {
+		c.sendReauthResult(false, "不支持重新认证", time.Time{})
+		return true
+	}
+
+	data, _ := msg["data"].(map[string]interface{})
+	token, _ := data["token"].(string)
+	if token == "" {
+		c.sendReauthResult(false, "未提供认证令牌", time.Time{})
+		return true
+	}
+
+	claims, err := c.validator.ValidateToken(token)
+	if err != nil || claims.UserID != c.UserID {
+		c.Hub.logger.Warn("WebSocket 重新认证失败", zap.Uint("user_id", c.UserID), zap.Error(err))
+		c.sendReauthResult(false, "无效的认证令牌", time.Time{})
+		return true
+	}
+
+	if c.revocation != nil {
+		revoked, err := c.revocation.IsTokenRevoked(context.Background(), claims)
+		if err != nil {
+			c.Hub.logger.Warn("WebSocket 重新认证时吊销检查失败", zap.Uint("user_id", c.UserID), zap.Error(err))
+			c.sendReauthResult(false, "服务暂时不可用，请稍后重试", time.Time{})
+			return true
+		}
+		if revoked {
+			c.Hub.logger.Info("WebSocket 重新认证的令牌已吊销，断开连接", zap.Uint("user_id", c.UserID))
+			c.sendReauthResult(false, "认证令牌已失效", time.Time{})
+			c.Hub.closeClient(c, CloseReasonUnauthorized)
+			return true
+		}
+	}
+
+	expiresAt := tokenExpiryOf(claims)
+	// 只保留最新的过期时间，写协程尚未消费的旧值直接丢弃
+	select {
+	case <-c.expiryUpdates:
+	default:
+	}
+	select {
+	case c.expiryUpdates <- expiresAt:
+	default:
+	}
+
+	c.sendReauthResult(true, "", expiresAt)
+	return true
+}
+
+// sendReauthResult 发送重新认证结果
+func (c *Client) sendReauthResult(ok bool, message string, expiresAt time.Time) {
+	result := map[string]interface{}{"success": ok}
+	if message != "" {
+		result["message"] = message
+	}
+	if !expiresAt.IsZero() {
+		result["expires_at"] = expiresAt.Unix()
+	}
+	c.Hub.sendToClient(c, map[string]interface{}{
+		"type": "reauth_result",
+		"data": result,
+	})
+}
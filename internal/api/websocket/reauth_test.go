@@ -0,0 +1,170 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// issueToken 签发指定用户的令牌
+func issueToken(t *testing.T, jwtService *utils.JWTService, userID uint) string {
+	t.Helper()
+	token, err := jwtService.GenerateToken(userID, "alice", utils.ClientTypeWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// startReauthPumps 启动携带令牌过期时间的测试连接
+func startReauthPumps(t *testing.T, options HubOptions, jwtService *utils.JWTService, expiresAt time.Time) *websocket.Conn {
+	t.Helper()
+	hub := NewHub(zap.NewNop(), options)
+	return startPumpsWith(t, hub, func(client *Client) {
+		client.validator = jwtService
+		client.tokenExpiry = expiresAt
+		client.expiryUpdates = make(chan time.Time, 1)
+	})
+}
+
+func TestTokenExpiryClose(t *testing.T) {
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+
+	t.Run("令牌过期时断开", func(t *testing.T) {
+		conn := startReauthPumps(t, HubOptions{CloseOnTokenExpiry: true}, jwtService, time.Now().Add(50*time.Millisecond))
+
+		closeErr := readCloseError(t, conn)
+		if closeErr.Code != CloseUnauthorized {
+			t.Errorf("close code = %d, want %d", closeErr.Code, CloseUnauthorized)
+		}
+	})
+
+	t.Run("未开启时不断开", func(t *testing.T) {
+		conn := startReauthPumps(t, HubOptions{}, jwtService, time.Now().Add(50*time.Millisecond))
+
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		_, _, err := conn.ReadMessage()
+		if _, ok := err.(*websocket.CloseError); ok {
+			t.Errorf("ReadMessage() error = %v, want read timeout", err)
+		}
+	})
+}
+
+func TestReauth(t *testing.T) {
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+	validToken := issueToken(t, jwtService, 1)
+	otherUserToken := issueToken(t, jwtService, 2)
+
+	tests := []struct {
+		name        string
+		allowReauth bool
+		token       string
+		wantSuccess bool
+	}{
+		{"续期成功", true, validToken, true},
+		{"其他用户的令牌", true, otherUserToken, false},
+		{"无效令牌", true, "not-a-token", false},
+		{"未开启重新认证", false, validToken, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := HubOptions{CloseOnTokenExpiry: true, AllowReauth: tt.allowReauth}
+			conn := startReauthPumps(t, options, jwtService, time.Now().Add(300*time.Millisecond))
+
+			msg, _ := json.Marshal(map[string]interface{}{
+				"type": MessageTypeReauth,
+				"data": map[string]interface{}{"token": tt.token},
+			})
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				t.Fatal(err)
+			}
+
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			var result struct {
+				Type string `json:"type"`
+				Data struct {
+					Success bool `json:"success"`
+				} `json:"data"`
+			}
+			if err := conn.ReadJSON(&result); err != nil {
+				t.Fatalf("ReadJSON() error = %v", err)
+			}
+			if result.Type != "reauth_result" || result.Data.Success != tt.wantSuccess {
+				t.Fatalf("result = %+v, want success %v", result, tt.wantSuccess)
+			}
+
+			// 续期成功后超过原过期时间仍保持连接，失败时按原过期时间断开
+			conn.SetReadDeadline(time.Now().Add(600 * time.Millisecond))
+			_, _, err := conn.ReadMessage()
+			closeErr, closed := err.(*websocket.CloseError)
+			if tt.wantSuccess && closed {
+				t.Errorf("续期后连接被关闭: %v", err)
+			}
+			if !tt.wantSuccess && (!closed || closeErr.Code != CloseUnauthorized) {
+				t.Errorf("ReadMessage() error = %v, want close %d", err, CloseUnauthorized)
+			}
+		})
+	}
+}
+
+func TestReauthRevocation(t *testing.T) {
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+
+	tests := []struct {
+		name      string
+		checker   TokenRevocationChecker
+		wantClose bool
+	}{
+		{"令牌已吊销时断开", stubRevocationChecker{revoked: true}, true},
+		{"吊销检查失败时拒绝续期", stubRevocationChecker{err: errors.New("redis down")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(zap.NewNop(), HubOptions{CloseOnTokenExpiry: true, AllowReauth: true})
+			conn := startPumpsWith(t, hub, func(client *Client) {
+				client.validator = jwtService
+				client.revocation = tt.checker
+				client.tokenExpiry = time.Now().Add(time.Hour)
+				client.expiryUpdates = make(chan time.Time, 1)
+			})
+
+			msg, _ := json.Marshal(map[string]interface{}{
+				"type": MessageTypeReauth,
+				"data": map[string]interface{}{"token": issueToken(t, jwtService, 1)},
+			})
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				t.Fatal(err)
+			}
+
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			var result struct {
+				Type string `json:"type"`
+				Data struct {
+					Success bool `json:"success"`
+				} `json:"data"`
+			}
+			if err := conn.ReadJSON(&result); err != nil {
+				t.Fatalf("ReadJSON() error = %v", err)
+			}
+			if result.Type != "reauth_result" || result.Data.Success {
+				t.Fatalf("result = %+v, want failure", result)
+			}
+
+			// 原令牌一小时后才过期，断开只可能来自吊销
+			conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+			_, _, err := conn.ReadMessage()
+			closeErr, closed := err.(*websocket.CloseError)
+			if tt.wantClose && (!closed || closeErr.Code != CloseUnauthorized) {
+				t.Errorf("ReadMessage() error = %v, want close %d", err, CloseUnauthorized)
+			}
+			if !tt.wantClose && closed {
+				t.Errorf("吊销检查失败不应断开连接: %v", err)
+			}
+		})
+	}
+}
@@ -1,9 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/game-apps/pkg/origins"
 	"github.com/spf13/viper"
 )
 
@@ -15,6 +25,40 @@ type Config struct {
 	Log        LogConfig        `mapstructure:"log"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
 	Game       GameConfig        `mapstructure:"game"`
+	WebSocket  WebSocketConfig  `mapstructure:"websocket"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	Password   PasswordConfig   `mapstructure:"password"`
+	Features   map[string]bool  `mapstructure:"features"` // 功能开关，未配置的开关视为关闭
+	ConfigStore ConfigStoreConfig `mapstructure:"config_store"`
+	Startup    StartupConfig    `mapstructure:"startup"`
+}
+
+// StartupConfig 启动时连接数据库和 Redis 的重试策略，依赖服务比本服务晚就绪时等待而不是直接退出
+type StartupConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`    // 每个依赖的最大连接尝试次数
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"` // 第一次失败后的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`     // 等待时间上限
+	Timeout        time.Duration `mapstructure:"timeout"`         // 等待全部依赖就绪的总时间
+}
+
+// ConfigStoreConfig 管理端可编辑的配置（服务配置、系统配置）的存储
+type ConfigStoreConfig struct {
+	Driver string `mapstructure:"driver"` // file: 本地文件（PROJECT_ROOT 下）, redis: Redis，多实例共享且不依赖本地磁盘
+}
+
+// 功能开关名称
+const (
+	FeatureSpectators = "spectators" // 观战
+	FeatureWaitlist   = "waitlist"   // 满员房间的候补队列
+)
+
+// KnownFeatures 服务支持的全部功能开关
+var KnownFeatures = []string{FeatureSpectators, FeatureWaitlist}
+
+// IsEnabled 检查功能开关是否开启，未知的开关视为关闭
+func (c *Config) IsEnabled(flag string) bool {
+	// viper 读取的键统一为小写
+	return c.Features[strings.ToLower(flag)]
 }
 
 type ServerConfig struct {
@@ -24,10 +68,14 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // 可信代理的 IP 或 CIDR，只有来自这些地址的请求才使用 X-Forwarded-For 确定客户端 IP
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // 跨域来源白名单，CORS 与 WebSocket 共用，支持 "*" 和 "https://*.example.com"
 }
 
 type DatabaseConfig struct {
 	Driver   string         `mapstructure:"driver"`
+	AutoMigrate        bool          `mapstructure:"auto_migrate"`         // 启动时自动迁移表结构，也可通过 GAME_APPS_AUTO_MIGRATE 开启
+	MigrateLockTimeout time.Duration `mapstructure:"migrate_lock_timeout"` // 等待其他实例完成迁移的最长时间
 	MySQL    MySQLConfig    `mapstructure:"mysql"`
 	Postgres PostgresConfig `mapstructure:"postgres"`
 }
@@ -36,7 +84,7 @@ type MySQLConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
 	User            string        `mapstructure:"user"`
-	Password        string        `mapstructure:"password"`
+	Password        string        `mapstructure:"password" redact:"true"`
 	DBName          string        `mapstructure:"dbname"`
 	Charset         string        `mapstructure:"charset"`
 	ParseTime       bool          `mapstructure:"parse_time"`
@@ -50,7 +98,7 @@ type PostgresConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
 	User            string        `mapstructure:"user"`
-	Password        string        `mapstructure:"password"`
+	Password        string        `mapstructure:"password" redact:"true"`
 	DBName          string        `mapstructure:"dbname"`
 	SSLMode         string        `mapstructure:"sslmode"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
@@ -59,20 +107,31 @@ type PostgresConfig struct {
 }
 
 type RedisConfig struct {
-	Addr         string        `mapstructure:"addr"`
-	Password     string        `mapstructure:"password"`
-	DB           int           `mapstructure:"db"`
-	PoolSize     int           `mapstructure:"pool_size"`
-	MinIdleConns int           `mapstructure:"min_idle_conns"`
-	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	Addr            string        `mapstructure:"addr"`
+	Password        string        `mapstructure:"password" redact:"true"`
+	DB              int           `mapstructure:"db"`
+	PoolSize        int           `mapstructure:"pool_size"`
+	MinIdleConns    int           `mapstructure:"min_idle_conns"`
+	MaxRetries      int           `mapstructure:"max_retries"` // 命令失败后的最大重试次数，-1 表示不重试
+	DialTimeout     time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	PoolTimeout     time.Duration `mapstructure:"pool_timeout"`       // 连接池耗尽时等待空闲连接的时间
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"` // 空闲连接的最长保留时间，-1 表示不回收
+	CommandTimeout  time.Duration `mapstructure:"command_timeout"`    // 调用方未设置截止时间时单条命令的默认超时，0 表示不限制
 }
 
 type JWTConfig struct {
-	Secret                string `mapstructure:"secret"`
+	Secret                string `mapstructure:"secret" redact:"true"`
 	ExpirationHours       int    `mapstructure:"expiration_hours"`
 	RefreshExpirationHours int    `mapstructure:"refresh_expiration_hours"`
+	Issuer                string        `mapstructure:"issuer"`
+	Audience              string        `mapstructure:"audience"`
+	Leeway                time.Duration `mapstructure:"leeway"` // 允许的节点间时钟偏差
+	Algorithm             string        `mapstructure:"algorithm"` // HS256、RS256 或 ES256
+	PrivateKeyPath        string        `mapstructure:"private_key_path"` // 非对称算法的签名私钥，仅验证的服务可不配置
+	PublicKeyPath         string        `mapstructure:"public_key_path"`
+	KeyID                 string        `mapstructure:"key_id"`
 }
 
 type LogConfig struct {
@@ -80,6 +139,14 @@ type LogConfig struct {
 	Format string     `mapstructure:"format"`
 	Output string     `mapstructure:"output"`
 	File   LogFileConfig `mapstructure:"file"`
+	Request RequestLogConfig `mapstructure:"request"`
+}
+
+// RequestLogConfig HTTP 请求日志过滤，5xx 响应始终记录
+type RequestLogConfig struct {
+	SkipPaths     []string      `mapstructure:"skip_paths"`     // 不记录日志的路径
+	DebugPaths    []string      `mapstructure:"debug_paths"`    // 以 debug 级别记录的路径
+	SlowThreshold time.Duration `mapstructure:"slow_threshold"` // 慢请求阈值，超过时以 warn 级别记录，0 表示不检查
 }
 
 type LogFileConfig struct {
@@ -93,6 +160,7 @@ type LogFileConfig struct {
 type MonitoringConfig struct {
 	MetricsEnabled bool   `mapstructure:"metrics_enabled"`
 	MetricsPath    string `mapstructure:"metrics_path"`
+	MetricsStatusBuckets bool `mapstructure:"metrics_status_buckets"` // HTTP 指标的 status 标签按 2xx/4xx/5xx 分组
 	HealthPath     string `mapstructure:"health_path"`
 	ReadyPath      string `mapstructure:"ready_path"`
 }
@@ -100,21 +168,106 @@ type MonitoringConfig struct {
 type GameConfig struct {
 	Room    RoomConfig    `mapstructure:"room"`
 	Session SessionConfig `mapstructure:"session"`
+	Events  EventsConfig  `mapstructure:"events"`
+	JSON    JSONConfig    `mapstructure:"json"`
+}
+
+// JSONConfig 客户端提交的任意 JSON（房间设置、游戏操作）的解析限制
+type JSONConfig struct {
+	MaxDepth int `mapstructure:"max_depth"` // 最大嵌套层级
+	MaxBytes int `mapstructure:"max_bytes"` // 最大字节数
+}
+
+// EventsConfig 房间事件历史的保留策略
+type EventsConfig struct {
+	MaxLength int           `mapstructure:"max_length"` // 每个房间保留的最近事件数，超出时丢弃最旧的事件
+	TTL       time.Duration `mapstructure:"ttl"`        // 事件历史在最后一次追加后的保留时间
 }
 
 type RoomConfig struct {
 	MaxPlayers     int           `mapstructure:"max_players"`
+	MinPlayers     int           `mapstructure:"min_players"`
 	DefaultTimeout time.Duration `mapstructure:"default_timeout"`
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+	AllowMultiRoom bool          `mapstructure:"allow_multi_room"` // 是否允许同时在多个房间中
+	OwnerAutoReady  bool `mapstructure:"owner_auto_ready"`  // 房主创建房间时自动准备，开始游戏时视为始终已准备
+	RequireAllReady bool `mapstructure:"require_all_ready"` // 开始游戏前要求所有玩家已准备
+	TurnTimeLimit   time.Duration `mapstructure:"turn_time_limit"` // 回合时限，超时自动跳过当前玩家，0 表示不限时
+	AFKGracePeriod  time.Duration `mapstructure:"afk_grace_period"` // 游戏中断线超过该时间未重连时标记为暂离并跳过其回合（需启用回合时限），0 表示不标记
+	Types          map[string]RoomTypeConfig `mapstructure:"types"` // 按游戏类型覆盖的房间默认值
+}
+
+// hasTurnTimeLimit 全局或任一游戏类型是否启用了回合时限
+func (c RoomConfig) hasTurnTimeLimit() bool {
+	if c.TurnTimeLimit > 0 {
+		return true
+	}
+	for _, typeCfg := range c.Types {
+		if typeCfg.TurnTimeLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomTypeConfig 单个游戏类型的房间默认值，未设置（零值）的字段沿用全局配置
+type RoomTypeConfig struct {
+	MaxPlayers     int           `mapstructure:"max_players"`
+	MinPlayers     int           `mapstructure:"min_players"`
+	DefaultTimeout time.Duration `mapstructure:"default_timeout"`
+	TurnTimeLimit  time.Duration `mapstructure:"turn_time_limit"`
 }
 
 type SessionConfig struct {
 	HeartbeatInterval  time.Duration `mapstructure:"heartbeat_interval"`
 	Timeout            time.Duration `mapstructure:"timeout"`
+	TimeoutByRole      map[string]time.Duration `mapstructure:"timeout_by_role"` // 按用户角色覆盖会话超时
 	MaxReconnectAttempts int         `mapstructure:"max_reconnect_attempts"`
+	Mode               string        `mapstructure:"mode"`            // single: 单设备登录, multi: 允许多设备
+	ConflictPolicy     string        `mapstructure:"conflict_policy"` // single 模式下的冲突处理: reject 拒绝新会话, kick 踢出旧会话
+}
+
+type WebSocketConfig struct {
+	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
+	SendBufferSize int           `mapstructure:"send_buffer_size"`
+	OverflowPolicy string        `mapstructure:"overflow_policy"` // disconnect、drop_oldest 或 warn
+	MaxOverflows   int           `mapstructure:"max_overflows"`   // drop_oldest 和 warn 策略下连续溢出多少次后断开
+	ReadBufferSize    int           `mapstructure:"read_buffer_size"`  // 升级连接时的读缓冲区大小
+	WriteBufferSize   int           `mapstructure:"write_buffer_size"` // 升级连接时的写缓冲区大小
+	MaxMessageSize    int64         `mapstructure:"max_message_size"`  // 单条消息最大字节数
+	PingInterval      time.Duration `mapstructure:"ping_interval"`     // 服务端 ping 间隔，需小于 pong_wait
+	PongWait          time.Duration `mapstructure:"pong_wait"`         // 等待客户端 pong 的超时时间
+	MessageRate       float64 `mapstructure:"message_rate"`        // 每个连接每秒允许的消息数，0 表示不限流
+	MessageBurst      int     `mapstructure:"message_burst"`       // 允许的突发消息数
+	MaxRateViolations int     `mapstructure:"max_rate_violations"` // 短时间内超限多少次后断开，0 表示不断开
+	StateCoalesceInterval   time.Duration            `mapstructure:"state_coalesce_interval"`     // 房间状态合并发送间隔，0 表示不合并
+	StateCoalesceByGameType map[string]time.Duration `mapstructure:"state_coalesce_by_game_type"` // 按游戏类型覆盖合并间隔
+	CloseOnTokenExpiry bool `mapstructure:"close_on_token_expiry"` // 令牌过期时主动断开连接
+	AllowReauth        bool `mapstructure:"allow_reauth"`          // 允许通过 reauth 消息续期连接
+	DuplicatePolicy  string `mapstructure:"duplicate_policy"`       // 同一用户建立新连接时: replace 替换旧连接, reject 拒绝新连接；每个实例上每个用户最多保留一个连接
 }
 
-var globalConfig *Config
+type RateLimitConfig struct {
+	FailOpen     bool          `mapstructure:"fail_open"` // Redis 不可用时是否放行请求
+	Login        RateLimitRule `mapstructure:"login"`
+	Register     RateLimitRule `mapstructure:"register"`
+	Availability RateLimitRule `mapstructure:"availability"` // 用户名/邮箱可用性检查
+}
+
+// RateLimitRule 单个接口的限流规则，Limit 为 0 表示不限流
+type RateLimitRule struct {
+	Limit  int           `mapstructure:"limit"`  // 窗口内允许的请求数
+	Window time.Duration `mapstructure:"window"` // 滑动窗口大小
+}
+
+// PasswordConfig 注册密码策略
+type PasswordConfig struct {
+	RequireClasses bool `mapstructure:"require_classes"` // 要求包含大小写字母、数字和特殊字符
+	MinScore       int  `mapstructure:"min_score"`       // 最低强度评分（0-4），0 表示不检查
+}
+
+// globalConfig 全局配置，ReloadFrom 时整体替换
+var globalConfig atomic.Pointer[Config]
 
 // Load 加载配置
 func Load(configPath string) (*Config, error) {
@@ -129,15 +282,28 @@ func Load(configPath string) (*Config, error) {
 		viper.AddConfigPath("../../configs")
 	}
 
-	// 环境变量支持
+	// 环境变量支持，嵌套键使用下划线，如 GAME_APPS_SERVER_HTTP_PORT
 	viper.SetEnvPrefix("GAME_APPS")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	// 设置默认值
-	setDefaults()
+	setDefaults(viper.GetViper())
+	bindEnvs(viper.GetViper())
 
 	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		// 配置文件可选时，找不到文件则仅使用默认值和环境变量
+		if !configOptional() || !isConfigNotFound(err) {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+	}
+
+	// 按顺序合并额外的配置文件，后面的文件覆盖前面的
+	for _, file := range configFiles() {
+		viper.SetConfigFile(file)
+		if err := viper.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("合并配置文件 %s 失败: %w", file, err)
+		}
 	}
 
 	var config Config
@@ -150,77 +316,399 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
-	globalConfig = &config
+	globalConfig.Store(&config)
+	return &config, nil
+}
+
+// ReloadFrom 以 content 作为主配置重新加载（同样合并额外的配置文件和环境变量），验证通过后替换全局配置
+// content 通常来自配置存储，使各实例与管理后台保存的配置一致；验证失败时保留原配置。
+// 只有在运行时支持热更新的配置项（如 Redis 连接）才会实际生效
+func ReloadFrom(content []byte) (*Config, error) {
+	if globalConfig.Load() == nil {
+		return nil, errors.New("配置尚未加载")
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetEnvPrefix("GAME_APPS")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	setDefaults(v)
+	bindEnvs(v)
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("读取配置失败: %w", err)
+	}
+	for _, file := range configFiles() {
+		v.SetConfigFile(file)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("合并配置文件 %s 失败: %w", file, err)
+		}
+	}
+
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	globalConfig.Store(&config)
 	return &config, nil
 }
 
+// ConfigOptionalEnv 设置为 true 时允许没有配置文件，仅通过默认值和环境变量加载配置
+const ConfigOptionalEnv = "GAME_APPS_CONFIG_OPTIONAL"
+
+// AutoMigrateEnv 设置为 true 时启动时自动迁移表结构，等同于 database.auto_migrate
+const AutoMigrateEnv = "GAME_APPS_AUTO_MIGRATE"
+
+// ConfigFilesEnv 逗号分隔的额外配置文件列表，在主配置文件之后按顺序合并
+// 例如 GAME_APPS_CONFIG_FILES=configs/production.yaml,/run/secrets/game-apps.yaml
+const ConfigFilesEnv = "GAME_APPS_CONFIG_FILES"
+
+// configFiles 获取需要合并的额外配置文件
+func configFiles() []string {
+	var files []string
+	for _, file := range strings.Split(os.Getenv(ConfigFilesEnv), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// configOptional 配置文件是否可选
+func configOptional() bool {
+	optional, _ := strconv.ParseBool(os.Getenv(ConfigOptionalEnv))
+	return optional
+}
+
+// isConfigNotFound 判断是否为配置文件不存在错误
+func isConfigNotFound(err error) bool {
+	var notFound viper.ConfigFileNotFoundError
+	return errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist)
+}
+
+// bindEnvs 绑定所有配置项的环境变量，使没有默认值的配置项也可以只通过环境变量设置
+// viper 的 AutomaticEnv 在 Unmarshal 时只会覆盖已知的键，因此按 Config 的结构逐个绑定
+func bindEnvs(v *viper.Viper) {
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		v.BindEnv(key)
+	}
+
+	v.BindEnv("database.auto_migrate", AutoMigrateEnv, "GAME_APPS_DATABASE_AUTO_MIGRATE")
+}
+
+// configKeys 按 mapstructure 标签列出结构体中所有叶子配置项的键
+// map 类型的配置项（如 features、game.room.types）无法用单个环境变量表示，不绑定
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, configKeys(field.Type, key)...)
+		case reflect.Map:
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 // Get 获取全局配置
 func Get() *Config {
-	return globalConfig
+	return globalConfig.Load()
+}
+
+// Parse 从 YAML 内容解析配置（带默认值），不影响全局配置
+// 未知字段和类型不匹配的字段会作为错误返回
+func Parse(content []byte) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	setDefaults(v)
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("读取配置失败: %w", err)
+	}
+
+	var config Config
+	if err := v.UnmarshalExact(&config); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return &config, nil
 }
 
-// Validate 验证配置
+// Validate 验证配置，返回发现的第一个问题
 func (c *Config) Validate() error {
+	if problems := c.Problems(); len(problems) > 0 {
+		return errors.New(problems[0])
+	}
+	return nil
+}
+
+// Problems 检查配置并返回所有问题
+func (c *Config) Problems() []string {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
 	if c.Server.HTTPPort <= 0 || c.Server.HTTPPort > 65535 {
-		return fmt.Errorf("HTTP 端口无效: %d", c.Server.HTTPPort)
+		addf("HTTP 端口无效: %d", c.Server.HTTPPort)
 	}
 
 	if c.Server.GRPCPort <= 0 || c.Server.GRPCPort > 65535 {
-		return fmt.Errorf("gRPC 端口无效: %d", c.Server.GRPCPort)
+		addf("gRPC 端口无效: %d", c.Server.GRPCPort)
 	}
 
-	if c.Database.Driver != "mysql" && c.Database.Driver != "postgres" {
-		return fmt.Errorf("不支持的数据库驱动: %s", c.Database.Driver)
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				addf("可信代理地址无效: %s", proxy)
+			}
+		}
 	}
 
-	if c.JWT.Secret == "" || c.JWT.Secret == "change-me-in-production" {
-		return fmt.Errorf("JWT secret 未设置或使用默认值")
+	if _, err := origins.NewMatcher(c.Server.AllowedOrigins); err != nil {
+		addf("跨域来源白名单配置无效: %v", err)
 	}
 
-	return nil
+	switch c.Database.Driver {
+	case "mysql":
+		if c.Database.MySQL.User == "" || c.Database.MySQL.DBName == "" {
+			addf("MySQL 用户名和数据库名不能为空")
+		}
+	case "postgres":
+		if c.Database.Postgres.User == "" || c.Database.Postgres.DBName == "" {
+			addf("PostgreSQL 用户名和数据库名不能为空")
+		}
+	default:
+		addf("不支持的数据库驱动: %s", c.Database.Driver)
+	}
+
+	if c.Redis.Addr == "" {
+		addf("Redis 地址不能为空")
+	}
+	if c.Redis.MaxRetries < -1 {
+		addf("Redis 最大重试次数不能小于 -1")
+	}
+	if c.Redis.PoolTimeout < 0 || c.Redis.CommandTimeout < 0 {
+		addf("Redis 连接池等待时间和命令超时不能为负数")
+	}
+
+	switch c.JWT.Algorithm {
+	case "HS256":
+		if c.JWT.Secret == "" || c.JWT.Secret == "change-me-in-production" {
+			addf("JWT secret 未设置或使用默认值")
+		}
+	case "RS256", "ES256":
+		if c.JWT.PublicKeyPath == "" {
+			addf("JWT 算法 %s 需要配置公钥", c.JWT.Algorithm)
+		}
+	default:
+		addf("不支持的 JWT 算法: %s", c.JWT.Algorithm)
+	}
+	// 未配置签发方时不校验 iss，共用密钥的其他服务签发的令牌也会被接受
+	if strings.TrimSpace(c.JWT.Issuer) == "" {
+		addf("JWT issuer 不能为空")
+	}
+
+	if c.Game.Session.Mode != "single" && c.Game.Session.Mode != "multi" {
+		addf("不支持的会话模式: %s", c.Game.Session.Mode)
+	}
+
+	if c.Game.Session.ConflictPolicy != "reject" && c.Game.Session.ConflictPolicy != "kick" {
+		addf("不支持的会话冲突策略: %s", c.Game.Session.ConflictPolicy)
+	}
+
+	if c.ConfigStore.Driver != "file" && c.ConfigStore.Driver != "redis" {
+		addf("不支持的配置存储: %s", c.ConfigStore.Driver)
+	}
+
+	if c.Startup.MaxAttempts <= 0 {
+		addf("启动连接最大尝试次数必须大于 0")
+	}
+	if c.Startup.InitialBackoff <= 0 || c.Startup.MaxBackoff < c.Startup.InitialBackoff {
+		addf("启动重试间隔无效：初始间隔必须大于 0 且不大于上限")
+	}
+	if c.Startup.Timeout <= 0 {
+		addf("启动等待时间必须大于 0")
+	}
+
+	if p := c.WebSocket.OverflowPolicy; p != "disconnect" && p != "drop_oldest" && p != "warn" {
+		addf("不支持的 WebSocket 溢出策略: %s", c.WebSocket.OverflowPolicy)
+	}
+	if c.WebSocket.DuplicatePolicy != "replace" && c.WebSocket.DuplicatePolicy != "reject" {
+		addf("不支持的 WebSocket 重复连接策略: %s", c.WebSocket.DuplicatePolicy)
+	}
+
+	if c.WebSocket.PingInterval >= c.WebSocket.PongWait {
+		addf("WebSocket ping_interval 必须小于 pong_wait")
+	}
+
+	if c.Password.MinScore < 0 || c.Password.MinScore > 4 {
+		addf("密码最低强度评分需在 0 到 4 之间: %d", c.Password.MinScore)
+	}
+
+	if c.Game.Events.MaxLength <= 0 {
+		addf("房间事件保留条数必须大于 0: %d", c.Game.Events.MaxLength)
+	}
+	if c.Game.Events.TTL <= 0 {
+		addf("房间事件保留时间必须大于 0: %s", c.Game.Events.TTL)
+	}
+	if c.Game.JSON.MaxDepth <= 0 {
+		addf("JSON 最大嵌套层级必须大于 0: %d", c.Game.JSON.MaxDepth)
+	}
+	if c.Game.JSON.MaxBytes <= 0 {
+		addf("JSON 最大字节数必须大于 0: %d", c.Game.JSON.MaxBytes)
+	}
+
+	if c.Log.Request.SlowThreshold < 0 {
+		addf("慢请求阈值不能为负数: %s", c.Log.Request.SlowThreshold)
+	}
+
+	if c.Game.Room.AFKGracePeriod < 0 {
+		addf("暂离宽限期不能为负数")
+	}
+	if c.Game.Room.AFKGracePeriod > 0 && !c.Game.Room.hasTurnTimeLimit() {
+		addf("暂离宽限期需要配合回合时限使用，请设置 turn_time_limit 或将 afk_grace_period 设为 0")
+	}
+
+	for gameType, typeCfg := range c.Game.Room.Types {
+		if typeCfg.MaxPlayers < 0 || typeCfg.MinPlayers < 0 {
+			addf("游戏类型 %s 的房间人数配置无效", gameType)
+		}
+		if typeCfg.MaxPlayers > 0 && typeCfg.MinPlayers > typeCfg.MaxPlayers {
+			addf("游戏类型 %s 的最少人数大于最多人数", gameType)
+		}
+		if typeCfg.TurnTimeLimit < 0 {
+			addf("游戏类型 %s 的回合时限无效", gameType)
+		}
+	}
+
+	return problems
 }
 
-func setDefaults() {
-	viper.SetDefault("server.host", "0.0.0.0")
-	viper.SetDefault("server.http_port", 8080)
-	viper.SetDefault("server.grpc_port", 9090)
-	viper.SetDefault("server.read_timeout", "30s")
-	viper.SetDefault("server.write_timeout", "30s")
-	viper.SetDefault("server.idle_timeout", "120s")
-
-	viper.SetDefault("database.driver", "mysql")
-	viper.SetDefault("database.mysql.host", "localhost")
-	viper.SetDefault("database.mysql.port", 3306)
-	viper.SetDefault("database.mysql.charset", "utf8mb4")
-	viper.SetDefault("database.mysql.max_open_conns", 100)
-	viper.SetDefault("database.mysql.max_idle_conns", 10)
-
-	viper.SetDefault("database.postgres.host", "localhost")
-	viper.SetDefault("database.postgres.port", 5432)
-	viper.SetDefault("database.postgres.sslmode", "disable")
-	viper.SetDefault("database.postgres.max_open_conns", 100)
-	viper.SetDefault("database.postgres.max_idle_conns", 10)
-
-	viper.SetDefault("redis.addr", "localhost:6379")
-	viper.SetDefault("redis.db", 0)
-	viper.SetDefault("redis.pool_size", 100)
-	viper.SetDefault("redis.min_idle_conns", 10)
-
-	viper.SetDefault("jwt.expiration_hours", 24)
-	viper.SetDefault("jwt.refresh_expiration_hours", 168)
-
-	viper.SetDefault("log.level", "info")
-	viper.SetDefault("log.format", "json")
-	viper.SetDefault("log.output", "stdout")
-
-	viper.SetDefault("monitoring.metrics_enabled", true)
-	viper.SetDefault("monitoring.metrics_path", "/metrics")
-	viper.SetDefault("monitoring.health_path", "/health")
-	viper.SetDefault("monitoring.ready_path", "/ready")
-
-	viper.SetDefault("game.room.max_players", 10)
-	viper.SetDefault("game.room.default_timeout", "300s")
-	viper.SetDefault("game.session.heartbeat_interval", "30s")
-	viper.SetDefault("game.session.timeout", "120s")
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.host", "0.0.0.0")
+	v.SetDefault("server.http_port", 8080)
+	v.SetDefault("server.grpc_port", 9090)
+	v.SetDefault("server.read_timeout", "30s")
+	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.idle_timeout", "120s")
+	v.SetDefault("server.trusted_proxies", []string{})
+	v.SetDefault("server.allowed_origins", []string{})
+
+	v.SetDefault("database.driver", "mysql")
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("database.migrate_lock_timeout", "5m")
+	v.SetDefault("database.mysql.host", "localhost")
+	v.SetDefault("database.mysql.port", 3306)
+	v.SetDefault("database.mysql.charset", "utf8mb4")
+	v.SetDefault("database.mysql.max_open_conns", 100)
+	v.SetDefault("database.mysql.max_idle_conns", 10)
+
+	v.SetDefault("database.postgres.host", "localhost")
+	v.SetDefault("database.postgres.port", 5432)
+	v.SetDefault("database.postgres.sslmode", "disable")
+	v.SetDefault("database.postgres.max_open_conns", 100)
+	v.SetDefault("database.postgres.max_idle_conns", 10)
+
+	v.SetDefault("redis.addr", "localhost:6379")
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.pool_size", 100)
+	v.SetDefault("redis.min_idle_conns", 10)
+	v.SetDefault("redis.max_retries", 3)
+	v.SetDefault("redis.dial_timeout", "5s")
+	v.SetDefault("redis.read_timeout", "3s")
+	v.SetDefault("redis.write_timeout", "3s")
+	v.SetDefault("redis.pool_timeout", "4s")
+	v.SetDefault("redis.conn_max_idle_time", "30m")
+	v.SetDefault("redis.command_timeout", 0)
+
+	v.SetDefault("jwt.expiration_hours", 24)
+	v.SetDefault("jwt.refresh_expiration_hours", 168)
+	v.SetDefault("jwt.issuer", "game-apps")
+	v.SetDefault("jwt.audience", "game-apps")
+	v.SetDefault("jwt.leeway", "30s")
+	v.SetDefault("jwt.algorithm", "HS256")
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "json")
+	v.SetDefault("log.output", "stdout")
+	v.SetDefault("log.request.skip_paths", []string{})
+	v.SetDefault("log.request.debug_paths", []string{"/health", "/ready", "/metrics"})
+	v.SetDefault("log.request.slow_threshold", "1s")
+
+	v.SetDefault("monitoring.metrics_enabled", true)
+	v.SetDefault("monitoring.metrics_path", "/metrics")
+	v.SetDefault("monitoring.metrics_status_buckets", false)
+	v.SetDefault("monitoring.health_path", "/health")
+	v.SetDefault("monitoring.ready_path", "/ready")
+
+	v.SetDefault("game.room.max_players", 10)
+	v.SetDefault("game.room.min_players", 1)
+	v.SetDefault("game.room.allow_multi_room", false)
+	v.SetDefault("game.room.owner_auto_ready", false)
+	v.SetDefault("game.room.require_all_ready", false)
+	v.SetDefault("game.room.default_timeout", "300s")
+	v.SetDefault("game.room.turn_time_limit", "0s")
+	v.SetDefault("game.room.afk_grace_period", "0s")
+	v.SetDefault("game.events.max_length", 500)
+	v.SetDefault("game.events.ttl", "24h")
+	v.SetDefault("config_store.driver", "file")
+
+	v.SetDefault("startup.max_attempts", 10)
+	v.SetDefault("startup.initial_backoff", "1s")
+	v.SetDefault("startup.max_backoff", "15s")
+	v.SetDefault("startup.timeout", "2m")
+	v.SetDefault("features.spectators", true)
+	v.SetDefault("features.waitlist", true)
+	v.SetDefault("game.json.max_depth", 32)
+	v.SetDefault("game.json.max_bytes", 65536)
+	v.SetDefault("game.session.heartbeat_interval", "30s")
+	v.SetDefault("game.session.timeout", "120s")
+	v.SetDefault("game.session.mode", "multi")
+	v.SetDefault("game.session.conflict_policy", "kick")
+
+	v.SetDefault("websocket.write_timeout", "10s")
+	v.SetDefault("websocket.send_buffer_size", 256)
+	v.SetDefault("websocket.overflow_policy", "drop_oldest")
+	v.SetDefault("websocket.duplicate_policy", "replace")
+	v.SetDefault("websocket.max_overflows", 32)
+	v.SetDefault("websocket.read_buffer_size", 1024)
+	v.SetDefault("websocket.write_buffer_size", 1024)
+	v.SetDefault("websocket.max_message_size", 65536)
+	v.SetDefault("websocket.ping_interval", "54s")
+	v.SetDefault("websocket.pong_wait", "60s")
+	v.SetDefault("websocket.message_rate", 10)
+	v.SetDefault("websocket.message_burst", 20)
+	v.SetDefault("websocket.max_rate_violations", 50)
+	v.SetDefault("websocket.state_coalesce_interval", "0s")
+	v.SetDefault("websocket.close_on_token_expiry", true)
+	v.SetDefault("websocket.allow_reauth", true)
+
+	v.SetDefault("rate_limit.fail_open", true)
+	v.SetDefault("rate_limit.login.limit", 10)
+	v.SetDefault("rate_limit.login.window", "1m")
+	v.SetDefault("rate_limit.register.limit", 5)
+	v.SetDefault("rate_limit.register.window", "10m")
+	v.SetDefault("rate_limit.availability.limit", 30)
+	v.SetDefault("rate_limit.availability.window", "1m")
+
+	v.SetDefault("password.require_classes", true)
+	v.SetDefault("password.min_score", 0)
 }
 
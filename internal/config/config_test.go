@@ -0,0 +1,414 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// validYAML 在默认值基础上补全必填项的最小配置
+const validYAML = `
+database:
+  mysql:
+    user: game
+    password: db-secret
+    dbname: game_apps
+redis:
+  password: redis-secret
+jwt:
+  secret: test-jwt-secret
+`
+
+// parseValid 解析最小配置，失败时终止测试
+func parseValid(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Parse([]byte(validYAML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return cfg
+}
+
+func TestProblems(t *testing.T) {
+	if problems := parseValid(t).Problems(); len(problems) != 0 {
+		t.Fatalf("最小配置不应有问题, got %v", problems)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // 问题描述中应包含的内容，为空表示没有问题
+	}{
+		{"HTTP 端口无效", func(c *Config) { c.Server.HTTPPort = 70000 }, "HTTP 端口无效"},
+		{"不支持的数据库驱动", func(c *Config) { c.Database.Driver = "sqlite" }, "不支持的数据库驱动"},
+		{"MySQL 缺少数据库名", func(c *Config) { c.Database.MySQL.DBName = "" }, "MySQL 用户名和数据库名不能为空"},
+		{"JWT 使用默认密钥", func(c *Config) { c.JWT.Secret = "change-me-in-production" }, "JWT secret"},
+		{"非对称算法缺少公钥", func(c *Config) { c.JWT.Algorithm = "RS256" }, "需要配置公钥"},
+		{"JWT 签发方为空", func(c *Config) { c.JWT.Issuer = " " }, "JWT issuer 不能为空"},
+		{"ping 间隔不小于 pong 等待时间", func(c *Config) { c.WebSocket.PingInterval = c.WebSocket.PongWait }, "ping_interval"},
+		{"不支持的会话模式", func(c *Config) { c.Game.Session.Mode = "shared" }, "不支持的会话模式"},
+		{"不支持的重复连接策略", func(c *Config) { c.WebSocket.DuplicatePolicy = "ignore" }, "不支持的 WebSocket 重复连接策略"},
+		{"告警溢出策略", func(c *Config) { c.WebSocket.OverflowPolicy = "warn" }, ""},
+		{"可信代理 IP 和 CIDR", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.1", "172.16.0.0/12", "::1"} }, ""},
+		{"可信代理地址无效", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/33"} }, "可信代理地址无效"},
+		{"慢请求阈值为负数", func(c *Config) { c.Log.Request.SlowThreshold = -time.Second }, "慢请求阈值不能为负数"},
+		{"Redis 不重试", func(c *Config) { c.Redis.MaxRetries = -1 }, ""},
+		{"Redis 重试次数无效", func(c *Config) { c.Redis.MaxRetries = -2 }, "Redis 最大重试次数不能小于 -1"},
+		{"Redis 命令超时为负数", func(c *Config) { c.Redis.CommandTimeout = -time.Second }, "命令超时不能为负数"},
+		{"启动连接次数无效", func(c *Config) { c.Startup.MaxAttempts = 0 }, "启动连接最大尝试次数必须大于 0"},
+		{"启动重试间隔大于上限", func(c *Config) { c.Startup.MaxBackoff = c.Startup.InitialBackoff / 2 }, "启动重试间隔无效"},
+		{"暂离未配合回合时限", func(c *Config) { c.Game.Room.AFKGracePeriod = 30 * time.Second }, "暂离宽限期需要配合回合时限使用"},
+		{"游戏类型启用回合时限时可暂离", func(c *Config) {
+			c.Game.Room.AFKGracePeriod = 30 * time.Second
+			c.Game.Room.Types = map[string]RoomTypeConfig{"chess": {TurnTimeLimit: time.Minute}}
+		}, ""},
+		{"JSON 嵌套层级无效", func(c *Config) { c.Game.JSON.MaxDepth = 0 }, "JSON 最大嵌套层级必须大于 0"},
+		{"游戏类型人数无效", func(c *Config) {
+			c.Game.Room.Types = map[string]RoomTypeConfig{"chess": {MinPlayers: 4, MaxPlayers: 2}}
+		}, "最少人数大于最多人数"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseValid(t)
+			tt.modify(cfg)
+			problems := cfg.Problems()
+
+			if tt.want == "" {
+				if len(problems) != 0 {
+					t.Errorf("Problems() = %v, want none", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("Problems() = %v, want one problem containing %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestProblemsReportsAll(t *testing.T) {
+	cfg := parseValid(t)
+	cfg.Server.HTTPPort = 0
+	cfg.Server.GRPCPort = 0
+	cfg.Redis.Addr = ""
+
+	if problems := cfg.Problems(); len(problems) != 3 {
+		t.Errorf("Problems() = %v, want 3 problems", problems)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "HTTP 端口无效") {
+		t.Errorf("Validate() error = %v, want the first problem", err)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{"合法配置", validYAML, false},
+		{"未知字段", validYAML + "unknown_section:\n  key: value\n", true},
+		{"类型不匹配", "server:\n  http_port: not-a-number\n", true},
+		{"YAML 语法错误", "server:\n  http_port: [\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.yaml)); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	redacted := parseValid(t).Redacted()
+
+	tests := []struct {
+		name string
+		path []string
+		want interface{}
+	}{
+		{"JWT 密钥", []string{"jwt", "secret"}, RedactedValue},
+		{"MySQL 密码", []string{"database", "mysql", "password"}, RedactedValue},
+		{"未设置的 PostgreSQL 密码", []string{"database", "postgres", "password"}, ""},
+		{"Redis 密码", []string{"redis", "password"}, RedactedValue},
+		{"非敏感字段保持原值", []string{"database", "mysql", "user"}, "game"},
+		{"时长格式化为字符串", []string{"jwt", "leeway"}, "30s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{} = redacted
+			for _, key := range tt.path {
+				m, ok := value.(map[string]interface{})
+				if !ok {
+					t.Fatalf("%s 不是对象", key)
+				}
+				value = m[key]
+			}
+			if value != tt.want {
+				t.Errorf("%s = %v, want %v", strings.Join(tt.path, "."), value, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactedLogField(t *testing.T) {
+	cfg := parseValid(t)
+	cfg.Database.Postgres.Password = "pg-secret"
+
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.InfoLevel)
+	zap.New(core).Info("应用启动", zap.Any("config", cfg.Redacted()))
+
+	for _, secret := range []string{"test-jwt-secret", "db-secret", "pg-secret", "redis-secret"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log output contains secret %q: %s", secret, buf.String())
+		}
+	}
+
+	var entry struct {
+		Config struct {
+			JWT struct {
+				Secret string `json:"secret"`
+			} `json:"jwt"`
+			Database struct {
+				MySQL struct {
+					Password string `json:"password"`
+				} `json:"mysql"`
+				Postgres struct {
+					Password string `json:"password"`
+				} `json:"postgres"`
+			} `json:"database"`
+			Redis struct {
+				Password string `json:"password"`
+			} `json:"redis"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	masked := map[string]string{
+		"jwt.secret":                 entry.Config.JWT.Secret,
+		"database.mysql.password":    entry.Config.Database.MySQL.Password,
+		"database.postgres.password": entry.Config.Database.Postgres.Password,
+		"redis.password":             entry.Config.Redis.Password,
+	}
+	for key, value := range masked {
+		if value != RedactedValue {
+			t.Errorf("%s = %q, want %q", key, value, RedactedValue)
+		}
+	}
+}
+
+func TestLoadWithoutConfigFile(t *testing.T) {
+	previous := globalConfig.Load()
+	t.Cleanup(func() {
+		globalConfig.Store(previous)
+		viper.Reset()
+	})
+	missing := filepath.Join(t.TempDir(), "config.yaml")
+
+	required := map[string]string{
+		"GAME_APPS_DATABASE_MYSQL_USER":   "env-user",
+		"GAME_APPS_DATABASE_MYSQL_DBNAME": "env-db",
+		"GAME_APPS_JWT_SECRET":            "env-secret",
+	}
+
+	tests := []struct {
+		name     string
+		optional string
+		env      map[string]string
+		wantErr  string // 错误中应包含的内容，为空表示加载成功
+		wantPort int
+	}{
+		{"可选时只使用环境变量", "true", required, "", 8080},
+		{"环境变量覆盖默认值", "true", map[string]string{
+			"GAME_APPS_DATABASE_MYSQL_USER":   "env-user",
+			"GAME_APPS_DATABASE_MYSQL_DBNAME": "env-db",
+			"GAME_APPS_JWT_SECRET":            "env-secret",
+			"GAME_APPS_SERVER_HTTP_PORT":      "9000",
+		}, "", 9000},
+		{"未开启时缺少配置文件报错", "", required, "读取配置文件失败", 0},
+		{"缺少必填项时验证失败", "true", nil, "配置验证失败", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Setenv(ConfigOptionalEnv, tt.optional)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(missing)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Server.HTTPPort != tt.wantPort || cfg.Database.MySQL.User != "env-user" || cfg.JWT.Secret != "env-secret" {
+				t.Errorf("Load() = port %d, mysql user %q, jwt secret %q", cfg.Server.HTTPPort, cfg.Database.MySQL.User, cfg.JWT.Secret)
+			}
+			// 没有配置文件时 Redis 超时也要有默认值，否则连通性检查会立即超时
+			if cfg.Redis.DialTimeout != 5*time.Second || cfg.Redis.ReadTimeout != 3*time.Second || cfg.Redis.WriteTimeout != 3*time.Second {
+				t.Errorf("Load() redis timeouts = %v/%v/%v", cfg.Redis.DialTimeout, cfg.Redis.ReadTimeout, cfg.Redis.WriteTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadBindsEveryKeyFromEnv(t *testing.T) {
+	previous := globalConfig.Load()
+	t.Cleanup(func() {
+		globalConfig.Store(previous)
+		viper.Reset()
+	})
+	viper.Reset()
+
+	// 这些配置项没有默认值，只通过环境变量设置
+	for key, value := range map[string]string{
+		ConfigOptionalEnv:                     "true",
+		"GAME_APPS_DATABASE_MYSQL_USER":       "env-user",
+		"GAME_APPS_DATABASE_MYSQL_DBNAME":     "env-db",
+		"GAME_APPS_JWT_SECRET":                "env-secret",
+		"GAME_APPS_REDIS_DIAL_TIMEOUT":        "5s",
+		"GAME_APPS_DATABASE_MYSQL_PARSE_TIME": "true",
+		"GAME_APPS_SERVER_TRUSTED_PROXIES":    "10.0.0.0/8,192.168.0.1",
+	} {
+		t.Setenv(key, value)
+	}
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Redis.DialTimeout != 5*time.Second {
+		t.Errorf("Redis.DialTimeout = %v, want 5s", cfg.Redis.DialTimeout)
+	}
+	if !cfg.Database.MySQL.ParseTime {
+		t.Error("Database.MySQL.ParseTime = false, want true")
+	}
+	if got := strings.Join(cfg.Server.TrustedProxies, ","); got != "10.0.0.0/8,192.168.0.1" {
+		t.Errorf("Server.TrustedProxies = %v", cfg.Server.TrustedProxies)
+	}
+}
+
+func TestLoadMergesConfigFiles(t *testing.T) {
+	previous := globalConfig.Load()
+	t.Cleanup(func() {
+		globalConfig.Store(previous)
+		viper.Reset()
+	})
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("config.yaml", validYAML+"server:\n  http_port: 8081\n  grpc_port: 9091\n")
+	override := write("production.yaml", "server:\n  http_port: 9000\n")
+	secrets := write("secrets.yaml", "jwt:\n  secret: override-secret\n")
+	invalid := write("invalid.yaml", "jwt:\n  secret: \"\"\n")
+
+	tests := []struct {
+		name       string
+		files      string
+		wantErr    string
+		wantPort   int
+		wantGRPC   int
+		wantSecret string
+	}{
+		{"不合并额外文件", "", "", 8081, 9091, "test-jwt-secret"},
+		{"后面的文件覆盖前面的", override + ", " + secrets, "", 9000, 9091, "override-secret"},
+		{"文件不存在", filepath.Join(dir, "missing.yaml"), "合并配置文件", 0, 0, ""},
+		{"合并后的配置需要通过验证", invalid, "配置验证失败", 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Setenv(ConfigFilesEnv, tt.files)
+
+			cfg, err := Load(base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Server.HTTPPort != tt.wantPort || cfg.Server.GRPCPort != tt.wantGRPC || cfg.JWT.Secret != tt.wantSecret {
+				t.Errorf("Load() = http %d, grpc %d, jwt secret %q", cfg.Server.HTTPPort, cfg.Server.GRPCPort, cfg.JWT.Secret)
+			}
+		})
+	}
+}
+
+func TestReloadFrom(t *testing.T) {
+	previous := globalConfig.Load()
+	t.Cleanup(func() {
+		globalConfig.Store(previous)
+		viper.Reset()
+	})
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(strings.Replace(validYAML, "redis:\n", "redis:\n  addr: old:6379\n", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 以传入的内容为准，不读取本地配置文件
+	cfg, err := ReloadFrom([]byte(strings.Replace(validYAML, "redis:\n", "redis:\n  addr: new:6379\n", 1)))
+	if err != nil {
+		t.Fatalf("ReloadFrom() error = %v", err)
+	}
+	if cfg.Redis.Addr != "new:6379" || Get() != cfg {
+		t.Errorf("ReloadFrom() redis addr = %q, want new:6379", cfg.Redis.Addr)
+	}
+
+	// 验证失败时保留原配置
+	if _, err := ReloadFrom([]byte("jwt:\n  secret: \"\"\n")); err == nil || !strings.Contains(err.Error(), "配置验证失败") {
+		t.Fatalf("ReloadFrom() error = %v, want validation error", err)
+	}
+	if Get() != cfg {
+		t.Error("验证失败时不应替换全局配置")
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	cfg := parseValid(t)
+	cfg.Features = map[string]bool{"spectators": true, "waitlist": false}
+
+	tests := []struct {
+		flag string
+		want bool
+	}{
+		{FeatureSpectators, true},
+		{"Spectators", true},
+		{FeatureWaitlist, false},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			if got := cfg.IsEnabled(tt.flag); got != tt.want {
+				t.Errorf("IsEnabled(%q) = %v, want %v", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// RedactedValue 敏感字段脱敏后的占位值
+const RedactedValue = "******"
+
+// Redacted 返回脱敏后的配置，键名与配置文件一致
+// 标记了 `redact:"true"` 的字段会被替换为占位值，新增敏感字段只需加上该标记
+func (c *Config) Redacted() map[string]interface{} {
+	return redactValue(reflect.ValueOf(*c)).(map[string]interface{})
+}
+
+func redactValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		out := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Tag.Get("mapstructure")
+			if name == "" {
+				name = field.Name
+			}
+			if field.Tag.Get("redact") == "true" {
+				if v.Field(i).IsZero() {
+					out[name] = ""
+				} else {
+					out[name] = RedactedValue
+				}
+				continue
+			}
+			out[name] = redactValue(v.Field(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
@@ -1,15 +1,24 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
 )
 
+// TokenRevocationChecker 令牌吊销检查
+type TokenRevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error)
+}
+
 // AuthMiddleware JWT 认证中间件
-func AuthMiddleware(jwtService *utils.JWTService) gin.HandlerFunc {
+// 吊销检查失败（如 Redis 不可用）时记录日志并放行，与限流等依赖 Redis 的检查一致
+// 放行的请求带有未完成吊销检查的标记，管理接口和破坏性操作由 RequireRevocationCheck 拒绝
+func AuthMiddleware(jwtService *utils.JWTService, revocationChecker TokenRevocationChecker, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从 Header 获取 Token
 		authHeader := c.GetHeader("Authorization")
@@ -46,11 +55,98 @@ func AuthMiddleware(jwtService *utils.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		// 检查令牌是否已被吊销（如登出所有设备）
+		if revocationChecker != nil {
+			revoked, err := revocationChecker.IsTokenRevoked(c.Request.Context(), claims)
+			if err != nil {
+				logger.Warn("令牌吊销检查失败，放行请求",
+					zap.Error(err),
+					zap.Uint("user_id", claims.UserID),
+				)
+				c.Set(revocationUncheckedKey, true)
+			} else if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    utils.ErrCodeUnauthorized,
+					"message": "认证令牌已失效",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// 将用户信息存储到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("claims", claims)
+		if claims.IsImpersonation() {
+			c.Set(impersonatedByKey, claims.ImpersonatedBy)
+		}
+
+		c.Next()
+	}
+}
+
+// revocationUncheckedKey 吊销检查失败、请求被放行时在上下文中设置的标记
+const revocationUncheckedKey = "revocation_unchecked"
+
+// RequireRevocationCheck 要求令牌吊销检查已成功完成，否则返回 503
+// 用于管理接口和删除账号等破坏性操作，避免 Redis 故障期间已吊销的令牌继续执行高危操作
+// 注意：这个中间件需要在 AuthMiddleware 之后使用
+func RequireRevocationCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool(revocationUncheckedKey) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"code":    utils.ErrCodeInternal,
+				"message": "服务暂时不可用，请稍后重试",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// impersonatedByKey 模拟登录的管理员 ID 在上下文中的键
+const impersonatedByKey = "impersonated_by"
+
+// GetImpersonatedBy 获取发起模拟登录的管理员 ID，非模拟登录时返回 0
+func GetImpersonatedBy(c *gin.Context) uint {
+	return c.GetUint(impersonatedByKey)
+}
+
+// ForbidImpersonation 禁止模拟登录令牌访问，用于删除账号等破坏性操作
+// 注意：这个中间件需要在 AuthMiddleware 之后使用
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetImpersonatedBy(c) != 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    utils.ErrCodeForbidden,
+				"message": "模拟登录不允许执行该操作",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// RequireAudience 要求令牌受众包含指定的客户端类型
+// 注意：这个中间件需要在 AuthMiddleware 之后使用
+func RequireAudience(audience string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("claims")
+		claims, ok := value.(*utils.JWTClaims)
+		if !ok || !claims.HasAudience(audience) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    utils.ErrCodeForbidden,
+				"message": "令牌不适用于该客户端",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// stubRevocationChecker 返回固定结果的吊销检查
+type stubRevocationChecker struct {
+	revoked bool
+	err     error
+}
+
+func (c stubRevocationChecker) IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error) {
+	return c.revoked, c.err
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	jwtService := utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+	token, err := jwtService.GenerateToken(7, "alice", utils.ClientTypeWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := utils.NewJWTService("other-secret", 1, 24, "game-services", "", 0).GenerateToken(7, "alice", utils.ClientTypeWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonation, err := jwtService.GenerateImpersonationToken(7, "alice", 1, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		checker    TokenRevocationChecker
+		extra      []gin.HandlerFunc
+		wantStatus int
+	}{
+		{"有效令牌", "Bearer " + token, nil, nil, http.StatusOK},
+		{"未提供令牌", "", nil, nil, http.StatusUnauthorized},
+		{"缺少 Bearer 前缀", token, nil, nil, http.StatusUnauthorized},
+		{"签名无效", "Bearer " + foreign, nil, nil, http.StatusUnauthorized},
+		{"令牌已吊销", "Bearer " + token, stubRevocationChecker{revoked: true}, nil, http.StatusUnauthorized},
+		{"未吊销", "Bearer " + token, stubRevocationChecker{}, nil, http.StatusOK},
+		{"吊销检查失败时放行", "Bearer " + token, stubRevocationChecker{err: errors.New("redis down")}, nil, http.StatusOK},
+		{"吊销检查失败时拒绝高危操作", "Bearer " + token, stubRevocationChecker{err: errors.New("redis down")}, []gin.HandlerFunc{RequireRevocationCheck()}, http.StatusServiceUnavailable},
+		{"吊销检查通过时允许高危操作", "Bearer " + token, stubRevocationChecker{}, []gin.HandlerFunc{RequireRevocationCheck()}, http.StatusOK},
+		{"受众匹配", "Bearer " + token, nil, []gin.HandlerFunc{RequireAudience(utils.ClientTypeWeb)}, http.StatusOK},
+		{"受众不匹配", "Bearer " + token, nil, []gin.HandlerFunc{RequireAudience(utils.ClientTypeAdmin)}, http.StatusForbidden},
+		{"模拟登录访问普通接口", "Bearer " + impersonation, nil, nil, http.StatusOK},
+		{"模拟登录禁止破坏性操作", "Bearer " + impersonation, nil, []gin.HandlerFunc{ForbidImpersonation()}, http.StatusForbidden},
+		{"普通令牌允许破坏性操作", "Bearer " + token, nil, []gin.HandlerFunc{ForbidImpersonation()}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			handlers := append([]gin.HandlerFunc{AuthMiddleware(jwtService, tt.checker, zap.NewNop())}, tt.extra...)
+			handlers = append(handlers, func(c *gin.Context) {
+				if c.GetUint("user_id") != 7 {
+					c.Status(http.StatusInternalServerError)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+			router.GET("/me", handlers...)
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// stubAdminChecker 固定的管理员列表
+type stubAdminChecker map[uint]bool
+
+func (c stubAdminChecker) IsAdmin(ctx context.Context, userID uint) bool {
+	return c[userID]
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint
+		wantStatus int
+	}{
+		{"管理员", 1, http.StatusOK},
+		{"普通用户", 2, http.StatusForbidden},
+		{"未认证", 0, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin",
+				func(c *gin.Context) {
+					if tt.userID != 0 {
+						c.Set("user_id", tt.userID)
+					}
+				},
+				AdminMiddleware(stubAdminChecker{1: true}),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
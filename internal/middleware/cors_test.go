@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/game-apps/pkg/origins"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	matcher, err := origins.NewMatcher([]string{"https://example.com", "https://*.game.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed bool
+	}{
+		{"精确匹配", http.MethodGet, "https://example.com", false, http.StatusOK, true},
+		{"通配子域名", http.MethodGet, "https://eu.game.example.com", false, http.StatusOK, true},
+		{"未配置的来源不返回跨域头", http.MethodGet, "https://attacker.com", false, http.StatusOK, false},
+		{"后缀相同的其他域名", http.MethodGet, "https://evilgame.example.com", false, http.StatusOK, false},
+		{"无 Origin 的请求", http.MethodGet, "", false, http.StatusOK, false},
+		{"允许的预检请求", http.MethodOptions, "https://eu.game.example.com", true, http.StatusNoContent, true},
+		{"拒绝的预检请求", http.MethodOptions, "https://attacker.com", true, http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORSMiddleware(matcher))
+			router.Handle(tt.method, "/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.wantAllowed && got != tt.origin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.origin)
+			}
+			if !tt.wantAllowed && got != "" {
+				t.Errorf("Access-Control-Allow-Origin = %q, want empty", got)
+			}
+		})
+	}
+}
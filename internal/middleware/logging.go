@@ -7,8 +7,24 @@ import (
 	"go.uber.org/zap"
 )
 
-// LoggingMiddleware 日志中间件
-func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
+// LoggingOptions 请求日志过滤选项
+type LoggingOptions struct {
+	SkipPaths     []string      // 不记录日志的路径（如健康检查），精确匹配
+	DebugPaths    []string      // 以 debug 级别记录的路径
+	SlowThreshold time.Duration // 耗时超过该值的请求以 warn 级别记录，<= 0 表示不检查
+}
+
+// LoggingMiddleware 日志中间件，5xx 响应始终以 error 级别记录，不受路径过滤影响
+func LoggingMiddleware(logger *zap.Logger, options LoggingOptions) gin.HandlerFunc {
+	skip := make(map[string]bool, len(options.SkipPaths))
+	for _, p := range options.SkipPaths {
+		skip[p] = true
+	}
+	debug := make(map[string]bool, len(options.DebugPaths))
+	for _, p := range options.DebugPaths {
+		debug[p] = true
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -18,6 +34,23 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
+		slow := options.SlowThreshold > 0 && latency > options.SlowThreshold
+
+		level := zap.InfoLevel
+		switch {
+		case status >= 500:
+			level = zap.ErrorLevel
+		case slow:
+			level = zap.WarnLevel
+		case skip[path]:
+			return
+		case debug[path]:
+			level = zap.DebugLevel
+		}
+		if !logger.Core().Enabled(level) {
+			return
+		}
+
 		method := c.Request.Method
 		ip := c.ClientIP()
 
@@ -25,13 +58,26 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			path = path + "?" + query
 		}
 
-		logger.Info("HTTP Request",
+		fields := []zap.Field{
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.Int("status", status),
 			zap.Duration("latency", latency),
 			zap.String("ip", ip),
-		)
+			zap.String("request_id", GetRequestID(c)),
+		}
+		// 模拟登录的请求记录发起的管理员，便于审计
+		if adminID := GetImpersonatedBy(c); adminID != 0 {
+			fields = append(fields, zap.Uint("impersonated_by", adminID))
+		}
+
+		msg := "HTTP Request"
+		if level == zap.WarnLevel {
+			msg = "Slow HTTP Request"
+		}
+		if ce := logger.Check(level, msg); ce != nil {
+			ce.Write(fields...)
+		}
 	}
 }
 
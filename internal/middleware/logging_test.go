@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	options := LoggingOptions{
+		SkipPaths:     []string{"/health"},
+		DebugPaths:    []string{"/metrics"},
+		SlowThreshold: 20 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		delay     time.Duration
+		minLevel  zapcore.Level
+		wantLevel *zapcore.Level // nil 表示不记录
+		wantMsg   string
+	}{
+		{"健康检查不记录", "/health", http.StatusOK, 0, zapcore.DebugLevel, nil, ""},
+		{"健康检查出错仍记录", "/health", http.StatusServiceUnavailable, 0, zapcore.InfoLevel, levelPtr(zapcore.ErrorLevel), "HTTP Request"},
+		{"指标以 debug 级别记录", "/metrics", http.StatusOK, 0, zapcore.DebugLevel, levelPtr(zapcore.DebugLevel), "HTTP Request"},
+		{"未开启 debug 时不记录指标请求", "/metrics", http.StatusOK, 0, zapcore.InfoLevel, nil, ""},
+		{"普通请求", "/api/rooms", http.StatusOK, 0, zapcore.InfoLevel, levelPtr(zapcore.InfoLevel), "HTTP Request"},
+		{"慢请求以 warn 级别记录", "/api/rooms", http.StatusOK, 30 * time.Millisecond, zapcore.InfoLevel, levelPtr(zapcore.WarnLevel), "Slow HTTP Request"},
+		{"跳过的路径变慢时仍记录", "/health", http.StatusOK, 30 * time.Millisecond, zapcore.InfoLevel, levelPtr(zapcore.WarnLevel), "Slow HTTP Request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(tt.minLevel)
+			router := gin.New()
+			router.Use(LoggingMiddleware(zap.New(core), options))
+			router.GET(tt.path, func(c *gin.Context) {
+				time.Sleep(tt.delay)
+				c.Status(tt.status)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			entries := logs.All()
+			if tt.wantLevel == nil {
+				if len(entries) != 0 {
+					t.Fatalf("got %d log entries, want none", len(entries))
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(entries))
+			}
+			if entries[0].Level != *tt.wantLevel || entries[0].Message != tt.wantMsg {
+				t.Errorf("log = %s %q, want %s %q", entries[0].Level, entries[0].Message, *tt.wantLevel, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func levelPtr(level zapcore.Level) *zapcore.Level {
+	return &level
+}
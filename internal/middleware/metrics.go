@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"strconv"
 	"time"
 
@@ -28,23 +29,53 @@ var (
 	)
 )
 
+// unmatchedEndpoint 未匹配任何路由的请求统一使用的 endpoint 标签，避免按原始 URL 产生大量时间序列
+const unmatchedEndpoint = "unmatched"
+
+// MetricsOptions 指标收集选项
+type MetricsOptions struct {
+	StatusBuckets bool // status 标签按 2xx/3xx/4xx/5xx 分组，而不是具体状态码
+}
+
 // MetricsMiddleware 指标收集中间件
-func MetricsMiddleware() gin.HandlerFunc {
+func MetricsMiddleware(options MetricsOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		method := c.Request.Method
+		method := metricsMethod(c.Request.Method)
+
+		c.Next()
+
+		// 路由模板在路由匹配后才确定，未匹配的请求不使用原始路径作为标签
 		endpoint := c.FullPath()
 		if endpoint == "" {
-			endpoint = c.Request.URL.Path
+			endpoint = unmatchedEndpoint
 		}
 
-		c.Next()
-
 		duration := time.Since(start).Seconds()
-		status := strconv.Itoa(c.Writer.Status())
+		status := metricsStatus(c.Writer.Status(), options.StatusBuckets)
 
 		httpRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
 		httpRequestDuration.WithLabelValues(method, endpoint).Observe(duration)
 	}
 }
 
+// metricsMethod 非标准的请求方法统一记为 OTHER，防止客户端构造任意方法名
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// metricsStatus 生成 status 标签，bucketed 为 true 时按百位分组
+func metricsStatus(status int, bucketed bool) string {
+	if !bucketed {
+		return strconv.Itoa(status)
+	}
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
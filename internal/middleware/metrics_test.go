@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		bucketed bool
+		want     [2]string // method, status
+	}{
+		{"标准方法", http.MethodGet, 200, false, [2]string{"GET", "200"}},
+		{"非标准方法", "PROPFIND", 404, false, [2]string{"OTHER", "404"}},
+		{"状态码分组", http.MethodPost, 201, true, [2]string{"POST", "2xx"}},
+		{"服务端错误分组", http.MethodDelete, 503, true, [2]string{"DELETE", "5xx"}},
+		{"无效状态码", http.MethodGet, 0, true, [2]string{"GET", "other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [2]string{metricsMethod(tt.method), metricsStatus(tt.status, tt.bucketed)}
+			if got != tt.want {
+				t.Errorf("labels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// endpointLabels 收集 http_requests_total 中出现过的 endpoint 标签
+func endpointLabels(t *testing.T) map[string]bool {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric, 1024)
+	httpRequestsTotal.Collect(ch)
+	close(ch)
+
+	endpoints := make(map[string]bool)
+	for metric := range ch {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "endpoint" {
+				endpoints[label.GetValue()] = true
+			}
+		}
+	}
+	return endpoints
+}
+
+func TestMetricsMiddlewareEndpointCardinality(t *testing.T) {
+	router := gin.New()
+	router.Use(MetricsMiddleware(MetricsOptions{StatusBuckets: true}))
+	router.GET("/api/v1/rooms/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	paths := []string{
+		"/api/v1/rooms/1",
+		"/api/v1/rooms/2",
+		"/wp-admin/setup.php",
+		"/random/path/abc123",
+		"/.env",
+	}
+	for _, path := range paths {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	endpoints := endpointLabels(t)
+	if !endpoints["/api/v1/rooms/:id"] {
+		t.Errorf("匹配的路由应使用路由模板作为标签, got %v", endpoints)
+	}
+	if !endpoints[unmatchedEndpoint] {
+		t.Errorf("未匹配的请求应使用 %q 标签, got %v", unmatchedEndpoint, endpoints)
+	}
+	for _, path := range paths {
+		if endpoints[path] {
+			t.Errorf("原始路径 %q 不应作为标签", path)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimitMiddleware 按客户端 IP 限流，name 用于区分不同接口的限流计数
+func RateLimitMiddleware(limiter *ratelimit.Limiter, name string, limit int, window time.Duration, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := name + ":" + c.ClientIP()
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), key, limit, window)
+		if err != nil {
+			logger.Warn("限流检查失败",
+				zap.Error(err),
+				zap.String("limiter", name),
+				zap.Bool("allowed", allowed),
+			)
+		}
+
+		if !allowed {
+			if retryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code":    utils.ErrCodeTooManyRequests,
+				"message": "请求过于频繁，请稍后重试",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/pkg/cache"
+	"github.com/game-apps/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRateLimitMiddlewareTrustedProxies(t *testing.T) {
+	const trustedProxy = "10.0.0.1"
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantStatus int // 第二个请求（携带不同 X-Forwarded-For）的状态码
+	}{
+		{"不可信来源伪造 X-Forwarded-For 被忽略", "203.0.113.5:40000", http.StatusTooManyRequests},
+		{"可信代理转发的客户端 IP 分别计数", trustedProxy + ":40000", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			client, err := cache.NewClient(cache.Options{
+				Addr:         mr.Addr(),
+				PoolSize:     10,
+				DialTimeout:  time.Second,
+				ReadTimeout:  time.Second,
+				WriteTimeout: time.Second,
+			})
+			if err != nil {
+				t.Fatalf("连接 miniredis 失败: %v", err)
+			}
+			t.Cleanup(func() { client.Close() })
+
+			router := gin.New()
+			if err := router.SetTrustedProxies([]string{trustedProxy}); err != nil {
+				t.Fatal(err)
+			}
+			router.Use(RateLimitMiddleware(ratelimit.NewLimiter(client, false), "login", 1, time.Minute, zap.NewNop()))
+			router.GET("/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			var last int
+			for _, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/login", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", forwarded)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				last = w.Code
+			}
+			if last != tt.wantStatus {
+				t.Errorf("status = %d, want %d", last, tt.wantStatus)
+			}
+		})
+	}
+}
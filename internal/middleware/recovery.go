@@ -17,18 +17,21 @@ func RecoveryMiddleware(logger *zap.Logger) gin.HandlerFunc {
 					zap.Any("error", err),
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
+					zap.String("request_id", GetRequestID(c)),
 				)
 
 				// 检查是否是 AppError
 				if appErr, ok := err.(*utils.AppError); ok {
 					c.JSON(appErr.HTTPStatus(), gin.H{
-						"code":    appErr.Code,
-						"message": appErr.Message,
+						"code":     appErr.Code,
+						"message":  appErr.Message,
+						"trace_id": GetRequestID(c),
 					})
 				} else {
 					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    utils.ErrCodeInternal,
-						"message": "内部服务器错误",
+						"code":     utils.ErrCodeInternal,
+						"message":  "内部服务器错误",
+						"trace_id": GetRequestID(c),
 					})
 				}
 
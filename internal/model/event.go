@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// OutboxEvent 待投递的事件（事务性发件箱）
+// 与业务状态变更在同一事务中写入，由后台任务投递到 Redis 后标记为已发送
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Channel       string     `gorm:"size:100;not null" json:"channel"`
+	Type          string     `gorm:"size:50;not null" json:"type"`
+	RoomID        uint       `gorm:"index" json:"room_id"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	LastError     string     `gorm:"size:500" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	SentAt        *time.Time `gorm:"index" json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (OutboxEvent) TableName() string {
+	return "events"
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// GameHistory 对局记录，游戏结束时为每个参与者写入一条
+type GameHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index:idx_game_histories_user_played,priority:1;not null" json:"user_id"`
+	RoomID    uint      `gorm:"index;not null" json:"room_id"`
+	RoomName  string    `gorm:"size:100" json:"room_name"` // 冗余房间名，避免查询历史时关联房间表
+	GameType  string    `gorm:"size:50" json:"game_type"`
+	Won       bool      `gorm:"default:false" json:"won"`
+	Score     int64     `gorm:"default:0" json:"score"`
+	PlayedAt  time.Time `gorm:"index:idx_game_histories_user_played,priority:2" json:"played_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (GameHistory) TableName() string {
+	return "game_histories"
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,23 +18,33 @@ const (
 	RoomStatusCancelled RoomStatus = 4 // 已取消
 )
 
+// 房间字段的最大长度（字符数），与数据库列定义一致，写入前由服务层校验
+const (
+	MaxRoomNameLength = 100
+	MaxGameTypeLength = 50
+)
+
 // Room 房间模型
 type Room struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	RoomCode    string         `gorm:"uniqueIndex;size:20;not null" json:"room_code"`
 	Name        string         `gorm:"size:100" json:"name"`
-	OwnerID     uint           `gorm:"not null" json:"owner_id"`
-	Status      RoomStatus     `gorm:"default:1" json:"status"`
+	OwnerID     uint           `gorm:"not null;index:idx_rooms_owner_id;index:idx_rooms_owner_status,priority:1" json:"owner_id"`
+	Status      RoomStatus     `gorm:"default:1;index:idx_rooms_owner_status,priority:2" json:"status"`
 	MaxPlayers  int            `gorm:"default:10" json:"max_players"`
+	MinPlayers  int            `gorm:"default:1" json:"min_players"`
 	CurrentPlayers int         `gorm:"default:0" json:"current_players"`
 	GameType    string         `gorm:"size:50" json:"game_type"`
 	Settings    string         `gorm:"type:text" json:"settings"` // JSON 格式的游戏设置
 	StartedAt   *time.Time     `json:"started_at"`
 	EndedAt     *time.Time     `json:"ended_at"`
 	ExpiresAt   *time.Time     `json:"expires_at"`
-	CreatedAt   time.Time      `json:"created_at"`
+	CreatedAt   time.Time      `gorm:"index:idx_rooms_created_at" json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	settingsCache    map[string]json.RawMessage // Settings 解析缓存
+	settingsCacheSrc string                     // 缓存对应的 Settings 原文
 }
 
 // TableName 表名
@@ -40,13 +52,97 @@ func (Room) TableName() string {
 	return "rooms"
 }
 
+// settingsMap 解析房间设置，Settings 未变化时复用上次的解析结果
+func (r *Room) settingsMap() (map[string]json.RawMessage, error) {
+	if r.settingsCache != nil && r.settingsCacheSrc == r.Settings {
+		return r.settingsCache, nil
+	}
+
+	settings := make(map[string]json.RawMessage)
+	if r.Settings != "" {
+		if err := json.Unmarshal([]byte(r.Settings), &settings); err != nil {
+			return nil, fmt.Errorf("房间设置不是合法的 JSON 对象: %w", err)
+		}
+	}
+
+	r.settingsCache = settings
+	r.settingsCacheSrc = r.Settings
+	return settings, nil
+}
+
+// ValidateSettings 检查房间设置是否为合法的 JSON 对象
+func (r *Room) ValidateSettings() error {
+	_, err := r.settingsMap()
+	return err
+}
+
+// GetSetting 读取指定设置项到 v，设置项不存在时返回 false
+func (r *Room) GetSetting(key string, v interface{}) (bool, error) {
+	settings, err := r.settingsMap()
+	if err != nil {
+		return false, err
+	}
+
+	raw, ok := settings[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("房间设置 %s 类型不匹配: %w", key, err)
+	}
+	return true, nil
+}
+
+// UnmarshalSettings 将全部房间设置解析到 v
+func (r *Room) UnmarshalSettings(v interface{}) error {
+	if _, err := r.settingsMap(); err != nil {
+		return err
+	}
+	if r.Settings == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(r.Settings), v)
+}
+
+// SetSetting 设置指定设置项并更新 Settings
+func (r *Room) SetSetting(key string, value interface{}) error {
+	settings, err := r.settingsMap()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化房间设置 %s 失败: %w", key, err)
+	}
+
+	updated := make(map[string]json.RawMessage, len(settings)+1)
+	for k, v := range settings {
+		updated[k] = v
+	}
+	updated[key] = raw
+
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("序列化房间设置失败: %w", err)
+	}
+
+	r.Settings = string(data)
+	r.settingsCache = updated
+	r.settingsCacheSrc = r.Settings
+	return nil
+}
+
 // RoomPlayer 房间玩家关系模型
 type RoomPlayer struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
-	RoomID     uint      `gorm:"index;not null" json:"room_id"`
+	RoomID     uint      `gorm:"index;uniqueIndex:idx_room_players_room_position,priority:1;not null" json:"room_id"`
 	UserID     uint      `gorm:"index;not null" json:"user_id"`
 	IsReady    bool      `gorm:"default:false" json:"is_ready"`
-	Position   int       `gorm:"default:0" json:"position"` // 在房间中的位置
+	// 在房间中的位置，已离开的玩家和观战者为空
+	// (room_id, position) 唯一，NULL 不参与唯一约束，因此只约束在场玩家
+	Position   *int      `gorm:"uniqueIndex:idx_room_players_room_position,priority:2" json:"position"`
+	IsSpectator bool     `gorm:"default:false" json:"is_spectator"` // 是否为观战者，观战者不占用座位
 	JoinedAt   time.Time `json:"joined_at"`
 	LeftAt     *time.Time `json:"left_at"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -0,0 +1,91 @@
+package model
+
+import "testing"
+
+func TestRoomGetSetting(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		key      string
+		wantOK   bool
+		wantErr  bool
+		want     int
+	}{
+		{"读取整数设置", `{"turn_time_limit":30}`, "turn_time_limit", true, false, 30},
+		{"设置项不存在", `{"turn_time_limit":30}`, "max_rounds", false, false, 0},
+		{"设置为空", "", "turn_time_limit", false, false, 0},
+		{"类型不匹配", `{"turn_time_limit":"thirty"}`, "turn_time_limit", true, true, 0},
+		{"不是 JSON 对象", `[1,2,3]`, "turn_time_limit", false, true, 0},
+		{"JSON 格式错误", `{"turn_time_limit":`, "turn_time_limit", false, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := &Room{Settings: tt.settings}
+			var got int
+			ok, err := room.GetSetting(tt.key, &got)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("GetSetting(%q) = %v, %v, want %v, wantErr %v", tt.key, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetSetting(%q) value = %d, want %d", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoomSetSetting(t *testing.T) {
+	room := &Room{Settings: `{"mode":"blitz"}`}
+
+	// 先读取一次，确认修改后缓存失效
+	var mode string
+	if ok, err := room.GetSetting("mode", &mode); !ok || err != nil || mode != "blitz" {
+		t.Fatalf("GetSetting(mode) = %q, %v, %v", mode, ok, err)
+	}
+	if err := room.SetSetting("turn_time_limit", 15); err != nil {
+		t.Fatalf("SetSetting() error = %v", err)
+	}
+
+	var limit int
+	if ok, err := room.GetSetting("turn_time_limit", &limit); !ok || err != nil || limit != 15 {
+		t.Errorf("GetSetting(turn_time_limit) = %d, %v, %v, want 15", limit, ok, err)
+	}
+	if ok, err := room.GetSetting("mode", &mode); !ok || err != nil || mode != "blitz" {
+		t.Errorf("SetSetting 不应丢失已有设置, mode = %q", mode)
+	}
+
+	// 直接修改 Settings 后应重新解析
+	room.Settings = `{"mode":"classic"}`
+	if ok, err := room.GetSetting("turn_time_limit", &limit); ok || err != nil {
+		t.Errorf("Settings 被替换后仍读取到旧设置")
+	}
+}
+
+func TestRoomUnmarshalSettings(t *testing.T) {
+	type settings struct {
+		Mode   string `json:"mode"`
+		Rounds int    `json:"rounds"`
+	}
+
+	tests := []struct {
+		name     string
+		settings string
+		want     settings
+		wantErr  bool
+	}{
+		{"完整设置", `{"mode":"blitz","rounds":3}`, settings{Mode: "blitz", Rounds: 3}, false},
+		{"设置为空", "", settings{}, false},
+		{"JSON 格式错误", `{"mode":`, settings{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got settings
+			err := (&Room{Settings: tt.settings}).UnmarshalSettings(&got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
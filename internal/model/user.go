@@ -6,6 +6,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	UserRolePlayer = "player"
+	UserRoleAdmin  = "admin"
+)
+
+// 用户状态
+const (
+	UserStatusActive   = 1 // 正常
+	UserStatusDisabled = 2 // 禁用
+)
+
+// 用户字段的最大长度（字符数），与数据库列定义一致，写入前由服务层校验
+const (
+	MaxUsernameLength = 50
+	MaxEmailLength    = 100
+	MaxNicknameLength = 50
+	MaxAvatarLength   = 255
+	MaxLocationLength = 100
+)
+
 // User 用户模型
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -15,6 +36,7 @@ type User struct {
 	Nickname  string         `gorm:"size:50" json:"nickname"`
 	Avatar    string         `gorm:"size:255" json:"avatar"`
 	Status    int            `gorm:"default:1" json:"status"` // 1:正常 2:禁用
+	Role      string         `gorm:"size:20;default:player" json:"role"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -25,6 +47,20 @@ func (User) TableName() string {
 	return "users"
 }
 
+// UsernameHistory 用户名变更历史
+type UsernameHistory struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	OldUsername string    `gorm:"index;size:50;not null" json:"old_username"`
+	NewUsername string    `gorm:"size:50;not null" json:"new_username"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (UsernameHistory) TableName() string {
+	return "username_histories"
+}
+
 // UserProfile 用户资料模型
 type UserProfile struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -53,7 +89,7 @@ type UserStats struct {
 	TotalScore   int64     `gorm:"default:0" json:"total_score"`
 	Level        int       `gorm:"default:1" json:"level"`
 	Experience   int64     `gorm:"default:0" json:"experience"`
-	LastPlayedAt *time.Time `json:"last_played_at"`
+	LastPlayedAt *time.Time `gorm:"index" json:"last_played_at"` // 最近一次完成对局的时间
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 通知渠道
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+// defaultHTTPTimeout 调用第三方通知服务的默认超时
+const defaultHTTPTimeout = 10 * time.Second
+
+// Config 通知配置，对应系统配置中的 notification 部分
+type Config struct {
+	Email EmailConfig
+	SMS   SMSConfig
+	Push  PushConfig
+}
+
+// EmailConfig 邮件渠道配置
+type EmailConfig struct {
+	Enabled      bool
+	Provider     string // 为空时使用 smtp
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	FromEmail    string
+	FromName     string
+}
+
+// SMSConfig 短信渠道配置
+type SMSConfig struct {
+	Enabled   bool
+	Provider  string
+	APIKey    string
+	APISecret string
+	From      string // 发送方号码或签名
+}
+
+// PushConfig 推送渠道配置
+type PushConfig struct {
+	Enabled     bool
+	Provider    string
+	APIKey      string
+	Credentials string // 服务账号 JSON 密钥（fcm）
+}
+
+// EmailSender 邮件发送接口
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// SMSSender 短信发送接口
+type SMSSender interface {
+	SendSMS(ctx context.Context, phone, text string) error
+}
+
+// PushSender 推送发送接口
+type PushSender interface {
+	SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) error
+}
+
+// 各渠道服务商的构造函数
+type (
+	EmailFactory func(cfg EmailConfig, client *http.Client) (EmailSender, error)
+	SMSFactory   func(cfg SMSConfig, client *http.Client) (SMSSender, error)
+	PushFactory  func(cfg PushConfig, client *http.Client) (PushSender, error)
+)
+
+var (
+	registryMu     sync.RWMutex
+	emailProviders = make(map[string]EmailFactory)
+	smsProviders   = make(map[string]SMSFactory)
+	pushProviders  = make(map[string]PushFactory)
+)
+
+// RegisterEmailProvider 注册邮件服务商，同名服务商会被覆盖
+func RegisterEmailProvider(name string, factory EmailFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	emailProviders[name] = factory
+}
+
+// RegisterSMSProvider 注册短信服务商，同名服务商会被覆盖
+func RegisterSMSProvider(name string, factory SMSFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	smsProviders[name] = factory
+}
+
+// RegisterPushProvider 注册推送服务商，同名服务商会被覆盖
+func RegisterPushProvider(name string, factory PushFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	pushProviders[name] = factory
+}
+
+// Notifier 按渠道分发通知，未启用的渠道发送时直接忽略
+type Notifier struct {
+	email EmailSender
+	sms   SMSSender
+	push  PushSender
+}
+
+// New 根据配置创建各渠道的发送器，已启用渠道的服务商未注册时返回错误
+// client 为空时使用默认超时的 HTTP 客户端
+func New(cfg Config, client *http.Client) (*Notifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	n := &Notifier{}
+	if cfg.Email.Enabled {
+		provider := cfg.Email.Provider
+		if provider == "" {
+			provider = "smtp"
+		}
+		factory, ok := emailProviders[provider]
+		if !ok {
+			return nil, fmt.Errorf("未知的邮件服务商: %s（可用: %v）", provider, providerNames(emailProviders))
+		}
+		sender, err := factory(cfg.Email, client)
+		if err != nil {
+			return nil, fmt.Errorf("初始化邮件服务商 %s 失败: %w", provider, err)
+		}
+		n.email = sender
+	}
+	if cfg.SMS.Enabled {
+		factory, ok := smsProviders[cfg.SMS.Provider]
+		if !ok {
+			return nil, fmt.Errorf("未知的短信服务商: %s（可用: %v）", cfg.SMS.Provider, providerNames(smsProviders))
+		}
+		sender, err := factory(cfg.SMS, client)
+		if err != nil {
+			return nil, fmt.Errorf("初始化短信服务商 %s 失败: %w", cfg.SMS.Provider, err)
+		}
+		n.sms = sender
+	}
+	if cfg.Push.Enabled {
+		factory, ok := pushProviders[cfg.Push.Provider]
+		if !ok {
+			return nil, fmt.Errorf("未知的推送服务商: %s（可用: %v）", cfg.Push.Provider, providerNames(pushProviders))
+		}
+		sender, err := factory(cfg.Push, client)
+		if err != nil {
+			return nil, fmt.Errorf("初始化推送服务商 %s 失败: %w", cfg.Push.Provider, err)
+		}
+		n.push = sender
+	}
+	return n, nil
+}
+
+// Enabled 渠道是否已启用
+func (n *Notifier) Enabled(channel string) bool {
+	switch channel {
+	case ChannelEmail:
+		return n.email != nil
+	case ChannelSMS:
+		return n.sms != nil
+	case ChannelPush:
+		return n.push != nil
+	}
+	return false
+}
+
+// SendEmail 发送邮件，邮件渠道未启用时忽略
+func (n *Notifier) SendEmail(ctx context.Context, to, subject, body string) error {
+	if n.email == nil {
+		return nil
+	}
+	return n.email.SendEmail(ctx, to, subject, body)
+}
+
+// SendSMS 发送短信，短信渠道未启用时忽略
+func (n *Notifier) SendSMS(ctx context.Context, phone, text string) error {
+	if n.sms == nil {
+		return nil
+	}
+	return n.sms.SendSMS(ctx, phone, text)
+}
+
+// SendPush 发送推送，推送渠道未启用时忽略
+func (n *Notifier) SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) error {
+	if n.push == nil {
+		return nil
+	}
+	return n.push.SendPush(ctx, deviceToken, title, body, data)
+}
+
+// providerNames 已注册的服务商名称，用于错误提示
+func providerNames[F any](providers map[string]F) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
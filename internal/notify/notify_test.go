@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// recordingSender 记录收到的通知的发送器，同时实现三个渠道
+type recordingSender struct {
+	mu    sync.Mutex
+	calls []string // 收到通知的渠道
+}
+
+func (s *recordingSender) record(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, channel)
+}
+
+func (s *recordingSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.record(ChannelEmail)
+	return nil
+}
+
+func (s *recordingSender) SendSMS(ctx context.Context, phone, text string) error {
+	s.record(ChannelSMS)
+	return nil
+}
+
+func (s *recordingSender) SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) error {
+	s.record(ChannelPush)
+	return nil
+}
+
+// registerFakeProviders 以 "fake" 为名注册三个渠道的服务商，所有渠道共用同一个发送器
+func registerFakeProviders(sender *recordingSender) {
+	RegisterEmailProvider("fake", func(cfg EmailConfig, client *http.Client) (EmailSender, error) { return sender, nil })
+	RegisterSMSProvider("fake", func(cfg SMSConfig, client *http.Client) (SMSSender, error) { return sender, nil })
+	RegisterPushProvider("fake", func(cfg PushConfig, client *http.Client) (PushSender, error) { return sender, nil })
+}
+
+func TestNotifierDispatch(t *testing.T) {
+	sender := &recordingSender{}
+	registerFakeProviders(sender)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"全部未启用", Config{}, nil},
+		{"只启用邮件", Config{Email: EmailConfig{Enabled: true, Provider: "fake"}}, []string{ChannelEmail}},
+		{"只启用短信", Config{SMS: SMSConfig{Enabled: true, Provider: "fake"}}, []string{ChannelSMS}},
+		{"只启用推送", Config{Push: PushConfig{Enabled: true, Provider: "fake"}}, []string{ChannelPush}},
+		{"全部启用", Config{
+			Email: EmailConfig{Enabled: true, Provider: "fake"},
+			SMS:   SMSConfig{Enabled: true, Provider: "fake"},
+			Push:  PushConfig{Enabled: true, Provider: "fake"},
+		}, []string{ChannelEmail, ChannelSMS, ChannelPush}},
+		{"服务商只在启用时生效", Config{SMS: SMSConfig{Enabled: false, Provider: "unknown"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender.calls = nil
+			n, err := New(tt.cfg, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			ctx := context.Background()
+			for _, send := range []func() error{
+				func() error { return n.SendEmail(ctx, "alice@example.com", "subject", "body") },
+				func() error { return n.SendSMS(ctx, "+8613800000000", "text") },
+				func() error { return n.SendPush(ctx, "device-token", "title", "body", nil) },
+			} {
+				if err := send(); err != nil {
+					t.Fatalf("send error = %v", err)
+				}
+			}
+
+			if len(sender.calls) != len(tt.want) {
+				t.Fatalf("calls = %v, want %v", sender.calls, tt.want)
+			}
+			for i, channel := range tt.want {
+				if sender.calls[i] != channel {
+					t.Errorf("calls = %v, want %v", sender.calls, tt.want)
+				}
+				if !n.Enabled(channel) {
+					t.Errorf("Enabled(%s) = false", channel)
+				}
+			}
+		})
+	}
+}
+
+func TestNewProviderErrors(t *testing.T) {
+	errInit := errors.New("missing api key")
+	RegisterSMSProvider("broken", func(cfg SMSConfig, client *http.Client) (SMSSender, error) { return nil, errInit })
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"未注册的邮件服务商", Config{Email: EmailConfig{Enabled: true, Provider: "unknown"}}, nil},
+		{"未注册的推送服务商", Config{Push: PushConfig{Enabled: true, Provider: "unknown"}}, nil},
+		{"服务商初始化失败", Config{SMS: SMSConfig{Enabled: true, Provider: "broken"}}, errInit},
+		{"FCM 缺少服务账号密钥", Config{Push: PushConfig{Enabled: true, Provider: "fcm"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg, nil)
+			if err == nil {
+				t.Fatalf("New() 应返回错误")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
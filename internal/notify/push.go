@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func init() {
+	RegisterPushProvider("fcm", newFCMSender)
+}
+
+// FCM HTTP v1 接口
+const (
+	fcmEndpoint     = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	fcmScope        = "https://www.googleapis.com/auth/firebase.messaging"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+)
+
+// fcmTokenRefreshMargin 访问令牌在过期前提前刷新的时间
+const fcmTokenRefreshMargin = time.Minute
+
+// serviceAccount Google 服务账号 JSON 密钥中用到的字段
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// fcmSender 通过 Firebase Cloud Messaging HTTP v1 接口发送推送，
+// Credentials 为服务账号 JSON 密钥，用于换取 OAuth2 访问令牌
+type fcmSender struct {
+	account  serviceAccount
+	key      *rsa.PrivateKey
+	endpoint string
+	client   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// newFCMSender 创建 FCM 推送发送器
+func newFCMSender(cfg PushConfig, client *http.Client) (PushSender, error) {
+	if cfg.Credentials == "" {
+		return nil, errors.New("credentials 不能为空")
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal([]byte(cfg.Credentials), &account); err != nil {
+		return nil, fmt.Errorf("解析服务账号密钥失败: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("服务账号密钥缺少 project_id、client_email 或 private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("解析服务账号私钥失败: %w", err)
+	}
+
+	return &fcmSender{
+		account:  account,
+		key:      key,
+		endpoint: fmt.Sprintf(fcmEndpoint, url.PathEscape(account.ProjectID)),
+		client:   client,
+	}, nil
+}
+
+// SendPush 发送推送
+func (s *fcmSender) SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) error {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": deviceToken,
+			"notification": map[string]string{
+				"title": title,
+				"body":  body,
+			},
+			"data": data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(s.client, req)
+}
+
+// accessToken 获取 OAuth2 访问令牌，缓存到过期前，过期后用服务账号签名的 JWT 重新换取
+func (s *fcmSender) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Add(fcmTokenRefreshMargin).Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("响应中没有访问令牌")
+	}
+
+	s.token = result.AccessToken
+	s.tokenExpiry = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.token, nil
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// serviceAccountJSON 生成指向测试令牌接口的服务账号密钥
+func serviceAccountJSON(t *testing.T, tokenURI string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	data, err := json.Marshal(map[string]string{
+		"project_id":   "game-apps-test",
+		"client_email": "push@game-apps-test.iam.gserviceaccount.com",
+		"private_key":  string(privatePEM),
+		"token_uri":    tokenURI,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFCMSenderSendPush(t *testing.T) {
+	var tokenRequests, pushRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		n := tokenRequests.Add(1)
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("assertion") == "" {
+			http.Error(w, "missing assertion", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600}`, n)
+	})
+	mux.HandleFunc("/send", func(w http.ResponseWriter, r *http.Request) {
+		pushRequests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Message.Token != "device-token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"name":"projects/game-apps-test/messages/1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sender, err := newFCMSender(PushConfig{Credentials: serviceAccountJSON(t, server.URL+"/token")}, server.Client())
+	if err != nil {
+		t.Fatalf("newFCMSender() error = %v", err)
+	}
+	fcm := sender.(*fcmSender)
+	if !strings.Contains(fcm.endpoint, "/projects/game-apps-test/") {
+		t.Errorf("endpoint = %q", fcm.endpoint)
+	}
+	fcm.endpoint = server.URL + "/send"
+
+	// 访问令牌在有效期内复用
+	for i := 0; i < 3; i++ {
+		if err := sender.SendPush(context.Background(), "device-token", "title", "body", map[string]string{"room_id": "1"}); err != nil {
+			t.Fatalf("SendPush() error = %v", err)
+		}
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("换取访问令牌 %d 次, want 1", got)
+	}
+	if got := pushRequests.Load(); got != 3 {
+		t.Errorf("发送推送 %d 次, want 3", got)
+	}
+}
+
+func TestNewFCMSenderInvalidCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials string
+	}{
+		{"缺少密钥", ""},
+		{"不是 JSON", "not-json"},
+		{"缺少字段", `{"project_id":"game-apps-test"}`},
+		{"私钥无效", `{"project_id":"p","client_email":"e","private_key":"not-a-key"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newFCMSender(PushConfig{Credentials: tt.credentials}, http.DefaultClient); err == nil {
+				t.Errorf("newFCMSender() 应返回错误")
+			}
+		})
+	}
+}
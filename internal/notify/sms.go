@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	RegisterSMSProvider("twilio", newTwilioSender)
+	RegisterSMSProvider("vonage", newVonageSender)
+}
+
+// twilioSender 通过 Twilio 发送短信，APIKey 为 Account SID，APISecret 为 Auth Token
+type twilioSender struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// newTwilioSender 创建 Twilio 短信发送器
+func newTwilioSender(cfg SMSConfig, client *http.Client) (SMSSender, error) {
+	if cfg.APIKey == "" || cfg.APISecret == "" || cfg.From == "" {
+		return nil, errors.New("api_key、api_secret 和 from 不能为空")
+	}
+	return &twilioSender{cfg: cfg, client: client}, nil
+}
+
+// SendSMS 发送短信
+func (s *twilioSender) SendSMS(ctx context.Context, phone, text string) error {
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(s.cfg.APIKey))
+	form := url.Values{"To": {phone}, "From": {s.cfg.From}, "Body": {text}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.cfg.APIKey, s.cfg.APISecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doRequest(s.client, req)
+}
+
+// vonageSender 通过 Vonage（原 Nexmo）发送短信
+type vonageSender struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// newVonageSender 创建 Vonage 短信发送器
+func newVonageSender(cfg SMSConfig, client *http.Client) (SMSSender, error) {
+	if cfg.APIKey == "" || cfg.APISecret == "" || cfg.From == "" {
+		return nil, errors.New("api_key、api_secret 和 from 不能为空")
+	}
+	return &vonageSender{cfg: cfg, client: client}, nil
+}
+
+// vonageResponse Vonage 短信接口响应，HTTP 状态码为 200 时仍需检查每条消息的状态
+type vonageResponse struct {
+	Messages []struct {
+		Status    string `json:"status"`
+		ErrorText string `json:"error-text"`
+	} `json:"messages"`
+}
+
+// SendSMS 发送短信
+func (s *vonageSender) SendSMS(ctx context.Context, phone, text string) error {
+	form := url.Values{
+		"api_key":    {s.cfg.APIKey},
+		"api_secret": {s.cfg.APISecret},
+		"from":       {s.cfg.From},
+		"to":         {phone},
+		"text":       {text},
+		"type":       {"unicode"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://rest.nexmo.com/sms/json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	var result vonageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	for _, msg := range result.Messages {
+		if msg.Status != "0" {
+			return fmt.Errorf("发送短信失败: %s", msg.ErrorText)
+		}
+	}
+	return nil
+}
+
+// doRequest 发送请求，非 2xx 响应视为失败
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// statusError 根据失败响应生成错误，附带截断的响应内容便于排查
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("服务商返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
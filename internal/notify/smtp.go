@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterEmailProvider("smtp", newSMTPSender)
+}
+
+// smtpSender 通过 SMTP 发送邮件
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from mail.Address
+}
+
+// newSMTPSender 创建 SMTP 邮件发送器
+func newSMTPSender(cfg EmailConfig, _ *http.Client) (EmailSender, error) {
+	if cfg.SMTPHost == "" || cfg.FromEmail == "" {
+		return nil, errors.New("smtp_host 和 from_email 不能为空")
+	}
+	port := cfg.SMTPPort
+	if port <= 0 {
+		port = 587
+	}
+
+	s := &smtpSender{
+		addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)),
+		from: mail.Address{Name: cfg.FromName, Address: cfg.FromEmail},
+	}
+	if cfg.SMTPUser != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return s, nil
+}
+
+// SendEmail 发送纯文本邮件
+func (s *smtpSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return errors.New("收件人或主题包含非法字符")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+
+	// net/smtp 不支持 context，在单独的协程中发送，context 取消时提前返回
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, s.from.Address, []string{to}, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
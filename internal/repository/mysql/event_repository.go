@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventRepository 事件发件箱数据访问层（MySQL）
+type EventRepository struct {
+	db *gorm.DB
+}
+
+// NewEventRepository 创建事件发件箱仓库
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// SaveGameEnd 在同一事务中更新房间状态、写入待投递事件和参与者的对局记录
+func (r *EventRepository) SaveGameEnd(ctx context.Context, room *model.Room, event *model.OutboxEvent, histories []*model.GameHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只写入状态和结束时间，不覆盖其他字段
+		if err := tx.Model(&model.Room{}).Where("id = ?", room.ID).
+			Updates(map[string]interface{}{"status": room.Status, "ended_at": room.EndedAt}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		if len(histories) == 0 {
+			return nil
+		}
+		if err := tx.Create(histories).Error; err != nil {
+			return err
+		}
+
+		// 更新参与者的最近对局时间，尚无统计记录的用户在首次更新统计时写入
+		userIDs := make([]uint, len(histories))
+		for i, history := range histories {
+			userIDs[i] = history.UserID
+		}
+		return tx.Model(&model.UserStats{}).
+			Where("user_id IN ?", userIDs).
+			Update("last_played_at", histories[0].PlayedAt).Error
+	})
+}
+
+// ClaimPending 认领到期待投递的事件：跳过其他实例已锁定的行，并将下次投递时间推后 lease，
+// 认领期间其他实例不会重复投递；投递方崩溃时事件在 lease 过后重新到期
+func (r *EventRepository) ClaimPending(ctx context.Context, maxAttempts, limit int, lease time.Duration) ([]*model.OutboxEvent, error) {
+	var events []*model.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND attempts < ? AND next_attempt_at <= ?", maxAttempts, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return tx.Model(&model.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+// MarkSent 标记事件已投递
+func (r *EventRepository) MarkSent(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", id).
+		Update("sent_at", time.Now()).Error
+}
+
+// MarkFailed 记录投递失败，并设置下次重试时间
+func (r *EventRepository) MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
@@ -0,0 +1,35 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaimPending(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewEventRepository(db)
+
+	// 跳过其他实例已锁定的行，并推后已认领事件的下次投递时间
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `events` WHERE sent_at IS NULL AND attempts < \\? AND next_attempt_at <= \\? ORDER BY id ASC LIMIT 100 FOR UPDATE SKIP LOCKED").
+		WithArgs(10, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel"}).AddRow(3, "game:events").AddRow(5, "game:events"))
+	mock.ExpectExec("UPDATE `events` SET `next_attempt_at`=\\? WHERE id IN \\(\\?,\\?\\)").
+		WithArgs(sqlmock.AnyArg(), 3, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	events, err := repo.ClaimPending(context.Background(), 10, 100, 30*time.Second)
+	if err != nil {
+		t.Fatalf("ClaimPending() error = %v", err)
+	}
+	if len(events) != 2 || events[0].ID != 3 || events[1].ID != 5 {
+		t.Errorf("ClaimPending() = %+v", events)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/game-apps/internal/model"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB 创建基于 sqlmock 的 gorm 连接
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestResetReady(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRoomPlayerRepository(db)
+
+	// 单条 UPDATE 重置房间内所有在场玩家
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `room_players` SET `is_ready`=\\?.* WHERE room_id = \\? AND left_at IS NULL").
+		WithArgs(false, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	if err := repo.ResetReady(context.Background(), 7); err != nil {
+		t.Fatalf("ResetReady() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFirstFreePosition(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []int
+		want  int
+	}{
+		{"空房间", nil, 0},
+		{"连续位置", []int{0, 1, 2}, 3},
+		{"中间空位", []int{0, 2, 3}, 1},
+		{"首位空闲", []int{1, 2}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstFreePosition(tt.taken); got != tt.want {
+				t.Errorf("firstFreePosition(%v) = %d, want %d", tt.taken, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveWithFreePositionRetriesOnConflict(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRoomPlayerRepository(db)
+	selectPositions := "SELECT `position` FROM `room_players` WHERE room_id = \\? AND position IS NOT NULL"
+
+	// 第一次分配的位置被并发加入的玩家抢占，唯一约束冲突后重新分配
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectPositions).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO `room_players`").
+		WillReturnError(&gomysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectPositions).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0).AddRow(1))
+	mock.ExpectExec("INSERT INTO `room_players`").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	player := &model.RoomPlayer{RoomID: 7, UserID: 3}
+	if err := repo.SaveWithFreePosition(context.Background(), player); err != nil {
+		t.Fatalf("SaveWithFreePosition() error = %v", err)
+	}
+	if player.ID != 5 || player.Position == nil || *player.Position != 2 {
+		t.Errorf("player = id %d at %v, want id 5 at 2", player.ID, player.Position)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestListAndCountByOwner(t *testing.T) {
+	playing := model.RoomStatusPlaying
+
+	tests := []struct {
+		name      string
+		status    *model.RoomStatus
+		where     string
+		whereArgs []driver.Value
+	}{
+		{"全部状态", nil, "WHERE owner_id = \\? AND `rooms`.`deleted_at` IS NULL", []driver.Value{7}},
+		{"按状态筛选", &playing, "WHERE owner_id = \\? AND status = \\? AND `rooms`.`deleted_at` IS NULL", []driver.Value{7, playing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewRoomRepository(db)
+			ctx := context.Background()
+
+			mock.ExpectQuery("SELECT \\* FROM `rooms` " + tt.where + " ORDER BY created_at DESC LIMIT 10 OFFSET 20").
+				WithArgs(tt.whereArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "status"}).AddRow(3, 7, playing).AddRow(1, 7, playing))
+			rooms, err := repo.ListByOwner(ctx, 7, tt.status, 10, 20)
+			if err != nil {
+				t.Fatalf("ListByOwner() error = %v", err)
+			}
+			if len(rooms) != 2 || rooms[0].ID != 3 || rooms[0].OwnerID != 7 {
+				t.Errorf("ListByOwner() = %+v", rooms)
+			}
+
+			mock.ExpectQuery("SELECT count\\(\\*\\) FROM `rooms` " + tt.where).
+				WithArgs(tt.whereArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+			total, err := repo.CountByOwner(ctx, 7, tt.status)
+			if err != nil || total != 5 {
+				t.Errorf("CountByOwner() = %d, %v, want 5", total, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUpdateCurrentPlayers(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRoomRepository(db)
+
+	// 只更新 current_players 一列，不触碰 updated_at 等其他字段
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `rooms` SET `current_players`=\\? WHERE id = \\?").
+		WithArgs(3, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateCurrentPlayers(context.Background(), 7, 3); err != nil {
+		t.Fatalf("UpdateCurrentPlayers() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMarkStarted(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRoomRepository(db)
+	startedAt := time.Now()
+
+	// 只更新状态和开始时间，不覆盖并发修改的设置、房主等字段
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `rooms` SET `started_at`=\\?,`status`=\\?,`updated_at`=\\? WHERE id = \\? AND `rooms`.`deleted_at` IS NULL").
+		WithArgs(startedAt, model.RoomStatusPlaying, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.MarkStarted(context.Background(), 7, startedAt); err != nil {
+		t.Fatalf("MarkStarted() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
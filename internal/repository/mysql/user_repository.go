@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/game-apps/internal/model"
 	"gorm.io/gorm"
@@ -62,6 +63,16 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.U
 	return &user, nil
 }
 
+// GetByIDs 根据 ID 批量获取用户，不存在的 ID 会被忽略
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	var users []*model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // Update 更新用户
 func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
@@ -106,6 +117,34 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int, keyword st
 	return users, total, nil
 }
 
+// UsernameHistoryRepository 用户名变更历史数据访问层
+type UsernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewUsernameHistoryRepository 创建用户名变更历史仓库
+func NewUsernameHistoryRepository(db *gorm.DB) *UsernameHistoryRepository {
+	return &UsernameHistoryRepository{db: db}
+}
+
+// Create 记录用户名变更
+func (r *UsernameHistoryRepository) Create(ctx context.Context, history *model.UsernameHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+// GetLatestByUserID 获取用户最近一次用户名变更
+func (r *UsernameHistoryRepository) GetLatestByUserID(ctx context.Context, userID uint) (*model.UsernameHistory, error) {
+	var history model.UsernameHistory
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &history, nil
+}
+
 // UserProfileRepository 用户资料数据访问层
 type UserProfileRepository struct {
 	db *gorm.DB
@@ -167,11 +206,44 @@ func (r *UserStatsRepository) GetByUserID(ctx context.Context, userID uint) (*mo
 	return &stats, nil
 }
 
+// GetByUserIDs 根据用户 ID 批量获取统计，没有统计记录的用户会被忽略
+func (r *UserStatsRepository) GetByUserIDs(ctx context.Context, userIDs []uint) ([]*model.UserStats, error) {
+	var stats []*model.UserStats
+	if len(userIDs) == 0 {
+		return stats, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&stats).Error
+	return stats, err
+}
+
 // Update 更新用户统计
 func (r *UserStatsRepository) Update(ctx context.Context, stats *model.UserStats) error {
 	return r.db.WithContext(ctx).Save(stats).Error
 }
 
+// ListInactive 列出最近一次对局早于 since 的用户统计，按最近对局时间升序，从未完成对局的用户不包含在内
+func (r *UserStatsRepository) ListInactive(ctx context.Context, since time.Time, limit, offset int) ([]*model.UserStats, error) {
+	var stats []*model.UserStats
+	err := r.db.WithContext(ctx).
+		Where("last_played_at < ?", since).
+		Order("last_played_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&stats).Error
+	return stats, err
+}
+
+// CountInactive 统计最近一次对局早于 since 的用户数
+func (r *UserStatsRepository) CountInactive(ctx context.Context, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&model.UserStats{}).
+		Where("last_played_at < ?", since).
+		Count(&total).Error
+	return total, err
+}
+
 // UpdateWinRate 更新胜率
 func (r *UserStatsRepository) UpdateWinRate(ctx context.Context, userID uint) error {
 	var stats model.UserStats
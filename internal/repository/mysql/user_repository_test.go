@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListAndCountInactive(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserStatsRepository(db)
+	ctx := context.Background()
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	// 从未完成对局的用户 last_played_at 为 NULL，不满足比较条件
+	mock.ExpectQuery("SELECT \\* FROM `user_stats` WHERE last_played_at < \\? ORDER BY last_played_at ASC,id ASC LIMIT 10 OFFSET 20").
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "last_played_at"}).AddRow(1, 7, since.AddDate(0, -1, 0)))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user_stats` WHERE last_played_at < \\?").
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	stats, err := repo.ListInactive(ctx, since, 10, 20)
+	if err != nil {
+		t.Fatalf("ListInactive() error = %v", err)
+	}
+	if len(stats) != 1 || stats[0].UserID != 7 || stats[0].LastPlayedAt == nil {
+		t.Errorf("ListInactive() = %+v", stats)
+	}
+	total, err := repo.CountInactive(ctx, since)
+	if err != nil || total != 21 {
+		t.Errorf("CountInactive() = %d, %v, want 21", total, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
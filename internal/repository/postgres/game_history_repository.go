@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/game-apps/internal/model"
+	"gorm.io/gorm"
+)
+
+// GameHistoryRepository 对局记录数据访问层（PostgreSQL）
+type GameHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewGameHistoryRepository 创建对局记录仓库
+func NewGameHistoryRepository(db *gorm.DB) *GameHistoryRepository {
+	return &GameHistoryRepository{db: db}
+}
+
+// ListByUserID 按对局时间倒序获取用户的对局记录
+func (r *GameHistoryRepository) ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.GameHistory, error) {
+	var histories []*model.GameHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("played_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&histories).Error
+	return histories, err
+}
+
+// CountByUserID 统计用户的对局数量
+func (r *GameHistoryRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.GameHistory{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+// ListByRoomIDs 获取多个房间的全部对局记录，用于查询对手
+func (r *GameHistoryRepository) ListByRoomIDs(ctx context.Context, roomIDs []uint) ([]*model.GameHistory, error) {
+	var histories []*model.GameHistory
+	if len(roomIDs) == 0 {
+		return histories, nil
+	}
+	err := r.db.WithContext(ctx).Where("room_id IN ?", roomIDs).Find(&histories).Error
+	return histories, err
+}
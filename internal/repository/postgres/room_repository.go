@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/game-apps/internal/model"
+	"github.com/game-apps/pkg/database"
 	"gorm.io/gorm"
 )
 
@@ -62,11 +64,79 @@ func (r *RoomRepository) List(ctx context.Context, status *model.RoomStatus, lim
 	return rooms, err
 }
 
+// ListAfter 按创建时间倒序进行游标分页，返回排在 (afterCreatedAt, afterID) 之后的房间
+// afterID 为 0 时从第一条开始；排序包含 id，同一时间创建的房间也不会重复或遗漏
+func (r *RoomRepository) ListAfter(ctx context.Context, status *model.RoomStatus, afterCreatedAt time.Time, afterID uint, limit int) ([]*model.Room, error) {
+	var rooms []*model.Room
+	query := r.db.WithContext(ctx)
+
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	if afterID != 0 {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", afterCreatedAt, afterCreatedAt, afterID)
+	}
+
+	err := query.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&rooms).Error
+	return rooms, err
+}
+
+// Count 统计房间数量
+func (r *RoomRepository) Count(ctx context.Context, status *model.RoomStatus) (int64, error) {
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.Room{})
+
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	err := query.Count(&total).Error
+	return total, err
+}
+
+// ListByOwner 列出用户创建的房间，status 为空时不按状态筛选
+func (r *RoomRepository) ListByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus, limit, offset int) ([]*model.Room, error) {
+	var rooms []*model.Room
+	query := r.db.WithContext(ctx).Where("owner_id = ?", ownerID)
+
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&rooms).Error
+	return rooms, err
+}
+
+// CountByOwner 统计用户创建的房间数量，status 为空时不按状态筛选
+func (r *RoomRepository) CountByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus) (int64, error) {
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.Room{}).Where("owner_id = ?", ownerID)
+
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	err := query.Count(&total).Error
+	return total, err
+}
+
 // Update 更新房间
 func (r *RoomRepository) Update(ctx context.Context, room *model.Room) error {
 	return r.db.WithContext(ctx).Save(room).Error
 }
 
+// UpdateCurrentPlayers 只更新房间的当前玩家数，不覆盖其他字段
+func (r *RoomRepository) UpdateCurrentPlayers(ctx context.Context, roomID uint, currentPlayers int) error {
+	return r.db.WithContext(ctx).Model(&model.Room{}).Where("id = ?", roomID).
+		UpdateColumn("current_players", currentPlayers).Error
+}
+
+// MarkStarted 只更新房间状态为进行中和开始时间，不覆盖其他字段
+func (r *RoomRepository) MarkStarted(ctx context.Context, roomID uint, startedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Room{}).Where("id = ?", roomID).
+		Updates(map[string]interface{}{"status": model.RoomStatusPlaying, "started_at": startedAt}).Error
+}
+
 // Delete 删除房间（软删除）
 func (r *RoomRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Room{}, id).Error
@@ -107,17 +177,114 @@ func (r *RoomPlayerRepository) GetByRoomIDAndUserID(ctx context.Context, roomID,
 	return &player, nil
 }
 
+// GetActiveByUserID 获取用户在未结束房间（等待中或进行中）中的玩家关系
+func (r *RoomPlayerRepository) GetActiveByUserID(ctx context.Context, userID uint) (*model.RoomPlayer, error) {
+	var player model.RoomPlayer
+	err := r.db.WithContext(ctx).
+		Joins("JOIN rooms ON rooms.id = room_players.room_id AND rooms.deleted_at IS NULL").
+		Where("room_players.user_id = ? AND room_players.left_at IS NULL", userID).
+		Where("rooms.status IN ?", []model.RoomStatus{model.RoomStatusWaiting, model.RoomStatusPlaying}).
+		First(&player).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &player, nil
+}
+
+// positionAllocRetries 分配位置遇到唯一约束冲突时的最大尝试次数
+const positionAllocRetries = 3
+
+// SaveWithFreePosition 在事务中为玩家分配第一个空闲位置并保存（ID 为 0 时创建）
+// 并发加入导致位置冲突时重新分配
+func (r *RoomPlayerRepository) SaveWithFreePosition(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	isNew := roomPlayer.ID == 0
+	var err error
+	for attempt := 0; attempt < positionAllocRetries; attempt++ {
+		if isNew {
+			roomPlayer.ID = 0
+		}
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var taken []int
+			if err := tx.Model(&model.RoomPlayer{}).
+				Where("room_id = ? AND position IS NOT NULL", roomPlayer.RoomID).
+				Order("position ASC").
+				Pluck("position", &taken).Error; err != nil {
+				return err
+			}
+
+			position := firstFreePosition(taken)
+			roomPlayer.Position = &position
+			return tx.Save(roomPlayer).Error
+		})
+		if err == nil || !database.IsDuplicateKey(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// firstFreePosition 返回升序位置列表中第一个未被占用的位置
+func firstFreePosition(taken []int) int {
+	position := 0
+	for _, p := range taken {
+		if p > position {
+			break
+		}
+		if p == position {
+			position++
+		}
+	}
+	return position
+}
+
 // Update 更新房间玩家关系
 func (r *RoomPlayerRepository) Update(ctx context.Context, roomPlayer *model.RoomPlayer) error {
 	return r.db.WithContext(ctx).Save(roomPlayer).Error
 }
 
+// ResetReady 将房间内所有在场玩家重置为未准备
+func (r *RoomPlayerRepository) ResetReady(ctx context.Context, roomID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&model.RoomPlayer{}).
+		Where("room_id = ? AND left_at IS NULL", roomID).
+		Update("is_ready", false).Error
+}
+
+// ReassignPositions 将房间内在场玩家的位置重新压缩为连续的 0..n-1，保持原有先后顺序
+// 返回是否有玩家的位置发生变化
+func (r *RoomPlayerRepository) ReassignPositions(ctx context.Context, roomID uint) (bool, error) {
+	changed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var players []*model.RoomPlayer
+		if err := tx.Where("room_id = ? AND left_at IS NULL AND is_spectator = ?", roomID, false).
+			Order("position ASC, joined_at ASC, id ASC").
+			Find(&players).Error; err != nil {
+			return err
+		}
+
+		for i, player := range players {
+			if player.Position != nil && *player.Position == i {
+				continue
+			}
+			if err := tx.Model(&model.RoomPlayer{}).Where("id = ?", player.ID).Update("position", i).Error; err != nil {
+				return err
+			}
+			changed = true
+		}
+		return nil
+	})
+	return changed, err
+}
+
 // LeaveRoom 离开房间
 func (r *RoomPlayerRepository) LeaveRoom(ctx context.Context, roomID, userID uint) error {
 	now := gorm.Expr("NOW()")
 	return r.db.WithContext(ctx).
 		Model(&model.RoomPlayer{}).
 		Where("room_id = ? AND user_id = ?", roomID, userID).
-		Update("left_at", now).Error
+		Updates(map[string]interface{}{"left_at": now, "position": nil}).Error
 }
 
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/game-apps/internal/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB 创建基于 sqlmock 的 gorm 连接
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestListAndCountByOwner(t *testing.T) {
+	playing := model.RoomStatusPlaying
+
+	tests := []struct {
+		name      string
+		status    *model.RoomStatus
+		where     string
+		whereArgs []driver.Value
+	}{
+		{"全部状态", nil, `WHERE owner_id = \$1 AND "rooms"."deleted_at" IS NULL`, []driver.Value{7}},
+		{"按状态筛选", &playing, `WHERE owner_id = \$1 AND status = \$2 AND "rooms"."deleted_at" IS NULL`, []driver.Value{7, playing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewRoomRepository(db)
+			ctx := context.Background()
+
+			mock.ExpectQuery(`SELECT \* FROM "rooms" ` + tt.where + ` ORDER BY created_at DESC LIMIT 10 OFFSET 20`).
+				WithArgs(tt.whereArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "status"}).AddRow(3, 7, playing).AddRow(1, 7, playing))
+			rooms, err := repo.ListByOwner(ctx, 7, tt.status, 10, 20)
+			if err != nil {
+				t.Fatalf("ListByOwner() error = %v", err)
+			}
+			if len(rooms) != 2 || rooms[0].ID != 3 || rooms[0].OwnerID != 7 {
+				t.Errorf("ListByOwner() = %+v", rooms)
+			}
+
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "rooms" ` + tt.where).
+				WithArgs(tt.whereArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+			total, err := repo.CountByOwner(ctx, 7, tt.status)
+			if err != nil || total != 5 {
+				t.Errorf("CountByOwner() = %d, %v, want 5", total, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	lockAcquireTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redis_lock_acquire_total",
+			Help: "Total number of distributed lock acquire attempts",
+		},
+		[]string{"resource", "result"},
+	)
+
+	lockAcquireDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_lock_acquire_duration_seconds",
+			Help:    "Time spent acquiring distributed locks in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"resource"},
+	)
+
+	lockHoldDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_lock_hold_duration_seconds",
+			Help:    "Time distributed locks were held in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"resource"},
+	)
+)
+
+// 锁获取结果标签
+const (
+	lockResultAcquired = "acquired"
+	lockResultFailed   = "failed"
+	lockResultError    = "error"
+)
+
+// lockResourceLabel 取资源名的前缀（如 room、game）作为指标标签，避免标签基数过高
+func lockResourceLabel(resource string) string {
+	if i := strings.Index(resource, ":"); i > 0 {
+		return resource[:i]
+	}
+	return "other"
+}
@@ -2,11 +2,18 @@ package redis
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/game-apps/pkg/cache"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 // Repository Redis 数据访问层
@@ -60,6 +67,68 @@ func (r *SessionRepository) DeleteSession(ctx context.Context, userID uint) erro
 	return r.cache.Del(ctx, key)
 }
 
+// AddDeviceSession 添加设备会话（每次登录一条，按会话 ID 区分）
+func (r *SessionRepository) AddDeviceSession(ctx context.Context, userID uint, sessionID string, data map[string]interface{}, expiration time.Duration) error {
+	key := fmt.Sprintf("session:%d:%s", userID, sessionID)
+	indexKey := fmt.Sprintf("session:devices:%d", userID)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := r.cache.Set(ctx, key, jsonData, expiration); err != nil {
+		return err
+	}
+	if err := r.cache.SAdd(ctx, indexKey, sessionID); err != nil {
+		return err
+	}
+	return r.cache.Expire(ctx, indexKey, expiration)
+}
+
+// DeleteAllSessions 删除用户的所有会话（包括所有设备会话）
+func (r *SessionRepository) DeleteAllSessions(ctx context.Context, userID uint) error {
+	indexKey := fmt.Sprintf("session:devices:%d", userID)
+	sessionIDs, err := r.cache.SMembers(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{fmt.Sprintf("session:%d", userID), indexKey}
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, fmt.Sprintf("session:%d:%s", userID, sessionID))
+	}
+	return r.cache.Del(ctx, keys...)
+}
+
+// RevokeTokensBefore 吊销用户在指定时间之前签发的所有令牌，时间点精确到毫秒
+func (r *SessionRepository) RevokeTokensBefore(ctx context.Context, userID uint, before time.Time, expiration time.Duration) error {
+	key := fmt.Sprintf("token:revoked_before:%d", userID)
+	return r.cache.Set(ctx, key, before.UnixMilli(), expiration)
+}
+
+// legacyRevokedBeforeLimit 小于该值的吊销时间点是旧版本按秒写入的
+const legacyRevokedBeforeLimit = 1e11
+
+// GetTokensRevokedBefore 获取用户令牌的吊销时间点，未吊销时返回零值
+func (r *SessionRepository) GetTokensRevokedBefore(ctx context.Context, userID uint) (time.Time, error) {
+	key := fmt.Sprintf("token:revoked_before:%d", userID)
+	data, err := r.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	millis, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if millis < legacyRevokedBeforeLimit {
+		return time.Unix(millis, 0), nil
+	}
+	return time.UnixMilli(millis), nil
+}
+
 // RoomRepository 房间缓存
 type RoomRepository struct {
 	*Repository
@@ -82,6 +151,103 @@ func (r *RoomRepository) GetRoomState(ctx context.Context, roomID uint) (map[str
 	return r.cache.HGetAll(ctx, key)
 }
 
+// GetRoomStatesBatch 批量获取房间状态，Redis 中不存在的房间不会出现在结果中
+func (r *RoomRepository) GetRoomStatesBatch(ctx context.Context, roomIDs []uint) (map[uint]map[string]string, error) {
+	keys := make([]string, len(roomIDs))
+	for i, roomID := range roomIDs {
+		keys[i] = fmt.Sprintf("room:%d", roomID)
+	}
+
+	results, err := r.cache.HGetAllBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[uint]map[string]string, len(roomIDs))
+	for i, state := range results {
+		if len(state) > 0 {
+			states[roomIDs[i]] = state
+		}
+	}
+	return states, nil
+}
+
+// ClearRoomState 清除房间状态和操作序号（保留房间玩家列表）
+func (r *RoomRepository) ClearRoomState(ctx context.Context, roomID uint) error {
+	key := fmt.Sprintf("room:%d", roomID)
+	seqKey := fmt.Sprintf("room:move_seq:%d", roomID)
+	return r.cache.Del(ctx, key, seqKey)
+}
+
+// advanceMoveSeqScript 仅当新序号大于已应用的序号时更新，返回 {是否更新, 当前序号}
+const advanceMoveSeqScript = `
+local current = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+local seq = tonumber(ARGV[2])
+if seq > current then
+	redis.call('HSET', KEYS[1], ARGV[1], seq)
+	redis.call('EXPIRE', KEYS[1], ARGV[3])
+	return {1, seq}
+end
+return {0, current}
+`
+
+// GetMoveSeq 获取玩家在房间内已应用的操作序号，没有记录时返回 0
+func (r *RoomRepository) GetMoveSeq(ctx context.Context, roomID, userID uint) (int64, error) {
+	key := fmt.Sprintf("room:move_seq:%d", roomID)
+	value, err := r.cache.HGet(ctx, key, fmt.Sprintf("%d", userID))
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// AdvanceMoveSeq 原子地推进玩家在房间内的操作序号
+// 返回是否推进成功，以及推进后（或失败时已应用）的序号
+func (r *RoomRepository) AdvanceMoveSeq(ctx context.Context, roomID, userID uint, seq int64, expiration time.Duration) (bool, int64, error) {
+	key := fmt.Sprintf("room:move_seq:%d", roomID)
+	result, err := r.cache.Eval(ctx, advanceMoveSeqScript, []string{key}, userID, seq, int64(expiration.Seconds()))
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected script result: %v", result)
+	}
+	advanced, _ := values[0].(int64)
+	current, _ := values[1].(int64)
+	return advanced == 1, current, nil
+}
+
+// SetRoomCode 设置房间代码到房间 ID 的索引
+func (r *RoomRepository) SetRoomCode(ctx context.Context, roomCode string, roomID uint, expiration time.Duration) error {
+	key := fmt.Sprintf("room:code:%s", roomCode)
+	return r.cache.Set(ctx, key, roomID, expiration)
+}
+
+// GetRoomIDByCode 通过房间代码获取房间 ID
+func (r *RoomRepository) GetRoomIDByCode(ctx context.Context, roomCode string) (uint, error) {
+	key := fmt.Sprintf("room:code:%s", roomCode)
+	data, err := r.cache.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	roomID, err := strconv.ParseUint(data, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(roomID), nil
+}
+
+// DeleteRoomCode 删除房间代码索引
+func (r *RoomRepository) DeleteRoomCode(ctx context.Context, roomCode string) error {
+	key := fmt.Sprintf("room:code:%s", roomCode)
+	return r.cache.Del(ctx, key)
+}
+
 // AddRoomPlayer 添加房间玩家
 func (r *RoomRepository) AddRoomPlayer(ctx context.Context, roomID uint, userID uint) error {
 	key := fmt.Sprintf("room:players:%d", roomID)
@@ -106,11 +272,216 @@ func (r *RoomRepository) IsRoomPlayer(ctx context.Context, roomID uint, userID u
 	return r.cache.SIsMember(ctx, key, fmt.Sprintf("%d", userID))
 }
 
+// SetPlayerAFK 标记玩家暂离（断线超过宽限期但未离开房间）
+func (r *RoomRepository) SetPlayerAFK(ctx context.Context, roomID, userID uint) error {
+	key := fmt.Sprintf("room:afk:%d", roomID)
+	return r.cache.SAdd(ctx, key, userID)
+}
+
+// ClearPlayerAFK 清除玩家的暂离标记
+func (r *RoomRepository) ClearPlayerAFK(ctx context.Context, roomID, userID uint) error {
+	key := fmt.Sprintf("room:afk:%d", roomID)
+	return r.cache.SRem(ctx, key, userID)
+}
+
+// IsPlayerAFK 检查玩家是否处于暂离状态
+func (r *RoomRepository) IsPlayerAFK(ctx context.Context, roomID, userID uint) (bool, error) {
+	key := fmt.Sprintf("room:afk:%d", roomID)
+	return r.cache.SIsMember(ctx, key, fmt.Sprintf("%d", userID))
+}
+
+// GetAFKPlayers 获取房间内处于暂离状态的玩家
+func (r *RoomRepository) GetAFKPlayers(ctx context.Context, roomID uint) (map[uint]bool, error) {
+	key := fmt.Sprintf("room:afk:%d", roomID)
+	members, err := r.cache.SMembers(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	afk := make(map[uint]bool, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			afk[uint(id)] = true
+		}
+	}
+	return afk, nil
+}
+
+// ClearAFKPlayers 清除房间内全部玩家的暂离标记
+func (r *RoomRepository) ClearAFKPlayers(ctx context.Context, roomID uint) error {
+	key := fmt.Sprintf("room:afk:%d", roomID)
+	return r.cache.Del(ctx, key)
+}
+
+// joinWaitlistScript 用户不在候补队列中时按加入时间入队，返回用户在队列中的排名（从 0 开始）
+const joinWaitlistScript = `
+redis.call('ZADD', KEYS[1], 'NX', ARGV[2], ARGV[1])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[3])
+end
+return redis.call('ZRANK', KEYS[1], ARGV[1])
+`
+
+// popWaitlistScript 取出候补队列队首，返回成员和分数，队列为空时返回空数组
+const popWaitlistScript = `
+local head = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #head == 0 then
+	return {}
+end
+redis.call('ZREM', KEYS[1], head[1])
+return head
+`
+
+// leaveWaitlistScript 将用户移出候补队列，返回移除的数量
+const leaveWaitlistScript = `return redis.call('ZREM', KEYS[1], ARGV[1])`
+
+// JoinWaitlist 加入房间候补队列，重复加入保持原有顺序，返回排名（从 0 开始）
+func (r *RoomRepository) JoinWaitlist(ctx context.Context, roomID, userID uint, expiration time.Duration) (int64, error) {
+	key := fmt.Sprintf("room:waitlist:%d", roomID)
+	result, err := r.cache.Eval(ctx, joinWaitlistScript, []string{key}, userID, time.Now().UnixMicro(), int64(expiration.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	rank, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected script result: %v", result)
+	}
+	return rank, nil
+}
+
+// LeaveWaitlist 离开房间候补队列，返回用户是否在队列中
+func (r *RoomRepository) LeaveWaitlist(ctx context.Context, roomID, userID uint) (bool, error) {
+	key := fmt.Sprintf("room:waitlist:%d", roomID)
+	result, err := r.cache.Eval(ctx, leaveWaitlistScript, []string{key}, userID)
+	if err != nil {
+		return false, err
+	}
+	removed, _ := result.(int64)
+	return removed > 0, nil
+}
+
+// PopWaitlist 取出候补队列中最早加入的用户及其分数（加入时间），队列为空时返回 0
+func (r *RoomRepository) PopWaitlist(ctx context.Context, roomID uint) (uint, float64, error) {
+	key := fmt.Sprintf("room:waitlist:%d", roomID)
+	result, err := r.cache.Eval(ctx, popWaitlistScript, []string{key})
+	if err != nil {
+		return 0, 0, err
+	}
+	values, _ := result.([]interface{})
+	if len(values) != 2 {
+		return 0, 0, nil
+	}
+	member, _ := values[0].(string)
+	rawScore, _ := values[1].(string)
+	userID, err := strconv.ParseUint(member, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	score, err := strconv.ParseFloat(rawScore, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(userID), score, nil
+}
+
+// RequeueWaitlist 将取出的候补用户以原分数放回队列，保持其原有顺序
+func (r *RoomRepository) RequeueWaitlist(ctx context.Context, roomID, userID uint, score float64) error {
+	key := fmt.Sprintf("room:waitlist:%d", roomID)
+	return r.cache.ZAdd(ctx, key, score, userID)
+}
+
+// turnDeadlinesKey 所有进行中回合的截止时间（有序集合，分数为截止时间的毫秒时间戳）
+const turnDeadlinesKey = "game:turn_deadlines"
+
+// ScheduleTurnDeadline 记录房间当前回合的截止时间，覆盖之前的截止时间
+func (r *RoomRepository) ScheduleTurnDeadline(ctx context.Context, roomID uint, deadline time.Time) error {
+	return r.cache.ZAdd(ctx, turnDeadlinesKey, float64(deadline.UnixMilli()), roomID)
+}
+
+// ClearTurnDeadline 移除房间的回合截止时间
+func (r *RoomRepository) ClearTurnDeadline(ctx context.Context, roomID uint) error {
+	return r.cache.ZRem(ctx, turnDeadlinesKey, roomID)
+}
+
+// DueTurnDeadlines 获取截止时间不晚于 now 的房间 ID，按截止时间升序
+func (r *RoomRepository) DueTurnDeadlines(ctx context.Context, now time.Time, limit int) ([]uint, error) {
+	members, err := r.cache.ZRangeByScore(ctx, turnDeadlinesKey, "-inf", strconv.FormatInt(now.UnixMilli(), 10), int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	roomIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			roomIDs = append(roomIDs, uint(id))
+		}
+	}
+	return roomIDs, nil
+}
+
+// NextEventSeq 获取房间的下一个事件序号，同一房间内严格递增，不同房间互相独立
+// 序号键不随房间状态清除，重开游戏后序号继续递增；与事件列表一同过期
+func (r *RoomRepository) NextEventSeq(ctx context.Context, roomID uint) (int64, error) {
+	key := fmt.Sprintf("room:event_seq:%d", roomID)
+	return r.cache.Incr(ctx, key)
+}
+
+// appendRoomEventScript 追加事件到房间事件列表并裁剪到最大长度，刷新事件列表和序号的过期时间，
+// 并在序号更大时更新房间状态中的最新事件游标
+const appendRoomEventScript = `
+redis.call('RPUSH', KEYS[1], ARGV[2])
+redis.call('LTRIM', KEYS[1], -tonumber(ARGV[3]), -1)
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('EXPIRE', KEYS[3], ARGV[4])
+local current = tonumber(redis.call('HGET', KEYS[2], 'last_event_seq') or '0')
+if tonumber(ARGV[1]) > current then
+	redis.call('HSET', KEYS[2], 'last_event_seq', ARGV[1])
+end
+return 1
+`
+
+// AppendRoomEvent 记录房间事件，payload 为序列化后的事件，只保留最近 maxLength 条，
+// 事件列表和序号在最后一次追加 expiration 后过期
+func (r *RoomRepository) AppendRoomEvent(ctx context.Context, roomID uint, seq int64, payload []byte, maxLength int, expiration time.Duration) error {
+	eventsKey := fmt.Sprintf("room:events:%d", roomID)
+	stateKey := fmt.Sprintf("room:%d", roomID)
+	seqKey := fmt.Sprintf("room:event_seq:%d", roomID)
+	_, err := r.cache.Eval(ctx, appendRoomEventScript, []string{eventsKey, stateKey, seqKey},
+		seq, payload, maxLength, int64(expiration.Seconds()))
+	return err
+}
+
+// ResetRoomEvents 清除房间的事件历史，事件序号保留并继续递增，客户端已有的游标仍然有效
+func (r *RoomRepository) ResetRoomEvents(ctx context.Context, roomID uint) error {
+	eventsKey := fmt.Sprintf("room:events:%d", roomID)
+	return r.cache.Del(ctx, eventsKey)
+}
+
+// GetRoomEvents 获取房间已记录的全部事件（按追加顺序）
+func (r *RoomRepository) GetRoomEvents(ctx context.Context, roomID uint) ([]string, error) {
+	key := fmt.Sprintf("room:events:%d", roomID)
+	return r.cache.LRange(ctx, key, 0, -1)
+}
+
+// RoomCacheKey 房间详情的读缓存键（cache-aside），与房间实时状态分开存放
+func RoomCacheKey(roomID uint) string {
+	return fmt.Sprintf("cache:room:%d", roomID)
+}
+
+// InvalidateRoomCache 删除房间详情的读缓存
+func (r *RoomRepository) InvalidateRoomCache(ctx context.Context, roomID uint) error {
+	return r.cache.Del(ctx, RoomCacheKey(roomID))
+}
+
 // DeleteRoom 删除房间缓存
 func (r *RoomRepository) DeleteRoom(ctx context.Context, roomID uint) error {
 	roomKey := fmt.Sprintf("room:%d", roomID)
 	playersKey := fmt.Sprintf("room:players:%d", roomID)
-	return r.cache.Del(ctx, roomKey, playersKey)
+	seqKey := fmt.Sprintf("room:move_seq:%d", roomID)
+	waitlistKey := fmt.Sprintf("room:waitlist:%d", roomID)
+	eventSeqKey := fmt.Sprintf("room:event_seq:%d", roomID)
+	eventsKey := fmt.Sprintf("room:events:%d", roomID)
+	afkKey := fmt.Sprintf("room:afk:%d", roomID)
+	return r.cache.Del(ctx, roomKey, playersKey, seqKey, waitlistKey, eventSeqKey, eventsKey, afkKey, RoomCacheKey(roomID))
 }
 
 // Client 获取 Redis 客户端
@@ -140,7 +511,157 @@ func (r *OnlineUserRepository) RemoveOnlineUser(ctx context.Context, userID uint
 
 // IsOnline 检查用户是否在线
 func (r *OnlineUserRepository) IsOnline(ctx context.Context, userID uint) (bool, error) {
-	return r.cache.SIsMember(ctx, "user:online", userID)
+	return r.cache.SIsMember(ctx, "user:online", fmt.Sprintf("%d", userID))
+}
+
+// ConnectionRepository 记录用户在各实例上的 WebSocket 连接
+// 每个实例把本实例上已连接的用户写入自己的集合，并在实例登记中记录过期时间；
+// 心跳定期用本实例的实际连接覆盖集合并续期，实例崩溃后其记录在 ttl 后失效，不会让用户一直显示为已连接
+type ConnectionRepository struct {
+	*Repository
+	instanceID string
+	ttl        time.Duration
+}
+
+const (
+	// connectionInstancesKey 实例登记，有序集合的成员为实例 ID，分数为记录的过期时间（毫秒）
+	connectionInstancesKey = "user:connections:instances"
+	// connectionsKeyPrefix 各实例已连接用户集合的键前缀，后接实例 ID
+	connectionsKeyPrefix = "user:connections:instance:"
+)
+
+// addConnectionScript 将用户加入本实例的集合，同时续期集合和实例登记
+const addConnectionScript = `
+redis.call('SADD', KEYS[1], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[4])
+return 1
+`
+
+// hasConnectionScript 检查用户是否在任一未过期实例的集合中
+const hasConnectionScript = `
+local instances = redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. ARGV[1], '+inf')
+for _, id in ipairs(instances) do
+	if redis.call('SISMEMBER', ARGV[2] .. id, ARGV[3]) == 1 then
+		return 1
+	end
+end
+return 0
+`
+
+// syncConnectionsScript 用给定的用户覆盖本实例的集合并续期，同时清理已过期的实例
+// ARGV[1] 集合过期时间（毫秒），ARGV[2] 登记的过期时间点，ARGV[3] 实例 ID，ARGV[4] 当前时间，ARGV[5] 集合键前缀，之后为用户 ID
+const syncConnectionsScript = `
+redis.call('DEL', KEYS[1])
+for i = 6, #ARGV do
+	redis.call('SADD', KEYS[1], ARGV[i])
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[4])
+for _, id in ipairs(expired) do
+	redis.call('DEL', ARGV[5] .. id)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[4])
+return #expired
+`
+
+// resetConnectionsScript 删除本实例的集合和登记，同时清理已过期的实例
+const resetConnectionsScript = `
+redis.call('DEL', KEYS[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+for _, id in ipairs(expired) do
+	redis.call('DEL', ARGV[3] .. id)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+return #expired
+`
+
+// NewConnectionRepository 创建连接记录仓库，instanceID 在所有实例中唯一，
+// ttl 为记录的有效期，需要大于心跳间隔（SyncConnections 的调用间隔）
+func NewConnectionRepository(repo *Repository, instanceID string, ttl time.Duration) *ConnectionRepository {
+	return &ConnectionRepository{Repository: repo, instanceID: instanceID, ttl: ttl}
+}
+
+// NewInstanceID 生成本进程的实例 ID，格式为 主机名:进程号
+func NewInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// instanceKey 本实例已连接用户集合的键
+func (r *ConnectionRepository) instanceKey() string {
+	return connectionsKeyPrefix + r.instanceID
+}
+
+// AddConnection 用户在本实例上建立 WebSocket 连接
+func (r *ConnectionRepository) AddConnection(ctx context.Context, userID uint) error {
+	expiresAt := time.Now().Add(r.ttl).UnixMilli()
+	_, err := r.cache.Eval(ctx, addConnectionScript, []string{r.instanceKey(), connectionInstancesKey},
+		userID, r.ttl.Milliseconds(), expiresAt, r.instanceID)
+	return err
+}
+
+// RemoveConnection 用户在本实例上断开全部连接
+func (r *ConnectionRepository) RemoveConnection(ctx context.Context, userID uint) error {
+	return r.cache.SRem(ctx, r.instanceKey(), userID)
+}
+
+// HasConnection 检查用户是否在任一实例上仍有 WebSocket 连接，已过期实例上的记录不计入
+func (r *ConnectionRepository) HasConnection(ctx context.Context, userID uint) (bool, error) {
+	result, err := r.cache.Eval(ctx, hasConnectionScript, []string{connectionInstancesKey},
+		time.Now().UnixMilli(), connectionsKeyPrefix, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.(int64)
+	return n == 1, nil
+}
+
+// SyncConnections 心跳：用本实例当前已连接的用户覆盖记录并续期，修正乱序的增减通知造成的偏差，
+// 同时清理已过期实例的记录
+func (r *ConnectionRepository) SyncConnections(ctx context.Context, userIDs []uint) error {
+	now := time.Now()
+	args := make([]interface{}, 0, len(userIDs)+5)
+	args = append(args, r.ttl.Milliseconds(), now.Add(r.ttl).UnixMilli(), r.instanceID, now.UnixMilli(), connectionsKeyPrefix)
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	_, err := r.cache.Eval(ctx, syncConnectionsScript, []string{r.instanceKey(), connectionInstancesKey}, args...)
+	return err
+}
+
+// ResetConnections 删除本实例的记录并清理已过期实例的记录，在启动和退出时调用
+func (r *ConnectionRepository) ResetConnections(ctx context.Context) error {
+	_, err := r.cache.Eval(ctx, resetConnectionsScript, []string{r.instanceKey(), connectionInstancesKey},
+		r.instanceID, time.Now().UnixMilli(), connectionsKeyPrefix)
+	return err
+}
+
+// AreOnline 批量检查用户是否在线，一次 SMISMEMBER 完成
+func (r *OnlineUserRepository) AreOnline(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	presence := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return presence, nil
+	}
+
+	members := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = userID
+	}
+	results, err := r.cache.SMIsMember(ctx, "user:online", members...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, userID := range userIDs {
+		presence[userID] = i < len(results) && results[i]
+	}
+	return presence, nil
 }
 
 // GetOnlineUsers 获取所有在线用户
@@ -148,25 +669,167 @@ func (r *OnlineUserRepository) GetOnlineUsers(ctx context.Context) ([]string, er
 	return r.cache.SMembers(ctx, "user:online")
 }
 
+// onlineScanBatch 每次 SSCAN 的建议返回数量
+const onlineScanBatch = 500
+
+// ListOnlineUsers 使用 SSCAN 分页获取在线用户 ID，避免一次性 SMEMBERS 大集合
+// 集合在迭代期间变化时，分页结果可能有重复或遗漏
+func (r *OnlineUserRepository) ListOnlineUsers(ctx context.Context, offset, limit int) ([]uint, error) {
+	userIDs := make([]uint, 0, limit)
+	skipped := 0
+	var cursor uint64
+	for {
+		members, next, err := r.cache.SScan(ctx, "user:online", cursor, "", onlineScanBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			id, err := strconv.ParseUint(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			userIDs = append(userIDs, uint(id))
+			if len(userIDs) >= limit {
+				return userIDs, nil
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return userIDs, nil
+		}
+	}
+}
+
+// CountOnline 获取在线用户数量
+func (r *OnlineUserRepository) CountOnline(ctx context.Context) (int64, error) {
+	return r.cache.SCard(ctx, "user:online")
+}
+
 // LockRepository 分布式锁
 type LockRepository struct {
 	*Repository
 }
 
+// LockToken 一次成功获取锁的凭证，释放时用于校验持有者，获取时间用于统计持有时长
+// 由调用方保存并传给 ReleaseLock，同一进程内对同一资源的多次获取互不干扰
+type LockToken struct {
+	value      string
+	acquiredAt time.Time
+}
+
+// releaseLockScript 仅当锁的值仍为给定令牌时删除，避免删除过期后被他人重新获取的锁
+const releaseLockScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// newLockToken 生成锁的持有者令牌，格式为 主机名:进程号:随机串，便于排查锁的持有者
+func newLockToken() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(buf)), nil
+}
+
 // NewLockRepository 创建锁仓库
 func NewLockRepository(repo *Repository) *LockRepository {
-	return &LockRepository{Repository: repo}
+	return &LockRepository{
+		Repository: repo,
+	}
 }
 
-// AcquireLock 获取锁
-func (r *LockRepository) AcquireLock(ctx context.Context, resource string, expiration time.Duration) (bool, error) {
+// AcquireLock 获取锁，锁的值为本次获取生成的持有者令牌，获取成功时返回的凭证用于 ReleaseLock
+func (r *LockRepository) AcquireLock(ctx context.Context, resource string, expiration time.Duration) (LockToken, bool, error) {
 	key := fmt.Sprintf("lock:%s", resource)
-	return r.cache.SetNX(ctx, key, "1", expiration)
+	label := lockResourceLabel(resource)
+
+	token, err := newLockToken()
+	if err != nil {
+		return LockToken{}, false, fmt.Errorf("生成锁令牌失败: %w", err)
+	}
+
+	start := time.Now()
+	acquired, err := r.cache.SetNX(ctx, key, token, expiration)
+	lockAcquireDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err != nil:
+		lockAcquireTotal.WithLabelValues(label, lockResultError).Inc()
+	case acquired:
+		lockAcquireTotal.WithLabelValues(label, lockResultAcquired).Inc()
+		return LockToken{value: token, acquiredAt: time.Now()}, true, nil
+	default:
+		lockAcquireTotal.WithLabelValues(label, lockResultFailed).Inc()
+	}
+
+	return LockToken{}, false, err
 }
 
-// ReleaseLock 释放锁
-func (r *LockRepository) ReleaseLock(ctx context.Context, resource string) error {
+// 锁等待重试的退避参数
+const (
+	lockRetryBaseDelay = 20 * time.Millisecond
+	lockRetryMaxDelay  = 200 * time.Millisecond
+)
+
+// AcquireLockWait 获取锁，锁被占用时以带抖动的指数退避重试，直到获取成功、超过 maxWait 或 ctx 取消
+func (r *LockRepository) AcquireLockWait(ctx context.Context, resource string, expiration, maxWait time.Duration) (LockToken, bool, error) {
+	deadline := time.Now().Add(maxWait)
+	delay := lockRetryBaseDelay
+
+	for {
+		token, acquired, err := r.AcquireLock(ctx, resource, expiration)
+		if err != nil || acquired {
+			return token, acquired, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return LockToken{}, false, nil
+		}
+
+		// 在 [delay/2, delay) 区间内随机抖动，避免多个等待者同时重试
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+		if wait > remaining {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return LockToken{}, false, ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > lockRetryMaxDelay {
+			delay = lockRetryMaxDelay
+		}
+	}
+}
+
+// ReleaseLock 释放 token 对应的锁，锁已过期或已被他人持有时不做处理
+func (r *LockRepository) ReleaseLock(ctx context.Context, resource string, token LockToken) error {
+	if token.value == "" {
+		return nil
+	}
+	lockHoldDuration.WithLabelValues(lockResourceLabel(resource)).Observe(time.Since(token.acquiredAt).Seconds())
+
 	key := fmt.Sprintf("lock:%s", resource)
-	return r.cache.Del(ctx, key)
+	_, err := r.cache.Eval(ctx, releaseLockScript, []string{key}, token.value)
+	return err
 }
 
@@ -0,0 +1,438 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/internal/testutil"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestRepository 基于 miniredis 创建 Redis 仓库
+func newTestRepository(t *testing.T) (*Repository, *miniredis.Miniredis) {
+	t.Helper()
+	client, mr := testutil.NewCacheClient(t)
+	return NewRepository(client), mr
+}
+
+func TestAcquireLockMetrics(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	locks := NewLockRepository(repo)
+	ctx := context.Background()
+
+	acquired := promtestutil.ToFloat64(lockAcquireTotal.WithLabelValues("room", lockResultAcquired))
+	failed := promtestutil.ToFloat64(lockAcquireTotal.WithLabelValues("room", lockResultFailed))
+
+	_, ok, err := locks.AcquireLock(ctx, "room:ABC123", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("AcquireLock() = %v, %v, want true", ok, err)
+	}
+	// 锁已被持有，再次获取失败并计入 failed
+	_, ok, err = locks.AcquireLock(ctx, "room:ABC123", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("AcquireLock() on held lock = %v, %v, want false", ok, err)
+	}
+
+	if got := promtestutil.ToFloat64(lockAcquireTotal.WithLabelValues("room", lockResultAcquired)) - acquired; got != 1 {
+		t.Errorf("acquired counter delta = %v, want 1", got)
+	}
+	if got := promtestutil.ToFloat64(lockAcquireTotal.WithLabelValues("room", lockResultFailed)) - failed; got != 1 {
+		t.Errorf("failed counter delta = %v, want 1", got)
+	}
+}
+
+func TestLockResourceLabel(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{"room:ABC123", "room"},
+		{"game:lock:42", "game"},
+		{"noprefix", "other"},
+		{":leading", "other"},
+	}
+	for _, tt := range tests {
+		if got := lockResourceLabel(tt.resource); got != tt.want {
+			t.Errorf("lockResourceLabel(%q) = %q, want %q", tt.resource, got, tt.want)
+		}
+	}
+}
+
+func TestAcquireLockWait(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	locks := NewLockRepository(repo)
+	ctx := context.Background()
+
+	t.Run("等待期间锁被释放后获取成功", func(t *testing.T) {
+		token, ok, err := locks.AcquireLock(ctx, "room:wait", time.Minute)
+		if err != nil || !ok {
+			t.Fatalf("AcquireLock() = %v, %v", ok, err)
+		}
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			locks.ReleaseLock(ctx, "room:wait", token)
+		}()
+
+		_, ok, err = locks.AcquireLockWait(ctx, "room:wait", time.Minute, time.Second)
+		if err != nil || !ok {
+			t.Fatalf("AcquireLockWait() = %v, %v, want true", ok, err)
+		}
+	})
+
+	t.Run("超过最长等待时间", func(t *testing.T) {
+		if _, ok, err := locks.AcquireLock(ctx, "room:busy", time.Minute); err != nil || !ok {
+			t.Fatalf("AcquireLock() = %v, %v", ok, err)
+		}
+
+		start := time.Now()
+		_, ok, err := locks.AcquireLockWait(ctx, "room:busy", time.Minute, 100*time.Millisecond)
+		if err != nil || ok {
+			t.Fatalf("AcquireLockWait() = %v, %v, want false", ok, err)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+			t.Errorf("AcquireLockWait() returned after %v, want about maxWait", elapsed)
+		}
+	})
+
+	t.Run("ctx 取消时停止等待", func(t *testing.T) {
+		cctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		_, ok, err := locks.AcquireLockWait(cctx, "room:busy", time.Minute, time.Second)
+		if ok || err == nil {
+			t.Fatalf("AcquireLockWait() = %v, %v, want ctx error", ok, err)
+		}
+	})
+}
+
+func TestReleaseLockChecksToken(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	locks := NewLockRepository(repo)
+	ctx := context.Background()
+	const key = "lock:room:token"
+
+	token, ok, err := locks.AcquireLock(ctx, "room:token", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("AcquireLock() = %v, %v", ok, err)
+	}
+	holder, err := mr.Get(key)
+	if err != nil || holder == "" || holder == "1" {
+		t.Fatalf("lock value = %q, %v, want owner token", holder, err)
+	}
+
+	// 锁过期后被其他实例重新获取，原持有者释放时不删除
+	mr.Set(key, "other-holder")
+	if err := locks.ReleaseLock(ctx, "room:token", token); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if got, _ := mr.Get(key); got != "other-holder" {
+		t.Errorf("lock value = %q, want other-holder", got)
+	}
+
+	// 仍持有时正常释放
+	mr.Del(key)
+	token, ok, err = locks.AcquireLock(ctx, "room:token", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("AcquireLock() = %v, %v", ok, err)
+	}
+	if err := locks.ReleaseLock(ctx, "room:token", token); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if mr.Exists(key) {
+		t.Error("ReleaseLock() 应删除令牌对应的锁")
+	}
+
+	// 未获取到锁时的零值令牌不删除任何锁
+	mr.Set(key, "other-holder")
+	if err := locks.ReleaseLock(ctx, "room:token", LockToken{}); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if got, _ := mr.Get(key); got != "other-holder" {
+		t.Errorf("lock value = %q, want other-holder", got)
+	}
+}
+
+func TestReleaseLockSameProcess(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	locks := NewLockRepository(repo)
+	ctx := context.Background()
+	const key = "lock:room:reuse"
+
+	// 同一进程内第一个持有者的锁过期后，第二个调用方重新获取
+	first, ok, err := locks.AcquireLock(ctx, "room:reuse", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("AcquireLock() = %v, %v", ok, err)
+	}
+	mr.Del(key)
+	second, ok, err := locks.AcquireLock(ctx, "room:reuse", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("AcquireLock() = %v, %v", ok, err)
+	}
+
+	// 第一个持有者迟到的释放不能删除第二个调用方的锁
+	if err := locks.ReleaseLock(ctx, "room:reuse", first); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if !mr.Exists(key) {
+		t.Fatal("过期持有者的释放删除了新持有者的锁")
+	}
+	if err := locks.ReleaseLock(ctx, "room:reuse", second); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if mr.Exists(key) {
+		t.Error("ReleaseLock() 应删除令牌对应的锁")
+	}
+}
+
+func TestGetRoomStatesBatch(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	rooms := NewRoomRepository(repo)
+	ctx := context.Background()
+
+	rooms.SetRoomState(ctx, 1, map[string]interface{}{"status": 0, "current_players": 2}, 0)
+	rooms.SetRoomState(ctx, 3, map[string]interface{}{"status": 1, "current_players": 4}, 0)
+
+	tests := []struct {
+		name    string
+		roomIDs []uint
+		want    map[uint]string // 房间 ID -> current_players
+	}{
+		{"包含不在 Redis 中的房间", []uint{1, 2, 3}, map[uint]string{1: "2", 3: "4"}},
+		{"全部不存在", []uint{4, 5}, map[uint]string{}},
+		{"空列表", nil, map[uint]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := rooms.GetRoomStatesBatch(ctx, tt.roomIDs)
+			if err != nil {
+				t.Fatalf("GetRoomStatesBatch() error = %v", err)
+			}
+			if len(states) != len(tt.want) {
+				t.Fatalf("GetRoomStatesBatch() = %v, want %d rooms", states, len(tt.want))
+			}
+			for roomID, players := range tt.want {
+				if got := states[roomID]["current_players"]; got != players {
+					t.Errorf("room %d current_players = %q, want %q", roomID, got, players)
+				}
+			}
+		})
+	}
+}
+
+func TestNextEventSeq(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	rooms := NewRoomRepository(repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		roomID uint
+		want   int64
+	}{
+		{"房间 1 第一个事件", 1, 1},
+		{"房间 1 第二个事件", 1, 2},
+		{"房间 2 独立计数", 2, 1},
+		{"房间 1 继续递增", 1, 3},
+		{"房间 2 继续递增", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rooms.NextEventSeq(ctx, tt.roomID)
+			if err != nil {
+				t.Fatalf("NextEventSeq() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NextEventSeq(%d) = %d, want %d", tt.roomID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendRoomEvent(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	rooms := NewRoomRepository(repo)
+	ctx := context.Background()
+
+	// 并发发布时较小的序号可能后追加，游标只前进不后退
+	for _, seq := range []int64{1, 3, 2} {
+		if err := rooms.AppendRoomEvent(ctx, 1, seq, []byte(fmt.Sprintf(`{"seq":%d}`, seq)), 10, time.Hour); err != nil {
+			t.Fatalf("AppendRoomEvent() error = %v", err)
+		}
+	}
+
+	events, err := rooms.GetRoomEvents(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[2] != `{"seq":2}` {
+		t.Errorf("GetRoomEvents() = %v", events)
+	}
+	state, err := rooms.GetRoomState(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state["last_event_seq"] != "3" {
+		t.Errorf("last_event_seq = %q, want 3", state["last_event_seq"])
+	}
+}
+
+func TestAppendRoomEventRetention(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	rooms := NewRoomRepository(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		seq, err := rooms.NextEventSeq(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rooms.AppendRoomEvent(ctx, 1, seq, []byte(fmt.Sprintf(`{"seq":%d}`, seq)), 3, time.Hour); err != nil {
+			t.Fatalf("AppendRoomEvent() error = %v", err)
+		}
+	}
+
+	// 超出上限时裁剪最旧的事件
+	events, err := rooms.GetRoomEvents(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0] != `{"seq":3}` || events[2] != `{"seq":5}` {
+		t.Errorf("GetRoomEvents() = %v, want seq 3..5", events)
+	}
+
+	// 事件列表和序号都设置了过期时间
+	for _, key := range []string{"room:events:1", "room:event_seq:1"} {
+		if ttl := mr.TTL(key); ttl != time.Hour {
+			t.Errorf("TTL(%s) = %v, want %v", key, ttl, time.Hour)
+		}
+	}
+	mr.FastForward(time.Hour)
+	if events, _ := rooms.GetRoomEvents(ctx, 1); len(events) != 0 {
+		t.Errorf("过期后仍有事件: %v", events)
+	}
+}
+
+func TestPopAndRequeueWaitlist(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	rooms := NewRoomRepository(repo)
+	ctx := context.Background()
+	const roomID = 1
+
+	if userID, _, err := rooms.PopWaitlist(ctx, roomID); err != nil || userID != 0 {
+		t.Fatalf("空队列 PopWaitlist() = %d, %v, want 0", userID, err)
+	}
+	for _, userID := range []uint{3, 4, 5} {
+		if _, err := rooms.JoinWaitlist(ctx, roomID, userID, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	userID, score, err := rooms.PopWaitlist(ctx, roomID)
+	if err != nil || userID != 3 || score <= 0 {
+		t.Fatalf("PopWaitlist() = %d, %v, %v, want user 3", userID, score, err)
+	}
+
+	// 按原分数放回后仍在队首
+	if err := rooms.RequeueWaitlist(ctx, roomID, userID, score); err != nil {
+		t.Fatalf("RequeueWaitlist() error = %v", err)
+	}
+	for _, want := range []uint{3, 4, 5} {
+		got, _, err := rooms.PopWaitlist(ctx, roomID)
+		if err != nil || got != want {
+			t.Fatalf("PopWaitlist() = %d, %v, want %d", got, err, want)
+		}
+	}
+}
+
+func TestConnectionPresence(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	first := NewConnectionRepository(repo, "a", time.Minute)
+	second := NewConnectionRepository(repo, "b", time.Minute)
+	ctx := context.Background()
+	const userID = 7
+
+	// 两个实例各有一个连接，断开一个后仍视为已连接
+	first.AddConnection(ctx, userID)
+	second.AddConnection(ctx, userID)
+	first.RemoveConnection(ctx, userID)
+	if connected, err := first.HasConnection(ctx, userID); err != nil || !connected {
+		t.Fatalf("HasConnection() = %v, %v, want true", connected, err)
+	}
+
+	// 重复的断开通知不会影响其他实例上的连接
+	first.RemoveConnection(ctx, userID)
+	if connected, err := first.HasConnection(ctx, userID); err != nil || !connected {
+		t.Fatalf("重复断开后 HasConnection() = %v, %v, want true", connected, err)
+	}
+
+	second.RemoveConnection(ctx, userID)
+	if connected, err := first.HasConnection(ctx, userID); err != nil || connected {
+		t.Fatalf("HasConnection() = %v, %v, want false", connected, err)
+	}
+}
+
+func TestConnectionPresenceExpires(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	const ttl = 20 * time.Millisecond
+	crashed := NewConnectionRepository(repo, "crashed", ttl)
+	live := NewConnectionRepository(repo, "live", time.Minute)
+	ctx := context.Background()
+
+	// 实例崩溃后不再心跳，记录过期后不再计入
+	crashed.AddConnection(ctx, 7)
+	time.Sleep(2 * ttl)
+	if connected, err := live.HasConnection(ctx, 7); err != nil || connected {
+		t.Fatalf("HasConnection() = %v, %v, want false", connected, err)
+	}
+
+	// 其他实例的心跳清理过期实例的记录
+	if err := live.SyncConnections(ctx, []uint{8}); err != nil {
+		t.Fatalf("SyncConnections() error = %v", err)
+	}
+	if mr.Exists(connectionsKeyPrefix + "crashed") {
+		t.Error("心跳后应删除过期实例的连接集合")
+	}
+	if instances, _ := mr.ZMembers(connectionInstancesKey); len(instances) != 1 || instances[0] != "live" {
+		t.Errorf("实例登记 = %v, want [live]", instances)
+	}
+}
+
+func TestSyncConnections(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	connections := NewConnectionRepository(repo, "a", time.Minute)
+	ctx := context.Background()
+
+	// 断开通知先于连接通知到达等乱序造成的偏差由心跳修正
+	connections.AddConnection(ctx, 1)
+	connections.AddConnection(ctx, 2)
+	if err := connections.SyncConnections(ctx, []uint{2, 3}); err != nil {
+		t.Fatalf("SyncConnections() error = %v", err)
+	}
+	for userID, want := range map[uint]bool{1: false, 2: true, 3: true} {
+		if connected, err := connections.HasConnection(ctx, userID); err != nil || connected != want {
+			t.Errorf("HasConnection(%d) = %v, %v, want %v", userID, connected, err, want)
+		}
+	}
+}
+
+func TestResetConnections(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	ctx := context.Background()
+
+	// 同一实例 ID 重启时删除上次运行留下的记录
+	previous := NewConnectionRepository(repo, "a", time.Minute)
+	previous.AddConnection(ctx, 7)
+	restarted := NewConnectionRepository(repo, "a", time.Minute)
+	if err := restarted.ResetConnections(ctx); err != nil {
+		t.Fatalf("ResetConnections() error = %v", err)
+	}
+	if connected, err := restarted.HasConnection(ctx, 7); err != nil || connected {
+		t.Fatalf("HasConnection() = %v, %v, want false", connected, err)
+	}
+	if mr.Exists(connectionsKeyPrefix+"a") || mr.Exists(connectionInstancesKey) {
+		t.Error("ResetConnections() 后仍有本实例的记录")
+	}
+}
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/game-apps/internal/service/game"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// 公告受众
+const (
+	AnnouncementAudienceAll   = "all"   // 所有在线客户端
+	AnnouncementAudienceLobby = "lobby" // 订阅了指定游戏类型大厅的客户端
+	AnnouncementAudienceRoom  = "room"  // 指定房间内的玩家
+)
+
+// maxAnnouncementRetention 公告保留补发的最长时间
+const maxAnnouncementRetention = time.Hour
+
+// AnnouncementBroadcaster 公告推送接口
+type AnnouncementBroadcaster interface {
+	BroadcastRetained(message interface{}, ttl time.Duration)
+	BroadcastToLobby(gameType string, message interface{})
+	BroadcastToUsers(userIDs []uint, message interface{})
+}
+
+// AnnouncementService 系统公告服务
+type AnnouncementService struct {
+	roomPlayerRepo game.RoomPlayerRepository
+	broadcaster    AnnouncementBroadcaster
+	logger         *zap.Logger
+}
+
+// NewAnnouncementService 创建系统公告服务
+func NewAnnouncementService(roomPlayerRepo game.RoomPlayerRepository, broadcaster AnnouncementBroadcaster, logger *zap.Logger) *AnnouncementService {
+	return &AnnouncementService{
+		roomPlayerRepo: roomPlayerRepo,
+		broadcaster:    broadcaster,
+		logger:         logger,
+	}
+}
+
+// AnnouncementRequest 发布公告请求
+type AnnouncementRequest struct {
+	Message       string `json:"message" binding:"required"`
+	Audience      string `json:"audience" binding:"required"` // all、lobby 或 room
+	GameType      string `json:"game_type"`                   // audience 为 lobby 时必填
+	RoomID        uint   `json:"room_id"`                     // audience 为 room 时必填
+	RetainSeconds int    `json:"retain_seconds"`              // 保留时长，期间新连接的客户端也会收到，仅 audience 为 all 时有效
+}
+
+// Announcement 推送给客户端的公告
+type Announcement struct {
+	Message   string `json:"message"`
+	Audience  string `json:"audience"`
+	GameType  string `json:"game_type,omitempty"`
+	RoomID    uint   `json:"room_id,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Announce 发布系统公告
+func (s *AnnouncementService) Announce(ctx context.Context, adminID uint, req *AnnouncementRequest) (*Announcement, error) {
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "公告内容不能为空")
+	}
+
+	retention := time.Duration(req.RetainSeconds) * time.Second
+	if retention < 0 || retention > maxAnnouncementRetention {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "公告保留时长超出范围")
+	}
+
+	announcement := &Announcement{
+		Message:   message,
+		Audience:  req.Audience,
+		CreatedAt: time.Now().Unix(),
+	}
+	event := map[string]interface{}{
+		"type": "announcement",
+		"data": announcement,
+	}
+
+	switch req.Audience {
+	case AnnouncementAudienceAll:
+		s.broadcaster.BroadcastRetained(event, retention)
+	case AnnouncementAudienceLobby:
+		if strings.TrimSpace(req.GameType) == "" {
+			return nil, utils.NewError(utils.ErrCodeInvalidInput, "大厅公告需指定游戏类型")
+		}
+		announcement.GameType = req.GameType
+		s.broadcaster.BroadcastToLobby(req.GameType, event)
+	case AnnouncementAudienceRoom:
+		if req.RoomID == 0 {
+			return nil, utils.NewError(utils.ErrCodeInvalidInput, "房间公告需指定房间")
+		}
+		players, err := s.roomPlayerRepo.GetByRoomID(ctx, req.RoomID)
+		if err != nil {
+			s.logger.Error("查询房间玩家失败", zap.Error(err), zap.Uint("room_id", req.RoomID))
+			return nil, utils.NewError(utils.ErrCodeInternal, "发布公告失败")
+		}
+		userIDs := make([]uint, 0, len(players))
+		for _, player := range players {
+			userIDs = append(userIDs, player.UserID)
+		}
+		announcement.RoomID = req.RoomID
+		s.broadcaster.BroadcastToUsers(userIDs, event)
+	default:
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "不支持的公告受众")
+	}
+
+	// 审计日志
+	s.logger.Info("管理员发布系统公告",
+		zap.Uint("admin_id", adminID),
+		zap.String("audience", req.Audience),
+		zap.String("game_type", announcement.GameType),
+		zap.Uint("room_id", announcement.RoomID),
+		zap.Duration("retention", retention),
+		zap.String("message", message),
+	)
+
+	return announcement, nil
+}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// announcementRecorder 记录公告推送
+type announcementRecorder struct {
+	all       int
+	retention time.Duration
+	lobbies   []string
+	users     []uint
+}
+
+func (r *announcementRecorder) BroadcastRetained(message interface{}, ttl time.Duration) {
+	r.all++
+	r.retention = ttl
+}
+
+func (r *announcementRecorder) BroadcastToLobby(gameType string, message interface{}) {
+	r.lobbies = append(r.lobbies, gameType)
+}
+
+func (r *announcementRecorder) BroadcastToUsers(userIDs []uint, message interface{}) {
+	r.users = append(r.users, userIDs...)
+}
+
+// stubRoomPlayerRepo 返回固定玩家列表的房间玩家仓库
+type stubRoomPlayerRepo struct {
+	players []*model.RoomPlayer
+}
+
+func (r *stubRoomPlayerRepo) Create(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	return nil
+}
+
+func (r *stubRoomPlayerRepo) GetByRoomID(ctx context.Context, roomID uint) ([]*model.RoomPlayer, error) {
+	return r.players, nil
+}
+
+func (r *stubRoomPlayerRepo) GetByRoomIDAndUserID(ctx context.Context, roomID, userID uint) (*model.RoomPlayer, error) {
+	return nil, nil
+}
+
+func (r *stubRoomPlayerRepo) GetActiveByUserID(ctx context.Context, userID uint) (*model.RoomPlayer, error) {
+	return nil, nil
+}
+
+func (r *stubRoomPlayerRepo) Update(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	return nil
+}
+
+func (r *stubRoomPlayerRepo) ResetReady(ctx context.Context, roomID uint) error {
+	return nil
+}
+
+func (r *stubRoomPlayerRepo) ReassignPositions(ctx context.Context, roomID uint) (bool, error) {
+	return false, nil
+}
+
+func (r *stubRoomPlayerRepo) SaveWithFreePosition(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	return nil
+}
+
+func (r *stubRoomPlayerRepo) LeaveRoom(ctx context.Context, roomID, userID uint) error {
+	return nil
+}
+
+func TestAnnounce(t *testing.T) {
+	players := &stubRoomPlayerRepo{players: []*model.RoomPlayer{{UserID: 1}, {UserID: 2}}}
+
+	tests := []struct {
+		name     string
+		req      AnnouncementRequest
+		wantCode int
+		check    func(t *testing.T, r *announcementRecorder)
+	}{
+		{
+			name: "全部在线客户端",
+			req:  AnnouncementRequest{Message: "维护通知", Audience: AnnouncementAudienceAll, RetainSeconds: 60},
+			check: func(t *testing.T, r *announcementRecorder) {
+				if r.all != 1 || r.retention != time.Minute {
+					t.Errorf("all = %d, retention = %v", r.all, r.retention)
+				}
+			},
+		},
+		{
+			name: "游戏大厅",
+			req:  AnnouncementRequest{Message: "活动开始", Audience: AnnouncementAudienceLobby, GameType: "poker"},
+			check: func(t *testing.T, r *announcementRecorder) {
+				if len(r.lobbies) != 1 || r.lobbies[0] != "poker" {
+					t.Errorf("lobbies = %v", r.lobbies)
+				}
+			},
+		},
+		{
+			name: "房间玩家",
+			req:  AnnouncementRequest{Message: "房间即将关闭", Audience: AnnouncementAudienceRoom, RoomID: 7},
+			check: func(t *testing.T, r *announcementRecorder) {
+				if len(r.users) != 2 {
+					t.Errorf("users = %v", r.users)
+				}
+			},
+		},
+		{name: "内容为空", req: AnnouncementRequest{Message: "  ", Audience: AnnouncementAudienceAll}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "大厅未指定游戏类型", req: AnnouncementRequest{Message: "x", Audience: AnnouncementAudienceLobby}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "房间未指定房间", req: AnnouncementRequest{Message: "x", Audience: AnnouncementAudienceRoom}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "保留时长超出范围", req: AnnouncementRequest{Message: "x", Audience: AnnouncementAudienceAll, RetainSeconds: 7200}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "不支持的受众", req: AnnouncementRequest{Message: "x", Audience: "everyone"}, wantCode: utils.ErrCodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &announcementRecorder{}
+			s := NewAnnouncementService(players, recorder, zap.NewNop())
+
+			_, err := s.Announce(context.Background(), 99, &tt.req)
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("Announce() error = %v, want code %d", err, tt.wantCode)
+				}
+				if recorder.all != 0 || len(recorder.lobbies) != 0 || len(recorder.users) != 0 {
+					t.Errorf("校验失败时不应推送公告")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Announce() error = %v", err)
+			}
+			tt.check(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// allowedKeyPrefixes 允许查看和清理的键前缀，模式中通配符之前的部分必须以其中之一开头
+var allowedKeyPrefixes = []string{"room:", "lock:", "cache:"}
+
+// 键扫描参数
+const (
+	defaultKeyScanCount = 100
+	maxKeyScanCount     = 1000
+	maxKeysPerDelete    = 10000 // 单次清理的最大键数，超出时需再次调用
+	keyDeleteBatchSize  = 100
+)
+
+// CacheService 缓存键运维服务，用于排障时查看和清理残留的房间、锁等键
+type CacheService struct {
+	client *cache.Client
+	logger *zap.Logger
+}
+
+// NewCacheService 创建缓存键运维服务
+func NewCacheService(client *cache.Client, logger *zap.Logger) *CacheService {
+	return &CacheService{
+		client: client,
+		logger: logger,
+	}
+}
+
+// CacheKeyPage 一页扫描结果，NextCursor 为 0 表示扫描结束
+type CacheKeyPage struct {
+	Keys       []string `json:"keys"`
+	NextCursor uint64   `json:"next_cursor"`
+}
+
+// CacheKeyDeleteResult 清理结果，HasMore 为 true 表示达到单次上限，还有匹配的键未清理
+type CacheKeyDeleteResult struct {
+	Deleted int  `json:"deleted"`
+	HasMore bool `json:"has_more"`
+}
+
+// checkKeyPattern 校验键模式：通配符之前的固定前缀必须在白名单内，拒绝 * 等匹配全部键的模式
+func checkKeyPattern(pattern string) error {
+	literal := pattern
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		literal = pattern[:i]
+	}
+	for _, prefix := range allowedKeyPrefixes {
+		if strings.HasPrefix(literal, prefix) {
+			return nil
+		}
+	}
+	return utils.NewError(utils.ErrCodeInvalidInput, "不允许的键模式，仅支持前缀: "+strings.Join(allowedKeyPrefixes, ", "))
+}
+
+// ListKeys 使用 SCAN 分页查看匹配模式的键，单页返回的键数可能少于 count
+func (s *CacheService) ListKeys(ctx context.Context, pattern string, cursor uint64, count int) (*CacheKeyPage, error) {
+	if err := checkKeyPattern(pattern); err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		count = defaultKeyScanCount
+	}
+	if count > maxKeyScanCount {
+		count = maxKeyScanCount
+	}
+
+	keys, next, err := s.client.Scan(ctx, cursor, pattern, int64(count))
+	if err != nil {
+		s.logger.Error("扫描缓存键失败", zap.Error(err), zap.String("pattern", pattern))
+		return nil, utils.NewInternalError("扫描缓存键失败", err)
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	return &CacheKeyPage{Keys: keys, NextCursor: next}, nil
+}
+
+// DeleteKeys 清理匹配模式的键，单次最多清理 maxKeysPerDelete 个
+func (s *CacheService) DeleteKeys(ctx context.Context, adminID uint, pattern string) (*CacheKeyDeleteResult, error) {
+	if err := checkKeyPattern(pattern); err != nil {
+		return nil, err
+	}
+
+	result := &CacheKeyDeleteResult{}
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, pattern, keyDeleteBatchSize)
+		if err != nil {
+			s.logger.Error("扫描缓存键失败", zap.Error(err), zap.String("pattern", pattern))
+			return nil, utils.NewInternalError("清理缓存键失败", err)
+		}
+		if remaining := maxKeysPerDelete - result.Deleted; len(keys) > remaining {
+			keys = keys[:remaining]
+			result.HasMore = true
+		}
+		if len(keys) > 0 {
+			if err := s.client.Del(ctx, keys...); err != nil {
+				s.logger.Error("删除缓存键失败", zap.Error(err), zap.String("pattern", pattern))
+				return nil, utils.NewInternalError("清理缓存键失败", err)
+			}
+			result.Deleted += len(keys)
+		}
+
+		cursor = next
+		if cursor == 0 || result.HasMore {
+			break
+		}
+		if result.Deleted >= maxKeysPerDelete {
+			result.HasMore = true
+			break
+		}
+	}
+
+	// 审计日志
+	s.logger.Info("管理员清理缓存键",
+		zap.Uint("admin_id", adminID),
+		zap.String("pattern", pattern),
+		zap.Int("deleted", result.Deleted),
+		zap.Bool("has_more", result.HasMore),
+	)
+
+	return result, nil
+}
+
+// lockKeyPrefix 分布式锁键的前缀，与 redis.LockRepository 一致
+const lockKeyPrefix = "lock:"
+
+// deleteLockIfHolderScript 仅当锁的值仍为查询到的持有者令牌时删除，返回删除的数量
+const deleteLockIfHolderScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// LockInfo 当前被持有的锁
+type LockInfo struct {
+	Resource  string `json:"resource"`
+	TTLMillis int64  `json:"ttl_ms"` // 剩余过期时间，-1 表示没有过期时间
+	Holder    string `json:"holder"` // 持有者令牌，格式为 主机名:进程号:随机串
+}
+
+// LockPage 一页锁扫描结果，NextCursor 为 0 表示扫描结束
+type LockPage struct {
+	Locks      []*LockInfo `json:"locks"`
+	NextCursor uint64      `json:"next_cursor"`
+}
+
+// ListLocks 使用 SCAN 分页列出当前被持有的锁，用于排查“房间正在被操作”等卡死问题
+func (s *CacheService) ListLocks(ctx context.Context, cursor uint64, count int) (*LockPage, error) {
+	if count <= 0 {
+		count = defaultKeyScanCount
+	}
+	if count > maxKeyScanCount {
+		count = maxKeyScanCount
+	}
+
+	keys, next, err := s.client.Scan(ctx, cursor, lockKeyPrefix+"*", int64(count))
+	if err != nil {
+		s.logger.Error("扫描锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取锁列表失败", err)
+	}
+
+	locks := make([]*LockInfo, 0, len(keys))
+	for _, key := range keys {
+		ttl, err := s.client.TTL(ctx, key)
+		if err != nil {
+			s.logger.Error("查询锁过期时间失败", zap.Error(err), zap.String("key", key))
+			return nil, utils.NewInternalError("获取锁列表失败", err)
+		}
+		// 扫描后已释放或过期的锁跳过
+		if ttl == -2 {
+			continue
+		}
+		holder, err := s.client.Get(ctx, key)
+		if errors.Is(err, goredis.Nil) {
+			continue
+		}
+		if err != nil {
+			s.logger.Error("查询锁持有者失败", zap.Error(err), zap.String("key", key))
+			return nil, utils.NewInternalError("获取锁列表失败", err)
+		}
+
+		ttlMillis := int64(-1)
+		if ttl >= 0 {
+			ttlMillis = ttl.Milliseconds()
+		}
+		locks = append(locks, &LockInfo{
+			Resource:  strings.TrimPrefix(key, lockKeyPrefix),
+			TTLMillis: ttlMillis,
+			Holder:    holder,
+		})
+	}
+	return &LockPage{Locks: locks, NextCursor: next}, nil
+}
+
+// ReleaseLock 强制释放卡住的锁，持有者之后的释放操作按令牌校验，不会误删他人的锁
+// 查询到锁之后若锁已过期并被重新获取，不会删除新持有者的锁
+func (s *CacheService) ReleaseLock(ctx context.Context, adminID uint, resource string) error {
+	if resource == "" {
+		return utils.NewError(utils.ErrCodeInvalidInput, "锁资源不能为空")
+	}
+	key := lockKeyPrefix + resource
+
+	holder, err := s.client.Get(ctx, key)
+	if errors.Is(err, goredis.Nil) {
+		return utils.NewError(utils.ErrCodeNotFound, "锁不存在或已释放")
+	}
+	if err != nil {
+		s.logger.Error("查询锁失败", zap.Error(err), zap.String("resource", resource))
+		return utils.NewInternalError("释放锁失败", err)
+	}
+	result, err := s.client.Eval(ctx, deleteLockIfHolderScript, []string{key}, holder)
+	if err != nil {
+		s.logger.Error("释放锁失败", zap.Error(err), zap.String("resource", resource))
+		return utils.NewInternalError("释放锁失败", err)
+	}
+	if deleted, _ := result.(int64); deleted == 0 {
+		return utils.NewError(utils.ErrCodeConflict, "锁已过期或持有者已变更，请刷新后重试")
+	}
+
+	// 审计日志
+	s.logger.Warn("管理员强制释放锁",
+		zap.Uint("admin_id", adminID),
+		zap.String("resource", resource),
+		zap.String("holder", holder),
+	)
+	return nil
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestCheckKeyPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{"房间键", "room:*", false},
+		{"指定房间的全部键", "room:events:42*", false},
+		{"锁", "lock:room:*", false},
+		{"缓存键精确匹配", "cache:room_code:ABC123", false},
+		{"匹配全部键", "*", true},
+		{"空模式", "", true},
+		{"会话键", "session:*", true},
+		{"前缀中使用通配符绕过", "r*m:*", true},
+		{"单字符通配绕过", "?oom:*", true},
+		{"字符集绕过", "[rs]oom:*", true},
+		{"转义字符绕过", `\room:*`, true},
+		{"前缀不完整", "room*", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKeyPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkKeyPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			var appErr *utils.AppError
+			if err != nil && (!errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInvalidInput) {
+				t.Errorf("checkKeyPattern(%q) error = %v, want ErrCodeInvalidInput", tt.pattern, err)
+			}
+		})
+	}
+}
+
+func TestCacheServiceRejectsBeforeRedis(t *testing.T) {
+	// 不允许的请求在访问 Redis 之前被拒绝，client 为空也不会被调用
+	s := NewCacheService(nil, zap.NewNop())
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"查看全部键", func() error { _, err := s.ListKeys(ctx, "*", 0, 10); return err }},
+		{"清理会话键", func() error { _, err := s.DeleteKeys(ctx, 1, "session:*"); return err }},
+		{"释放空资源的锁", func() error { return s.ReleaseLock(ctx, 1, "") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInvalidInput {
+				t.Errorf("error = %v, want ErrCodeInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestCacheServiceListAndDeleteKeys(t *testing.T) {
+	client, mr := testutil.NewCacheClient(t)
+	s := NewCacheService(client, zap.NewNop())
+	ctx := context.Background()
+
+	for _, key := range []string{"room:1", "room:2", "room:events:1", "lock:room:1", "session:1"} {
+		mr.Set(key, "v")
+	}
+
+	var keys []string
+	var cursor uint64
+	for {
+		page, err := s.ListKeys(ctx, "room:*", cursor, 1)
+		if err != nil {
+			t.Fatalf("ListKeys() error = %v", err)
+		}
+		keys = append(keys, page.Keys...)
+		if cursor = page.NextCursor; cursor == 0 {
+			break
+		}
+	}
+	sort.Strings(keys)
+	want := []string{"room:1", "room:2", "room:events:1"}
+	if len(keys) != len(want) {
+		t.Fatalf("ListKeys() = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("ListKeys() = %v, want %v", keys, want)
+		}
+	}
+
+	result, err := s.DeleteKeys(ctx, 1, "room:*")
+	if err != nil {
+		t.Fatalf("DeleteKeys() error = %v", err)
+	}
+	if result.Deleted != 3 || result.HasMore {
+		t.Errorf("DeleteKeys() = %+v, want 3 deleted", result)
+	}
+	for _, key := range want {
+		if mr.Exists(key) {
+			t.Errorf("%s 应被清理", key)
+		}
+	}
+	for _, key := range []string{"lock:room:1", "session:1"} {
+		if !mr.Exists(key) {
+			t.Errorf("%s 不应被清理", key)
+		}
+	}
+}
+
+func TestCacheServiceLocks(t *testing.T) {
+	client, mr := testutil.NewCacheClient(t)
+	s := NewCacheService(client, zap.NewNop())
+	ctx := context.Background()
+
+	mr.Set("lock:room:1", "holder-token")
+	mr.SetTTL("lock:room:1", 10*time.Second)
+	mr.Set("room:1", "v")
+
+	page, err := s.ListLocks(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListLocks() error = %v", err)
+	}
+	if len(page.Locks) != 1 {
+		t.Fatalf("ListLocks() = %d locks, want 1", len(page.Locks))
+	}
+	lock := page.Locks[0]
+	if lock.Resource != "room:1" || lock.Holder != "holder-token" || lock.TTLMillis <= 0 || lock.TTLMillis > 10000 {
+		t.Errorf("lock = %+v", lock)
+	}
+
+	if err := s.ReleaseLock(ctx, 1, "room:1"); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if mr.Exists("lock:room:1") || !mr.Exists("room:1") {
+		t.Error("只应删除锁键")
+	}
+
+	err = s.ReleaseLock(ctx, 1, "room:1")
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeNotFound {
+		t.Errorf("ReleaseLock() on released lock error = %v, want ErrCodeNotFound", err)
+	}
+}
+
+func TestDeleteLockIfHolderScript(t *testing.T) {
+	client, mr := testutil.NewCacheClient(t)
+	ctx := context.Background()
+	mr.Set("lock:room:1", "new-holder")
+
+	// 查询后锁被其他持有者重新获取时不删除
+	result, err := client.Eval(ctx, deleteLockIfHolderScript, []string{"lock:room:1"}, "old-holder")
+	if err != nil || result != int64(0) || !mr.Exists("lock:room:1") {
+		t.Fatalf("Eval() = %v, %v, want lock kept", result, err)
+	}
+	result, err = client.Eval(ctx, deleteLockIfHolderScript, []string{"lock:room:1"}, "new-holder")
+	if err != nil || result != int64(1) || mr.Exists("lock:room:1") {
+		t.Fatalf("Eval() = %v, %v, want lock deleted", result, err)
+	}
+}
@@ -2,59 +2,107 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 	"github.com/iarna/toml"
+	"github.com/game-apps/internal/config"
 	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
 )
 
+// ReloadHook 配置重新加载后的回调，用于让支持热更新的组件应用新配置
+type ReloadHook func(old, updated *config.Config)
+
 // ConfigService 配置管理服务
 type ConfigService struct {
-	configBasePath string
+	store  ConfigStore
+	logger *zap.Logger
+
+	hooksMu     sync.Mutex
+	reloadHooks []ReloadHook
 }
 
 // NewConfigService 创建配置管理服务
-func NewConfigService(configBasePath string) *ConfigService {
+func NewConfigService(store ConfigStore, logger *zap.Logger) *ConfigService {
 	return &ConfigService{
-		configBasePath: configBasePath,
+		store:  store,
+		logger: logger,
 	}
 }
 
-// GetConfig 获取服务配置
-func (s *ConfigService) GetConfig(ctx context.Context, service string) (string, string, error) {
-	var configPath string
-	var fileType string
+// OnReload 注册 backend 配置重新加载后的回调
+func (s *ConfigService) OnReload(hook ReloadHook) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.reloadHooks = append(s.reloadHooks, hook)
+}
+
+// ReloadBackend 从配置存储读取本服务的配置并重新加载，然后依次调用回调，新配置验证失败时保留原配置
+func (s *ConfigService) ReloadBackend(ctx context.Context, adminID uint) error {
+	name, _, err := s.configFile("backend")
+	if err != nil {
+		return err
+	}
+	content, err := s.store.Read(ctx, name)
+	if errors.Is(err, ErrConfigNotFound) {
+		return utils.NewError(utils.ErrCodeNotFound, "配置文件不存在")
+	}
+	if err != nil {
+		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取配置文件失败: %v", err))
+	}
+
+	old := config.Get()
+	updated, err := config.ReloadFrom(content)
+	if err != nil {
+		return utils.NewError(utils.ErrCodeInvalidInput, fmt.Sprintf("重新加载配置失败: %v", err))
+	}
+
+	s.hooksMu.Lock()
+	hooks := append([]ReloadHook(nil), s.reloadHooks...)
+	s.hooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(old, updated)
+	}
+
+	// 审计日志
+	s.logger.Info("管理员重新加载配置", zap.Uint("admin_id", adminID))
+	return nil
+}
 
+// configFile 获取服务配置在存储中的名称和格式
+func (s *ConfigService) configFile(service string) (string, string, error) {
 	switch service {
 	case "backend":
-		configPath = filepath.Join(s.configBasePath, "game-services", "configs", "config.yaml")
-		fileType = "yaml"
+		return "game-services/configs/config.yaml", "yaml", nil
 	case "gateway":
-		configPath = filepath.Join(s.configBasePath, "game-gateway", "config", "default.toml")
-		fileType = "toml"
+		return "game-gateway/config/default.toml", "toml", nil
 	case "agent":
-		configPath = filepath.Join(s.configBasePath, "game-agent", "config", "config.yaml")
-		fileType = "yaml"
+		return "game-agent/config/config.yaml", "yaml", nil
 	default:
 		return "", "", utils.NewError(utils.ErrCodeInvalidInput, "不支持的服务类型")
 	}
+}
+
+// GetConfig 获取服务配置
+func (s *ConfigService) GetConfig(ctx context.Context, service string) (string, string, error) {
+	name, fileType, err := s.configFile(service)
+	if err != nil {
+		return "", "", err
+	}
 
-	// 如果配置文件不存在，尝试读取示例文件
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// 尝试读取示例文件
-		examplePath := configPath + ".example"
-		if _, err := os.Stat(examplePath); err == nil {
-			configPath = examplePath
-		} else {
+	content, err := s.store.Read(ctx, name)
+	if errors.Is(err, ErrConfigNotFound) {
+		// 配置不存在时尝试读取示例配置
+		content, err = s.store.Read(ctx, name+".example")
+		if errors.Is(err, ErrConfigNotFound) {
 			return "", "", utils.NewError(utils.ErrCodeNotFound, "配置文件不存在")
 		}
 	}
-
-	content, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return "", "", utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取配置文件失败: %v", err))
 	}
@@ -62,19 +110,11 @@ func (s *ConfigService) GetConfig(ctx context.Context, service string) (string,
 	return string(content), fileType, nil
 }
 
-// UpdateConfig 更新服务配置
+// UpdateConfig 更新服务配置，写入前将当前配置保存为备份版本
 func (s *ConfigService) UpdateConfig(ctx context.Context, service string, content string) error {
-	var configPath string
-
-	switch service {
-	case "backend":
-		configPath = filepath.Join(s.configBasePath, "game-services", "configs", "config.yaml")
-	case "gateway":
-		configPath = filepath.Join(s.configBasePath, "game-gateway", "config", "default.toml")
-	case "agent":
-		configPath = filepath.Join(s.configBasePath, "game-agent", "config", "config.yaml")
-	default:
-		return utils.NewError(utils.ErrCodeInvalidInput, "不支持的服务类型")
+	name, _, err := s.configFile(service)
+	if err != nil {
+		return err
 	}
 
 	// 验证配置格式
@@ -82,29 +122,86 @@ func (s *ConfigService) UpdateConfig(ctx context.Context, service string, conten
 		return err
 	}
 
-	// 创建备份
-	backupPath := configPath + ".backup"
-	if _, err := os.Stat(configPath); err == nil {
-		originalContent, err := ioutil.ReadFile(configPath)
-		if err == nil {
-			ioutil.WriteFile(backupPath, originalContent, 0644)
-		}
-	}
-
-	// 确保目录存在
-	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("创建配置目录失败: %v", err))
+	// 创建备份，备份失败时不覆盖当前配置
+	if err := s.store.Backup(ctx, name); err != nil {
+		s.logger.Error("备份当前配置失败", zap.Error(err), zap.String("service", service))
+		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("备份当前配置失败: %v", err))
 	}
 
 	// 写入新配置
-	if err := ioutil.WriteFile(configPath, []byte(content), 0644); err != nil {
+	if err := s.store.Write(ctx, name, []byte(content)); err != nil {
 		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("写入配置文件失败: %v", err))
 	}
 
 	return nil
 }
 
+// ListBackups 列出服务配置的备份版本，最新的在前
+func (s *ConfigService) ListBackups(ctx context.Context, service string) ([]ConfigVersion, error) {
+	name, _, err := s.configFile(service)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.store.ListVersions(ctx, name)
+	if err != nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取配置备份失败: %v", err))
+	}
+	return versions, nil
+}
+
+// RestoreBackup 将备份版本恢复为当前配置，version 为空时恢复最新的备份
+// 当前配置会先保存为新的备份版本，返回恢复后的内容
+func (s *ConfigService) RestoreBackup(ctx context.Context, adminID uint, service, version string) (string, error) {
+	name, _, err := s.configFile(service)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		versions, err := s.store.ListVersions(ctx, name)
+		if err != nil {
+			return "", utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取配置备份失败: %v", err))
+		}
+		if len(versions) == 0 {
+			return "", utils.NewError(utils.ErrCodeNotFound, "配置备份不存在")
+		}
+		version = versions[0].ID
+	}
+
+	backupContent, err := s.store.ReadVersion(ctx, name, version)
+	if err != nil {
+		if errors.Is(err, ErrConfigNotFound) {
+			return "", utils.NewError(utils.ErrCodeNotFound, "配置备份不存在")
+		}
+		return "", utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取配置备份失败: %v", err))
+	}
+
+	// 备份内容可能已损坏，恢复前同样需要校验
+	if err := s.ValidateConfig(service, string(backupContent)); err != nil {
+		return "", err
+	}
+
+	// 当前配置未能备份时不覆盖，避免恢复后无法回退
+	if err := s.store.Backup(ctx, name); err != nil {
+		s.logger.Error("备份当前配置失败", zap.Error(err), zap.String("service", service))
+		return "", utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("备份当前配置失败: %v", err))
+	}
+	if err := s.store.Write(ctx, name, backupContent); err != nil {
+		return "", utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("写入配置文件失败: %v", err))
+	}
+
+	// 审计日志
+	s.logger.Info("管理员恢复配置备份",
+		zap.Uint("admin_id", adminID),
+		zap.String("service", service),
+		zap.String("version", version),
+		zap.Int("size", len(backupContent)),
+	)
+
+	return string(backupContent), nil
+}
+
 // ValidateConfig 验证配置格式
 func (s *ConfigService) ValidateConfig(service string, content string) error {
 	switch service {
@@ -124,3 +221,100 @@ func (s *ConfigService) ValidateConfig(service string, content string) error {
 	return nil
 }
 
+
+// ValidateConfigDeep 按配置结构校验配置内容，返回发现的所有问题（不写入文件）
+// 仅 backend 有已知的配置结构，其他服务只做格式校验
+func (s *ConfigService) ValidateConfigDeep(service string, content string) ([]string, error) {
+	switch service {
+	case "backend", "gateway", "agent":
+	default:
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "不支持的服务类型")
+	}
+
+	if err := s.ValidateConfig(service, content); err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	if service != "backend" {
+		return nil, nil
+	}
+
+	cfg, err := config.Parse([]byte(content))
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	return cfg.Problems(), nil
+}
+
+// ConfigError 配置校验发现的问题，Line/Column 从 1 开始，为 0 表示无法定位
+type ConfigError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// configErrorPosition 匹配 YAML/TOML 解析错误中的位置，如 "yaml: line 3: ..." 或 "line 3, column 5"
+var configErrorPosition = regexp.MustCompile(`line (\d+)(?:,? column (\d+))?`)
+
+// newConfigError 从错误信息中提取位置，没有位置信息时只保留原始信息
+func newConfigError(message string) ConfigError {
+	configErr := ConfigError{Message: message}
+	if m := configErrorPosition.FindStringSubmatch(message); m != nil {
+		configErr.Line, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			configErr.Column, _ = strconv.Atoi(m[2])
+		}
+	}
+	return configErr
+}
+
+// CheckConfig 校验配置内容并返回结构化的问题列表，没有问题时返回空列表
+// deep 为 true 时按配置结构校验（见 ValidateConfigDeep），否则只做格式校验
+func (s *ConfigService) CheckConfig(service string, content string, deep bool) ([]ConfigError, error) {
+	var problems []string
+	if deep {
+		var err error
+		if problems, err = s.ValidateConfigDeep(service, content); err != nil {
+			return nil, err
+		}
+	} else if err := s.ValidateConfig(service, content); err != nil {
+		problems = []string{err.Error()}
+	}
+
+	configErrors := make([]ConfigError, len(problems))
+	for i, problem := range problems {
+		configErrors[i] = newConfigError(problem)
+	}
+	return configErrors, nil
+}
+
+// GetFeatureFlags 获取当前生效的功能开关，包含所有已知开关和配置中出现的其他开关
+func (s *ConfigService) GetFeatureFlags(ctx context.Context) (map[string]bool, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, "配置尚未加载")
+	}
+
+	flags := make(map[string]bool, len(config.KnownFeatures)+len(cfg.Features))
+	for _, flag := range config.KnownFeatures {
+		flags[flag] = cfg.IsEnabled(flag)
+	}
+	for flag, enabled := range cfg.Features {
+		flags[flag] = enabled
+	}
+	return flags, nil
+}
+
+// GetEffectiveConfig 获取服务当前实际生效的配置（合并默认值和环境变量，敏感字段已脱敏）
+// 只有 backend 即本服务的运行配置可以获取
+func (s *ConfigService) GetEffectiveConfig(ctx context.Context, service string) (map[string]interface{}, error) {
+	if service != "backend" {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "仅支持查看 backend 的生效配置")
+	}
+
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, "配置尚未加载")
+	}
+	return cfg.Redacted(), nil
+}
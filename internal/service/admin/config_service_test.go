@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/game-apps/internal/config"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestValidateConfigDeep(t *testing.T) {
+	s := NewConfigService(NewFileConfigStore(t.TempDir()), zap.NewNop())
+
+	const base = `
+database:
+  mysql:
+    user: game
+    dbname: game_apps
+jwt:
+  secret: test-jwt-secret
+`
+
+	tests := []struct {
+		name    string
+		service string
+		content string
+		want    []string // 每个问题中应包含的内容
+	}{
+		{"合法配置", "backend", base, nil},
+		{"端口超出范围", "backend", base + "server:\n  http_port: 70000\n", []string{"HTTP 端口无效"}},
+		{"多个问题全部返回", "backend", base + "server:\n  http_port: 0\n  grpc_port: -1\n", []string{"HTTP 端口无效", "gRPC 端口无效"}},
+		{"未知字段", "backend", base + "unknown: 1\n", []string{"解析配置失败"}},
+		{"YAML 格式错误", "backend", "server: [\n", []string{"YAML 格式错误"}},
+		{"其他服务只做格式校验", "gateway", "listen = 8080\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := s.ValidateConfigDeep(tt.service, tt.content)
+			if err != nil {
+				t.Fatalf("ValidateConfigDeep() error = %v", err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("ValidateConfigDeep() = %v, want %d problems", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problems[%d] = %q, want containing %q", i, problems[i], want)
+				}
+			}
+		})
+	}
+
+	if _, err := s.ValidateConfigDeep("unknown", ""); err == nil {
+		t.Error("ValidateConfigDeep() with unknown service should fail")
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	const name = "game-agent/config/config.yaml"
+
+	tests := []struct {
+		name        string
+		current     string
+		backup      string // 为空时不创建备份
+		wantCode    int
+		wantCurrent string
+		wantBackup  string
+	}{
+		{"恢复备份并备份当前配置", "level: debug\n", "level: info\n", 0, "level: info\n", "level: debug\n"},
+		{"备份不存在", "level: debug\n", "", utils.ErrCodeNotFound, "level: debug\n", ""},
+		{"备份格式错误", "level: debug\n", "level: [\n", utils.ErrCodeInvalidInput, "level: debug\n", "level: [\n"},
+	}
+	stores := map[string]func(t *testing.T) ConfigStore{
+		"文件": func(t *testing.T) ConfigStore { return NewFileConfigStore(t.TempDir()) },
+		"Redis": func(t *testing.T) ConfigStore {
+			client, _ := testutil.NewCacheClient(t)
+			return NewRedisConfigStore(client)
+		},
+	}
+	for storeName, newStore := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				store := newStore(t)
+				s := NewConfigService(store, zap.NewNop())
+				if tt.backup != "" {
+					store.Write(ctx, name, []byte(tt.backup))
+					if err := store.Backup(ctx, name); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := store.Write(ctx, name, []byte(tt.current)); err != nil {
+					t.Fatal(err)
+				}
+
+				content, err := s.RestoreBackup(ctx, 1, "agent", "")
+				if tt.wantCode != 0 {
+					var appErr *utils.AppError
+					if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+						t.Errorf("RestoreBackup() error = %v, want code %d", err, tt.wantCode)
+					}
+				} else if err != nil || content != tt.backup {
+					t.Errorf("RestoreBackup() = %q, %v, want %q", content, err, tt.backup)
+				}
+
+				if got, _ := store.Read(ctx, name); string(got) != tt.wantCurrent {
+					t.Errorf("config = %q, want %q", got, tt.wantCurrent)
+				}
+				versions, err := store.ListVersions(ctx, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var newest []byte
+				if len(versions) > 0 {
+					newest, _ = store.ReadVersion(ctx, name, versions[0].ID)
+				}
+				if string(newest) != tt.wantBackup {
+					t.Errorf("newest backup = %q, want %q", newest, tt.wantBackup)
+				}
+			})
+		}
+	}
+}
+
+// failingBackupStore 备份总是失败的配置存储
+type failingBackupStore struct {
+	ConfigStore
+}
+
+func (s failingBackupStore) Backup(ctx context.Context, name string) error {
+	return errors.New("disk full")
+}
+
+func TestRestoreBackupAbortsWhenBackupFails(t *testing.T) {
+	const name = "game-agent/config/config.yaml"
+	ctx := context.Background()
+	store := NewFileConfigStore(t.TempDir())
+	store.Write(ctx, name, []byte("level: info\n"))
+	if err := store.Backup(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	store.Write(ctx, name, []byte("level: debug\n"))
+
+	// 当前配置无法备份时不覆盖
+	s := NewConfigService(failingBackupStore{store}, zap.NewNop())
+	_, err := s.RestoreBackup(ctx, 1, "agent", "")
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInternal {
+		t.Fatalf("RestoreBackup() error = %v, want internal error", err)
+	}
+	if got, _ := store.Read(ctx, name); string(got) != "level: debug\n" {
+		t.Errorf("config = %q, want unchanged", got)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	s := NewConfigService(NewFileConfigStore(t.TempDir()), zap.NewNop())
+
+	tests := []struct {
+		name     string
+		service  string
+		content  string
+		wantLine int
+	}{
+		{"格式正确", "agent", "server:\n  port: 8080\n", 0},
+		{"YAML 第三行出错", "agent", "server:\n  port: 8080\n  host: [\n", 3},
+		{"YAML 缩进错误", "backend", "a: 1\nb: 2\n c: 3\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configErrors, err := s.CheckConfig(tt.service, tt.content, false)
+			if err != nil {
+				t.Fatalf("CheckConfig() error = %v", err)
+			}
+			if tt.wantLine == 0 {
+				if len(configErrors) != 0 {
+					t.Errorf("CheckConfig() = %+v, want no errors", configErrors)
+				}
+				return
+			}
+			if len(configErrors) != 1 || configErrors[0].Line != tt.wantLine || configErrors[0].Message == "" {
+				t.Errorf("CheckConfig() = %+v, want one error on line %d", configErrors, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestNewConfigError(t *testing.T) {
+	tests := []struct {
+		message    string
+		wantLine   int
+		wantColumn int
+	}{
+		{"yaml: line 3: mapping values are not allowed in this context", 3, 0},
+		{"toml: line 2, column 5: expected value", 2, 5},
+		{"Near line 4 (last key parsed 'a'): expected value", 4, 0},
+		{"server.port: 端口必须在 1-65535 之间", 0, 0},
+	}
+	for _, tt := range tests {
+		got := newConfigError(tt.message)
+		if got.Message != tt.message || got.Line != tt.wantLine || got.Column != tt.wantColumn {
+			t.Errorf("newConfigError(%q) = %+v, want line %d column %d", tt.message, got, tt.wantLine, tt.wantColumn)
+		}
+	}
+}
+
+func TestReloadBackendWithoutStoredConfig(t *testing.T) {
+	s := NewConfigService(NewFileConfigStore(t.TempDir()), zap.NewNop())
+	called := false
+	s.OnReload(func(old, updated *config.Config) { called = true })
+
+	// 配置存储中没有 backend 配置时不回退到本地文件
+	err := s.ReloadBackend(context.Background(), 1)
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeNotFound {
+		t.Fatalf("ReloadBackend() error = %v, want not found", err)
+	}
+	if called {
+		t.Error("重新加载失败时不应调用回调")
+	}
+}
+
+func TestUpdateConfigAbortsWhenBackupFails(t *testing.T) {
+	const name = "game-agent/config/config.yaml"
+	ctx := context.Background()
+	store := NewFileConfigStore(t.TempDir())
+	store.Write(ctx, name, []byte("level: debug\n"))
+
+	s := NewConfigService(failingBackupStore{store}, zap.NewNop())
+	err := s.UpdateConfig(ctx, "agent", "level: info\n")
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInternal {
+		t.Fatalf("UpdateConfig() error = %v, want internal error", err)
+	}
+	if got, _ := store.Read(ctx, name); string(got) != "level: debug\n" {
+		t.Errorf("config = %q, want unchanged", got)
+	}
+}
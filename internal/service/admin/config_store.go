@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConfigNotFound 配置不存在
+var ErrConfigNotFound = errors.New("config not found")
+
+// maxConfigVersions 每个配置保留的最大备份版本数，超出时删除最旧的版本
+const maxConfigVersions = 10
+
+// ConfigVersion 配置的一个备份版本
+type ConfigVersion struct {
+	ID        string    `json:"id"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConfigStore 配置内容的存储，name 为以 / 分隔的相对路径（如 game-services/configs/config.yaml）
+type ConfigStore interface {
+	// Read 读取当前内容，不存在时返回 ErrConfigNotFound
+	Read(ctx context.Context, name string) ([]byte, error)
+	// Revision 返回当前内容的修订标识，内容变化时改变，不存在时返回 ErrConfigNotFound
+	Revision(ctx context.Context, name string) (string, error)
+	// Write 写入当前内容
+	Write(ctx context.Context, name string, data []byte) error
+	// Backup 将当前内容保存为新的备份版本，当前内容不存在时不做处理
+	Backup(ctx context.Context, name string) error
+	// ListVersions 列出备份版本，最新的在前
+	ListVersions(ctx context.Context, name string) ([]ConfigVersion, error)
+	// ReadVersion 读取指定的备份版本，不存在时返回 ErrConfigNotFound
+	ReadVersion(ctx context.Context, name, id string) ([]byte, error)
+}
+
+// FileConfigStore 基于本地文件系统的配置存储，备份版本保存为同目录下的 <文件名>.backup.<时间戳>
+type FileConfigStore struct {
+	basePath string
+}
+
+// NewFileConfigStore 创建文件配置存储，name 相对于 basePath 解析
+func NewFileConfigStore(basePath string) *FileConfigStore {
+	return &FileConfigStore{basePath: basePath}
+}
+
+func (s *FileConfigStore) path(name string) string {
+	return filepath.Join(s.basePath, filepath.FromSlash(name))
+}
+
+func (s *FileConfigStore) versionPath(name, id string) string {
+	return s.path(name) + ".backup." + id
+}
+
+// Read 读取当前内容
+func (s *FileConfigStore) Read(ctx context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrConfigNotFound
+	}
+	return data, err
+}
+
+// Revision 以修改时间和大小作为修订标识
+func (s *FileConfigStore) Revision(ctx context.Context, name string) (string, error) {
+	info, err := os.Stat(s.path(name))
+	if os.IsNotExist(err) {
+		return "", ErrConfigNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// Write 原子地写入当前内容，目录不存在时自动创建
+func (s *FileConfigStore) Write(ctx context.Context, name string, data []byte) error {
+	path := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// Backup 将当前内容保存为新的备份版本，并清理超出保留数的旧版本
+func (s *FileConfigStore) Backup(ctx context.Context, name string) error {
+	data, err := s.Read(ctx, name)
+	if errors.Is(err, ErrConfigNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	id := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := writeFileAtomic(s.versionPath(name, id), data, 0644); err != nil {
+		return err
+	}
+
+	versions, err := s.ListVersions(ctx, name)
+	if err != nil {
+		return err
+	}
+	for i := maxConfigVersions; i < len(versions); i++ {
+		os.Remove(s.versionPath(name, versions[i].ID))
+	}
+	return nil
+}
+
+// ListVersions 列出备份版本，最新的在前
+func (s *FileConfigStore) ListVersions(ctx context.Context, name string) ([]ConfigVersion, error) {
+	prefix := s.path(name) + ".backup."
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]ConfigVersion, 0, len(matches))
+	for _, match := range matches {
+		id := strings.TrimPrefix(match, prefix)
+		nanos, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			// 不是本存储创建的备份（如写入中的临时文件）
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, ConfigVersion{
+			ID:        id,
+			Size:      info.Size(),
+			CreatedAt: time.Unix(0, nanos),
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].CreatedAt.After(versions[j].CreatedAt)
+	})
+	return versions, nil
+}
+
+// ReadVersion 读取指定的备份版本
+func (s *FileConfigStore) ReadVersion(ctx context.Context, name, id string) ([]byte, error) {
+	// 版本 ID 为时间戳，校验后再拼接路径，防止路径穿越
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, ErrConfigNotFound
+	}
+	data, err := os.ReadFile(s.versionPath(name, id))
+	if os.IsNotExist(err) {
+		return nil, ErrConfigNotFound
+	}
+	return data, err
+}
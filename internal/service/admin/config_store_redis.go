@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/game-apps/pkg/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RedisConfigStore 基于 Redis 的配置存储，多实例共享且不依赖本地磁盘
+// 当前内容保存在哈希 config:store:<name> 中，备份版本保存在列表 config:store:<name>:versions 中（最新的在前）
+type RedisConfigStore struct {
+	client *cache.Client
+}
+
+// NewRedisConfigStore 创建 Redis 配置存储
+func NewRedisConfigStore(client *cache.Client) *RedisConfigStore {
+	return &RedisConfigStore{client: client}
+}
+
+func configStoreKey(name string) string {
+	return "config:store:" + name
+}
+
+func configVersionsKey(name string) string {
+	return "config:store:" + name + ":versions"
+}
+
+// storedConfigVersion 列表中保存的备份版本，ID 为创建时的纳秒时间戳
+type storedConfigVersion struct {
+	ID   string `json:"id"`
+	Data string `json:"data"`
+}
+
+// Read 读取当前内容
+func (s *RedisConfigStore) Read(ctx context.Context, name string) ([]byte, error) {
+	fields, err := s.client.HGetAll(ctx, configStoreKey(name))
+	if err != nil {
+		return nil, err
+	}
+	data, ok := fields["data"]
+	if !ok {
+		return nil, ErrConfigNotFound
+	}
+	return []byte(data), nil
+}
+
+// Revision 以每次写入递增的修订号作为修订标识
+func (s *RedisConfigStore) Revision(ctx context.Context, name string) (string, error) {
+	revision, err := s.client.HGet(ctx, configStoreKey(name), "revision")
+	if errors.Is(err, goredis.Nil) {
+		return "", ErrConfigNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return revision, nil
+}
+
+// writeConfigScript 写入内容并递增修订号
+const writeConfigScript = `
+redis.call('HSET', KEYS[1], 'data', ARGV[1])
+return redis.call('HINCRBY', KEYS[1], 'revision', 1)
+`
+
+// Write 写入当前内容并递增修订号
+func (s *RedisConfigStore) Write(ctx context.Context, name string, data []byte) error {
+	_, err := s.client.Eval(ctx, writeConfigScript, []string{configStoreKey(name)}, data)
+	return err
+}
+
+// backupConfigScript 将当前内容包装为备份版本推入列表头部，并裁剪到最大版本数
+const backupConfigScript = `
+local data = redis.call('HGET', KEYS[1], 'data')
+if not data then
+	return 0
+end
+redis.call('LPUSH', KEYS[2], cjson.encode({id = ARGV[1], data = data}))
+redis.call('LTRIM', KEYS[2], 0, tonumber(ARGV[2]) - 1)
+return 1
+`
+
+// Backup 将当前内容保存为新的备份版本
+func (s *RedisConfigStore) Backup(ctx context.Context, name string) error {
+	id := strconv.FormatInt(time.Now().UnixNano(), 10)
+	_, err := s.client.Eval(ctx, backupConfigScript,
+		[]string{configStoreKey(name), configVersionsKey(name)}, id, maxConfigVersions)
+	return err
+}
+
+// loadVersions 读取并解析全部备份版本
+func (s *RedisConfigStore) loadVersions(ctx context.Context, name string) ([]storedConfigVersion, error) {
+	items, err := s.client.LRange(ctx, configVersionsKey(name), 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]storedConfigVersion, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal([]byte(item), &versions[i]); err != nil {
+			return nil, fmt.Errorf("解析配置备份失败: %w", err)
+		}
+	}
+	return versions, nil
+}
+
+// ListVersions 列出备份版本，最新的在前
+func (s *RedisConfigStore) ListVersions(ctx context.Context, name string) ([]ConfigVersion, error) {
+	stored, err := s.loadVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]ConfigVersion, len(stored))
+	for i, v := range stored {
+		nanos, _ := strconv.ParseInt(v.ID, 10, 64)
+		versions[i] = ConfigVersion{
+			ID:        v.ID,
+			Size:      int64(len(v.Data)),
+			CreatedAt: time.Unix(0, nanos),
+		}
+	}
+	return versions, nil
+}
+
+// ReadVersion 读取指定的备份版本
+func (s *RedisConfigStore) ReadVersion(ctx context.Context, name, id string) ([]byte, error) {
+	stored, err := s.loadVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range stored {
+		if v.ID == id {
+			return []byte(v.Data), nil
+		}
+	}
+	return nil, ErrConfigNotFound
+}
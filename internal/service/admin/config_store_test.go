@@ -0,0 +1,210 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/game-apps/internal/testutil"
+)
+
+const testConfigName = "game-services/configs/config.yaml"
+
+func TestFileConfigStoreReadWrite(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileConfigStore(t.TempDir())
+
+	if _, err := store.Read(ctx, testConfigName); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("Read() 不存在的配置 error = %v, want ErrConfigNotFound", err)
+	}
+	if _, err := store.Revision(ctx, testConfigName); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("Revision() 不存在的配置 error = %v, want ErrConfigNotFound", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"首次写入时创建目录", "server:\n  http_port: 8080\n"},
+		{"覆盖已有内容", "server:\n  http_port: 8081\n"},
+		{"写入更长的内容", "server:\n  http_port: 8082\n  host: 0.0.0.0\n"},
+	}
+	var lastRevision string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Write(ctx, testConfigName, []byte(tt.content)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			data, err := store.Read(ctx, testConfigName)
+			if err != nil || string(data) != tt.content {
+				t.Fatalf("Read() = %q, %v, want %q", data, err, tt.content)
+			}
+			revision, err := store.Revision(ctx, testConfigName)
+			if err != nil {
+				t.Fatalf("Revision() error = %v", err)
+			}
+			if revision == lastRevision {
+				t.Errorf("内容变化后修订标识未变化: %s", revision)
+			}
+			lastRevision = revision
+		})
+	}
+
+	// 原子写入不应留下临时文件
+	entries, err := os.ReadDir(filepath.Dir(store.path(testConfigName)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("配置目录中有 %d 个文件, want 1", len(entries))
+	}
+}
+
+func TestFileConfigStoreBackup(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileConfigStore(t.TempDir())
+
+	// 当前内容不存在时备份不做处理
+	if err := store.Backup(ctx, testConfigName); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	contents := make([]string, maxConfigVersions+3)
+	for i := range contents {
+		contents[i] = strings.Repeat("#", i+1)
+		if err := store.Write(ctx, testConfigName, []byte(contents[i])); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Backup(ctx, testConfigName); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	versions, err := store.ListVersions(ctx, testConfigName)
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(versions) != maxConfigVersions {
+		t.Fatalf("保留 %d 个版本, want %d", len(versions), maxConfigVersions)
+	}
+
+	// 最新的在前，最旧的版本已被清理
+	for i, version := range versions {
+		want := contents[len(contents)-1-i]
+		data, err := store.ReadVersion(ctx, testConfigName, version.ID)
+		if err != nil || string(data) != want {
+			t.Errorf("ReadVersion(%s) = %q, %v, want %q", version.ID, data, err, want)
+		}
+		if version.Size != int64(len(want)) {
+			t.Errorf("version %d Size = %d, want %d", i, version.Size, len(want))
+		}
+	}
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"不存在的版本", "1"},
+		{"非时间戳的版本", "latest"},
+		{"路径穿越", "../../../etc/passwd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.ReadVersion(ctx, testConfigName, tt.id); !errors.Is(err, ErrConfigNotFound) {
+				t.Errorf("ReadVersion(%q) error = %v, want ErrConfigNotFound", tt.id, err)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+
+	// 目标位置被非空目录占用，写入临时文件成功但重命名失败
+	target := filepath.Join(dir, "config.yaml")
+	original := filepath.Join(target, "original")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(original, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(target, []byte("updated"), 0644); err == nil {
+		t.Fatalf("writeFileAtomic() 应返回错误")
+	}
+
+	data, err := os.ReadFile(original)
+	if err != nil || string(data) != "original" {
+		t.Errorf("原内容被修改: %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("写入失败后残留临时文件 %s", entry.Name())
+		}
+	}
+}
+
+func TestRedisConfigStore(t *testing.T) {
+	ctx := context.Background()
+	client, _ := testutil.NewCacheClient(t)
+	store := NewRedisConfigStore(client)
+
+	if _, err := store.Read(ctx, testConfigName); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("Read() error = %v, want ErrConfigNotFound", err)
+	}
+	if _, err := store.Revision(ctx, testConfigName); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("Revision() error = %v, want ErrConfigNotFound", err)
+	}
+	if err := store.Backup(ctx, testConfigName); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	// 每次写入修订号都会变化
+	var lastRevision string
+	contents := make([]string, maxConfigVersions+3)
+	for i := range contents {
+		contents[i] = strings.Repeat("#", i+1)
+		if err := store.Write(ctx, testConfigName, []byte(contents[i])); err != nil {
+			t.Fatal(err)
+		}
+		revision, err := store.Revision(ctx, testConfigName)
+		if err != nil || revision == lastRevision {
+			t.Fatalf("Revision() = %q, %v, previous %q", revision, err, lastRevision)
+		}
+		lastRevision = revision
+		if err := store.Backup(ctx, testConfigName); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	data, err := store.Read(ctx, testConfigName)
+	if err != nil || string(data) != contents[len(contents)-1] {
+		t.Errorf("Read() = %q, %v", data, err)
+	}
+
+	versions, err := store.ListVersions(ctx, testConfigName)
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(versions) != maxConfigVersions {
+		t.Fatalf("保留 %d 个版本, want %d", len(versions), maxConfigVersions)
+	}
+	for i, version := range versions {
+		want := contents[len(contents)-1-i]
+		data, err := store.ReadVersion(ctx, testConfigName, version.ID)
+		if err != nil || string(data) != want {
+			t.Errorf("ReadVersion(%s) = %q, %v, want %q", version.ID, data, err, want)
+		}
+	}
+	if _, err := store.ReadVersion(ctx, testConfigName, "1"); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("ReadVersion() error = %v, want ErrConfigNotFound", err)
+	}
+}
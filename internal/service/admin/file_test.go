@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, path string)
+		wantErr bool
+		check   func(t *testing.T, path string)
+	}{
+		{
+			name:  "覆盖已有文件",
+			setup: func(t *testing.T, path string) { os.WriteFile(path, []byte("old"), 0600) },
+			check: func(t *testing.T, path string) {
+				info, err := os.Stat(path)
+				if err != nil || info.Mode().Perm() != 0644 {
+					t.Errorf("stat = %v, %v, want mode 0644", info, err)
+				}
+				if got, _ := os.ReadFile(path); string(got) != "new" {
+					t.Errorf("content = %q, want %q", got, "new")
+				}
+			},
+		},
+		{
+			name: "重命名失败时原内容保持不变",
+			setup: func(t *testing.T, path string) {
+				// 目标是非空目录，重命名会失败
+				os.Mkdir(path, 0755)
+				os.WriteFile(filepath.Join(path, "keep"), []byte("old"), 0644)
+			},
+			wantErr: true,
+			check: func(t *testing.T, path string) {
+				if got, _ := os.ReadFile(filepath.Join(path, "keep")); string(got) != "old" {
+					t.Errorf("original content = %q, want %q", got, "old")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			tt.setup(t, path)
+
+			err := writeFileAtomic(path, []byte("new"), 0644)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeFileAtomic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			tt.check(t, path)
+
+			// 无论成功失败都不应留下临时文件
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				t.Errorf("dir entries = %v, want only the target", entries)
+			}
+		})
+	}
+
+	if err := writeFileAtomic(filepath.Join(t.TempDir(), "missing", "config.json"), []byte("new"), 0644); err == nil {
+		t.Error("writeFileAtomic() into missing directory should fail")
+	}
+}
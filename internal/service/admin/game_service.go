@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/service/game"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// GameService 进行中游戏管理服务
+type GameService struct {
+	roomRepo       game.RoomRepository
+	processService *game.ProcessService
+	logger         *zap.Logger
+}
+
+// NewGameService 创建进行中游戏管理服务
+func NewGameService(roomRepo game.RoomRepository, processService *game.ProcessService, logger *zap.Logger) *GameService {
+	return &GameService{
+		roomRepo:       roomRepo,
+		processService: processService,
+		logger:         logger,
+	}
+}
+
+// LiveGame 进行中的游戏
+type LiveGame struct {
+	Room            *model.Room         `json:"room"`
+	State           *game.GameStateView `json:"state"`
+	DurationSeconds int64               `json:"duration_seconds"`
+}
+
+// ListLiveGames 列出进行中的游戏及其 Redis 状态和已进行时长
+func (s *GameService) ListLiveGames(ctx context.Context, page, pageSize int) (*utils.PageResult[*LiveGame], error) {
+	params := utils.Paginate(page, pageSize)
+	status := model.RoomStatusPlaying
+
+	total, err := s.roomRepo.Count(ctx, &status)
+	if err != nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, "查询进行中的游戏失败")
+	}
+
+	rooms, err := s.roomRepo.List(ctx, &status, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, "查询进行中的游戏失败")
+	}
+
+	now := time.Now()
+	games := make([]*LiveGame, 0, len(rooms))
+	for _, room := range rooms {
+		liveGame := &LiveGame{Room: room}
+
+		state, err := s.processService.GetGameState(ctx, room.ID)
+		if err != nil {
+			s.logger.Warn("获取游戏状态失败", zap.Error(err), zap.Uint("room_id", room.ID))
+		}
+		liveGame.State = state
+
+		if room.StartedAt != nil {
+			liveGame.DurationSeconds = int64(now.Sub(*room.StartedAt).Seconds())
+		}
+		games = append(games, liveGame)
+	}
+
+	return utils.NewPageResult(games, total, params), nil
+}
+
+// ForceEndGame 强制结束进行中的游戏
+func (s *GameService) ForceEndGame(ctx context.Context, adminID, roomID uint) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return utils.NewError(utils.ErrCodeInternal, "查询房间失败")
+	}
+	if room == nil {
+		return utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+	// 游戏状态在 ProcessService 的锁内检查
+	if err := s.processService.ForceEndGame(ctx, roomID, map[uint]interface{}{}); err != nil {
+		return err
+	}
+
+	// 审计日志
+	s.logger.Info("管理员强制结束游戏",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("room_id", roomID),
+		zap.String("room_code", room.RoomCode),
+	)
+
+	event := &game.GameEvent{
+		Type:      "game_force_ended",
+		RoomID:    roomID,
+		UserID:    adminID,
+		Data:      map[string]interface{}{"reason": "admin"},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.processService.PublishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	return nil
+}
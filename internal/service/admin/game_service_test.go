@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/service/game"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// newTestGameService 创建使用内存房间仓库和 miniredis 的游戏管理服务
+func newTestGameService(t *testing.T) (*GameService, *testutil.RoomRepo, *redis.Repository) {
+	t.Helper()
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	processService := game.NewProcessService(roomRepo, &stubRoomPlayerRepo{}, testutil.NewEventRepo(roomRepo), redis.NewRoomRepository(repo), redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", game.ReadyPolicy{}, game.TurnPolicy{}, game.EventRetention{}, utils.JSONLimits{}, game.AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	return NewGameService(roomRepo, processService, zap.NewNop()), roomRepo, repo
+}
+
+func TestListLiveGames(t *testing.T) {
+	s, roomRepo, repo := newTestGameService(t)
+	ctx := context.Background()
+
+	startedAt := time.Now().Add(-time.Minute)
+	playing := &model.Room{RoomCode: "PLAY01", Status: model.RoomStatusPlaying, StartedAt: &startedAt}
+	roomRepo.Create(ctx, playing)
+	roomRepo.Create(ctx, &model.Room{RoomCode: "WAIT01", Status: model.RoomStatusWaiting})
+	roomRepo.Create(ctx, &model.Room{RoomCode: "DONE01", Status: model.RoomStatusFinished})
+	redis.NewRoomRepository(repo).SetRoomState(ctx, playing.ID, map[string]interface{}{"game_state": 3}, 0)
+
+	result, err := s.ListLiveGames(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("ListLiveGames() error = %v", err)
+	}
+	if result.Total != 1 || len(result.Items) != 1 {
+		t.Fatalf("ListLiveGames() = %d items, total %d, want 1", len(result.Items), result.Total)
+	}
+
+	live := result.Items[0]
+	if live.Room.ID != playing.ID || live.State == nil || live.State.GameState != game.GameStatePlaying {
+		t.Errorf("live game = room %d, state %v", live.Room.ID, live.State)
+	}
+	if live.DurationSeconds < 59 || live.DurationSeconds > 61 {
+		t.Errorf("DurationSeconds = %d, want about 60", live.DurationSeconds)
+	}
+}
+
+func TestForceEndGame(t *testing.T) {
+	s, roomRepo, repo := newTestGameService(t)
+	ctx := context.Background()
+	const adminID = 99
+
+	playing := &model.Room{RoomCode: "PLAY01", Status: model.RoomStatusPlaying}
+	waiting := &model.Room{RoomCode: "WAIT01", Status: model.RoomStatusWaiting}
+	roomRepo.Create(ctx, playing)
+	roomRepo.Create(ctx, waiting)
+
+	events, closeSub := subscribeEvents(t, repo)
+	defer closeSub()
+
+	if err := s.ForceEndGame(ctx, adminID, playing.ID); err != nil {
+		t.Fatalf("ForceEndGame() error = %v", err)
+	}
+	stored, _ := roomRepo.GetByID(ctx, playing.ID)
+	if stored.Status != model.RoomStatusFinished || stored.EndedAt == nil {
+		t.Errorf("room status = %d, ended_at = %v, want finished", stored.Status, stored.EndedAt)
+	}
+	if !waitForEvent(events, "game_force_ended") {
+		t.Error("game_force_ended event not published")
+	}
+
+	tests := []struct {
+		name     string
+		roomID   uint
+		wantCode int
+	}{
+		{"游戏未在进行中", waiting.ID, utils.ErrCodeConflict},
+		{"重复强制结束", playing.ID, utils.ErrCodeConflict},
+		{"房间不存在", 999, utils.ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ForceEndGame(ctx, adminID, tt.roomID)
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+				t.Errorf("ForceEndGame() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+// subscribeEvents 订阅游戏事件频道
+func subscribeEvents(t *testing.T, repo *redis.Repository) (<-chan string, func()) {
+	t.Helper()
+	pubsub := redis.NewRoomRepository(repo).Client().Subscribe(context.Background(), "game:events")
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan string, 16)
+	go func() {
+		for msg := range pubsub.Channel() {
+			var event game.GameEvent
+			if json.Unmarshal([]byte(msg.Payload), &event) == nil {
+				out <- event.Type
+			}
+		}
+	}()
+	return out, func() { pubsub.Close() }
+}
+
+// waitForEvent 等待指定类型的事件
+func waitForEvent(events <-chan string, eventType string) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case got := <-events:
+			if got == eventType {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+)
+
+// newTestRepository 基于 miniredis 创建 Redis 仓库
+func newTestRepository(t *testing.T) (*redis.Repository, *miniredis.Miniredis) {
+	t.Helper()
+	client, mr := testutil.NewCacheClient(t)
+	return redis.NewRepository(client), mr
+}
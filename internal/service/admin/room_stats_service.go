@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"gorm.io/gorm"
+)
+
+// RoomStatsService 房间统计服务
+type RoomStatsService struct {
+	db *gorm.DB
+}
+
+// NewRoomStatsService 创建房间统计服务
+func NewRoomStatsService(db *gorm.DB) *RoomStatsService {
+	return &RoomStatsService{db: db}
+}
+
+// RoomStats 房间聚合统计
+type RoomStats struct {
+	Total          int64            `json:"total"`
+	ByStatus       map[string]int64 `json:"by_status"`
+	AveragePlayers float64          `json:"average_players"`
+	CreatedLast24h int64            `json:"created_last_24h"`
+}
+
+// roomStatusNames 房间状态名称
+var roomStatusNames = map[model.RoomStatus]string{
+	model.RoomStatusWaiting:   "waiting",
+	model.RoomStatusPlaying:   "playing",
+	model.RoomStatusFinished:  "finished",
+	model.RoomStatusCancelled: "cancelled",
+}
+
+// GetRoomStats 获取房间聚合统计（单次 GROUP BY 查询）
+func (s *RoomStatsService) GetRoomStats(ctx context.Context) (*RoomStats, error) {
+	var rows []struct {
+		Status     model.RoomStatus
+		Count      int64
+		AvgPlayers float64
+		Recent     int64
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	err := s.db.WithContext(ctx).Model(&model.Room{}).
+		Select("status, COUNT(*) AS count, COALESCE(AVG(current_players), 0) AS avg_players, "+
+			"SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END) AS recent", since).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("获取房间统计失败: %v", err))
+	}
+
+	stats := &RoomStats{
+		ByStatus: make(map[string]int64, len(roomStatusNames)),
+	}
+	for _, name := range roomStatusNames {
+		stats.ByStatus[name] = 0
+	}
+
+	var totalPlayers float64
+	for _, row := range rows {
+		name, ok := roomStatusNames[row.Status]
+		if !ok {
+			name = fmt.Sprintf("unknown_%d", row.Status)
+		}
+		stats.ByStatus[name] += row.Count
+		stats.Total += row.Count
+		stats.CreatedLast24h += row.Recent
+		totalPlayers += row.AvgPlayers * float64(row.Count)
+	}
+	if stats.Total > 0 {
+		stats.AveragePlayers = totalPlayers / float64(stats.Total)
+	}
+
+	return stats, nil
+}
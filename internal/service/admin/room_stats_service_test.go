@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/game-apps/internal/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB 创建基于 sqlmock 的 gorm 连接
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestGetRoomStats(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := NewRoomStatsService(db)
+
+	// 等待中 3 个（平均 2 人，24h 内 2 个），进行中 1 个（4 人），已结束 4 个（平均 3 人，24h 内 1 个）
+	mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) AS count, .* GROUP BY `status`").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count", "avg_players", "recent"}).
+			AddRow(model.RoomStatusWaiting, 3, 2.0, 2).
+			AddRow(model.RoomStatusPlaying, 1, 4.0, 1).
+			AddRow(model.RoomStatusFinished, 4, 3.0, 1))
+
+	stats, err := service.GetRoomStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Total != 8 {
+		t.Errorf("Total = %d, want 8", stats.Total)
+	}
+	if stats.CreatedLast24h != 4 {
+		t.Errorf("CreatedLast24h = %d, want 4", stats.CreatedLast24h)
+	}
+	if want := (3*2.0 + 4.0 + 4*3.0) / 8; math.Abs(stats.AveragePlayers-want) > 1e-9 {
+		t.Errorf("AveragePlayers = %v, want %v", stats.AveragePlayers, want)
+	}
+
+	wantByStatus := map[string]int64{"waiting": 3, "playing": 1, "finished": 4, "cancelled": 0}
+	for name, want := range wantByStatus {
+		if got := stats.ByStatus[name]; got != want {
+			t.Errorf("ByStatus[%s] = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestGetRoomStatsEmpty(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := NewRoomStatsService(db)
+
+	mock.ExpectQuery("SELECT status").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count", "avg_players", "recent"}))
+
+	stats, err := service.GetRoomStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 0 || stats.AveragePlayers != 0 || len(stats.ByStatus) != 4 {
+		t.Errorf("stats = %+v, want zero values with all statuses", stats)
+	}
+}
@@ -3,24 +3,32 @@ package admin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/game-apps/internal/notify"
 	"github.com/game-apps/internal/utils"
 )
 
+// systemConfigName 系统配置在存储中的名称
+const systemConfigName = "game-services/configs/system_config.json"
+
 // SystemService 系统配置管理服务
 type SystemService struct {
-	configPath string
+	store ConfigStore
+
+	// 配置内存缓存，以存储的修订标识判断是否被外部（或其他实例）修改
+	mu             sync.RWMutex
+	cached         *SystemConfig
+	cachedRevision string
 }
 
 // NewSystemService 创建系统配置管理服务
-func NewSystemService(configBasePath string) *SystemService {
-	configPath := filepath.Join(configBasePath, "game-services", "configs", "system_config.json")
+func NewSystemService(store ConfigStore) *SystemService {
 	return &SystemService{
-		configPath: configPath,
+		store: store,
 	}
 }
 
@@ -75,6 +83,7 @@ type NotificationConfig struct {
 
 type EmailConfig struct {
 	Enabled    bool   `json:"enabled"`
+	Provider   string `json:"provider"` // 为空时使用 smtp
 	SMTPHost   string `json:"smtp_host"`
 	SMTPPort   int    `json:"smtp_port"`
 	SMTPUser   string `json:"smtp_user"`
@@ -88,35 +97,175 @@ type SMSConfig struct {
 	Provider  string `json:"provider"`
 	APIKey    string `json:"api_key"`
 	APISecret string `json:"api_secret"`
+	From      string `json:"from"` // 发送方号码或签名
 }
 
 type PushConfig struct {
-	Enabled  bool   `json:"enabled"`
-	Provider string `json:"provider"`
-	APIKey   string `json:"api_key"`
+	Enabled     bool   `json:"enabled"`
+	Provider    string `json:"provider"`
+	APIKey      string `json:"api_key"`
+	Credentials string `json:"credentials"` // 服务账号 JSON 密钥内容，fcm 使用
+}
+
+// NotifyConfig 转换为通知发送器配置
+func (c NotificationConfig) NotifyConfig() notify.Config {
+	return notify.Config{
+		Email: notify.EmailConfig{
+			Enabled:      c.Email.Enabled,
+			Provider:     c.Email.Provider,
+			SMTPHost:     c.Email.SMTPHost,
+			SMTPPort:     c.Email.SMTPPort,
+			SMTPUser:     c.Email.SMTPUser,
+			SMTPPassword: c.Email.SMTPPassword,
+			FromEmail:    c.Email.FromEmail,
+			FromName:     c.Email.FromName,
+		},
+		SMS: notify.SMSConfig{
+			Enabled:   c.SMS.Enabled,
+			Provider:  c.SMS.Provider,
+			APIKey:    c.SMS.APIKey,
+			APISecret: c.SMS.APISecret,
+			From:      c.SMS.From,
+		},
+		Push: notify.PushConfig{
+			Enabled:     c.Push.Enabled,
+			Provider:    c.Push.Provider,
+			APIKey:      c.Push.APIKey,
+			Credentials: c.Push.Credentials,
+		},
+	}
 }
 
-// GetSystemConfig 获取系统配置
+// GetSystemConfig 获取系统配置，配置未变化时直接返回内存缓存
 func (s *SystemService) GetSystemConfig(ctx context.Context) (*SystemConfig, error) {
-	var config SystemConfig
+	// 如果配置不存在，返回默认配置
+	revision, err := s.store.Revision(ctx, systemConfigName)
+	if errors.Is(err, ErrConfigNotFound) {
+		return s.getDefaultConfig(), nil
+	}
+
+	if err == nil {
+		s.mu.RLock()
+		if s.isCacheFresh(revision) {
+			config := s.cached.clone()
+			s.mu.RUnlock()
+			return config, nil
+		}
+		s.mu.RUnlock()
+	}
 
-	// 如果配置文件不存在，返回默认配置
-	if _, err := os.Stat(s.configPath); os.IsNotExist(err) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config, err := s.loadConfigLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.clone(), nil
+}
+
+// isCacheFresh 缓存是否与存储一致，调用方需持有锁
+func (s *SystemService) isCacheFresh(revision string) bool {
+	return s.cached != nil && revision == s.cachedRevision
+}
+
+// loadConfigLocked 读取配置，配置有变化时重新加载，调用方需持有写锁
+// 返回的配置与缓存共享，修改前需先复制
+func (s *SystemService) loadConfigLocked(ctx context.Context) (*SystemConfig, error) {
+	revision, err := s.store.Revision(ctx, systemConfigName)
+	if errors.Is(err, ErrConfigNotFound) {
+		s.cached = nil
 		return s.getDefaultConfig(), nil
 	}
+	if err == nil && s.isCacheFresh(revision) {
+		return s.cached, nil
+	}
 
-	content, err := ioutil.ReadFile(s.configPath)
+	content, err := s.store.Read(ctx, systemConfigName)
+	if errors.Is(err, ErrConfigNotFound) {
+		s.cached = nil
+		return s.getDefaultConfig(), nil
+	}
 	if err != nil {
 		return nil, utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("读取系统配置文件失败: %v", err))
 	}
 
+	var config SystemConfig
 	if err := json.Unmarshal(content, &config); err != nil {
 		return nil, utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("解析系统配置文件失败: %v", err))
 	}
 
+	// 无法获取修订标识时不缓存，下次读取重新加载
+	s.cached = nil
+	if revision != "" {
+		s.cached = &config
+		s.cachedRevision = revision
+	}
 	return &config, nil
 }
 
+// PublicConfig 可公开给前端的系统配置，不包含安全和通知等敏感配置
+type PublicConfig struct {
+	SiteName        string `json:"site_name"`
+	SiteDescription string `json:"site_description"`
+	SiteLogo        string `json:"site_logo"`
+	Language        string `json:"language"` // 默认语言
+	Theme           string `json:"theme"`    // 默认主题
+}
+
+// GetPublicConfig 获取公开配置，供前端启动时加载默认语言和主题
+func (s *SystemService) GetPublicConfig(ctx context.Context) (*PublicConfig, error) {
+	config, err := s.GetSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := s.getDefaultConfig().Basic
+	public := &PublicConfig{
+		SiteName:        config.Basic.SiteName,
+		SiteDescription: config.Basic.SiteDescription,
+		SiteLogo:        config.Basic.SiteLogo,
+		Language:        config.Basic.Language,
+		Theme:           config.Basic.Theme,
+	}
+	// 未配置的语言和主题使用内置默认值
+	if public.Language == "" {
+		public.Language = defaults.Language
+	}
+	if public.Theme == "" {
+		public.Theme = defaults.Theme
+	}
+	return public, nil
+}
+
+// ServerTime 服务器时间，供客户端计算时钟偏差
+type ServerTime struct {
+	UnixMillis    int64  `json:"unix_ms"`
+	Timezone      string `json:"timezone"`
+	OffsetSeconds int    `json:"utc_offset_seconds"` // 配置时区当前相对 UTC 的偏移
+}
+
+// GetServerTime 获取服务器当前时间和配置的时区，时区无效时回退到 UTC
+func (s *SystemService) GetServerTime(ctx context.Context) (*ServerTime, error) {
+	now := time.Now()
+
+	timezone := s.getDefaultConfig().Basic.Timezone
+	if config, err := s.GetSystemConfig(ctx); err == nil && config.Basic.Timezone != "" {
+		timezone = config.Basic.Timezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		timezone, loc = "UTC", time.UTC
+	}
+	_, offset := now.In(loc).Zone()
+
+	return &ServerTime{
+		UnixMillis:    now.UnixMilli(),
+		Timezone:      timezone,
+		OffsetSeconds: offset,
+	}, nil
+}
+
 // GetSystemConfigCategory 获取分类配置
 func (s *SystemService) GetSystemConfigCategory(ctx context.Context, category string) (interface{}, error) {
 	config, err := s.GetSystemConfig(ctx)
@@ -138,10 +287,14 @@ func (s *SystemService) GetSystemConfigCategory(ctx context.Context, category st
 
 // UpdateSystemConfig 更新系统配置
 func (s *SystemService) UpdateSystemConfig(ctx context.Context, updates *SystemConfig) error {
-	config, err := s.GetSystemConfig(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.loadConfigLocked(ctx)
 	if err != nil {
 		return err
 	}
+	config := current.clone()
 
 	// 合并更新
 	if updates.Basic.SiteName != "" {
@@ -155,15 +308,19 @@ func (s *SystemService) UpdateSystemConfig(ctx context.Context, updates *SystemC
 	}
 
 	// 保存配置
-	return s.saveConfig(config)
+	return s.saveConfigLocked(ctx, config)
 }
 
 // UpdateSystemConfigCategory 更新分类配置
 func (s *SystemService) UpdateSystemConfigCategory(ctx context.Context, category string, data interface{}) error {
-	config, err := s.GetSystemConfig(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.loadConfigLocked(ctx)
 	if err != nil {
 		return err
 	}
+	config := current.clone()
 
 	// 将数据转换为 JSON 再解析，以支持部分更新
 	jsonData, err := json.Marshal(data)
@@ -188,23 +345,19 @@ func (s *SystemService) UpdateSystemConfigCategory(ctx context.Context, category
 		return utils.NewError(utils.ErrCodeInvalidInput, "不支持的配置分类")
 	}
 
-	return s.saveConfig(config)
+	return s.saveConfigLocked(ctx, config)
 }
 
-func (s *SystemService) saveConfig(config *SystemConfig) error {
-	// 创建备份
-	backupPath := s.configPath + ".backup"
-	if _, err := os.Stat(s.configPath); err == nil {
-		originalContent, err := ioutil.ReadFile(s.configPath)
-		if err == nil {
-			ioutil.WriteFile(backupPath, originalContent, 0644)
-		}
+// saveConfigLocked 写入配置并更新缓存，调用方需持有写锁
+func (s *SystemService) saveConfigLocked(ctx context.Context, config *SystemConfig) error {
+	// 通知服务商无效时拒绝保存，避免下次启动失败
+	if _, err := notify.New(config.Notification.NotifyConfig(), nil); err != nil {
+		return utils.NewError(utils.ErrCodeInvalidInput, fmt.Sprintf("通知配置无效: %v", err))
 	}
 
-	// 确保目录存在
-	dir := filepath.Dir(s.configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("创建配置目录失败: %v", err))
+	// 创建备份，备份失败时不覆盖当前配置
+	if err := s.store.Backup(ctx, systemConfigName); err != nil {
+		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("备份当前配置失败: %v", err))
 	}
 
 	// 写入配置
@@ -213,13 +366,32 @@ func (s *SystemService) saveConfig(config *SystemConfig) error {
 		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("序列化配置失败: %v", err))
 	}
 
-	if err := ioutil.WriteFile(s.configPath, jsonData, 0644); err != nil {
+	if err := s.store.Write(ctx, systemConfigName, jsonData); err != nil {
+		s.cached = nil
 		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("写入配置文件失败: %v", err))
 	}
 
+	// 写入成功后更新缓存，无法获取修订标识时清空缓存，下次读取重新加载
+	revision, err := s.store.Revision(ctx, systemConfigName)
+	if err != nil {
+		s.cached = nil
+		return nil
+	}
+	s.cached = config
+	s.cachedRevision = revision
+
 	return nil
 }
 
+// clone 深拷贝配置，避免调用方修改缓存
+func (c *SystemConfig) clone() *SystemConfig {
+	copied := *c
+	if c.Security.IPWhitelist != nil {
+		copied.Security.IPWhitelist = append([]string(nil), c.Security.IPWhitelist...)
+	}
+	return &copied
+}
+
 func (s *SystemService) getDefaultConfig() *SystemConfig {
 	return &SystemConfig{
 		Basic: BasicConfig{
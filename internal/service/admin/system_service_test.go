@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/testutil"
+)
+
+// newTestSystemService 创建使用临时目录的系统配置服务
+func newTestSystemService(t *testing.T) *SystemService {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "game-services", "configs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return NewSystemService(NewFileConfigStore(dir))
+}
+
+func TestSystemConfigReadAfterWrite(t *testing.T) {
+	s := newTestSystemService(t)
+	ctx := context.Background()
+
+	if err := s.UpdateSystemConfig(ctx, &SystemConfig{Basic: BasicConfig{SiteName: "first"}}); err != nil {
+		t.Fatalf("UpdateSystemConfig() error = %v", err)
+	}
+	config, err := s.GetSystemConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Basic.SiteName != "first" {
+		t.Errorf("SiteName = %q, want first", config.Basic.SiteName)
+	}
+
+	// 修改返回值不影响缓存
+	config.Basic.SiteName = "mutated"
+	config.Security.IPWhitelist = append(config.Security.IPWhitelist, "10.0.0.1")
+
+	if err := s.UpdateSystemConfigCategory(ctx, "basic", map[string]interface{}{"site_name": "second"}); err != nil {
+		t.Fatalf("UpdateSystemConfigCategory() error = %v", err)
+	}
+	config, err = s.GetSystemConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Basic.SiteName != "second" || len(config.Security.IPWhitelist) != 0 {
+		t.Errorf("config = %+v, want site_name second and no whitelist", config)
+	}
+}
+
+func TestSystemConfigExternalEdit(t *testing.T) {
+	// 两个实例共享同一个 Redis 存储
+	client, _ := testutil.NewCacheClient(t)
+	store := NewRedisConfigStore(client)
+	s := NewSystemService(store)
+	other := NewSystemService(store)
+	ctx := context.Background()
+
+	if err := s.UpdateSystemConfig(ctx, &SystemConfig{Basic: BasicConfig{SiteName: "cached"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSystemConfig(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一个实例修改配置
+	if err := other.UpdateSystemConfigCategory(ctx, "basic", map[string]interface{}{"site_name": "external"}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := s.GetSystemConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Basic.SiteName != "external" {
+		t.Errorf("SiteName = %q, want external", config.Basic.SiteName)
+	}
+
+	// 配置被删除时返回默认配置
+	if err := client.Del(ctx, configStoreKey(systemConfigName)); err != nil {
+		t.Fatal(err)
+	}
+	config, err = s.GetSystemConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Basic.SiteName != s.getDefaultConfig().Basic.SiteName {
+		t.Errorf("SiteName = %q, want default", config.Basic.SiteName)
+	}
+}
+
+func TestGetPublicConfig(t *testing.T) {
+	s := newTestSystemService(t)
+	ctx := context.Background()
+
+	updates := &SystemConfig{Basic: BasicConfig{SiteName: "游戏大厅", SiteLogo: "/logo.png"}}
+	if err := s.UpdateSystemConfig(ctx, updates); err != nil {
+		t.Fatal(err)
+	}
+	err := s.UpdateSystemConfigCategory(ctx, "security", map[string]interface{}{
+		"jwt": map[string]interface{}{"secret": "top-secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := s.GetPublicConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetPublicConfig() error = %v", err)
+	}
+	if public.SiteName != "游戏大厅" || public.SiteLogo != "/logo.png" {
+		t.Errorf("public = %+v", public)
+	}
+	if public.Language == "" || public.Theme == "" {
+		t.Errorf("未配置时应使用默认语言和主题: %+v", public)
+	}
+
+	// 序列化结果只包含站点基础信息
+	data, err := json.Marshal(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"security", "notification", "jwt", "secret", "password_policy", "email"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("公开配置包含敏感字段 %q", key)
+		}
+	}
+	if strings.Contains(string(data), "top-secret") {
+		t.Errorf("公开配置泄露了密钥: %s", data)
+	}
+}
+
+func TestGetServerTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		want     string
+	}{
+		{"默认时区", "", "Asia/Shanghai"},
+		{"配置的时区", "America/New_York", "America/New_York"},
+		{"无效时区回退到 UTC", "Mars/Olympus", "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSystemService(t)
+			ctx := context.Background()
+			if tt.timezone != "" {
+				if err := s.UpdateSystemConfigCategory(ctx, "basic", map[string]interface{}{"timezone": tt.timezone}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := time.Now().UnixMilli()
+			got, err := s.GetServerTime(ctx)
+			if err != nil {
+				t.Fatalf("GetServerTime() error = %v", err)
+			}
+			if got.UnixMillis < before || got.UnixMillis > time.Now().UnixMilli() {
+				t.Errorf("UnixMillis = %d, want around %d", got.UnixMillis, before)
+			}
+			if got.Timezone != tt.want {
+				t.Errorf("Timezone = %q, want %q", got.Timezone, tt.want)
+			}
+			loc, _ := time.LoadLocation(tt.want)
+			if _, offset := time.Now().In(loc).Zone(); got.OffsetSeconds != offset {
+				t.Errorf("OffsetSeconds = %d, want %d", got.OffsetSeconds, offset)
+			}
+		})
+	}
+}
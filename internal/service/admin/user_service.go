@@ -3,17 +3,30 @@ package admin
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/game-apps/internal/model"
 	"github.com/game-apps/internal/repository/mysql"
 	"github.com/game-apps/internal/repository/postgres"
 	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// ImpersonationTTL 模拟登录令牌的有效期
+const ImpersonationTTL = 15 * time.Minute
+
 // UserService 用户管理服务
 type UserService struct {
-	userRepo UserRepository
+	userRepo   UserRepository
+	jwtService *utils.JWTService
+	logger     *zap.Logger
+	notifier   AccountNotifier
+}
+
+// AccountNotifier 账号变更通知接口，邮件渠道未启用时发送为空操作
+type AccountNotifier interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
 }
 
 // UserRepository 用户仓库接口
@@ -24,10 +37,9 @@ type UserRepository interface {
 	List(ctx context.Context, limit, offset int, keyword string, status *string) ([]*model.User, int64, error)
 	Update(ctx context.Context, user *model.User) error
 }
-}
 
 // NewUserService 创建用户管理服务
-func NewUserService(db *gorm.DB, driver string) *UserService {
+func NewUserService(db *gorm.DB, driver string, jwtService *utils.JWTService, logger *zap.Logger, notifier AccountNotifier) *UserService {
 	var userRepo interface {
 		GetByID(ctx context.Context, id uint) (*model.User, error)
 		GetByUsername(ctx context.Context, username string) (*model.User, error)
@@ -43,7 +55,10 @@ func NewUserService(db *gorm.DB, driver string) *UserService {
 	}
 
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		jwtService: jwtService,
+		logger:     logger,
+		notifier:   notifier,
 	}
 }
 
@@ -55,36 +70,15 @@ type GetUserListRequest struct {
 	Status   *string
 }
 
-type GetUserListResponse struct {
-	List     []*model.User `json:"list"`
-	Total    int64         `json:"total"`
-	Page     int           `json:"page"`
-	PageSize int           `json:"page_size"`
-}
-
-func (s *UserService) GetUserList(ctx context.Context, req *GetUserListRequest) (*GetUserListResponse, error) {
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
-	}
-	if req.PageSize > 100 {
-		req.PageSize = 100
-	}
+func (s *UserService) GetUserList(ctx context.Context, req *GetUserListRequest) (*utils.PageResult[*model.User], error) {
+	params := utils.Paginate(req.Page, req.PageSize)
 
-	offset := (req.Page - 1) * req.PageSize
-	users, total, err := s.userRepo.List(ctx, req.PageSize, offset, req.Keyword, req.Status)
+	users, total, err := s.userRepo.List(ctx, params.Limit(), params.Offset(), req.Keyword, req.Status)
 	if err != nil {
 		return nil, utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("获取用户列表失败: %v", err))
 	}
 
-	return &GetUserListResponse{
-		List:     users,
-		Total:    total,
-		Page:     req.Page,
-		PageSize: req.PageSize,
-	}, nil
+	return utils.NewPageResult(users, total, params), nil
 }
 
 // GetUserDetail 获取用户详情
@@ -116,15 +110,22 @@ func (s *UserService) UpdateUser(ctx context.Context, id uint, req *UpdateUserRe
 	}
 
 	if req.Nickname != nil {
+		if err := utils.ValidateMaxLength("nickname", "昵称", *req.Nickname, model.MaxNicknameLength); err != nil {
+			return err
+		}
 		user.Nickname = *req.Nickname
 	}
 	if req.Email != nil {
+		if err := utils.ValidateMaxLength("email", "邮箱", *req.Email, model.MaxEmailLength); err != nil {
+			return err
+		}
 		// 检查邮箱是否已被使用
 		if existingUser, err := s.userRepo.GetByEmail(ctx, *req.Email); err == nil && existingUser.ID != id {
 			return utils.NewError(utils.ErrCodeInvalidInput, "邮箱已被使用")
 		}
 		user.Email = *req.Email
 	}
+	previousStatus := user.Status
 	if req.Status != nil {
 		statusInt := 1
 		if *req.Status == "inactive" {
@@ -137,9 +138,82 @@ func (s *UserService) UpdateUser(ctx context.Context, id uint, req *UpdateUserRe
 		return utils.NewError(utils.ErrCodeInternal, fmt.Sprintf("更新用户失败: %v", err))
 	}
 
+	if user.Status != previousStatus {
+		s.notifyStatusChange(ctx, user)
+	}
+
 	return nil
 }
 
+// notifyStatusChange 账号被禁用或恢复时邮件通知用户，发送失败只记录日志
+func (s *UserService) notifyStatusChange(ctx context.Context, user *model.User) {
+	if s.notifier == nil || user.Email == "" {
+		return
+	}
+
+	subject, body := "账号已恢复", fmt.Sprintf("%s，你的账号已恢复正常，可以重新登录。", user.Username)
+	if user.Status == model.UserStatusDisabled {
+		subject, body = "账号已被禁用", fmt.Sprintf("%s，你的账号已被管理员禁用，如有疑问请联系客服。", user.Username)
+	}
+	if err := s.notifier.SendEmail(ctx, user.Email, subject, body); err != nil {
+		s.logger.Warn("发送账号状态通知失败", zap.Error(err), zap.Uint("user_id", user.ID))
+	}
+}
+
+// ImpersonationResponse 模拟登录响应
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ImpersonateUser 以目标用户身份签发短期令牌，供客服复现问题
+// 只有管理员可以模拟登录，且不能模拟其他管理员，避免借此获得他人的管理权限
+// 令牌带有 impersonated_by 声明，不能刷新，也不能用于删除账号等破坏性操作
+func (s *UserService) ImpersonateUser(ctx context.Context, adminID, targetID uint) (*ImpersonationResponse, error) {
+	if adminID == targetID {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "不能模拟登录自己")
+	}
+
+	operator, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, utils.NewInternalError("模拟登录失败", err)
+	}
+	if operator == nil || operator.Role != model.UserRoleAdmin {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "需要管理员权限")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, targetID)
+	if err == gorm.ErrRecordNotFound || (err == nil && user == nil) {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "用户不存在")
+	}
+	if err != nil {
+		return nil, utils.NewInternalError("模拟登录失败", err)
+	}
+	if user.Role == model.UserRoleAdmin {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "不能模拟登录管理员")
+	}
+
+	token, err := s.jwtService.GenerateImpersonationToken(user.ID, user.Username, adminID, ImpersonationTTL)
+	if err != nil {
+		return nil, utils.NewInternalError("模拟登录失败", err)
+	}
+
+	// 审计日志
+	s.logger.Info("管理员模拟登录用户",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+		zap.Duration("ttl", ImpersonationTTL),
+	)
+
+	return &ImpersonationResponse{
+		Token:     token,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ImpersonationTTL),
+	}, nil
+}
+
 // UpdateUserStatus 更新用户状态
 func (s *UserService) UpdateUserStatus(ctx context.Context, id uint, status string) error {
 	req := &UpdateUserRequest{
@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// memUserRepo 内存用户仓库
+type memUserRepo map[uint]*model.User
+
+func (r memUserRepo) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	user, ok := r[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r memUserRepo) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	for _, user := range r {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r memUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, user := range r {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r memUserRepo) List(ctx context.Context, limit, offset int, keyword string, status *string) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (r memUserRepo) Update(ctx context.Context, user *model.User) error {
+	copied := *user
+	r[user.ID] = &copied
+	return nil
+}
+
+// emailRecorder 记录发送的邮件
+type emailRecorder struct {
+	subjects []string
+}
+
+func (r *emailRecorder) SendEmail(ctx context.Context, to, subject, body string) error {
+	r.subjects = append(r.subjects, subject)
+	return nil
+}
+
+func TestUpdateUserNotifiesStatusChange(t *testing.T) {
+	active, inactive := "active", "inactive"
+
+	tests := []struct {
+		name        string
+		email       string
+		current     int
+		status      *string
+		wantSubject string // 为空表示不发送
+	}{
+		{"禁用账号", "a@example.com", model.UserStatusActive, &inactive, "账号已被禁用"},
+		{"恢复账号", "a@example.com", model.UserStatusDisabled, &active, "账号已恢复"},
+		{"状态未变化", "a@example.com", model.UserStatusActive, &active, ""},
+		{"未修改状态", "a@example.com", model.UserStatusActive, nil, ""},
+		{"没有邮箱", "", model.UserStatusActive, &inactive, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &emailRecorder{}
+			users := memUserRepo{1: {ID: 1, Username: "alice", Email: tt.email, Status: tt.current}}
+			s := &UserService{userRepo: users, logger: zap.NewNop(), notifier: notifier}
+
+			if err := s.UpdateUser(context.Background(), 1, &UpdateUserRequest{Status: tt.status}); err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if tt.wantSubject == "" {
+				if len(notifier.subjects) != 0 {
+					t.Errorf("sent %v, want none", notifier.subjects)
+				}
+				return
+			}
+			if len(notifier.subjects) != 1 || notifier.subjects[0] != tt.wantSubject {
+				t.Errorf("sent %v, want [%s]", notifier.subjects, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestImpersonateUser(t *testing.T) {
+	users := memUserRepo{
+		1: {ID: 1, Username: "root", Role: model.UserRoleAdmin},
+		2: {ID: 2, Username: "alice", Role: model.UserRolePlayer},
+		3: {ID: 3, Username: "ops", Role: model.UserRoleAdmin},
+	}
+	s := &UserService{
+		userRepo:   users,
+		jwtService: utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0),
+		logger:     zap.NewNop(),
+	}
+
+	tests := []struct {
+		name     string
+		adminID  uint
+		targetID uint
+		wantCode int // 为 0 表示成功
+	}{
+		{"管理员模拟普通用户", 1, 2, 0},
+		{"模拟自己", 1, 1, utils.ErrCodeInvalidInput},
+		{"非管理员发起", 2, 1, utils.ErrCodeForbidden},
+		{"发起者不存在", 9, 2, utils.ErrCodeForbidden},
+		{"模拟其他管理员", 1, 3, utils.ErrCodeForbidden},
+		{"目标不存在", 1, 9, utils.ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.ImpersonateUser(context.Background(), tt.adminID, tt.targetID)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("ImpersonateUser() error = %v", err)
+				}
+				if resp.UserID != tt.targetID || resp.Token == "" {
+					t.Errorf("ImpersonateUser() = %+v", resp)
+				}
+				return
+			}
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+				t.Errorf("ImpersonateUser() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
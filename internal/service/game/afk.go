@@ -0,0 +1,198 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"go.uber.org/zap"
+)
+
+// AFKPolicy 玩家断线后的暂离策略
+// 只有启用回合时限的房间会跳过暂离玩家的回合，未限时的房间仅发布 player_afk / player_back 事件
+type AFKPolicy struct {
+	GracePeriod time.Duration // 断线超过该时间仍未重连时标记为暂离，<= 0 表示不标记
+}
+
+// ConnectionPresence 记录用户在所有实例上的 WebSocket 连接，用于判断断线玩家是否已连到其他实例
+// 每个实例只增减自己的记录，SyncConnections 由心跳定期调用，用本实例的实际连接覆盖记录并续期
+type ConnectionPresence interface {
+	AddConnection(ctx context.Context, userID uint) error
+	RemoveConnection(ctx context.Context, userID uint) error
+	HasConnection(ctx context.Context, userID uint) (bool, error)
+	SyncConnections(ctx context.Context, userIDs []uint) error
+}
+
+const (
+	// ConnectionHeartbeatInterval 刷新本实例连接记录的间隔
+	ConnectionHeartbeatInterval = 10 * time.Second
+	// ConnectionTTL 连接记录的有效期，实例停止心跳（如崩溃）超过该时间后其记录不再计入
+	ConnectionTTL = 3 * ConnectionHeartbeatInterval
+)
+
+// LocalConnections 本实例上已建立 WebSocket 连接的用户
+type LocalConnections interface {
+	ConnectedUserIDs() []uint
+}
+
+// ConnectionHeartbeat 连接记录心跳后台任务
+// 连接和断开的通知在独立的协程中执行，可能乱序到达；心跳按本实例的实际连接覆盖记录，偏差最多保留一个间隔
+type ConnectionHeartbeat struct {
+	presence ConnectionPresence
+	local    LocalConnections
+}
+
+// NewConnectionHeartbeat 创建连接记录心跳任务
+func NewConnectionHeartbeat(presence ConnectionPresence, local LocalConnections) *ConnectionHeartbeat {
+	return &ConnectionHeartbeat{presence: presence, local: local}
+}
+
+// Name 后台任务名称
+func (h *ConnectionHeartbeat) Name() string {
+	return "connection_heartbeat"
+}
+
+// Run 刷新一次本实例的连接记录
+func (h *ConnectionHeartbeat) Run(ctx context.Context) error {
+	return h.presence.SyncConnections(ctx, h.local.ConnectedUserIDs())
+}
+
+// UserDisconnected 玩家断开全部连接后开始计时，宽限期内重连（任一实例）则不标记暂离
+func (s *ProcessService) UserDisconnected(userID uint) {
+	if err := s.connections.RemoveConnection(context.Background(), userID); err != nil {
+		s.logger.Warn("更新用户连接数失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
+	if s.afkPolicy.GracePeriod <= 0 {
+		return
+	}
+
+	s.afkMu.Lock()
+	defer s.afkMu.Unlock()
+	if timer, ok := s.afkTimers[userID]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(s.afkPolicy.GracePeriod, func() {
+		s.afkMu.Lock()
+		if s.afkTimers[userID] != timer {
+			// 已重连或重新开始计时
+			s.afkMu.Unlock()
+			return
+		}
+		delete(s.afkTimers, userID)
+		s.afkMu.Unlock()
+
+		// 计时只在本实例进行，玩家可能已重连到其他实例
+		ctx := context.Background()
+		connected, err := s.connections.HasConnection(ctx, userID)
+		if err != nil {
+			s.logger.Warn("查询用户连接状态失败", zap.Error(err), zap.Uint("user_id", userID))
+			return
+		}
+		if connected {
+			return
+		}
+		s.markAFK(ctx, userID)
+	})
+	s.afkTimers[userID] = timer
+}
+
+// UserConnected 玩家重新连接，取消暂离计时并清除暂离标记
+func (s *ProcessService) UserConnected(userID uint) {
+	if err := s.connections.AddConnection(context.Background(), userID); err != nil {
+		s.logger.Warn("更新用户连接数失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
+
+	s.afkMu.Lock()
+	if timer, ok := s.afkTimers[userID]; ok {
+		timer.Stop()
+		delete(s.afkTimers, userID)
+	}
+	s.afkMu.Unlock()
+
+	s.clearAFK(context.Background(), userID)
+}
+
+// markAFK 将进行中游戏的在座玩家标记为暂离并发布 player_afk 事件，正轮到该玩家时跳过其回合
+func (s *ProcessService) markAFK(ctx context.Context, userID uint) {
+	player, err := s.roomPlayerRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Warn("查询用户所在房间失败", zap.Error(err), zap.Uint("user_id", userID))
+		return
+	}
+	if player == nil || player.IsSpectator || player.Position == nil {
+		return
+	}
+	roomID := player.RoomID
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil || room == nil || room.Status != model.RoomStatusPlaying {
+		return
+	}
+
+	if err := s.redisRoomRepo.SetPlayerAFK(ctx, roomID, userID); err != nil {
+		s.logger.Warn("标记玩家暂离失败", zap.Error(err), zap.Uint("room_id", roomID), zap.Uint("user_id", userID))
+		return
+	}
+	s.logger.Info("玩家断线超时，标记为暂离", zap.Uint("room_id", roomID), zap.Uint("user_id", userID))
+	event := &GameEvent{
+		Type:      "player_afk",
+		RoomID:    roomID,
+		UserID:    userID,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.PublishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("获取游戏状态失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return
+	}
+	if turn := parseTurnState(state); turn.active && turn.userID == userID {
+		if err := s.advanceTurn(ctx, roomID, userID, false); err != nil {
+			s.logger.Error("跳过暂离玩家的回合失败", zap.Error(err), zap.Uint("room_id", roomID))
+		}
+	}
+}
+
+// clearAFK 清除玩家的暂离标记并发布 player_back 事件，玩家未处于暂离状态时不处理
+func (s *ProcessService) clearAFK(ctx context.Context, userID uint) {
+	player, err := s.roomPlayerRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Warn("查询用户所在房间失败", zap.Error(err), zap.Uint("user_id", userID))
+		return
+	}
+	if player == nil {
+		return
+	}
+	roomID := player.RoomID
+	afk, err := s.redisRoomRepo.IsPlayerAFK(ctx, roomID, userID)
+	if err != nil || !afk {
+		return
+	}
+
+	if err := s.redisRoomRepo.ClearPlayerAFK(ctx, roomID, userID); err != nil {
+		s.logger.Warn("清除玩家暂离状态失败", zap.Error(err), zap.Uint("room_id", roomID), zap.Uint("user_id", userID))
+		return
+	}
+	event := &GameEvent{
+		Type:      "player_back",
+		RoomID:    roomID,
+		UserID:    userID,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.PublishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+}
+
+// afkPlayers 获取房间内暂离的玩家，查询失败时视为没有暂离玩家
+func (s *ProcessService) afkPlayers(ctx context.Context, roomID uint) map[uint]bool {
+	afk, err := s.redisRoomRepo.GetAFKPlayers(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("获取暂离玩家失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return map[uint]bool{}
+	}
+	return afk
+}
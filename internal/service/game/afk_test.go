@@ -0,0 +1,297 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestNextActiveInTurnOrder(t *testing.T) {
+	order := []uint{10, 20, 30, 40}
+
+	tests := []struct {
+		name    string
+		current uint
+		afk     map[uint]bool
+		want    uint
+	}{
+		{"下一位", 10, nil, 20},
+		{"最后一位之后回到第一位", 40, nil, 10},
+		{"当前玩家已离开时从头开始", 99, nil, 10},
+		{"游戏开始时从第一位开始", 0, nil, 10},
+		{"跳过暂离的下一位", 10, map[uint]bool{20: true}, 30},
+		{"跳过连续多位暂离玩家", 10, map[uint]bool{20: true, 30: true}, 40},
+		{"跳过时回绕", 30, map[uint]bool{40: true, 10: true}, 20},
+		{"游戏开始时跳过暂离的第一位", 0, map[uint]bool{10: true}, 20},
+		{"只剩自己在线时轮回自己", 20, map[uint]bool{10: true, 30: true, 40: true}, 20},
+		{"所有人暂离时按正常顺序", 20, map[uint]bool{10: true, 20: true, 30: true, 40: true}, 30},
+		{"暂离玩家返回后恢复轮转", 10, map[uint]bool{20: false}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextActiveInTurnOrder(order, tt.current, tt.afk); got != tt.want {
+				t.Errorf("nextActiveInTurnOrder(%d) = %d, want %d", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAFKSkipsTurn(t *testing.T) {
+	const firstID, secondID, thirdID = 1, 2, 3
+	const grace = 20 * time.Millisecond
+
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, roomPlayerRepo, testutil.NewEventRepo(roomRepo), redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{TimeLimit: time.Minute}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{GracePeriod: grace}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	ctx := context.Background()
+
+	room := &model.Room{OwnerID: firstID, Status: model.RoomStatusWaiting}
+	roomRepo.Create(ctx, room)
+	for i, userID := range []uint{firstID, secondID, thirdID} {
+		position := i
+		roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: userID, Position: &position})
+		redisRoomRepo.AddRoomPlayer(ctx, room.ID, userID)
+	}
+	if err := s.StartGame(ctx, room.ID, firstID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+
+	currentTurn := func() uint {
+		t.Helper()
+		state, err := redisRoomRepo.GetRoomState(ctx, room.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parseTurnState(state).userID
+	}
+	waitAFK := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			afk, err := redisRoomRepo.IsPlayerAFK(ctx, room.ID, secondID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if afk == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("IsPlayerAFK() = %v, want %v", afk, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	move := &SubmitMoveRequest{Move: json.RawMessage(`{"x":1}`)}
+
+	// 断线超过宽限期后标记暂离，轮到其回合时被跳过
+	s.UserDisconnected(secondID)
+	waitAFK(true)
+	move.Seq = 1
+	if _, err := s.SubmitMove(ctx, room.ID, firstID, move); err != nil {
+		t.Fatalf("SubmitMove() error = %v", err)
+	}
+	if got := currentTurn(); got != thirdID {
+		t.Fatalf("暂离后回合 = %d, want %d", got, thirdID)
+	}
+
+	// 重连后恢复轮转
+	s.UserConnected(secondID)
+	waitAFK(false)
+	move.Seq = 2
+	if _, err := s.SubmitMove(ctx, room.ID, thirdID, move); err != nil {
+		t.Fatalf("SubmitMove() error = %v", err)
+	}
+	move.Seq = 3
+	if _, err := s.SubmitMove(ctx, room.ID, firstID, move); err != nil {
+		t.Fatalf("SubmitMove() error = %v", err)
+	}
+	if got := currentTurn(); got != secondID {
+		t.Fatalf("重连后回合 = %d, want %d", got, secondID)
+	}
+
+	events, err := s.EventsSince(ctx, room.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var afkEvents []string
+	for _, event := range events {
+		if event.Type == "player_afk" || event.Type == "player_back" {
+			if event.UserID != secondID {
+				t.Errorf("%s event user = %d, want %d", event.Type, event.UserID, secondID)
+			}
+			afkEvents = append(afkEvents, event.Type)
+		}
+	}
+	if len(afkEvents) != 2 || afkEvents[0] != "player_afk" || afkEvents[1] != "player_back" {
+		t.Errorf("events = %v, want [player_afk player_back]", afkEvents)
+	}
+}
+
+func TestUserDisconnectedWithinGrace(t *testing.T) {
+	const userID = 2
+
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, roomPlayerRepo, testutil.NewEventRepo(roomRepo), redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{GracePeriod: 20 * time.Millisecond}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	ctx := context.Background()
+
+	room := &model.Room{OwnerID: 1, Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+	position := 0
+	roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: userID, Position: &position})
+
+	// 宽限期内重连不标记暂离
+	s.UserDisconnected(userID)
+	s.UserConnected(userID)
+	time.Sleep(50 * time.Millisecond)
+	if afk, _ := redisRoomRepo.IsPlayerAFK(ctx, room.ID, userID); afk {
+		t.Error("宽限期内重连不应标记暂离")
+	}
+}
+
+// fakePresence 记录连接数变化的 ConnectionPresence
+type fakePresence struct {
+	mu          sync.Mutex
+	connections map[uint]int
+	remote      bool  // 用户在其他实例上仍有连接
+	err         error // HasConnection 返回的错误
+}
+
+func (p *fakePresence) AddConnection(ctx context.Context, userID uint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connections[userID]++
+	return nil
+}
+
+func (p *fakePresence) RemoveConnection(ctx context.Context, userID uint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connections[userID]--
+	return nil
+}
+
+func (p *fakePresence) HasConnection(ctx context.Context, userID uint) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.remote || p.connections[userID] > 0, p.err
+}
+
+func (p *fakePresence) SyncConnections(ctx context.Context, userIDs []uint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connections = make(map[uint]int, len(userIDs))
+	for _, userID := range userIDs {
+		p.connections[userID] = 1
+	}
+	return nil
+}
+
+// localConnections 固定的本实例连接
+type localConnections []uint
+
+func (c localConnections) ConnectedUserIDs() []uint {
+	return c
+}
+
+func TestConnectionHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	// 用户 1 的断开通知丢失，用户 2 的断开通知晚于重连到达
+	presence := &fakePresence{connections: map[uint]int{1: 1, 2: 0}}
+	heartbeat := NewConnectionHeartbeat(presence, localConnections{2, 3})
+	if err := heartbeat.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for userID, want := range map[uint]bool{1: false, 2: true, 3: true} {
+		if connected, _ := presence.HasConnection(ctx, userID); connected != want {
+			t.Errorf("HasConnection(%d) = %v, want %v", userID, connected, want)
+		}
+	}
+}
+
+// lookupRecorder 记录查询玩家所在房间的调用，标记或清除暂离时都会先查询
+type lookupRecorder struct {
+	RoomPlayerRepository
+	lookups chan uint
+}
+
+func (r *lookupRecorder) GetActiveByUserID(ctx context.Context, userID uint) (*model.RoomPlayer, error) {
+	r.lookups <- userID
+	return nil, nil
+}
+
+func TestUserDisconnectedMarksAFK(t *testing.T) {
+	const userID = 7
+	const grace = 20 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		grace       time.Duration
+		remote      bool
+		presenceErr error
+		reconnect   bool // 宽限期内重连
+		wantMark    bool // 宽限期后是否尝试标记暂离
+	}{
+		{"宽限期内未重连", grace, false, nil, false, true},
+		{"宽限期内重连", grace, false, nil, true, false},
+		{"已连接到其他实例", grace, true, nil, false, false},
+		{"查询连接状态失败时不标记", grace, false, errors.New("redis down"), false, false},
+		{"未启用暂离", 0, false, nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presence := &fakePresence{connections: map[uint]int{userID: 1}, remote: tt.remote, err: tt.presenceErr}
+			players := &lookupRecorder{lookups: make(chan uint, 4)}
+			s := &ProcessService{
+				roomPlayerRepo: players,
+				logger:         zap.NewNop(),
+				afkPolicy:      AFKPolicy{GracePeriod: tt.grace},
+				connections:    presence,
+				afkTimers:      make(map[uint]*time.Timer),
+			}
+
+			s.UserDisconnected(userID)
+			if tt.reconnect {
+				s.UserConnected(userID)
+				// 重连时清除暂离标记也会查询一次
+				<-players.lookups
+			}
+
+			select {
+			case got := <-players.lookups:
+				if !tt.wantMark {
+					t.Fatalf("不应标记暂离，但查询了用户 %d", got)
+				}
+			case <-time.After(5 * grace):
+				if tt.wantMark {
+					t.Fatalf("宽限期后未标记暂离")
+				}
+			}
+
+			presence.mu.Lock()
+			connections := presence.connections[userID]
+			presence.mu.Unlock()
+			wantConnections := 0
+			if tt.reconnect {
+				wantConnections = 1
+			}
+			if connections != wantConnections {
+				t.Errorf("connections = %d, want %d", connections, wantConnections)
+			}
+		})
+	}
+}
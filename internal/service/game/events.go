@@ -0,0 +1,202 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// maxEventsSince 单次按游标拉取的最大事件数
+const maxEventsSince = 500
+
+// EventRetention 房间事件历史的保留策略
+type EventRetention struct {
+	MaxLength int           // 每个房间保留的最近事件数
+	TTL       time.Duration // 最后一次追加后的保留时间
+}
+
+// 未配置保留策略时的默认值
+const (
+	defaultEventMaxLength = 500
+	defaultEventTTL       = 24 * time.Hour
+)
+
+// withDefaults 补全未配置的保留参数
+func (r EventRetention) withDefaults() EventRetention {
+	if r.MaxLength <= 0 {
+		r.MaxLength = defaultEventMaxLength
+	}
+	if r.TTL <= 0 {
+		r.TTL = defaultEventTTL
+	}
+	return r
+}
+
+// stampEventSeq 为事件分配房间内递增的序号
+func stampEventSeq(ctx context.Context, redisRoomRepo *redis.RoomRepository, event *GameEvent) error {
+	seq, err := redisRoomRepo.NextEventSeq(ctx, event.RoomID)
+	if err != nil {
+		return err
+	}
+	event.Seq = seq
+	return nil
+}
+
+// recordEvent 为事件分配序号并记录到房间事件列表，返回序列化后的事件
+func recordEvent(ctx context.Context, redisRoomRepo *redis.RoomRepository, retention EventRetention, event *GameEvent) ([]byte, error) {
+	if err := stampEventSeq(ctx, redisRoomRepo, event); err != nil {
+		return nil, err
+	}
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := redisRoomRepo.AppendRoomEvent(ctx, event.RoomID, event.Seq, eventData, retention.MaxLength, retention.TTL); err != nil {
+		return nil, err
+	}
+	return eventData, nil
+}
+
+// CheckRoomMember 检查用户是否为房间成员（玩家或观战者），非成员返回无权限错误
+func (s *ProcessService) CheckRoomMember(ctx context.Context, roomID, userID uint) error {
+	member, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return utils.NewInternalError("获取房间事件失败", err)
+	}
+	if member == nil {
+		return utils.NewError(utils.ErrCodeForbidden, "不在房间中")
+	}
+	return nil
+}
+
+// EventsSince 获取房间内序号大于 cursor 的事件（按序号升序），用于断线重连后补齐事件
+// 返回的事件数超过上限时只返回最早的一批，客户端以最后一条的序号作为新游标继续拉取
+// 再来一局不会重置事件序号；序号随事件历史过期后会从 1 重新开始，cursor 大于现有的全部序号时从头返回
+func (s *ProcessService) EventsSince(ctx context.Context, roomID uint, cursor int64) ([]*GameEvent, error) {
+	payloads, err := s.redisRoomRepo.GetRoomEvents(ctx, roomID)
+	if err != nil {
+		s.logger.Error("获取房间事件失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return nil, utils.NewInternalError("获取房间事件失败", err)
+	}
+
+	all := make([]*GameEvent, 0, len(payloads))
+	var maxSeq int64
+	for _, payload := range payloads {
+		var event GameEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			s.logger.Warn("解析房间事件失败", zap.Error(err), zap.Uint("room_id", roomID))
+			continue
+		}
+		all = append(all, &event)
+		if event.Seq > maxSeq {
+			maxSeq = event.Seq
+		}
+	}
+	if maxSeq > 0 && cursor > maxSeq {
+		cursor = 0
+	}
+
+	events := make([]*GameEvent, 0, len(all))
+	for _, event := range all {
+		if event.Seq > cursor {
+			events = append(events, event)
+		}
+	}
+
+	// 并发发布时追加顺序可能与序号顺序不一致
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Seq < events[j].Seq
+	})
+	if len(events) > maxEventsSince {
+		events = events[:maxEventsSince]
+	}
+	return events, nil
+}
+
+// EventSender 向指定用户推送消息，并断开本实例上被踢下线用户的连接
+type EventSender interface {
+	BroadcastToUsers(userIDs []uint, message interface{})
+	KickUser(userID uint, reason string)
+}
+
+// EventTypeUserKicked 踢下线事件，不属于任何房间，不分配序号也不记录到房间事件历史
+const EventTypeUserKicked = "user_kicked"
+
+// KickUser 通过事件频道通知所有实例断开用户的连接（如登出所有设备）
+// 用户的连接可能在任意实例上，只断开本实例的连接不够
+func (s *ProcessService) KickUser(userID uint, reason string) {
+	event := &GameEvent{
+		Type:      EventTypeUserKicked,
+		UserID:    userID,
+		Data:      map[string]interface{}{"reason": reason},
+		Timestamp: time.Now().Unix(),
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("序列化事件失败", zap.Error(err))
+		return
+	}
+	if _, err := s.cacheClient.Publish(context.Background(), s.eventChannel, data); err != nil {
+		s.logger.Error("发布踢下线事件失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
+}
+
+// eventResubscribeDelay 订阅中断（如 Redis 重连）后重新订阅的间隔
+const eventResubscribeDelay = time.Second
+
+// ForwardEvents 订阅游戏事件并推送给本实例上连接的房间成员，订阅中断时自动重新订阅，直到 ctx 结束
+// 每个实例都需要运行，事件通过 Redis 广播到所有实例
+func (s *ProcessService) ForwardEvents(ctx context.Context, sender EventSender) {
+	for ctx.Err() == nil {
+		events, err := s.SubscribeEvents(ctx)
+		if err != nil {
+			s.logger.Error("订阅游戏事件失败", zap.Error(err))
+		} else {
+			for event := range events {
+				s.forwardEvent(ctx, sender, event)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(eventResubscribeDelay):
+		}
+	}
+}
+
+// forwardEvent 将事件推送给房间内的玩家，踢下线事件断开本实例上该用户的连接
+func (s *ProcessService) forwardEvent(ctx context.Context, sender EventSender, event *GameEvent) {
+	if event.Type == EventTypeUserKicked {
+		reason, _ := event.Data["reason"].(string)
+		sender.KickUser(event.UserID, reason)
+		return
+	}
+
+	members, err := s.redisRoomRepo.GetRoomPlayers(ctx, event.RoomID)
+	if err != nil {
+		s.logger.Warn("获取房间玩家失败", zap.Error(err), zap.Uint("room_id", event.RoomID))
+		return
+	}
+
+	userIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			userIDs = append(userIDs, uint(id))
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	sender.BroadcastToUsers(userIDs, map[string]interface{}{
+		"type":    event.Type,
+		"room_id": event.RoomID,
+		"data":    event,
+	})
+}
@@ -0,0 +1,168 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestEventsSince(t *testing.T) {
+	s, _, _ := newTestProcessService(t)
+	ctx := context.Background()
+	const roomID, otherRoomID = 1, 2
+
+	for i := 0; i < 3; i++ {
+		if err := s.PublishEvent(ctx, &GameEvent{Type: "game_move", RoomID: roomID, Timestamp: time.Now().Unix()}); err != nil {
+			t.Fatalf("PublishEvent() error = %v", err)
+		}
+	}
+	if err := s.PublishEvent(ctx, &GameEvent{Type: "game_move", RoomID: otherRoomID}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		roomID   uint
+		cursor   int64
+		wantSeqs []int64
+	}{
+		{"从头拉取", roomID, 0, []int64{1, 2, 3}},
+		{"从游标之后拉取", roomID, 1, []int64{2, 3}},
+		{"已是最新", roomID, 3, nil},
+		{"游标超过现有序号时从头拉取", roomID, 10, []int64{1, 2, 3}},
+		{"其他房间序号独立", otherRoomID, 0, []int64{1}},
+		{"没有事件的房间", 99, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := s.EventsSince(ctx, tt.roomID, tt.cursor)
+			if err != nil {
+				t.Fatalf("EventsSince() error = %v", err)
+			}
+			if len(events) != len(tt.wantSeqs) {
+				t.Fatalf("EventsSince() returned %d events, want %v", len(events), tt.wantSeqs)
+			}
+			for i, event := range events {
+				if event.Seq != tt.wantSeqs[i] || event.RoomID != tt.roomID {
+					t.Errorf("events[%d] = %+v, want seq %d", i, event, tt.wantSeqs[i])
+				}
+			}
+		})
+	}
+
+	// 房间状态记录最新的事件游标
+	view, err := s.GetGameState(ctx, roomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.LastEventSeq != 3 {
+		t.Errorf("LastEventSeq = %d, want 3", view.LastEventSeq)
+	}
+}
+
+func TestCheckRoomMember(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+	s := NewProcessService(roomRepo, roomPlayerRepo, testutil.NewEventRepo(roomRepo), redis.NewRoomRepository(repo), redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	ctx := context.Background()
+	const playerID, spectatorID, outsiderID = 1, 2, 3
+
+	room := &model.Room{OwnerID: playerID}
+	roomRepo.Create(ctx, room)
+	roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: playerID})
+	roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: spectatorID, IsSpectator: true})
+
+	if err := s.CheckRoomMember(ctx, room.ID, playerID); err != nil {
+		t.Errorf("玩家 CheckRoomMember() error = %v", err)
+	}
+	if err := s.CheckRoomMember(ctx, room.ID, spectatorID); err != nil {
+		t.Errorf("观战者 CheckRoomMember() error = %v", err)
+	}
+	assertErrCode(t, s.CheckRoomMember(ctx, room.ID, outsiderID), utils.ErrCodeForbidden)
+}
+
+// recordingSender 记录推送的消息和踢下线的用户
+type recordingSender struct {
+	userIDs []uint
+	message interface{}
+	kicked  map[uint]string
+}
+
+func (r *recordingSender) BroadcastToUsers(userIDs []uint, message interface{}) {
+	r.userIDs = userIDs
+	r.message = message
+}
+
+func (r *recordingSender) KickUser(userID uint, reason string) {
+	if r.kicked == nil {
+		r.kicked = make(map[uint]string)
+	}
+	r.kicked[userID] = reason
+}
+
+func TestForwardEvent(t *testing.T) {
+	s, _, redisRoomRepo := newTestProcessService(t)
+	ctx := context.Background()
+	const roomID = 1
+
+	redisRoomRepo.AddRoomPlayer(ctx, roomID, 1)
+	redisRoomRepo.AddRoomPlayer(ctx, roomID, 2)
+
+	sender := &recordingSender{}
+	s.forwardEvent(ctx, sender, &GameEvent{Type: "game_end", RoomID: roomID})
+	if len(sender.userIDs) != 2 {
+		t.Fatalf("推送用户 = %v，期望房间内两名玩家", sender.userIDs)
+	}
+	message, ok := sender.message.(map[string]interface{})
+	if !ok || message["type"] != "game_end" || message["room_id"] != uint(roomID) {
+		t.Errorf("消息 = %+v", sender.message)
+	}
+
+	// 房间内没有玩家时不推送
+	empty := &recordingSender{}
+	s.forwardEvent(ctx, empty, &GameEvent{Type: "game_end", RoomID: 99})
+	if empty.message != nil {
+		t.Errorf("空房间不应推送，got %+v", empty.message)
+	}
+	// 踢下线事件断开本实例上该用户的连接，不按房间推送
+	kicked := &recordingSender{}
+	s.forwardEvent(ctx, kicked, &GameEvent{Type: EventTypeUserKicked, UserID: 7, Data: map[string]interface{}{"reason": "登出"}})
+	if kicked.kicked[7] != "登出" || kicked.message != nil {
+		t.Errorf("kicked = %v, message = %+v，期望只断开用户 7", kicked.kicked, kicked.message)
+	}
+}
+
+func TestKickUserPublishesEvent(t *testing.T) {
+	s, _, redisRoomRepo := newTestProcessService(t)
+	ctx := context.Background()
+
+	pubsub := redisRoomRepo.Client().Subscribe(ctx, "game:events")
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 事件经频道广播到所有实例，由各实例的 ForwardEvents 断开本地连接
+	s.KickUser(7, "账号已在所有设备登出")
+	msgCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msg, err := pubsub.ReceiveMessage(msgCtx)
+	if err != nil {
+		t.Fatalf("ReceiveMessage() error = %v", err)
+	}
+	var event GameEvent
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypeUserKicked || event.UserID != 7 || event.Data["reason"] != "账号已在所有设备登出" || event.Seq != 0 {
+		t.Errorf("event = %+v", event)
+	}
+}
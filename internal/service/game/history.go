@@ -0,0 +1,66 @@
+package game
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"go.uber.org/zap"
+)
+
+// participantIDs 获取游戏参与者，合并结果中的玩家和 Redis 中记录的房间玩家
+func (s *ProcessService) participantIDs(ctx context.Context, roomID uint, results map[uint]interface{}) []uint {
+	seen := make(map[uint]struct{}, len(results))
+	ids := make([]uint, 0, len(results))
+	add := func(id uint) {
+		if _, ok := seen[id]; ok || id == 0 {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	for userID := range results {
+		add(userID)
+	}
+
+	players, err := s.redisRoomRepo.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("获取房间玩家失败，仅按结果记录对局", zap.Error(err), zap.Uint("room_id", roomID))
+	}
+	for _, player := range players {
+		if id, err := strconv.ParseUint(player, 10, 64); err == nil {
+			add(uint(id))
+		}
+	}
+
+	return ids
+}
+
+// newGameHistories 为每个参与者生成对局记录
+// 玩家结果为对象时读取其中的 won 和 score 字段，缺失时记为未获胜、0 分
+func newGameHistories(room *model.Room, participants []uint, results map[uint]interface{}, playedAt time.Time) []*model.GameHistory {
+	histories := make([]*model.GameHistory, 0, len(participants))
+	for _, userID := range participants {
+		history := &model.GameHistory{
+			UserID:   userID,
+			RoomID:   room.ID,
+			RoomName: room.Name,
+			GameType: room.GameType,
+			PlayedAt: playedAt,
+		}
+		if result, ok := results[userID].(map[string]interface{}); ok {
+			history.Won, _ = result["won"].(bool)
+			if score, ok := result["score"].(float64); ok {
+				history.Score = int64(score)
+			} else if score, ok := result["score"].(int64); ok {
+				history.Score = score
+			} else if score, ok := result["score"].(int); ok {
+				history.Score = int64(score)
+			}
+		}
+		histories = append(histories, history)
+	}
+	return histories
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestEndGameRecordsHistory(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	eventRepo := testutil.NewEventRepo(roomRepo)
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, testutil.NewRoomPlayerRepo(roomRepo), eventRepo, redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	ctx := context.Background()
+
+	room := &model.Room{OwnerID: 1, Name: "决赛", GameType: "chess", Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+	// 玩家 3 在房间中但结果里没有记录
+	for _, userID := range []uint{1, 2, 3} {
+		redisRoomRepo.AddRoomPlayer(ctx, room.ID, userID)
+	}
+
+	results := map[uint]interface{}{
+		1: map[string]interface{}{"won": true, "score": float64(120)},
+		2: map[string]interface{}{"won": false, "score": 80},
+	}
+	if err := s.EndGame(ctx, room.ID, 1, results); err != nil {
+		t.Fatalf("EndGame() error = %v", err)
+	}
+
+	histories := eventRepo.Histories()
+	sort.Slice(histories, func(i, j int) bool { return histories[i].UserID < histories[j].UserID })
+	want := []model.GameHistory{
+		{UserID: 1, Won: true, Score: 120},
+		{UserID: 2, Won: false, Score: 80},
+		{UserID: 3, Won: false, Score: 0},
+	}
+	if len(histories) != len(want) {
+		t.Fatalf("记录了 %d 条对局，期望 %d 条", len(histories), len(want))
+	}
+	for i, history := range histories {
+		if history.UserID != want[i].UserID || history.Won != want[i].Won || history.Score != want[i].Score {
+			t.Errorf("对局记录 = %+v, want %+v", history, want[i])
+		}
+		if history.RoomID != room.ID || history.RoomName != "决赛" || history.GameType != "chess" || history.PlayedAt.IsZero() {
+			t.Errorf("对局记录缺少房间信息: %+v", history)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// OutboxRelayInterval 发件箱投递任务的执行间隔
+const OutboxRelayInterval = time.Second
+
+// 发件箱投递参数
+const (
+	outboxBatchSize   = 100
+	outboxMaxAttempts = 10
+	outboxMaxBackoff  = 5 * time.Minute
+	outboxClaimLease  = 30 * time.Second // 认领后其他实例不会投递的时间，需远大于一批的投递耗时
+)
+
+// EventRepository 事件发件箱仓库接口
+type EventRepository interface {
+	SaveGameEnd(ctx context.Context, room *model.Room, event *model.OutboxEvent, histories []*model.GameHistory) error
+	ClaimPending(ctx context.Context, maxAttempts, limit int, lease time.Duration) ([]*model.OutboxEvent, error)
+	MarkSent(ctx context.Context, id uint) error
+	MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error
+}
+
+// newOutboxEvent 根据游戏事件创建待投递事件
+func newOutboxEvent(channel string, event *GameEvent) (*model.OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		Channel:       channel,
+		Type:          event.Type,
+		RoomID:        event.RoomID,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// OutboxRelay 将发件箱中的事件投递到 Redis，保证至少一次投递
+type OutboxRelay struct {
+	eventRepo   EventRepository
+	cacheClient *cache.Client
+	logger      *zap.Logger
+}
+
+// NewOutboxRelay 创建发件箱投递任务
+func NewOutboxRelay(eventRepo EventRepository, cacheClient *cache.Client, logger *zap.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		eventRepo:   eventRepo,
+		cacheClient: cacheClient,
+		logger:      logger,
+	}
+}
+
+// Name 后台任务名称
+func (r *OutboxRelay) Name() string {
+	return "outbox_relay"
+}
+
+// Run 认领并投递一批到期事件，单个事件投递失败只记录重试时间，不作为任务失败
+// 多个实例同时运行时各自认领不同的事件
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	events, err := r.eventRepo.ClaimPending(ctx, outboxMaxAttempts, outboxBatchSize, outboxClaimLease)
+	if err != nil {
+		return fmt.Errorf("查询待投递事件失败: %w", err)
+	}
+
+	for _, event := range events {
+		receivers, err := r.cacheClient.Publish(ctx, event.Channel, event.Payload)
+		// 没有订阅者时消息会被 Redis 直接丢弃，视为未投递
+		if err == nil && receivers == 0 {
+			err = errNoSubscribers
+		}
+		if err != nil {
+			r.logger.Warn("投递事件失败",
+				zap.Error(err),
+				zap.Uint("event_id", event.ID),
+				zap.Int("attempts", event.Attempts+1),
+			)
+			nextAttemptAt := time.Now().Add(outboxBackoff(event.Attempts + 1))
+			if err := r.eventRepo.MarkFailed(ctx, event.ID, err.Error(), nextAttemptAt); err != nil {
+				r.logger.Error("记录事件投递失败出错", zap.Error(err), zap.Uint("event_id", event.ID))
+			}
+			continue
+		}
+
+		if err := r.eventRepo.MarkSent(ctx, event.ID); err != nil {
+			// 标记失败会导致重复投递，订阅方需要幂等处理
+			r.logger.Error("标记事件已投递失败", zap.Error(err), zap.Uint("event_id", event.ID))
+		}
+	}
+	return nil
+}
+
+// errNoSubscribers 发布时频道没有订阅者
+var errNoSubscribers = errors.New("no subscribers on channel")
+
+// outboxBackoff 第 attempts 次失败后的重试间隔（指数退避）
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second << uint(attempts)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
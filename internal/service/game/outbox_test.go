@@ -0,0 +1,170 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestOutboxDeliversGameEnd(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	eventRepo := testutil.NewEventRepo(roomRepo)
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, testutil.NewRoomPlayerRepo(roomRepo), eventRepo, redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	relay := NewOutboxRelay(eventRepo, redisRoomRepo.Client(), zap.NewNop())
+	ctx := context.Background()
+
+	room := &model.Room{OwnerID: 1, Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+
+	// 没有订阅方时结束游戏，事件保存在发件箱中
+	if err := s.EndGame(ctx, room.ID, 1, map[uint]interface{}{1: "win"}); err != nil {
+		t.Fatalf("EndGame() error = %v", err)
+	}
+	stored, _ := roomRepo.GetByID(ctx, room.ID)
+	if stored.Status != model.RoomStatusFinished {
+		t.Fatalf("房间状态 = %v，期望已结束", stored.Status)
+	}
+	state, _ := redisRoomRepo.GetRoomState(ctx, room.ID)
+	if state["status"] != strconv.Itoa(int(model.RoomStatusFinished)) || state["game_state"] != strconv.Itoa(int(GameStateFinished)) {
+		t.Errorf("Redis 房间状态 = %v，期望已结束", state)
+	}
+	pending := eventRepo.Pending(outboxMaxAttempts)
+	if len(pending) != 1 || pending[0].Type != "game_end" {
+		t.Fatalf("待投递事件 = %+v，期望一个 game_end 事件", pending)
+	}
+
+	// 订阅方上线后由投递任务送达
+	pubsub := redisRoomRepo.Client().Subscribe(ctx, "game:events")
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	msgCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msg, err := pubsub.ReceiveMessage(msgCtx)
+	if err != nil {
+		t.Fatalf("未收到事件: %v", err)
+	}
+	var event GameEvent
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "game_end" || event.RoomID != room.ID {
+		t.Errorf("事件 = %+v", event)
+	}
+
+	pending = eventRepo.Pending(outboxMaxAttempts)
+	if len(pending) != 0 {
+		t.Errorf("投递后仍有 %d 个待投递事件", len(pending))
+	}
+}
+
+func TestOutboxRetriesFailedDelivery(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	eventRepo := testutil.NewEventRepo(roomRepo)
+	relay := NewOutboxRelay(eventRepo, redis.NewRoomRepository(repo).Client(), zap.NewNop())
+	ctx := context.Background()
+
+	event, err := newOutboxEvent("game:events", &GameEvent{Type: "game_end", RoomID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventRepo.SaveGameEnd(ctx, &model.Room{}, event, nil)
+
+	// Redis 不可用时记录失败并推迟重试
+	mr.Close()
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	stored := eventRepo.Event(event.ID)
+	if stored.SentAt != nil || stored.Attempts != 1 || stored.LastError == "" {
+		t.Fatalf("事件 = %+v，期望记录一次失败", stored)
+	}
+	if !stored.NextAttemptAt.After(time.Now()) {
+		t.Errorf("下次重试时间 %v 应在未来", stored.NextAttemptAt)
+	}
+	pending := eventRepo.Pending(outboxMaxAttempts)
+	if len(pending) != 0 {
+		t.Errorf("退避期内不应再次投递")
+	}
+}
+
+func TestOutboxRetriesUnheardPublish(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	eventRepo := testutil.NewEventRepo(roomRepo)
+	relay := NewOutboxRelay(eventRepo, redis.NewRoomRepository(repo).Client(), zap.NewNop())
+	ctx := context.Background()
+
+	event, err := newOutboxEvent("game:events", &GameEvent{Type: "game_end", RoomID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventRepo.SaveGameEnd(ctx, &model.Room{}, event, nil)
+
+	// 没有订阅者时 Redis 丢弃消息，事件保留在发件箱中等待重试
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	stored := eventRepo.Event(event.ID)
+	if stored.SentAt != nil || stored.Attempts != 1 || stored.LastError != errNoSubscribers.Error() {
+		t.Errorf("事件 = %+v，期望记录一次无订阅者失败", stored)
+	}
+}
+
+func TestOutboxClaimSkipsClaimed(t *testing.T) {
+	roomRepo := testutil.NewRoomRepo()
+	eventRepo := testutil.NewEventRepo(roomRepo)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		event, err := newOutboxEvent("game:events", &GameEvent{Type: "game_move", RoomID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		eventRepo.SaveGameEnd(ctx, &model.Room{}, event, nil)
+	}
+
+	// 认领后租约期内其他实例认领不到同一事件
+	first, _ := eventRepo.ClaimPending(ctx, outboxMaxAttempts, 1, outboxClaimLease)
+	second, _ := eventRepo.ClaimPending(ctx, outboxMaxAttempts, outboxBatchSize, outboxClaimLease)
+	if len(first) != 1 || len(second) != 1 || first[0].ID == second[0].ID {
+		t.Fatalf("认领结果 = %+v, %+v，期望各认领一个不同的事件", first, second)
+	}
+	if rest, _ := eventRepo.ClaimPending(ctx, outboxMaxAttempts, outboxBatchSize, outboxClaimLease); len(rest) != 0 {
+		t.Errorf("租约期内仍认领到 %d 个事件", len(rest))
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{20, outboxMaxBackoff},
+		{100, outboxMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
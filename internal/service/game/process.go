@@ -3,6 +3,8 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/game-apps/internal/model"
@@ -30,81 +32,162 @@ type GameEvent struct {
 	UserID    uint                   `json:"user_id"`
 	Data      map[string]interface{} `json:"data"`
 	Timestamp int64                  `json:"timestamp"`
+	Seq       int64                  `json:"seq,omitempty"` // 房间内递增的事件序号
+}
+
+// RoomStateBroadcaster 房间状态推送接口，实现方可以合并高频的状态更新
+type RoomStateBroadcaster interface {
+	BroadcastRoomState(roomID uint, gameType string, userIDs []uint, message interface{})
 }
 
 // ProcessService 游戏逻辑进程服务
 type ProcessService struct {
 	roomRepo      RoomRepository
+	roomPlayerRepo RoomPlayerRepository
+	eventRepo     EventRepository
 	redisRoomRepo *redis.RoomRepository
 	lockRepo      *redis.LockRepository
+	broadcaster   RoomStateBroadcaster
 	logger        *zap.Logger
 	eventChannel  string
+	readyPolicy   ReadyPolicy
+	turnPolicy    TurnPolicy
+	eventRetention EventRetention
+	jsonLimits     utils.JSONLimits
+	afkPolicy      AFKPolicy
+	connections    ConnectionPresence
+
+	afkMu     sync.Mutex
+	afkTimers map[uint]*time.Timer // 断线玩家的暂离计时
+	cacheClient   *cache.Client
 }
 
 // NewProcessService 创建游戏进程服务
 func NewProcessService(
 	roomRepo RoomRepository,
+	roomPlayerRepo RoomPlayerRepository,
+	eventRepo EventRepository,
 	redisRoomRepo *redis.RoomRepository,
 	lockRepo *redis.LockRepository,
+	broadcaster RoomStateBroadcaster,
 	logger *zap.Logger,
 	eventChannel string,
+	readyPolicy ReadyPolicy,
+	turnPolicy TurnPolicy,
+	eventRetention EventRetention,
+	jsonLimits utils.JSONLimits,
+	afkPolicy AFKPolicy,
+	connections ConnectionPresence,
 ) *ProcessService {
 	cacheClient := redisRoomRepo.Client()
 	return &ProcessService{
 		roomRepo:      roomRepo,
+		roomPlayerRepo: roomPlayerRepo,
+		eventRepo:     eventRepo,
 		redisRoomRepo: redisRoomRepo,
 		lockRepo:      lockRepo,
+		broadcaster:   broadcaster,
 		logger:        logger,
 		eventChannel:  eventChannel,
+		readyPolicy:   readyPolicy,
+		turnPolicy:    turnPolicy,
+		eventRetention: eventRetention.withDefaults(),
+		jsonLimits:     jsonLimits,
+		afkPolicy:      afkPolicy,
+		connections:    connections,
+		afkTimers:      make(map[uint]*time.Timer),
 		cacheClient:   cacheClient,
 	}
 }
 
-// StartGame 开始游戏
-func (s *ProcessService) StartGame(ctx context.Context, roomID uint) error {
-	// 获取分布式锁
-	lockKey := "game:lock:" + string(rune(roomID))
-	acquired, err := s.lockRepo.AcquireLock(ctx, lockKey, 10*time.Second)
+// lockRoomAndGame 依次获取房间锁和游戏锁，返回释放两把锁的函数
+// 开始和结束游戏既要与加入、准备、换角色、修改设置和再来一局（房间锁）互斥，
+// 也要与提交操作和推进回合（游戏锁）互斥；加锁顺序固定为先房间锁后游戏锁，其他操作不会在持有游戏锁时获取房间锁
+func (s *ProcessService) lockRoomAndGame(ctx context.Context, roomID uint, failMessage string) (func(), error) {
+	roomKey := roomLockKey(roomID)
+	roomToken, acquired, err := s.lockRepo.AcquireLockWait(ctx, roomKey, 10*time.Second, lockMaxWait)
 	if err != nil {
 		s.logger.Error("获取锁失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "开始游戏失败")
+		return nil, utils.NewInternalError(failMessage, err)
 	}
 	if !acquired {
-		return utils.NewError(utils.ErrCodeConflict, "游戏正在被操作，请稍后重试")
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+
+	gameKey := gameLockKey(roomID)
+	gameToken, acquired, err := s.lockRepo.AcquireLockWait(ctx, gameKey, 10*time.Second, lockMaxWait)
+	if err != nil {
+		s.lockRepo.ReleaseLock(ctx, roomKey, roomToken)
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError(failMessage, err)
+	}
+	if !acquired {
+		s.lockRepo.ReleaseLock(ctx, roomKey, roomToken)
+		return nil, utils.NewError(utils.ErrCodeConflict, "游戏正在被操作，请稍后重试")
+	}
+
+	return func() {
+		s.lockRepo.ReleaseLock(ctx, gameKey, gameToken)
+		s.lockRepo.ReleaseLock(ctx, roomKey, roomToken)
+	}, nil
+}
+
+// StartGame 开始游戏，只有房主可以开始
+func (s *ProcessService) StartGame(ctx context.Context, roomID, userID uint) error {
+	// 获取分布式锁，准备检查和状态变更之间玩家不能加入或取消准备
+	release, err := s.lockRoomAndGame(ctx, roomID, "开始游戏失败")
+	if err != nil {
+		return err
 	}
-	defer s.lockRepo.ReleaseLock(ctx, lockKey)
+	defer release()
 
 	// 获取房间
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		s.logger.Error("查询房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "开始游戏失败")
+		return utils.NewInternalError("开始游戏失败", err)
 	}
 	if room == nil {
 		return utils.NewError(utils.ErrCodeNotFound, "房间不存在")
 	}
+	if room.OwnerID != userID {
+		return utils.NewError(utils.ErrCodeForbidden, "只有房主可以开始游戏")
+	}
 
 	// 检查房间状态
 	if room.Status != model.RoomStatusWaiting {
 		return utils.NewError(utils.ErrCodeConflict, "房间状态不允许开始游戏")
 	}
 
-	// 更新房间状态
+	// 检查玩家准备状态
+	if s.readyPolicy.RequireAllReady {
+		players, err := s.roomPlayerRepo.GetByRoomID(ctx, roomID)
+		if err != nil {
+			s.logger.Error("查询房间玩家失败", zap.Error(err))
+			return utils.NewInternalError("开始游戏失败", err)
+		}
+		if err := s.readyPolicy.checkAllReady(room, players); err != nil {
+			return err
+		}
+	}
+
+	// 更新房间状态，只写入状态和开始时间，不覆盖其他字段
 	now := time.Now()
 	room.Status = model.RoomStatusPlaying
 	room.StartedAt = &now
-	if err := s.roomRepo.Update(ctx, room); err != nil {
+	if err := s.roomRepo.MarkStarted(ctx, roomID, now); err != nil {
 		s.logger.Error("更新房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "开始游戏失败")
+		return utils.NewInternalError("开始游戏失败", err)
 	}
 
 	// 同步到 Redis
 	roomData := map[string]interface{}{
-		"status":    room.Status,
+		"status":     int(room.Status),
 		"started_at": now.Unix(),
-		"game_state": GameStateStarting,
+		"game_state": int(GameStateStarting),
 	}
 	s.redisRoomRepo.SetRoomState(ctx, roomID, roomData, 0)
+	s.redisRoomRepo.InvalidateRoomCache(ctx, roomID)
 
 	// 发布游戏开始事件
 	event := &GameEvent{
@@ -117,94 +200,285 @@ func (s *ProcessService) StartGame(ctx context.Context, roomID uint) error {
 		s.logger.Warn("发布事件失败", zap.Error(err))
 	}
 
+	// 设置了回合时限的房间开始第一个回合
+	if err := s.startFirstTurn(ctx, room); err != nil {
+		s.logger.Error("开始回合失败", zap.Error(err), zap.Uint("room_id", roomID))
+	}
+
 	return nil
 }
 
-// EndGame 结束游戏
-func (s *ProcessService) EndGame(ctx context.Context, roomID uint, results map[uint]interface{}) error {
+// EndGame 结束游戏，只有房主可以结束
+func (s *ProcessService) EndGame(ctx context.Context, roomID, userID uint, results map[uint]interface{}) error {
+	return s.endGame(ctx, roomID, &userID, results)
+}
+
+// ForceEndGame 强制结束游戏，不检查操作者身份，供管理后台使用
+func (s *ProcessService) ForceEndGame(ctx context.Context, roomID uint, results map[uint]interface{}) error {
+	return s.endGame(ctx, roomID, nil, results)
+}
+
+// endGame 结束游戏，ownerID 非空时要求操作者为房主
+func (s *ProcessService) endGame(ctx context.Context, roomID uint, ownerID *uint, results map[uint]interface{}) error {
 	// 获取分布式锁
-	lockKey := "game:lock:" + string(rune(roomID))
-	acquired, err := s.lockRepo.AcquireLock(ctx, lockKey, 10*time.Second)
+	release, err := s.lockRoomAndGame(ctx, roomID, "结束游戏失败")
 	if err != nil {
-		s.logger.Error("获取锁失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "结束游戏失败")
-	}
-	if !acquired {
-		return utils.NewError(utils.ErrCodeConflict, "游戏正在被操作，请稍后重试")
+		return err
 	}
-	defer s.lockRepo.ReleaseLock(ctx, lockKey)
+	defer release()
 
 	// 获取房间
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		s.logger.Error("查询房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "结束游戏失败")
+		return utils.NewInternalError("结束游戏失败", err)
 	}
 	if room == nil {
 		return utils.NewError(utils.ErrCodeNotFound, "房间不存在")
 	}
+	if ownerID != nil && room.OwnerID != *ownerID {
+		return utils.NewError(utils.ErrCodeForbidden, "只有房主可以结束游戏")
+	}
+	// 在锁内检查状态，避免并发结束时重复写入对局记录
+	if room.Status != model.RoomStatusPlaying {
+		return utils.NewError(utils.ErrCodeConflict, "游戏未在进行中")
+	}
 
-	// 更新房间状态
+	// 更新房间状态，游戏结束事件与状态变更在同一事务中写入发件箱，保证可靠投递
+	// 参与者的对局记录也在同一事务中写入
 	now := time.Now()
 	room.Status = model.RoomStatusFinished
 	room.EndedAt = &now
-	if err := s.roomRepo.Update(ctx, room); err != nil {
+
+	event := &GameEvent{
+		Type:      "game_end",
+		RoomID:    roomID,
+		Data:      map[string]interface{}{"room": room, "results": results},
+		Timestamp: now.Unix(),
+	}
+	// 序号分配失败不影响结束游戏，事件不带序号投递
+	if err := stampEventSeq(ctx, s.redisRoomRepo, event); err != nil {
+		s.logger.Warn("分配事件序号失败", zap.Error(err), zap.Uint("room_id", roomID))
+	}
+	outboxEvent, err := newOutboxEvent(s.eventChannel, event)
+	if err != nil {
+		s.logger.Error("序列化事件失败", zap.Error(err))
+		return utils.NewInternalError("结束游戏失败", err)
+	}
+	histories := newGameHistories(room, s.participantIDs(ctx, roomID, results), results, now)
+	if err := s.eventRepo.SaveGameEnd(ctx, room, outboxEvent, histories); err != nil {
 		s.logger.Error("更新房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "结束游戏失败")
+		return utils.NewInternalError("结束游戏失败", err)
 	}
 
-	// 同步到 Redis
+	// 同步到 Redis（房间状态哈希只能保存标量，结果序列化为 JSON）
+	resultsJSON, _ := json.Marshal(results)
 	roomData := map[string]interface{}{
-		"status":    room.Status,
-		"ended_at":  now.Unix(),
-		"game_state": GameStateFinished,
-		"results":    results,
+		"status":     int(room.Status),
+		"ended_at":   now.Unix(),
+		"game_state": int(GameStateFinished),
+		"results":    string(resultsJSON),
 	}
 	s.redisRoomRepo.SetRoomState(ctx, roomID, roomData, 0)
+	s.redisRoomRepo.InvalidateRoomCache(ctx, roomID)
+	if event.Seq > 0 {
+		if err := s.redisRoomRepo.AppendRoomEvent(ctx, roomID, event.Seq, []byte(outboxEvent.Payload), s.eventRetention.MaxLength, s.eventRetention.TTL); err != nil {
+			s.logger.Warn("记录房间事件失败", zap.Error(err), zap.Uint("room_id", roomID))
+		}
+	}
+	if err := s.redisRoomRepo.ClearTurnDeadline(ctx, roomID); err != nil {
+		s.logger.Warn("清理回合截止时间失败", zap.Error(err), zap.Uint("room_id", roomID))
+	}
+	if err := s.redisRoomRepo.ClearAFKPlayers(ctx, roomID); err != nil {
+		s.logger.Warn("清理暂离玩家失败", zap.Error(err), zap.Uint("room_id", roomID))
+	}
+
+	return nil
+}
+
+// moveSeqExpiration 操作序号的保留时间
+const moveSeqExpiration = 24 * time.Hour
+
+// SubmitMoveRequest 提交操作请求
+type SubmitMoveRequest struct {
+	Seq  int64           `json:"seq" binding:"required,min=1"` // 客户端为每个玩家维护的单调递增序号
+	Move json.RawMessage `json:"move" binding:"required"`       // 操作内容，须为 JSON 对象，解析前检查大小和嵌套层级
+}
 
-	// 发布游戏结束事件
+// SubmitMoveResponse 提交操作响应
+type SubmitMoveResponse struct {
+	Seq       int64 `json:"seq"`
+	Duplicate bool  `json:"duplicate"` // 是否为重复提交（未重复应用）
+}
+
+// SubmitMove 提交游戏操作
+// 序号等于已应用的序号时视为重试，直接确认而不重复应用；小于时视为乱序并拒绝
+func (s *ProcessService) SubmitMove(ctx context.Context, roomID, userID uint, req *SubmitMoveRequest) (*SubmitMoveResponse, error) {
+	var move map[string]interface{}
+	if err := utils.DecodeJSON(req.Move, &move, s.jsonLimits); err != nil {
+		return nil, err
+	}
+	if move == nil {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "操作内容不能为空")
+	}
+
+	// 获取房间
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("提交操作失败", err)
+	}
+	if room == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+	if room.Status != model.RoomStatusPlaying {
+		return nil, utils.NewError(utils.ErrCodeConflict, "游戏未在进行中")
+	}
+
+	isPlayer, err := s.redisRoomRepo.IsRoomPlayer(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return nil, utils.NewInternalError("提交操作失败", err)
+	}
+	if !isPlayer {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "不在房间中")
+	}
+
+	// 检查回合、推进序号和推进回合在游戏锁内完成，避免并发操作同时通过回合检查
+	lockKey := gameLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 10*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("提交操作失败", err)
+	}
+	if !acquired {
+		return nil, utils.NewError(utils.ErrCodeConflict, "游戏正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	// 先识别重试：已应用的操作不再检查回合（回合可能已经交给下一位玩家）
+	current, err := s.redisRoomRepo.GetMoveSeq(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询操作序号失败", zap.Error(err))
+		return nil, utils.NewInternalError("提交操作失败", err)
+	}
+	if req.Seq == current {
+		return &SubmitMoveResponse{Seq: current, Duplicate: true}, nil
+	}
+	if req.Seq < current {
+		return nil, utils.NewError(utils.ErrCodeConflict, "操作序号已过期")
+	}
+
+	turnBased, err := s.checkTurn(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 推进操作序号，防止网络重试导致重复应用
+	advanced, current, err := s.redisRoomRepo.AdvanceMoveSeq(ctx, roomID, userID, req.Seq, moveSeqExpiration)
+	if err != nil {
+		s.logger.Error("更新操作序号失败", zap.Error(err))
+		return nil, utils.NewInternalError("提交操作失败", err)
+	}
+	if !advanced {
+		if req.Seq == current {
+			return &SubmitMoveResponse{Seq: current, Duplicate: true}, nil
+		}
+		return nil, utils.NewError(utils.ErrCodeConflict, "操作序号已过期")
+	}
+
+	// 发布操作事件
 	event := &GameEvent{
-		Type:      "game_end",
+		Type:      "game_move",
 		RoomID:    roomID,
-		Data:      map[string]interface{}{"room": room, "results": results},
+		UserID:    userID,
+		Data:      map[string]interface{}{"seq": req.Seq, "move": move},
 		Timestamp: time.Now().Unix(),
 	}
 	if err := s.PublishEvent(ctx, event); err != nil {
 		s.logger.Warn("发布事件失败", zap.Error(err))
 	}
 
-	return nil
+	// 当前玩家完成操作后将回合交给下一位玩家
+	if turnBased {
+		if err := s.advanceTurnLocked(ctx, roomID, userID, false); err != nil {
+			s.logger.Error("推进回合失败", zap.Error(err), zap.Uint("room_id", roomID))
+		}
+	}
+
+	return &SubmitMoveResponse{Seq: req.Seq}, nil
 }
 
 // UpdateGameState 更新游戏状态
 func (s *ProcessService) UpdateGameState(ctx context.Context, roomID uint, state GameState, data map[string]interface{}) error {
 	roomData := map[string]interface{}{
-		"game_state": state,
+		"game_state": int(state),
 	}
 	for k, v := range data {
 		roomData[k] = v
 	}
-	return s.redisRoomRepo.SetRoomState(ctx, roomID, roomData, 0)
+	if err := s.redisRoomRepo.SetRoomState(ctx, roomID, roomData, 0); err != nil {
+		return err
+	}
+
+	s.broadcastState(ctx, roomID)
+	return nil
 }
 
-// GetGameState 获取游戏状态
-func (s *ProcessService) GetGameState(ctx context.Context, roomID uint) (map[string]string, error) {
-	return s.redisRoomRepo.GetRoomState(ctx, roomID)
+// broadcastState 向房间玩家推送最新的游戏状态快照
+func (s *ProcessService) broadcastState(ctx context.Context, roomID uint) {
+	if s.broadcaster == nil {
+		return
+	}
+
+	state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("获取游戏状态失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return
+	}
+	members, err := s.redisRoomRepo.GetRoomPlayers(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("获取房间玩家失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return
+	}
+
+	userIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			userIDs = append(userIDs, uint(id))
+		}
+	}
+
+	s.broadcaster.BroadcastRoomState(roomID, state["game_type"], userIDs, map[string]interface{}{
+		"type":    "game_state",
+		"room_id": roomID,
+		"data":    state,
+	})
 }
 
-// PublishEvent 发布游戏事件
-func (s *ProcessService) PublishEvent(ctx context.Context, event *GameEvent) error {
-	eventData, err := json.Marshal(event)
+// GetGameState 获取游戏状态，房间状态不存在时返回 nil
+func (s *ProcessService) GetGameState(ctx context.Context, roomID uint) (*GameStateView, error) {
+	state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return ParseGameStateView(state), nil
+}
 
+// PublishEvent 发布游戏事件，发布前分配房间内的事件序号并记录到房间事件列表
+func (s *ProcessService) PublishEvent(ctx context.Context, event *GameEvent) error {
 	// 使用 Redis Pub/Sub 发布事件
 	if s.cacheClient == nil {
 		return utils.NewError(utils.ErrCodeInternal, "Redis 客户端不可用")
 	}
 
-	return s.cacheClient.GetClient().Publish(ctx, s.eventChannel, eventData).Err()
+	eventData, err := recordEvent(ctx, s.redisRoomRepo, s.eventRetention, event)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cacheClient.Publish(ctx, s.eventChannel, eventData)
+	return err
 }
 
 // SubscribeEvents 订阅游戏事件
@@ -213,7 +487,7 @@ func (s *ProcessService) SubscribeEvents(ctx context.Context) (<-chan *GameEvent
 		return nil, utils.NewError(utils.ErrCodeInternal, "Redis 客户端不可用")
 	}
 
-	pubsub := s.cacheClient.GetClient().Subscribe(ctx, s.eventChannel)
+	pubsub := s.cacheClient.Subscribe(ctx, s.eventChannel)
 	eventChan := make(chan *GameEvent, 100)
 
 	go func() {
@@ -0,0 +1,254 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// newTestProcessService 创建使用内存仓库和 miniredis 的游戏进程服务
+func newTestProcessService(t *testing.T) (*ProcessService, *testutil.RoomRepo, *redis.RoomRepository) {
+	t.Helper()
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, testutil.NewRoomPlayerRepo(roomRepo), testutil.NewEventRepo(roomRepo), redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	return s, roomRepo, redisRoomRepo
+}
+
+func TestSubmitMoveSeq(t *testing.T) {
+	s, roomRepo, redisRoomRepo := newTestProcessService(t)
+	ctx := context.Background()
+	const userID, otherID = 1, 2
+
+	room := &model.Room{Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+	redisRoomRepo.AddRoomPlayer(ctx, room.ID, userID)
+	redisRoomRepo.AddRoomPlayer(ctx, room.ID, otherID)
+
+	tests := []struct {
+		name          string
+		userID        uint
+		seq           int64
+		wantCode      int
+		wantDuplicate bool
+	}{
+		{"首个操作", userID, 1, 0, false},
+		{"序号递增", userID, 2, 0, false},
+		{"重复序号不重复应用", userID, 2, 0, true},
+		{"更小的序号被拒绝", userID, 1, utils.ErrCodeConflict, false},
+		{"序号可以跳跃", userID, 5, 0, false},
+		{"各玩家序号独立", otherID, 1, 0, false},
+		{"非房间玩家", 3, 1, utils.ErrCodeForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.SubmitMove(ctx, room.ID, tt.userID, &SubmitMoveRequest{Seq: tt.seq, Move: json.RawMessage(`{"x":1}`)})
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitMove() error = %v", err)
+			}
+			if resp.Seq != tt.seq || resp.Duplicate != tt.wantDuplicate {
+				t.Errorf("SubmitMove() = %+v, want seq %d duplicate %v", resp, tt.seq, tt.wantDuplicate)
+			}
+		})
+	}
+}
+
+func TestSubmitMoveRequiresPlaying(t *testing.T) {
+	s, roomRepo, redisRoomRepo := newTestProcessService(t)
+	ctx := context.Background()
+
+	room := &model.Room{Status: model.RoomStatusWaiting}
+	roomRepo.Create(ctx, room)
+	redisRoomRepo.AddRoomPlayer(ctx, room.ID, 1)
+
+	move := json.RawMessage(`{"x":1}`)
+	_, err := s.SubmitMove(ctx, room.ID, 1, &SubmitMoveRequest{Seq: 1, Move: move})
+	assertErrCode(t, err, utils.ErrCodeConflict)
+
+	_, err = s.SubmitMove(ctx, 999, 1, &SubmitMoveRequest{Seq: 1, Move: move})
+	assertErrCode(t, err, utils.ErrCodeNotFound)
+}
+
+func TestSubmitMoveJSONLimits(t *testing.T) {
+	s, roomRepo, redisRoomRepo := newTestProcessService(t)
+	s.jsonLimits = utils.JSONLimits{MaxDepth: 8, MaxBytes: 1024}
+	ctx := context.Background()
+
+	room := &model.Room{Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+	redisRoomRepo.AddRoomPlayer(ctx, room.ID, 1)
+
+	tests := []struct {
+		name     string
+		move     string
+		wantCode int
+	}{
+		{"正常操作", `{"x":1,"path":[[0,1],[1,2]]}`, 0},
+		{"嵌套过深", strings.Repeat(`{"a":`, 9) + "1" + strings.Repeat("}", 9), utils.ErrCodeInvalidInput},
+		{"数据过大", `{"x":"` + strings.Repeat("a", 1024) + `"}`, utils.ErrCodeInvalidInput},
+		{"不是对象", `[1,2]`, utils.ErrCodeInvalidInput},
+		{"空对象", `null`, utils.ErrCodeInvalidInput},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SubmitMove(ctx, room.ID, 1, &SubmitMoveRequest{Seq: int64(i + 1), Move: json.RawMessage(tt.move)})
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitMove() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestStartEndGameOwnerOnly(t *testing.T) {
+	const ownerID, memberID = 1, 2
+
+	tests := []struct {
+		name     string
+		userID   uint
+		wantCode int
+	}{
+		{"非房主成员", memberID, utils.ErrCodeForbidden},
+		{"房主", ownerID, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, roomRepo, _ := newTestProcessService(t)
+			ctx := context.Background()
+
+			room := &model.Room{OwnerID: ownerID, Status: model.RoomStatusWaiting}
+			roomRepo.Create(ctx, room)
+
+			err := s.StartGame(ctx, room.ID, tt.userID)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				if stored, _ := roomRepo.GetByID(ctx, room.ID); stored.Status != model.RoomStatusWaiting {
+					t.Errorf("房间状态 = %v，期望未开始", stored.Status)
+				}
+			} else if err != nil {
+				t.Fatalf("StartGame() error = %v", err)
+			}
+
+			// 无论由谁开始，结束游戏同样只允许房主
+			room.Status = model.RoomStatusPlaying
+			roomRepo.Update(ctx, room)
+			err = s.EndGame(ctx, room.ID, tt.userID, nil)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("EndGame() error = %v", err)
+			}
+		})
+	}
+
+	// 管理后台强制结束不检查操作者
+	s, roomRepo, _ := newTestProcessService(t)
+	ctx := context.Background()
+	room := &model.Room{OwnerID: ownerID, Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, room)
+	if err := s.ForceEndGame(ctx, room.ID, nil); err != nil {
+		t.Fatalf("ForceEndGame() error = %v", err)
+	}
+}
+
+func TestEndGameRequiresPlaying(t *testing.T) {
+	s, roomRepo, _ := newTestProcessService(t)
+	eventRepo := s.eventRepo.(*testutil.EventRepo)
+	ctx := context.Background()
+
+	waiting := &model.Room{OwnerID: 1, Status: model.RoomStatusWaiting}
+	playing := &model.Room{OwnerID: 1, Status: model.RoomStatusPlaying}
+	roomRepo.Create(ctx, waiting)
+	roomRepo.Create(ctx, playing)
+
+	assertErrCode(t, s.EndGame(ctx, waiting.ID, 1, nil), utils.ErrCodeConflict)
+	if stored, _ := roomRepo.GetByID(ctx, waiting.ID); stored.Status != model.RoomStatusWaiting {
+		t.Errorf("房间状态 = %v，期望未开始", stored.Status)
+	}
+
+	if err := s.EndGame(ctx, playing.ID, 1, map[uint]interface{}{1: "win"}); err != nil {
+		t.Fatalf("EndGame() error = %v", err)
+	}
+	written := len(eventRepo.Histories())
+
+	// 重复结束（房主或管理后台）不再写入对局记录
+	assertErrCode(t, s.EndGame(ctx, playing.ID, 1, map[uint]interface{}{1: "win"}), utils.ErrCodeConflict)
+	assertErrCode(t, s.ForceEndGame(ctx, playing.ID, nil), utils.ErrCodeConflict)
+	if got := len(eventRepo.Histories()); got != written {
+		t.Errorf("对局记录 = %d 条，期望 %d 条", got, written)
+	}
+}
+
+func TestStartEndGameWaitForRoomLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		status model.RoomStatus
+		run    func(s *ProcessService, ctx context.Context, roomID uint) error
+		want   model.RoomStatus
+	}{
+		{"开始游戏", model.RoomStatusWaiting, func(s *ProcessService, ctx context.Context, roomID uint) error {
+			return s.StartGame(ctx, roomID, 1)
+		}, model.RoomStatusPlaying},
+		{"结束游戏", model.RoomStatusPlaying, func(s *ProcessService, ctx context.Context, roomID uint) error {
+			return s.EndGame(ctx, roomID, 1, nil)
+		}, model.RoomStatusFinished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, roomRepo, _ := newTestProcessService(t)
+			ctx := context.Background()
+			room := &model.Room{OwnerID: 1, Status: tt.status}
+			roomRepo.Create(ctx, room)
+
+			// 模拟正在进行的加入、准备等房间操作
+			lockKey := roomLockKey(room.ID)
+			token, acquired, err := s.lockRepo.AcquireLock(ctx, lockKey, 10*time.Second)
+			if err != nil || !acquired {
+				t.Fatalf("AcquireLock() = %v, %v", acquired, err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- tt.run(s, ctx, room.ID) }()
+
+			select {
+			case err := <-done:
+				t.Fatalf("房间锁被持有时操作已完成: %v", err)
+			case <-time.After(200 * time.Millisecond):
+			}
+			if stored, _ := roomRepo.GetByID(ctx, room.ID); stored.Status != tt.status {
+				t.Fatalf("房间锁被持有时状态 = %v，期望 %v", stored.Status, tt.status)
+			}
+
+			s.lockRepo.ReleaseLock(ctx, lockKey, token)
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("释放房间锁后操作失败: %v", err)
+				}
+			case <-time.After(lockMaxWait):
+				t.Fatal("释放房间锁后操作未完成")
+			}
+			if stored, _ := roomRepo.GetByID(ctx, room.ID); stored.Status != tt.want {
+				t.Errorf("房间状态 = %v，期望 %v", stored.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// ownerAutoReadySetting 房间设置中覆盖房主自动准备的设置项
+const ownerAutoReadySetting = "owner_auto_ready"
+
+// ReadyPolicy 玩家准备策略
+type ReadyPolicy struct {
+	OwnerAutoReady  bool // 房主自动准备，开始游戏时视为始终已准备
+	RequireAllReady bool // 开始游戏前要求所有玩家已准备
+}
+
+// ownerAutoReady 房主是否自动准备，房间设置中的 owner_auto_ready 优先于全局配置
+func (p ReadyPolicy) ownerAutoReady(room *model.Room) bool {
+	var autoReady bool
+	if ok, err := room.GetSetting(ownerAutoReadySetting, &autoReady); ok && err == nil {
+		return autoReady
+	}
+	return p.OwnerAutoReady
+}
+
+// checkAllReady 检查房间内玩家是否都已准备，房主自动准备时不要求房主准备
+func (p ReadyPolicy) checkAllReady(room *model.Room, players []*model.RoomPlayer) error {
+	if !p.RequireAllReady {
+		return nil
+	}
+	ownerExempt := p.ownerAutoReady(room)
+	for _, player := range players {
+		if player.IsSpectator || player.IsReady || (ownerExempt && player.UserID == room.OwnerID) {
+			continue
+		}
+		return utils.NewError(utils.ErrCodeConflict, "还有玩家未准备")
+	}
+	return nil
+}
+
+// SetReadyRequest 设置准备状态请求
+type SetReadyRequest struct {
+	Ready bool `json:"ready"`
+}
+
+// SetReady 设置玩家在等待中房间的准备状态
+func (s *RoomService) SetReady(ctx context.Context, userID uint, roomID uint, ready bool) error {
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return utils.NewInternalError("设置准备状态失败", err)
+	}
+	if !acquired {
+		return utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return utils.NewInternalError("设置准备状态失败", err)
+	}
+	if room == nil {
+		return utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+	if room.Status != model.RoomStatusWaiting {
+		return utils.NewError(utils.ErrCodeConflict, "房间不在等待状态")
+	}
+
+	player, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return utils.NewInternalError("设置准备状态失败", err)
+	}
+	if player == nil {
+		return utils.NewError(utils.ErrCodeForbidden, "不在房间中")
+	}
+	if player.IsSpectator {
+		return utils.NewError(utils.ErrCodeConflict, "观战者无需准备")
+	}
+	if player.IsReady == ready {
+		return nil
+	}
+
+	player.IsReady = ready
+	if err := s.roomPlayerRepo.Update(ctx, player); err != nil {
+		s.logger.Error("更新准备状态失败", zap.Error(err))
+		return utils.NewInternalError("设置准备状态失败", err)
+	}
+
+	event := &GameEvent{
+		Type:      "player_ready_changed",
+		RoomID:    roomID,
+		UserID:    userID,
+		Data:      map[string]interface{}{"ready": ready},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.publishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	return nil
+}
+
+// readyOwner 房主自动准备时将房主标记为已准备
+func (s *RoomService) readyOwner(ctx context.Context, room *model.Room) {
+	if !s.readyPolicy.ownerAutoReady(room) {
+		return
+	}
+
+	owner, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, room.ID, room.OwnerID)
+	if err != nil || owner == nil || owner.IsReady {
+		return
+	}
+	owner.IsReady = true
+	if err := s.roomPlayerRepo.Update(ctx, owner); err != nil {
+		s.logger.Warn("标记房主准备失败", zap.Error(err), zap.Uint("room_id", room.ID))
+	}
+}
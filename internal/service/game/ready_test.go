@@ -0,0 +1,84 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestCreateRoomOwnerAutoReady(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    ReadyPolicy
+		settings  string
+		wantReady bool
+	}{
+		{"默认需要房主准备", ReadyPolicy{}, "", false},
+		{"开启房主自动准备", ReadyPolicy{OwnerAutoReady: true}, "", true},
+		{"房间设置开启", ReadyPolicy{}, `{"owner_auto_ready":true}`, true},
+		{"房间设置关闭", ReadyPolicy{OwnerAutoReady: true}, `{"owner_auto_ready":false}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+			s.readyPolicy = tt.policy
+			ctx := context.Background()
+
+			created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess", Settings: tt.settings})
+			if err != nil {
+				t.Fatal(err)
+			}
+			owner, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, created.Room.ID, 1)
+			if owner == nil || owner.IsReady != tt.wantReady {
+				t.Errorf("owner = %+v, want IsReady %v", owner, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestStartGameReadiness(t *testing.T) {
+	const ownerID, otherID = 1, 2
+
+	tests := []struct {
+		name       string
+		policy     ReadyPolicy
+		ownerReady bool
+		otherReady bool
+		wantCode   int
+	}{
+		{"不要求准备", ReadyPolicy{}, false, false, 0},
+		{"全部已准备", ReadyPolicy{RequireAllReady: true}, true, true, 0},
+		{"房主未准备", ReadyPolicy{RequireAllReady: true}, false, true, utils.ErrCodeConflict},
+		{"房主自动准备时不要求房主", ReadyPolicy{RequireAllReady: true, OwnerAutoReady: true}, false, true, 0},
+		{"其他玩家未准备", ReadyPolicy{RequireAllReady: true, OwnerAutoReady: true}, true, false, utils.ErrCodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepository(t)
+			roomRepo := testutil.NewRoomRepo()
+			roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+			s := NewProcessService(roomRepo, roomPlayerRepo, testutil.NewEventRepo(roomRepo), redis.NewRoomRepository(repo), redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", tt.policy, TurnPolicy{}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+			ctx := context.Background()
+
+			room := &model.Room{OwnerID: ownerID, Status: model.RoomStatusWaiting}
+			roomRepo.Create(ctx, room)
+			roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: ownerID, IsReady: tt.ownerReady})
+			roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: otherID, IsReady: tt.otherReady})
+
+			err := s.StartGame(ctx, room.ID, ownerID)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("StartGame() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/game-apps/internal/config"
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// SwitchRoleRequest 切换玩家/观战者身份请求
+type SwitchRoleRequest struct {
+	ToSpectator bool `json:"to_spectator"`
+}
+
+// SwitchRole 在等待中的房间内切换玩家和观战者身份
+// 成为玩家时需要有空余座位，分配第一个空闲位置
+func (s *RoomService) SwitchRole(ctx context.Context, userID uint, roomID uint, toSpectator bool) (*model.Room, error) {
+	// 观战关闭时仍允许观战者切换回玩家
+	if toSpectator {
+		if err := s.checkFeature(config.FeatureSpectators, "观战"); err != nil {
+			return nil, err
+		}
+	}
+
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("切换身份失败", err)
+	}
+	if !acquired {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("切换身份失败", err)
+	}
+	if room == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+	if room.Status != model.RoomStatusWaiting {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间不在等待状态")
+	}
+
+	member, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return nil, utils.NewInternalError("切换身份失败", err)
+	}
+	if member == nil {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "不在房间中")
+	}
+	if member.IsSpectator == toSpectator {
+		return room, nil
+	}
+
+	if toSpectator {
+		if userID == room.OwnerID {
+			return nil, utils.NewError(utils.ErrCodeConflict, "房主不能切换为观战者")
+		}
+		member.IsSpectator = true
+		member.IsReady = false
+		member.Position = nil
+		err = s.roomPlayerRepo.Update(ctx, member)
+	} else {
+		if room.CurrentPlayers >= room.MaxPlayers {
+			return nil, utils.NewError(utils.ErrCodeConflict, "房间已满")
+		}
+		member.IsSpectator = false
+		err = s.roomPlayerRepo.SaveWithFreePosition(ctx, member)
+	}
+	if err != nil {
+		s.logger.Error("更新房间玩家失败", zap.Error(err))
+		return nil, utils.NewInternalError("切换身份失败", err)
+	}
+
+	if toSpectator {
+		room.CurrentPlayers--
+	} else {
+		room.CurrentPlayers++
+	}
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		s.logger.Error("更新房间失败", zap.Error(err))
+	}
+
+	// 同步到 Redis，Redis 中的房间玩家集合只包含占用座位的玩家
+	s.syncRoomToRedis(ctx, room)
+	if toSpectator {
+		s.redisRoomRepo.RemoveRoomPlayer(ctx, roomID, userID)
+		s.compactPositions(ctx, roomID)
+		s.promoteFromWaitlist(ctx, room)
+	} else {
+		s.redisRoomRepo.AddRoomPlayer(ctx, roomID, userID)
+	}
+	s.notifyLobby(LobbyEventRoomUpdated, room)
+
+	event := &GameEvent{
+		Type:   "role_changed",
+		RoomID: roomID,
+		UserID: userID,
+		Data: map[string]interface{}{
+			"spectator": toSpectator,
+			"position":  member.Position,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.publishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	return room, nil
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+)
+
+func TestSwitchRole(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const ownerID, playerID, otherID = 1, 2, 3
+
+	created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roomID := created.Room.ID
+	if _, err := s.JoinRoom(ctx, playerID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name         string
+		run          func() error
+		wantCode     int
+		wantPlayers  int
+		checkUser    uint
+		wantPosition int
+	}{
+		{
+			name:         "玩家切换为观战者",
+			run:          func() error { _, err := s.SwitchRole(ctx, playerID, roomID, true); return err },
+			wantPlayers:  1,
+			checkUser:    playerID,
+			wantPosition: -1,
+		},
+		{
+			name: "其他玩家占用空出的座位",
+			run: func() error {
+				_, err := s.JoinRoom(ctx, otherID, &JoinRoomRequest{RoomCode: created.Room.RoomCode})
+				return err
+			},
+			wantPlayers:  2,
+			checkUser:    otherID,
+			wantPosition: 1,
+		},
+		{
+			name:        "房间已满时不能切换为玩家",
+			run:         func() error { _, err := s.SwitchRole(ctx, playerID, roomID, false); return err },
+			wantCode:    utils.ErrCodeConflict,
+			wantPlayers: 2,
+		},
+		{
+			name:        "房主不能切换为观战者",
+			run:         func() error { _, err := s.SwitchRole(ctx, ownerID, roomID, true); return err },
+			wantCode:    utils.ErrCodeConflict,
+			wantPlayers: 2,
+		},
+		{
+			name:        "玩家离开空出座位",
+			run:         func() error { return s.LeaveRoom(ctx, otherID, roomID) },
+			wantPlayers: 1,
+		},
+		{
+			name:         "观战者坐到空出的座位",
+			run:          func() error { _, err := s.SwitchRole(ctx, playerID, roomID, false); return err },
+			wantPlayers:  2,
+			checkUser:    playerID,
+			wantPosition: 1,
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			err := step.run()
+			if step.wantCode != 0 {
+				assertErrCode(t, err, step.wantCode)
+			} else if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			room, _ := s.roomRepo.GetByID(ctx, roomID)
+			if room.CurrentPlayers != step.wantPlayers {
+				t.Errorf("CurrentPlayers = %d, want %d", room.CurrentPlayers, step.wantPlayers)
+			}
+			if step.checkUser != 0 {
+				member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, step.checkUser)
+				spectator := step.wantPosition < 0
+				if member == nil || member.IsSpectator != spectator ||
+					(spectator && member.Position != nil) ||
+					(!spectator && (member.Position == nil || *member.Position != step.wantPosition)) {
+					t.Errorf("member = %+v, want position %d", member, step.wantPosition)
+				}
+			}
+		})
+	}
+}
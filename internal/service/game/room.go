@@ -5,23 +5,72 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/game-apps/internal/config"
 	"github.com/game-apps/internal/model"
 	"github.com/game-apps/internal/repository/redis"
 	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
 	"go.uber.org/zap"
 )
 
+// lockMaxWait 获取房间/游戏锁时的最长等待时间，用于吸收短暂的锁竞争
+const lockMaxWait = 2 * time.Second
+
+// 大厅事件类型
+const (
+	LobbyEventRoomCreated = "lobby_room_created"
+	LobbyEventRoomUpdated = "lobby_room_updated"
+	LobbyEventRoomClosed  = "lobby_room_closed"
+)
+
+// LobbyNotifier 大厅通知接口，按游戏类型向订阅者广播房间变化
+type LobbyNotifier interface {
+	BroadcastToLobby(gameType string, message interface{})
+}
+
+// 加入房间（及候补）失败的细分原因，通过错误的 reason 字段返回给客户端
+const (
+	JoinReasonRoomNotFound  = "room_not_found" // 房间不存在
+	JoinReasonRoomStarted   = "room_started"   // 房间已开始或已结束
+	JoinReasonRoomFull      = "room_full"      // 房间已满，可加入候补
+	JoinReasonAlreadyJoined = "already_joined" // 已在该房间中
+	JoinReasonInOtherRoom   = "in_other_room"  // 已在其他房间中
+)
+
+// UserNotifier 向指定用户推送消息（如候补转正通知）
+type UserNotifier interface {
+	SendToUser(userID uint, message interface{})
+}
+
 // RoomService 房间服务
 type RoomService struct {
 	roomRepo      RoomRepository
 	roomPlayerRepo RoomPlayerRepository
 	redisRoomRepo *redis.RoomRepository
 	lockRepo      *redis.LockRepository
+	lobbyNotifier LobbyNotifier
+	userNotifier  UserNotifier
 	logger        *zap.Logger
-	maxPlayers     int
+	defaults       RoomDefaults
+	typeDefaults   map[string]RoomDefaults
 	defaultTimeout time.Duration
+	eventChannel   string
+	allowMultiRoom bool
+	readyPolicy    ReadyPolicy
+	eventRetention EventRetention
+	userRepo       UserRepository
+	jsonLimits     utils.JSONLimits
+}
+
+// RoomDefaults 房间默认值
+type RoomDefaults struct {
+	MaxPlayers     int
+	MinPlayers     int
+	DefaultTimeout time.Duration
 }
 
 // RoomRepository 房间仓库接口
@@ -30,7 +79,13 @@ type RoomRepository interface {
 	GetByID(ctx context.Context, id uint) (*model.Room, error)
 	GetByRoomCode(ctx context.Context, roomCode string) (*model.Room, error)
 	List(ctx context.Context, status *model.RoomStatus, limit, offset int) ([]*model.Room, error)
+	ListAfter(ctx context.Context, status *model.RoomStatus, afterCreatedAt time.Time, afterID uint, limit int) ([]*model.Room, error)
+	Count(ctx context.Context, status *model.RoomStatus) (int64, error)
+	ListByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus, limit, offset int) ([]*model.Room, error)
+	CountByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus) (int64, error)
 	Update(ctx context.Context, room *model.Room) error
+	UpdateCurrentPlayers(ctx context.Context, roomID uint, currentPlayers int) error
+	MarkStarted(ctx context.Context, roomID uint, startedAt time.Time) error
 	Delete(ctx context.Context, id uint) error
 }
 
@@ -39,7 +94,11 @@ type RoomPlayerRepository interface {
 	Create(ctx context.Context, roomPlayer *model.RoomPlayer) error
 	GetByRoomID(ctx context.Context, roomID uint) ([]*model.RoomPlayer, error)
 	GetByRoomIDAndUserID(ctx context.Context, roomID, userID uint) (*model.RoomPlayer, error)
+	GetActiveByUserID(ctx context.Context, userID uint) (*model.RoomPlayer, error)
 	Update(ctx context.Context, roomPlayer *model.RoomPlayer) error
+	ResetReady(ctx context.Context, roomID uint) error
+	ReassignPositions(ctx context.Context, roomID uint) (bool, error)
+	SaveWithFreePosition(ctx context.Context, roomPlayer *model.RoomPlayer) error
 	LeaveRoom(ctx context.Context, roomID, userID uint) error
 }
 
@@ -49,19 +108,84 @@ func NewRoomService(
 	roomPlayerRepo RoomPlayerRepository,
 	redisRoomRepo *redis.RoomRepository,
 	lockRepo *redis.LockRepository,
+	lobbyNotifier LobbyNotifier,
 	logger *zap.Logger,
-	maxPlayers int,
-	defaultTimeout time.Duration,
+	defaults RoomDefaults,
+	typeDefaults map[string]RoomDefaults,
+	eventChannel string,
+	allowMultiRoom bool,
+	readyPolicy ReadyPolicy,
+	userNotifier UserNotifier,
+	eventRetention EventRetention,
+	userRepo UserRepository,
+	jsonLimits utils.JSONLimits,
 ) *RoomService {
+	// 按游戏类型合并默认值，未设置的字段沿用全局默认值
+	merged := make(map[string]RoomDefaults, len(typeDefaults))
+	for gameType, d := range typeDefaults {
+		if d.MaxPlayers <= 0 {
+			d.MaxPlayers = defaults.MaxPlayers
+		}
+		if d.MinPlayers <= 0 {
+			d.MinPlayers = defaults.MinPlayers
+		}
+		if d.DefaultTimeout <= 0 {
+			d.DefaultTimeout = defaults.DefaultTimeout
+		}
+		merged[strings.ToLower(gameType)] = d
+	}
+
 	return &RoomService{
 		roomRepo:       roomRepo,
 		roomPlayerRepo: roomPlayerRepo,
 		redisRoomRepo:  redisRoomRepo,
 		lockRepo:       lockRepo,
+		lobbyNotifier:  lobbyNotifier,
+		userNotifier:   userNotifier,
 		logger:         logger,
-		maxPlayers:     maxPlayers,
-		defaultTimeout: defaultTimeout,
+		defaults:       defaults,
+		typeDefaults:   merged,
+		defaultTimeout: defaults.DefaultTimeout,
+		eventChannel:   eventChannel,
+		allowMultiRoom: allowMultiRoom,
+		readyPolicy:    readyPolicy,
+		eventRetention: eventRetention.withDefaults(),
+		userRepo:       userRepo,
+		jsonLimits:     jsonLimits,
+	}
+}
+
+// checkFeature 检查功能开关，关闭时返回 ErrCodeForbidden
+// 每次读取当前生效的配置，重新加载配置后立即生效；配置尚未加载时按默认开启处理
+func (s *RoomService) checkFeature(flag, name string) error {
+	if cfg := config.Get(); cfg != nil && !cfg.IsEnabled(flag) {
+		return utils.NewError(utils.ErrCodeForbidden, name+"功能未开启")
+	}
+	return nil
+}
+
+// checkUserActive 检查用户账号状态，被禁用的用户不能加入房间或候补
+func (s *RoomService) checkUserActive(ctx context.Context, userID uint) error {
+	users, err := s.userRepo.GetByIDs(ctx, []uint{userID})
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", userID))
+		return utils.NewInternalError("加入房间失败", err)
+	}
+	if len(users) == 0 {
+		return utils.NewError(utils.ErrCodeNotFound, "用户不存在")
+	}
+	if users[0].Status != model.UserStatusActive {
+		return utils.NewError(utils.ErrCodeForbidden, "账号已被禁用，无法加入房间")
+	}
+	return nil
+}
+
+// DefaultsFor 获取指定游戏类型的房间默认值，未配置的类型使用全局默认值
+func (s *RoomService) DefaultsFor(gameType string) RoomDefaults {
+	if d, ok := s.typeDefaults[strings.ToLower(gameType)]; ok {
+		return d
 	}
+	return s.defaults
 }
 
 // CreateRoomRequest 创建房间请求
@@ -78,15 +202,30 @@ type CreateRoomResponse struct {
 
 // CreateRoom 创建房间
 func (s *RoomService) CreateRoom(ctx context.Context, ownerID uint, req *CreateRoomRequest) (*CreateRoomResponse, error) {
+	if err := utils.ValidateMaxLength("name", "房间名称", req.Name, model.MaxRoomNameLength); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateMaxLength("game_type", "游戏类型", req.GameType, model.MaxGameTypeLength); err != nil {
+		return nil, err
+	}
+
 	// 生成房间代码
 	roomCode, err := generateRoomCode()
 	if err != nil {
 		s.logger.Error("生成房间代码失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "创建房间失败")
+		return nil, utils.NewInternalError("创建房间失败", err)
+	}
+
+	// 检查是否已在其他房间中
+	if err := s.checkNotInOtherRoom(ctx, ownerID, 0); err != nil {
+		return nil, err
 	}
 
+	// 按游戏类型获取默认值
+	defaults := s.DefaultsFor(req.GameType)
+
 	// 设置过期时间
-	expiresAt := time.Now().Add(s.defaultTimeout)
+	expiresAt := time.Now().Add(defaults.DefaultTimeout)
 
 	// 创建房间
 	room := &model.Room{
@@ -94,31 +233,37 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID uint, req *CreateR
 		Name:           req.Name,
 		OwnerID:        ownerID,
 		Status:         model.RoomStatusWaiting,
-		MaxPlayers:     s.maxPlayers,
+		MaxPlayers:     defaults.MaxPlayers,
+		MinPlayers:     defaults.MinPlayers,
 		CurrentPlayers: 0,
 		GameType:       req.GameType,
 		Settings:       req.Settings,
 		ExpiresAt:      &expiresAt,
 	}
+	if err := utils.CheckJSON([]byte(room.Settings), s.jsonLimits); err != nil {
+		return nil, err
+	}
+	if err := room.ValidateSettings(); err != nil {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, err.Error())
+	}
 
 	if err := s.roomRepo.Create(ctx, room); err != nil {
 		s.logger.Error("创建房间失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "创建房间失败")
+		return nil, utils.NewInternalError("创建房间失败", err)
 	}
 
 	// 添加房主到房间
 	roomPlayer := &model.RoomPlayer{
 		RoomID:   room.ID,
 		UserID:   ownerID,
-		IsReady:  false,
-		Position: 0,
+		IsReady:  s.readyPolicy.ownerAutoReady(room),
 		JoinedAt: time.Now(),
 	}
-	if err := s.roomPlayerRepo.Create(ctx, roomPlayer); err != nil {
+	if err := s.roomPlayerRepo.SaveWithFreePosition(ctx, roomPlayer); err != nil {
 		s.logger.Error("添加房主到房间失败", zap.Error(err))
 		// 回滚：删除房间
 		s.roomRepo.Delete(ctx, room.ID)
-		return nil, utils.NewError(utils.ErrCodeInternal, "创建房间失败")
+		return nil, utils.NewInternalError("创建房间失败", err)
 	}
 
 	// 更新房间玩家数
@@ -129,6 +274,8 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID uint, req *CreateR
 
 	// 同步到 Redis
 	s.syncRoomToRedis(ctx, room)
+	s.cacheRoomCode(ctx, room)
+	s.notifyLobby(LobbyEventRoomCreated, room)
 
 	return &CreateRoomResponse{
 		Room: room,
@@ -138,119 +285,164 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID uint, req *CreateR
 // JoinRoomRequest 加入房间请求
 type JoinRoomRequest struct {
 	RoomCode string `json:"room_code" binding:"required"`
+	Rejoin   bool   `json:"rejoin"` // 已在该房间中时返回当前房间状态而不是报错，便于断线重连
 }
 
 // JoinRoomResponse 加入房间响应
 type JoinRoomResponse struct {
-	Room *model.Room `json:"room"`
+	Room     *model.Room `json:"room"`
+	Rejoined bool        `json:"rejoined,omitempty"` // 是否为重复加入
 }
 
 // JoinRoom 加入房间
 func (s *RoomService) JoinRoom(ctx context.Context, userID uint, req *JoinRoomRequest) (*JoinRoomResponse, error) {
-	// 获取分布式锁
-	lockKey := "room:lock:" + req.RoomCode
-	acquired, err := s.lockRepo.AcquireLock(ctx, lockKey, 5*time.Second)
+	if err := s.checkUserActive(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// 通过房间代码索引获取房间 ID
+	roomID, err := s.resolveRoomID(ctx, req.RoomCode)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入房间失败", err)
+	}
+	if roomID == 0 {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在").WithReason(JoinReasonRoomNotFound)
+	}
+
+	// 获取分布式锁（与离开房间等操作使用同一把房间锁）
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
 	if err != nil {
 		s.logger.Error("获取锁失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "加入房间失败")
+		return nil, utils.NewInternalError("加入房间失败", err)
 	}
 	if !acquired {
 		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
 	}
-	defer s.lockRepo.ReleaseLock(ctx, lockKey)
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
 
-	// 获取房间
-	room, err := s.roomRepo.GetByRoomCode(ctx, req.RoomCode)
+	// 获取房间（持锁读取，优先使用缓存）
+	room, err := s.loadRoom(ctx, roomID)
 	if err != nil {
 		s.logger.Error("查询房间失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "加入房间失败")
+		return nil, utils.NewInternalError("加入房间失败", err)
 	}
 	if room == nil {
-		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+		s.invalidateRoomCode(ctx, req.RoomCode)
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在").WithReason(JoinReasonRoomNotFound)
+	}
+
+	// 检查是否已在房间中
+	existingPlayer, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, room.ID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入房间失败", err)
+	}
+	if existingPlayer != nil {
+		// 重连场景：已是房间成员时直接返回当前房间状态，不受房间状态和人数限制
+		if req.Rejoin {
+			return &JoinRoomResponse{
+				Room:     room,
+				Rejoined: true,
+			}, nil
+		}
+		return nil, utils.NewError(utils.ErrCodeConflict, "已在房间中").WithReason(JoinReasonAlreadyJoined)
 	}
 
 	// 检查房间状态
 	if room.Status != model.RoomStatusWaiting {
-		return nil, utils.NewError(utils.ErrCodeConflict, "房间已开始或已结束")
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间已开始或已结束").WithReason(JoinReasonRoomStarted)
 	}
 
 	// 检查房间是否已满
 	if room.CurrentPlayers >= room.MaxPlayers {
-		return nil, utils.NewError(utils.ErrCodeConflict, "房间已满")
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间已满").WithReason(JoinReasonRoomFull)
 	}
 
-	// 检查是否已在房间中
-	existingPlayer, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, room.ID, userID)
-	if err != nil {
-		s.logger.Error("查询房间玩家失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "加入房间失败")
-	}
-	if existingPlayer != nil {
-		return nil, utils.NewError(utils.ErrCodeConflict, "已在房间中")
+	// 检查是否已在其他房间中
+	if err := s.checkNotInOtherRoom(ctx, userID, room.ID); err != nil {
+		return nil, err
 	}
 
-	// 添加玩家到房间
-	players, err := s.roomPlayerRepo.GetByRoomID(ctx, room.ID)
-	if err != nil {
-		s.logger.Error("查询房间玩家失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "加入房间失败")
+	if err := s.addPlayer(ctx, room, userID); err != nil {
+		s.logger.Error("添加玩家到房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入房间失败", err)
 	}
 
+	return &JoinRoomResponse{
+		Room: room,
+	}, nil
+}
+
+// addPlayer 将用户作为玩家加入房间，分配第一个空闲位置并更新玩家数，调用方需持有房间锁
+func (s *RoomService) addPlayer(ctx context.Context, room *model.Room, userID uint) error {
 	roomPlayer := &model.RoomPlayer{
 		RoomID:   room.ID,
 		UserID:   userID,
 		IsReady:  false,
-		Position: len(players),
 		JoinedAt: time.Now(),
 	}
-	if err := s.roomPlayerRepo.Create(ctx, roomPlayer); err != nil {
-		s.logger.Error("添加玩家到房间失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "加入房间失败")
+	if err := s.roomPlayerRepo.SaveWithFreePosition(ctx, roomPlayer); err != nil {
+		return err
 	}
 
-	// 更新房间玩家数
+	// 更新房间玩家数，房间可能是从 Redis 状态还原的（缺少部分字段），只更新玩家数一列
 	room.CurrentPlayers++
-	if err := s.roomRepo.Update(ctx, room); err != nil {
+	if err := s.roomRepo.UpdateCurrentPlayers(ctx, room.ID, room.CurrentPlayers); err != nil {
 		s.logger.Error("更新房间失败", zap.Error(err))
 	}
 
 	// 同步到 Redis
 	s.syncRoomToRedis(ctx, room)
 	s.redisRoomRepo.AddRoomPlayer(ctx, room.ID, userID)
-
-	return &JoinRoomResponse{
-		Room: room,
-	}, nil
+	s.notifyLobby(LobbyEventRoomUpdated, room)
+	return nil
 }
 
 // LeaveRoom 离开房间
 func (s *RoomService) LeaveRoom(ctx context.Context, userID uint, roomID uint) error {
 	// 获取分布式锁
-	lockKey := "room:lock:" + string(rune(roomID))
-	acquired, err := s.lockRepo.AcquireLock(ctx, lockKey, 5*time.Second)
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
 	if err != nil {
 		s.logger.Error("获取锁失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "离开房间失败")
+		return utils.NewInternalError("离开房间失败", err)
 	}
 	if !acquired {
 		return utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
 	}
-	defer s.lockRepo.ReleaseLock(ctx, lockKey)
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
 
 	// 获取房间
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		s.logger.Error("查询房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "离开房间失败")
+		return utils.NewInternalError("离开房间失败", err)
 	}
 	if room == nil {
 		return utils.NewError(utils.ErrCodeNotFound, "房间不存在")
 	}
 
+	member, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return utils.NewInternalError("离开房间失败", err)
+	}
+	// 不在房间中时直接返回，避免重复离开把房间人数减到错误的值
+	if member == nil {
+		return utils.NewError(utils.ErrCodeNotFound, "不在房间中")
+	}
+
 	// 离开房间
 	if err := s.roomPlayerRepo.LeaveRoom(ctx, roomID, userID); err != nil {
 		s.logger.Error("离开房间失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "离开房间失败")
+		return utils.NewInternalError("离开房间失败", err)
+	}
+
+	// 观战者离开不影响座位
+	if member.IsSpectator {
+		return nil
 	}
 
 	// 更新房间玩家数
@@ -267,21 +459,230 @@ func (s *RoomService) LeaveRoom(ctx context.Context, userID uint, roomID uint) e
 			s.logger.Error("删除房间失败", zap.Error(err))
 		}
 		s.redisRoomRepo.DeleteRoom(ctx, roomID)
+		s.invalidateRoomCode(ctx, room.RoomCode)
+		s.notifyLobby(LobbyEventRoomClosed, room)
 	} else {
 		// 同步到 Redis
 		s.syncRoomToRedis(ctx, room)
 		s.redisRoomRepo.RemoveRoomPlayer(ctx, roomID, userID)
+		s.notifyLobby(LobbyEventRoomUpdated, room)
+		s.compactPositions(ctx, roomID)
+		s.promoteFromWaitlist(ctx, room)
 	}
 
 	return nil
 }
 
-// GetRoom 获取房间信息
-func (s *RoomService) GetRoom(ctx context.Context, roomID uint) (*model.Room, error) {
+// maxLeaveAllRooms LeaveAllRooms 最多离开的房间数，防止异常数据导致死循环
+const maxLeaveAllRooms = 20
+
+// LeaveAllRooms 离开用户所在的所有未结束房间（如注销账号时）
+func (s *RoomService) LeaveAllRooms(ctx context.Context, userID uint) error {
+	for i := 0; i < maxLeaveAllRooms; i++ {
+		active, err := s.roomPlayerRepo.GetActiveByUserID(ctx, userID)
+		if err != nil {
+			s.logger.Error("查询用户所在房间失败", zap.Error(err))
+			return utils.NewInternalError("离开房间失败", err)
+		}
+		if active == nil {
+			return nil
+		}
+		if err := s.LeaveRoom(ctx, userID, active.RoomID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactPositions 玩家离开后压缩剩余玩家的位置，保持在场玩家位置为 0..n-1
+// 位置发生变化时发布 positions_changed 事件，调用方需持有房间锁
+func (s *RoomService) compactPositions(ctx context.Context, roomID uint) {
+	changed, err := s.roomPlayerRepo.ReassignPositions(ctx, roomID)
+	if err != nil {
+		s.logger.Error("重排玩家位置失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return
+	}
+	if !changed {
+		return
+	}
+
+	players, err := s.roomPlayerRepo.GetByRoomID(ctx, roomID)
+	if err != nil {
+		s.logger.Warn("查询房间玩家失败", zap.Error(err), zap.Uint("room_id", roomID))
+		return
+	}
+	positions := make(map[uint]int, len(players))
+	for _, player := range players {
+		if player.Position != nil {
+			positions[player.UserID] = *player.Position
+		}
+	}
+
+	event := &GameEvent{
+		Type:      "positions_changed",
+		RoomID:    roomID,
+		Data:      map[string]interface{}{"positions": positions},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.publishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+}
+
+// UpdateRoomSettingsRequest 更新房间设置请求
+type UpdateRoomSettingsRequest struct {
+	Settings string `json:"settings" binding:"required"` // JSON 格式
+}
+
+// UpdateSettings 更新房间设置（仅房主，且房间处于等待状态）
+func (s *RoomService) UpdateSettings(ctx context.Context, ownerID uint, roomID uint, settings string) (*model.Room, error) {
+	if err := utils.CheckJSON([]byte(settings), s.jsonLimits); err != nil {
+		return nil, err
+	}
+	if err := (&model.Room{Settings: settings}).ValidateSettings(); err != nil {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, err.Error())
+	}
+
+	// 获取分布式锁
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("更新房间设置失败", err)
+	}
+	if !acquired {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	// 获取房间
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("更新房间设置失败", err)
+	}
+	if room == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+
+	// 检查权限和房间状态
+	if room.OwnerID != ownerID {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "只有房主可以修改房间设置")
+	}
+	if room.Status != model.RoomStatusWaiting {
+		return nil, utils.NewError(utils.ErrCodeConflict, "游戏已开始，无法修改房间设置")
+	}
+
+	room.Settings = settings
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		s.logger.Error("更新房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("更新房间设置失败", err)
+	}
+
+	// 同步到 Redis
+	s.syncRoomToRedis(ctx, room)
+	s.notifyLobby(LobbyEventRoomUpdated, room)
+
+	// 发布房间设置变更事件
+	event := &GameEvent{
+		Type:      "room_settings_changed",
+		RoomID:    room.ID,
+		UserID:    ownerID,
+		Data:      map[string]interface{}{"settings": room.Settings},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.publishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	return room, nil
+}
+
+// Rematch 再来一局：已结束的房间回到等待状态，保留原有玩家和观战者并重置准备状态（仅房主）
+// 上一局的游戏状态和事件历史被清除，事件序号继续递增，客户端已有的游标仍然有效
+func (s *RoomService) Rematch(ctx context.Context, ownerID uint, roomID uint) (*model.Room, error) {
+	// 获取分布式锁
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("再来一局失败", err)
+	}
+	if !acquired {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	// 获取房间
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		s.logger.Error("查询房间失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "获取房间失败")
+		return nil, utils.NewInternalError("再来一局失败", err)
+	}
+	if room == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
+	}
+
+	// 检查权限和房间状态
+	if room.OwnerID != ownerID {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "只有房主可以发起再来一局")
+	}
+	if room.Status != model.RoomStatusFinished {
+		return nil, utils.NewError(utils.ErrCodeConflict, "游戏尚未结束")
+	}
+
+	// 重置玩家准备状态
+	if err := s.roomPlayerRepo.ResetReady(ctx, room.ID); err != nil {
+		s.logger.Error("重置玩家准备状态失败", zap.Error(err))
+		return nil, utils.NewInternalError("再来一局失败", err)
+	}
+	s.readyOwner(ctx, room)
+
+	// 重置房间状态
+	room.Status = model.RoomStatusWaiting
+	room.StartedAt = nil
+	room.EndedAt = nil
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		s.logger.Error("更新房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("再来一局失败", err)
+	}
+
+	// 清除上一局的游戏状态和事件历史后重新同步到 Redis，观战者不受影响，事件序号继续递增
+	if err := s.redisRoomRepo.ClearRoomState(ctx, room.ID); err != nil {
+		s.logger.Warn("清除房间状态失败", zap.Error(err), zap.Uint("room_id", room.ID))
+	}
+	if err := s.redisRoomRepo.ResetRoomEvents(ctx, room.ID); err != nil {
+		s.logger.Warn("清除房间事件失败", zap.Error(err), zap.Uint("room_id", room.ID))
+	}
+	s.syncRoomToRedis(ctx, room)
+	s.notifyLobby(LobbyEventRoomUpdated, room)
+
+	// 发布重新开始事件
+	event := &GameEvent{
+		Type:      "game_restarted",
+		RoomID:    room.ID,
+		UserID:    ownerID,
+		Data:      map[string]interface{}{"room": room},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.publishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+
+	return room, nil
+}
+
+// roomCacheTTL 房间详情读缓存的有效期，房间变更时主动失效，较短的有效期兜底遗漏的失效
+const roomCacheTTL = 10 * time.Second
+
+// GetRoom 获取房间信息，优先读取缓存
+func (s *RoomService) GetRoom(ctx context.Context, roomID uint) (*model.Room, error) {
+	room, err := cache.GetOrLoad(ctx, s.redisRoomRepo.Client(), redis.RoomCacheKey(roomID), roomCacheTTL, func(ctx context.Context) (*model.Room, error) {
+		return s.roomRepo.GetByID(ctx, roomID)
+	})
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取房间失败", err)
 	}
 	if room == nil {
 		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在")
@@ -289,9 +690,89 @@ func (s *RoomService) GetRoom(ctx context.Context, roomID uint) (*model.Room, er
 	return room, nil
 }
 
-// ListRooms 列出房间
-func (s *RoomService) ListRooms(ctx context.Context, status *model.RoomStatus, limit, offset int) ([]*model.Room, error) {
-	return s.roomRepo.List(ctx, status, limit, offset)
+// ListRooms 分页列出房间
+func (s *RoomService) ListRooms(ctx context.Context, status *model.RoomStatus, page, pageSize int) (*utils.PageResult[*model.Room], error) {
+	params := utils.Paginate(page, pageSize)
+
+	total, err := s.roomRepo.Count(ctx, status)
+	if err != nil {
+		s.logger.Error("统计房间数量失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取房间列表失败", err)
+	}
+
+	rooms, err := s.roomRepo.List(ctx, status, params.Limit(), params.Offset())
+	if err != nil {
+		s.logger.Error("查询房间列表失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取房间列表失败", err)
+	}
+
+	s.applyLiveStates(ctx, rooms)
+
+	return utils.NewPageResult(rooms, total, params), nil
+}
+
+// ListRoomsByCursor 游标分页获取房间列表，深分页时性能不随页数下降
+// cursor 为空时从第一页开始，返回的 next_cursor 为空表示没有更多数据
+func (s *RoomService) ListRoomsByCursor(ctx context.Context, status *model.RoomStatus, cursor string, pageSize int) (*utils.CursorResult[*model.Room], error) {
+	params := utils.Paginate(1, pageSize)
+
+	var after utils.Cursor
+	if cursor != "" {
+		var err error
+		if after, err = utils.DecodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// 多查一条用于判断是否还有下一页
+	rooms, err := s.roomRepo.ListAfter(ctx, status, after.CreatedAt, after.ID, params.Limit()+1)
+	if err != nil {
+		s.logger.Error("查询房间列表失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取房间列表失败", err)
+	}
+
+	var next string
+	if len(rooms) > params.Limit() {
+		rooms = rooms[:params.Limit()]
+		last := rooms[len(rooms)-1]
+		next = utils.EncodeCursor(utils.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	s.applyLiveStates(ctx, rooms)
+
+	return utils.NewCursorResult(rooms, next), nil
+}
+
+// applyLiveStates 用 Redis 中的实时状态覆盖房间的玩家数和状态，一次批量读取
+// Redis 不可用或房间不在 Redis 中时保留数据库中的值
+func (s *RoomService) applyLiveStates(ctx context.Context, rooms []*model.Room) {
+	if len(rooms) == 0 {
+		return
+	}
+
+	roomIDs := make([]uint, len(rooms))
+	for i, room := range rooms {
+		roomIDs[i] = room.ID
+	}
+
+	states, err := s.redisRoomRepo.GetRoomStatesBatch(ctx, roomIDs)
+	if err != nil {
+		s.logger.Debug("批量获取房间状态失败，使用数据库数据", zap.Error(err))
+		return
+	}
+
+	for _, room := range rooms {
+		state, ok := states[room.ID]
+		if !ok {
+			continue
+		}
+		if players, err := strconv.Atoi(state["current_players"]); err == nil {
+			room.CurrentPlayers = players
+		}
+		if status, err := strconv.Atoi(state["status"]); err == nil {
+			room.Status = model.RoomStatus(status)
+		}
+	}
 }
 
 // syncRoomToRedis 同步房间到 Redis
@@ -299,10 +780,12 @@ func (s *RoomService) syncRoomToRedis(ctx context.Context, room *model.Room) {
 	roomData := map[string]interface{}{
 		"id":              room.ID,
 		"room_code":       room.RoomCode,
+		"created_at":      room.CreatedAt.Unix(),
 		"name":            room.Name,
 		"owner_id":        room.OwnerID,
-		"status":          room.Status,
+		"status":          int(room.Status),
 		"max_players":     room.MaxPlayers,
+		"min_players":     room.MinPlayers,
 		"current_players": room.CurrentPlayers,
 		"game_type":      room.GameType,
 		"settings":        room.Settings,
@@ -310,7 +793,67 @@ func (s *RoomService) syncRoomToRedis(ctx context.Context, room *model.Room) {
 	if room.ExpiresAt != nil {
 		roomData["expires_at"] = room.ExpiresAt.Unix()
 	}
-	s.redisRoomRepo.SetRoomState(ctx, room.ID, roomData, s.defaultTimeout)
+	if room.StartedAt != nil {
+		roomData["started_at"] = room.StartedAt.Unix()
+	}
+	if room.EndedAt != nil {
+		roomData["ended_at"] = room.EndedAt.Unix()
+	}
+	if err := s.redisRoomRepo.InvalidateRoomCache(ctx, room.ID); err != nil {
+		s.logger.Debug("删除房间读缓存失败", zap.Error(err), zap.Uint("room_id", room.ID))
+	}
+
+	// 房间缓存可降级，同步失败不影响主流程
+	if err := s.redisRoomRepo.SetRoomState(ctx, room.ID, roomData, s.defaultTimeout); err != nil {
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			s.logger.Debug("缓存不可用，跳过房间同步", zap.Uint("room_id", room.ID))
+			return
+		}
+		s.logger.Warn("同步房间到 Redis 失败", zap.Error(err), zap.Uint("room_id", room.ID))
+	}
+}
+
+// checkNotInOtherRoom 检查用户是否已在其他未结束的房间中（允许多房间时跳过）
+func (s *RoomService) checkNotInOtherRoom(ctx context.Context, userID uint, roomID uint) error {
+	if s.allowMultiRoom {
+		return nil
+	}
+
+	active, err := s.roomPlayerRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("查询用户所在房间失败", zap.Error(err))
+		return utils.NewInternalError("查询用户所在房间失败", err)
+	}
+	if active != nil && active.RoomID != roomID {
+		return utils.NewError(utils.ErrCodeConflict, "已在其他房间中，请先离开").WithReason(JoinReasonInOtherRoom)
+	}
+	return nil
+}
+
+// notifyLobby 通知对应游戏类型大厅的订阅者
+func (s *RoomService) notifyLobby(eventType string, room *model.Room) {
+	if s.lobbyNotifier == nil || room.GameType == "" {
+		return
+	}
+	s.lobbyNotifier.BroadcastToLobby(room.GameType, map[string]interface{}{
+		"type": eventType,
+		"data": room,
+	})
+}
+
+// publishEvent 发布房间事件，发布前分配房间内的事件序号并记录到房间事件列表
+func (s *RoomService) publishEvent(ctx context.Context, event *GameEvent) error {
+	eventData, err := recordEvent(ctx, s.redisRoomRepo, s.eventRetention, event)
+	if err != nil {
+		return err
+	}
+	_, err = s.redisRoomRepo.Client().Publish(ctx, s.eventChannel, eventData)
+	return err
+}
+
+// roomLockKey 房间 ID 对应的锁名
+func roomLockKey(roomID uint) string {
+	return "room:lock:" + strconv.FormatUint(uint64(roomID), 10)
 }
 
 // generateRoomCode 生成房间代码
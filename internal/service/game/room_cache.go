@@ -0,0 +1,130 @@
+package game
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"go.uber.org/zap"
+)
+
+// resolveRoomID 通过房间代码获取房间 ID，优先使用 Redis 索引，未命中时查询数据库并回填
+// 房间不存在时返回 0
+func (s *RoomService) resolveRoomID(ctx context.Context, roomCode string) (uint, error) {
+	if roomID, err := s.redisRoomRepo.GetRoomIDByCode(ctx, roomCode); err == nil && roomID != 0 {
+		return roomID, nil
+	}
+
+	room, err := s.roomRepo.GetByRoomCode(ctx, roomCode)
+	if err != nil {
+		return 0, err
+	}
+	if room == nil {
+		return 0, nil
+	}
+
+	s.cacheRoomCode(ctx, room)
+	return room.ID, nil
+}
+
+// loadRoom 获取房间，优先读取 Redis 中的房间状态，缓存不完整时回退到数据库
+// 调用方需持有房间锁，保证读取的状态不会被并发修改
+func (s *RoomService) loadRoom(ctx context.Context, roomID uint) (*model.Room, error) {
+	if state, err := s.redisRoomRepo.GetRoomState(ctx, roomID); err == nil {
+		if room, ok := roomFromState(state); ok && room.ID == roomID {
+			return room, nil
+		}
+	}
+	return s.roomRepo.GetByID(ctx, roomID)
+}
+
+// cacheRoomCode 写入房间代码到房间 ID 的索引，有效期与房间一致
+func (s *RoomService) cacheRoomCode(ctx context.Context, room *model.Room) {
+	expiration := s.defaultTimeout
+	if room.ExpiresAt != nil {
+		expiration = time.Until(*room.ExpiresAt)
+	}
+	if expiration <= 0 {
+		return
+	}
+
+	if err := s.redisRoomRepo.SetRoomCode(ctx, room.RoomCode, room.ID, expiration); err != nil {
+		s.logger.Debug("写入房间代码索引失败", zap.Error(err), zap.String("room_code", room.RoomCode))
+	}
+}
+
+// invalidateRoomCode 删除房间代码索引
+func (s *RoomService) invalidateRoomCode(ctx context.Context, roomCode string) {
+	if err := s.redisRoomRepo.DeleteRoomCode(ctx, roomCode); err != nil {
+		s.logger.Warn("删除房间代码索引失败", zap.Error(err), zap.String("room_code", roomCode))
+	}
+}
+
+// roomFromState 从 Redis 房间状态还原房间，缺少必要字段时返回 false
+func roomFromState(state map[string]string) (*model.Room, bool) {
+	room := &model.Room{
+		RoomCode: state["room_code"],
+		Name:     state["name"],
+		GameType: state["game_type"],
+		Settings: state["settings"],
+	}
+	if room.RoomCode == "" {
+		return nil, false
+	}
+
+	uints := map[string]*uint{
+		"id":       &room.ID,
+		"owner_id": &room.OwnerID,
+	}
+	for key, target := range uints {
+		value, err := strconv.ParseUint(state[key], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		*target = uint(value)
+	}
+
+	ints := map[string]*int{
+		"max_players":     &room.MaxPlayers,
+		"min_players":     &room.MinPlayers,
+		"current_players": &room.CurrentPlayers,
+	}
+	for key, target := range ints {
+		value, err := strconv.Atoi(state[key])
+		if err != nil {
+			return nil, false
+		}
+		*target = value
+	}
+
+	status, err := strconv.Atoi(state["status"])
+	if err != nil {
+		return nil, false
+	}
+	room.Status = model.RoomStatus(status)
+
+	createdAt, err := strconv.ParseInt(state["created_at"], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	room.CreatedAt = time.Unix(createdAt, 0)
+
+	times := map[string]**time.Time{
+		"started_at": &room.StartedAt,
+		"ended_at":   &room.EndedAt,
+		"expires_at": &room.ExpiresAt,
+	}
+	for key, target := range times {
+		if raw, ok := state[key]; ok {
+			unix, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			t := time.Unix(unix, 0)
+			*target = &t
+		}
+	}
+
+	return room, true
+}
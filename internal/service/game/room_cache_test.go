@@ -0,0 +1,131 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+)
+
+func TestJoinRoomCodeCache(t *testing.T) {
+	defaults := RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}
+	ctx := context.Background()
+
+	t.Run("命中缓存", func(t *testing.T) {
+		s, roomRepo, _ := newTestRoomService(t, defaults, nil)
+		created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "cached", GameType: "chess"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lookups := roomRepo.CodeLookups()
+
+		if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+			t.Fatalf("JoinRoom() error = %v", err)
+		}
+		if got := roomRepo.CodeLookups() - lookups; got != 0 {
+			t.Errorf("GetByRoomCode 调用 %d 次，期望走缓存", got)
+		}
+	})
+
+	t.Run("未命中时回退数据库并回填", func(t *testing.T) {
+		s, roomRepo, _ := newTestRoomService(t, defaults, nil)
+		room := &model.Room{RoomCode: "DBONLY", Name: "db", GameType: "chess", OwnerID: 1, Status: model.RoomStatusWaiting, MaxPlayers: 4, MinPlayers: 1}
+		roomRepo.Create(ctx, room)
+
+		if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: room.RoomCode}); err != nil {
+			t.Fatalf("JoinRoom() error = %v", err)
+		}
+		if got := roomRepo.CodeLookups(); got != 1 {
+			t.Errorf("GetByRoomCode 调用 %d 次，期望 1 次", got)
+		}
+		if roomID, err := s.redisRoomRepo.GetRoomIDByCode(ctx, room.RoomCode); err != nil || roomID != room.ID {
+			t.Errorf("索引 = %d, %v，期望已回填 %d", roomID, err, room.ID)
+		}
+	})
+
+	t.Run("删除房间后失效", func(t *testing.T) {
+		s, _, _ := newTestRoomService(t, defaults, nil)
+		created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "closing", GameType: "chess"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.LeaveRoom(ctx, 1, created.Room.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.redisRoomRepo.GetRoomIDByCode(ctx, created.Room.RoomCode); err == nil {
+			t.Error("房间删除后索引仍然存在")
+		}
+		_, err = s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode})
+		assertErrCode(t, err, utils.ErrCodeNotFound)
+	})
+}
+
+func TestJoinCachedRoomKeepsStoredFields(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "cached", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Redis 中的房间状态不包含更新时间，且创建时间只精确到秒
+	stored, _ := roomRepo.GetByID(ctx, created.Room.ID)
+	stored.CreatedAt = time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	stored.UpdatedAt = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	roomRepo.Update(ctx, stored)
+
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatalf("JoinRoom() error = %v", err)
+	}
+	got, _ := roomRepo.GetByID(ctx, created.Room.ID)
+	if got.CurrentPlayers != 2 {
+		t.Errorf("CurrentPlayers = %d, want 2", got.CurrentPlayers)
+	}
+	if !got.CreatedAt.Equal(stored.CreatedAt) || !got.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Errorf("CreatedAt, UpdatedAt = %v, %v, 不应被缓存中的房间覆盖", got.CreatedAt, got.UpdatedAt)
+	}
+}
+
+func TestRoomFromState(t *testing.T) {
+	s, _, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	startedAt := time.Unix(1700000000, 0)
+	room := &model.Room{
+		ID:             7,
+		RoomCode:       "ABC123",
+		Name:           "room",
+		OwnerID:        3,
+		GameType:       "chess",
+		Status:         model.RoomStatusPlaying,
+		MaxPlayers:     4,
+		MinPlayers:     2,
+		CurrentPlayers: 2,
+		Settings:       `{"rounds":3}`,
+		CreatedAt:      time.Unix(1690000000, 0),
+		StartedAt:      &startedAt,
+	}
+	s.syncRoomToRedis(ctx, room)
+
+	state, err := s.redisRoomRepo.GetRoomState(ctx, room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := roomFromState(state)
+	if !ok {
+		t.Fatalf("roomFromState(%v) 失败", state)
+	}
+	if got.ID != room.ID || got.RoomCode != room.RoomCode || got.OwnerID != room.OwnerID ||
+		got.Status != room.Status || got.CurrentPlayers != room.CurrentPlayers || got.Settings != room.Settings ||
+		!got.CreatedAt.Equal(room.CreatedAt) || got.StartedAt == nil || !got.StartedAt.Equal(startedAt) || got.EndedAt != nil {
+		t.Errorf("roomFromState() = %+v, want %+v", got, room)
+	}
+
+	delete(state, "created_at")
+	if _, ok := roomFromState(state); ok {
+		t.Error("缺少必要字段时应回退数据库")
+	}
+}
@@ -0,0 +1,871 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/config"
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// newTestRoomService 创建使用内存仓库和 miniredis 的房间服务
+func newTestRoomService(t *testing.T, defaults RoomDefaults, typeDefaults map[string]RoomDefaults) (*RoomService, *testutil.RoomRepo, *testutil.RoomPlayerRepo) {
+	t.Helper()
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+	s := NewRoomService(
+		roomRepo,
+		roomPlayerRepo,
+		redis.NewRoomRepository(repo),
+		redis.NewLockRepository(repo),
+		nil,
+		zap.NewNop(),
+		defaults,
+		typeDefaults,
+		"game:events",
+		false,
+		ReadyPolicy{},
+		nil,
+		EventRetention{},
+		userStatuses{},
+		utils.JSONLimits{},
+	)
+	return s, roomRepo, roomPlayerRepo
+}
+
+// useFeatures 加载只设置了功能开关的全局配置，测试结束后恢复为全部开启
+func useFeatures(t *testing.T, features map[string]bool) {
+	t.Helper()
+	load := func(features map[string]bool) {
+		var content strings.Builder
+		content.WriteString("database:\n  mysql:\n    user: game\n    dbname: game_apps\njwt:\n  secret: test-jwt-secret\nfeatures:\n")
+		for flag, enabled := range features {
+			fmt.Fprintf(&content, "  %s: %v\n", flag, enabled)
+		}
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		viper.Reset()
+		if _, err := config.Load(path); err != nil {
+			t.Fatalf("config.Load() error = %v", err)
+		}
+	}
+
+	load(features)
+	t.Cleanup(func() {
+		all := make(map[string]bool, len(config.KnownFeatures))
+		for _, flag := range config.KnownFeatures {
+			all[flag] = true
+		}
+		load(all)
+		viper.Reset()
+	})
+}
+
+// userStatuses 按用户 ID 指定账号状态的内存用户仓库，未指定的用户视为正常状态
+type userStatuses map[uint]int
+
+func (r userStatuses) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	users := make([]*model.User, 0, len(ids))
+	for _, id := range ids {
+		status, ok := r[id]
+		if !ok {
+			status = model.UserStatusActive
+		}
+		users = append(users, &model.User{ID: id, Status: status})
+	}
+	return users, nil
+}
+
+func TestCreateRoomUsesGameTypeDefaults(t *testing.T) {
+	s, _, _ := newTestRoomService(t,
+		RoomDefaults{MaxPlayers: 10, MinPlayers: 1, DefaultTimeout: 5 * time.Minute},
+		map[string]RoomDefaults{
+			"chess": {MaxPlayers: 2, MinPlayers: 2, DefaultTimeout: 10 * time.Minute},
+			"Poker": {MaxPlayers: 6, MinPlayers: 2},
+		},
+	)
+
+	tests := []struct {
+		name        string
+		gameType    string
+		wantMax     int
+		wantMin     int
+		wantTimeout time.Duration
+	}{
+		{"象棋", "chess", 2, 2, 10 * time.Minute},
+		{"扑克沿用全局超时", "poker", 6, 2, 5 * time.Minute},
+		{"未配置的类型使用全局默认值", "go", 10, 1, 5 * time.Minute},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			resp, err := s.CreateRoom(context.Background(), uint(i+1), &CreateRoomRequest{Name: "test", GameType: tt.gameType})
+			if err != nil {
+				t.Fatalf("CreateRoom() error = %v", err)
+			}
+			room := resp.Room
+			if room.MaxPlayers != tt.wantMax || room.MinPlayers != tt.wantMin {
+				t.Errorf("players = %d..%d, want %d..%d", room.MinPlayers, room.MaxPlayers, tt.wantMin, tt.wantMax)
+			}
+			if room.ExpiresAt == nil || room.ExpiresAt.Sub(before) < tt.wantTimeout || room.ExpiresAt.Sub(before) > tt.wantTimeout+time.Second {
+				t.Errorf("ExpiresAt = %v, want about %v after creation", room.ExpiresAt, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestCreateRoomValidatesLength(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		req      CreateRoomRequest
+		wantCode int
+	}{
+		{"名称达到上限", CreateRoomRequest{Name: strings.Repeat("房", model.MaxRoomNameLength), GameType: "chess"}, 0},
+		{"名称超长", CreateRoomRequest{Name: strings.Repeat("房", model.MaxRoomNameLength+1), GameType: "chess"}, utils.ErrCodeInvalidInput},
+		{"游戏类型超长", CreateRoomRequest{Name: "room", GameType: strings.Repeat("g", model.MaxGameTypeLength+1)}, utils.ErrCodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := roomRepo.Count(ctx, nil)
+			_, err := s.CreateRoom(ctx, 1, &tt.req)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				if after, _ := roomRepo.Count(ctx, nil); after != before {
+					t.Errorf("rejected room was saved: count %d -> %d", before, after)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateRoom() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRoomSettingsJSONLimits(t *testing.T) {
+	s, _, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	s.jsonLimits = utils.JSONLimits{MaxDepth: 8, MaxBytes: 1024}
+	ctx := context.Background()
+	deep := strings.Repeat(`{"a":`, 9) + "1" + strings.Repeat("}", 9)
+
+	_, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess", Settings: deep})
+	assertErrCode(t, err, utils.ErrCodeInvalidInput)
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess", Settings: `{"board":{"size":19}}`})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+	_, err = s.UpdateSettings(ctx, 1, created.Room.ID, deep)
+	assertErrCode(t, err, utils.ErrCodeInvalidInput)
+}
+
+func TestListRoomsPaging(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		roomRepo.Create(ctx, &model.Room{Name: "room", Status: model.RoomStatusWaiting})
+	}
+	roomRepo.Create(ctx, &model.Room{Name: "playing", Status: model.RoomStatusPlaying})
+
+	waiting := model.RoomStatusWaiting
+	tests := []struct {
+		name      string
+		status    *model.RoomStatus
+		page      int
+		pageSize  int
+		wantItems int
+		wantTotal int64
+		wantMore  bool
+	}{
+		{"第一页", &waiting, 1, 2, 2, 5, true},
+		{"最后一页", &waiting, 3, 2, 1, 5, false},
+		{"不过滤状态", nil, 1, 10, 6, 6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.ListRooms(ctx, tt.status, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("ListRooms() error = %v", err)
+			}
+			if len(result.Items) != tt.wantItems || result.Total != tt.wantTotal || result.HasMore != tt.wantMore {
+				t.Errorf("ListRooms() = %d items, total %d, has_more %v, want %d, %d, %v",
+					len(result.Items), result.Total, result.HasMore, tt.wantItems, tt.wantTotal, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestListRoomsByCursor(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	// 前 3 个房间创建时间相同，只能靠 ID 区分先后
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 7; i++ {
+		createdAt := base.Add(time.Duration(i) * time.Second)
+		if i < 3 {
+			createdAt = base
+		}
+		roomRepo.Create(ctx, &model.Room{Name: "room", Status: model.RoomStatusWaiting, CreatedAt: createdAt})
+	}
+
+	seen := make(map[uint]bool)
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 7 {
+			t.Fatal("分页未结束")
+		}
+		result, err := s.ListRoomsByCursor(ctx, nil, cursor, 2)
+		if err != nil {
+			t.Fatalf("ListRoomsByCursor() error = %v", err)
+		}
+		for _, room := range result.Items {
+			if seen[room.ID] {
+				t.Fatalf("房间 %d 重复出现", room.ID)
+			}
+			seen[room.ID] = true
+		}
+		if !result.HasMore {
+			break
+		}
+		cursor = result.NextCursor
+
+		// 翻页之间创建的新房间排在最前面，不影响后续页
+		roomRepo.Create(ctx, &model.Room{Name: "new", Status: model.RoomStatusWaiting})
+	}
+	for id := uint(1); id <= 7; id++ {
+		if !seen[id] {
+			t.Errorf("房间 %d 被跳过", id)
+		}
+	}
+
+	_, err := s.ListRoomsByCursor(ctx, nil, "not-a-cursor", 2)
+	assertErrCode(t, err, utils.ErrCodeInvalidInput)
+}
+
+func TestListRoomsLiveStates(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	live := &model.Room{Name: "live", Status: model.RoomStatusWaiting, CurrentPlayers: 1}
+	stale := &model.Room{Name: "stale", Status: model.RoomStatusWaiting, CurrentPlayers: 1}
+	roomRepo.Create(ctx, live)
+	roomRepo.Create(ctx, stale)
+	s.redisRoomRepo.SetRoomState(ctx, live.ID, map[string]interface{}{"status": int(model.RoomStatusPlaying), "current_players": 3}, 0)
+
+	result, err := s.ListRooms(ctx, nil, 1, 10)
+	if err != nil {
+		t.Fatalf("ListRooms() error = %v", err)
+	}
+	for _, room := range result.Items {
+		switch room.ID {
+		case live.ID:
+			if room.CurrentPlayers != 3 || room.Status != model.RoomStatusPlaying {
+				t.Errorf("live room = %d players, status %d, want 3, %d", room.CurrentPlayers, room.Status, model.RoomStatusPlaying)
+			}
+		case stale.ID:
+			// 不在 Redis 中的房间保留数据库中的值
+			if room.CurrentPlayers != 1 || room.Status != model.RoomStatusWaiting {
+				t.Errorf("stale room = %d players, status %d, want 1, %d", room.CurrentPlayers, room.Status, model.RoomStatusWaiting)
+			}
+		}
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const ownerID, otherID = 1, 2
+
+	waiting := &model.Room{OwnerID: ownerID, Status: model.RoomStatusWaiting, Settings: "{}"}
+	playing := &model.Room{OwnerID: ownerID, Status: model.RoomStatusPlaying, Settings: "{}"}
+	roomRepo.Create(ctx, waiting)
+	roomRepo.Create(ctx, playing)
+
+	tests := []struct {
+		name     string
+		userID   uint
+		roomID   uint
+		settings string
+		wantCode int
+	}{
+		{"房主修改等待中的房间", ownerID, waiting.ID, `{"rounds":3}`, 0},
+		{"非房主", otherID, waiting.ID, `{"rounds":5}`, utils.ErrCodeForbidden},
+		{"游戏已开始", ownerID, playing.ID, `{"rounds":5}`, utils.ErrCodeConflict},
+		{"非法 JSON", ownerID, waiting.ID, `{"rounds":`, utils.ErrCodeInvalidInput},
+		{"房间不存在", ownerID, 999, `{}`, utils.ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room, err := s.UpdateSettings(ctx, tt.userID, tt.roomID, tt.settings)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("UpdateSettings() error = %v", err)
+				}
+				if room.Settings != tt.settings {
+					t.Errorf("Settings = %q, want %q", room.Settings, tt.settings)
+				}
+				return
+			}
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+				t.Errorf("UpdateSettings() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+
+	// 被拒绝的修改不影响已保存的设置
+	stored, _ := roomRepo.GetByID(ctx, waiting.ID)
+	if stored.Settings != `{"rounds":3}` {
+		t.Errorf("stored Settings = %q, want %q", stored.Settings, `{"rounds":3}`)
+	}
+}
+
+func TestJoinRoomSingleRoom(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const userID = 1
+
+	first, err := s.CreateRoom(ctx, userID, &CreateRoomRequest{Name: "first", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 房间内保留一名其他玩家，避免离开后房间被删除
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: first.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreateRoom(ctx, 3, &CreateRoomRequest{Name: "second", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 已在未结束的房间中，不能再创建或加入其他房间
+	_, err = s.CreateRoom(ctx, userID, &CreateRoomRequest{Name: "third", GameType: "chess"})
+	assertErrCode(t, err, utils.ErrCodeConflict)
+	_, err = s.JoinRoom(ctx, userID, &JoinRoomRequest{RoomCode: second.Room.RoomCode})
+	assertErrCode(t, err, utils.ErrCodeConflict)
+
+	// 离开原房间后可以加入
+	if err := s.LeaveRoom(ctx, userID, first.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, userID, &JoinRoomRequest{RoomCode: second.Room.RoomCode}); err != nil {
+		t.Fatalf("JoinRoom() after leaving error = %v", err)
+	}
+
+	// 所在房间已结束时不受限制
+	room, _ := roomRepo.GetByID(ctx, second.Room.ID)
+	room.Status = model.RoomStatusFinished
+	roomRepo.Update(ctx, room)
+	if _, err := s.CreateRoom(ctx, userID, &CreateRoomRequest{Name: "fourth", GameType: "chess"}); err != nil {
+		t.Fatalf("CreateRoom() after room finished error = %v", err)
+	}
+}
+
+func TestJoinRoomDisabledUser(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	s.userRepo = userStatuses{2: model.UserStatusDisabled}
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 被禁用的用户不能加入房间，也不能加入候补
+	_, err = s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode})
+	assertErrCode(t, err, utils.ErrCodeForbidden)
+	if player, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, created.Room.ID, 2); player != nil {
+		t.Errorf("被禁用的用户已加入房间: %+v", player)
+	}
+	if _, err := s.JoinRoom(ctx, 3, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatalf("JoinRoom() error = %v", err)
+	}
+	_, err = s.JoinWaitlist(ctx, 2, created.Room.RoomCode)
+	assertErrCode(t, err, utils.ErrCodeForbidden)
+}
+
+func TestJoinRoomReasons(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	full, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "full", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: full.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	started, err := s.CreateRoom(ctx, 3, &CreateRoomRequest{Name: "started", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	room, _ := roomRepo.GetByID(ctx, started.Room.ID)
+	room.Status = model.RoomStatusPlaying
+	roomRepo.Update(ctx, room)
+	s.syncRoomToRedis(ctx, room)
+	open, err := s.CreateRoom(ctx, 4, &CreateRoomRequest{Name: "open", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		userID     uint
+		roomCode   string
+		wantCode   int
+		wantReason string
+	}{
+		{"房间不存在", 5, "NOPE00", utils.ErrCodeNotFound, JoinReasonRoomNotFound},
+		{"房间已满", 5, full.Room.RoomCode, utils.ErrCodeConflict, JoinReasonRoomFull},
+		{"房间已开始", 5, started.Room.RoomCode, utils.ErrCodeConflict, JoinReasonRoomStarted},
+		{"已在房间中", 4, open.Room.RoomCode, utils.ErrCodeConflict, JoinReasonAlreadyJoined},
+		{"已在其他房间中", 1, open.Room.RoomCode, utils.ErrCodeConflict, JoinReasonInOtherRoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.JoinRoom(ctx, tt.userID, &JoinRoomRequest{RoomCode: tt.roomCode})
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantCode || appErr.Reason != tt.wantReason {
+				t.Errorf("error = %v (%+v), want code %d reason %q", err, appErr, tt.wantCode, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		features     map[string]bool
+		wantSpectate int
+		wantWaitlist int
+	}{
+		{"全部开启", map[string]bool{config.FeatureSpectators: true, config.FeatureWaitlist: true}, 0, 0},
+		{"关闭观战", map[string]bool{config.FeatureSpectators: false, config.FeatureWaitlist: true}, utils.ErrCodeForbidden, 0},
+		{"关闭候补", map[string]bool{config.FeatureSpectators: true, config.FeatureWaitlist: false}, 0, utils.ErrCodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+			useFeatures(t, tt.features)
+			ctx := context.Background()
+
+			created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = s.JoinWaitlist(ctx, 3, created.Room.RoomCode)
+			if tt.wantWaitlist != 0 {
+				assertErrCode(t, err, tt.wantWaitlist)
+			} else if err != nil {
+				t.Errorf("JoinWaitlist() error = %v", err)
+			}
+
+			_, err = s.SwitchRole(ctx, 2, created.Room.ID, true)
+			if tt.wantSpectate != 0 {
+				assertErrCode(t, err, tt.wantSpectate)
+			} else if err != nil {
+				t.Errorf("SwitchRole() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestJoinRoomAllowMultiRoom(t *testing.T) {
+	s, _, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	s.allowMultiRoom = true
+	ctx := context.Background()
+
+	if _, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "first", GameType: "chess"}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreateRoom(ctx, 2, &CreateRoomRequest{Name: "second", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 1, &JoinRoomRequest{RoomCode: second.Room.RoomCode}); err != nil {
+		t.Errorf("JoinRoom() with multi-room allowed error = %v", err)
+	}
+}
+
+// assertErrCode 断言错误为指定错误码的 AppError
+func assertErrCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != code {
+		t.Errorf("error = %v, want code %d", err, code)
+	}
+}
+
+// lobbyRecorder 记录大厅广播
+type lobbyRecorder struct {
+	events map[string][]string // 游戏类型 -> 事件类型
+}
+
+func (r *lobbyRecorder) BroadcastToLobby(gameType string, message interface{}) {
+	if r.events == nil {
+		r.events = make(map[string][]string)
+	}
+	eventType, _ := message.(map[string]interface{})["type"].(string)
+	r.events[gameType] = append(r.events[gameType], eventType)
+}
+
+func TestRoomLobbyNotifications(t *testing.T) {
+	s, _, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	recorder := &lobbyRecorder{}
+	s.lobbyNotifier = recorder
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "poker", GameType: "poker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LeaveRoom(ctx, 2, created.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LeaveRoom(ctx, 1, created.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{LobbyEventRoomCreated, LobbyEventRoomUpdated, LobbyEventRoomUpdated, LobbyEventRoomClosed}
+	if got := recorder.events["poker"]; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("poker lobby events = %v, want %v", got, want)
+	}
+	if got := recorder.events["chess"]; len(got) != 0 {
+		t.Errorf("chess lobby events = %v, want none", got)
+	}
+}
+
+func TestJoinRoomRejoin(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	first, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "first", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: first.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.CreateRoom(ctx, 3, &CreateRoomRequest{Name: "other", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 房间已满且已开始时，成员重复加入仍返回当前状态
+	room, _ := roomRepo.GetByID(ctx, first.Room.ID)
+	room.Status = model.RoomStatusPlaying
+	roomRepo.Update(ctx, room)
+
+	tests := []struct {
+		name         string
+		userID       uint
+		req          *JoinRoomRequest
+		wantCode     int
+		wantRejoined bool
+	}{
+		{"重复加入返回当前状态", 2, &JoinRoomRequest{RoomCode: first.Room.RoomCode, Rejoin: true}, 0, true},
+		{"未开启时重复加入报冲突", 2, &JoinRoomRequest{RoomCode: first.Room.RoomCode}, utils.ErrCodeConflict, false},
+		{"加入其他房间仍报冲突", 2, &JoinRoomRequest{RoomCode: other.Room.RoomCode, Rejoin: true}, utils.ErrCodeConflict, false},
+		{"非成员不能加入已开始的房间", 4, &JoinRoomRequest{RoomCode: first.Room.RoomCode, Rejoin: true}, utils.ErrCodeConflict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.JoinRoom(ctx, tt.userID, tt.req)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("JoinRoom() error = %v", err)
+			}
+			if resp.Rejoined != tt.wantRejoined || resp.Room.ID != first.Room.ID || resp.Room.CurrentPlayers != 2 {
+				t.Errorf("JoinRoom() = %+v, room %+v", resp, resp.Room)
+			}
+		})
+	}
+}
+
+func TestRematch(t *testing.T) {
+	s, roomRepo, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const ownerID, otherID, spectatorID = 1, 2, 3
+
+	created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "rematch", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, otherID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, spectatorID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SwitchRole(ctx, spectatorID, created.Room.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	players, _ := roomPlayerRepo.GetByRoomID(ctx, created.Room.ID)
+	for _, p := range players {
+		p.IsReady = true
+		roomPlayerRepo.Update(ctx, p)
+	}
+
+	setStatus := func(status model.RoomStatus) {
+		room, _ := roomRepo.GetByID(ctx, created.Room.ID)
+		now := time.Now()
+		room.Status = status
+		room.StartedAt = &now
+		if status == model.RoomStatusFinished {
+			room.EndedAt = &now
+		}
+		roomRepo.Update(ctx, room)
+	}
+
+	// 游戏进行中不能再来一局
+	setStatus(model.RoomStatusPlaying)
+	_, err = s.Rematch(ctx, ownerID, created.Room.ID)
+	assertErrCode(t, err, utils.ErrCodeConflict)
+
+	setStatus(model.RoomStatusFinished)
+	_, err = s.Rematch(ctx, otherID, created.Room.ID)
+	assertErrCode(t, err, utils.ErrCodeForbidden)
+
+	before, err := s.redisRoomRepo.GetRoomEvents(ctx, created.Room.ID)
+	if err != nil || len(before) == 0 {
+		t.Fatalf("GetRoomEvents() = %v, %v, want events before rematch", before, err)
+	}
+	var last GameEvent
+	if err := json.Unmarshal([]byte(before[len(before)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+
+	room, err := s.Rematch(ctx, ownerID, created.Room.ID)
+	if err != nil {
+		t.Fatalf("Rematch() error = %v", err)
+	}
+	if room.Status != model.RoomStatusWaiting || room.StartedAt != nil || room.EndedAt != nil {
+		t.Errorf("Rematch() room = status %d, started %v, ended %v", room.Status, room.StartedAt, room.EndedAt)
+	}
+
+	// 保留原有玩家和观战者，全部重置为未准备
+	players, _ = roomPlayerRepo.GetByRoomID(ctx, created.Room.ID)
+	if len(players) != 3 {
+		t.Fatalf("players = %d, want 3", len(players))
+	}
+	for _, p := range players {
+		if p.IsReady {
+			t.Errorf("player %d still ready", p.UserID)
+		}
+		if p.IsSpectator != (p.UserID == spectatorID) {
+			t.Errorf("player %d IsSpectator = %v", p.UserID, p.IsSpectator)
+		}
+	}
+
+	// 上一局的事件历史被清除，只剩下 game_restarted，序号继续递增
+	payloads, err := s.redisRoomRepo.GetRoomEvents(ctx, created.Room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("events = %v, want only game_restarted", payloads)
+	}
+	var event GameEvent
+	if err := json.Unmarshal([]byte(payloads[0]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "game_restarted" || event.Seq != last.Seq+1 {
+		t.Errorf("event = %s seq %d, want game_restarted seq %d", event.Type, event.Seq, last.Seq+1)
+	}
+}
+
+func TestLeaveRoomCompactsPositions(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 5, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uint{2, 3, 4} {
+		if _, err := s.JoinRoom(ctx, userID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 中间位置的玩家离开后，后面的玩家前移
+	if err := s.LeaveRoom(ctx, 2, created.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 5, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+
+	players, _ := roomPlayerRepo.GetByRoomID(ctx, created.Room.ID)
+	want := []uint{1, 3, 4, 5}
+	if len(players) != len(want) {
+		t.Fatalf("players = %d, want %d", len(players), len(want))
+	}
+	for i, player := range players {
+		if player.Position == nil || *player.Position != i || player.UserID != want[i] {
+			t.Errorf("players[%d] = user %d at %v, want user %d at %d", i, player.UserID, player.Position, want[i], i)
+		}
+	}
+}
+
+func TestLeaveRoomNotMember(t *testing.T) {
+	s, roomRepo, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: created.Room.ID, UserID: 3, IsSpectator: true})
+
+	// 从未加入、观战者离开后、玩家重复离开都不改变房间人数
+	assertErrCode(t, s.LeaveRoom(ctx, 9, created.Room.ID), utils.ErrCodeNotFound)
+	if err := s.LeaveRoom(ctx, 3, created.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertErrCode(t, s.LeaveRoom(ctx, 3, created.Room.ID), utils.ErrCodeNotFound)
+	if err := s.LeaveRoom(ctx, 2, created.Room.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertErrCode(t, s.LeaveRoom(ctx, 2, created.Room.ID), utils.ErrCodeNotFound)
+
+	room, _ := roomRepo.GetByID(ctx, created.Room.ID)
+	if room == nil || room.CurrentPlayers != 1 {
+		t.Errorf("room = %+v, want 1 player left", room)
+	}
+}
+
+func TestJoinRoomConcurrentPositions(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 8, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 7)
+	for userID := uint(2); userID <= 8; userID++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			if _, err := s.JoinRoom(ctx, userID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+				errs <- err
+			}
+		}(userID)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("JoinRoom() error = %v", err)
+	}
+
+	players, _ := roomPlayerRepo.GetByRoomID(ctx, created.Room.ID)
+	seen := make(map[int]uint, len(players))
+	for _, player := range players {
+		if player.Position == nil {
+			t.Fatalf("user %d has no position", player.UserID)
+		}
+		if other, ok := seen[*player.Position]; ok {
+			t.Errorf("users %d and %d share position %d", other, player.UserID, *player.Position)
+		}
+		seen[*player.Position] = player.UserID
+	}
+	if len(seen) != 8 {
+		t.Errorf("distinct positions = %d, want 8", len(seen))
+	}
+}
+
+func TestLeaveAllRooms(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	s.allowMultiRoom = true
+	ctx := context.Background()
+	const userID = 1
+
+	for _, ownerID := range []uint{2, 3} {
+		created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "room", GameType: "chess"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.JoinRoom(ctx, userID, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.LeaveAllRooms(ctx, userID); err != nil {
+		t.Fatalf("LeaveAllRooms() error = %v", err)
+	}
+	if active, _ := roomPlayerRepo.GetActiveByUserID(ctx, userID); active != nil {
+		t.Errorf("LeaveAllRooms() left user in room %d", active.RoomID)
+	}
+}
+
+func TestGetRoomCache(t *testing.T) {
+	s, roomRepo, _ := newTestRoomService(t, RoomDefaults{MaxPlayers: 4, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+
+	created, err := s.CreateRoom(ctx, 1, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roomID := created.Room.ID
+	if _, err := s.GetRoom(ctx, roomID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 绕过服务直接修改数据库，读缓存未失效时返回缓存的值
+	stored, _ := roomRepo.GetByID(ctx, roomID)
+	stored.Name = "renamed"
+	roomRepo.Update(ctx, stored)
+	if room, _ := s.GetRoom(ctx, roomID); room.Name != "room" {
+		t.Errorf("Name = %q, want cached room", room.Name)
+	}
+
+	// 通过服务变更房间后读缓存失效
+	if _, err := s.JoinRoom(ctx, 2, &JoinRoomRequest{RoomCode: created.Room.RoomCode}); err != nil {
+		t.Fatal(err)
+	}
+	room, err := s.GetRoom(ctx, roomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.CurrentPlayers != 2 {
+		t.Errorf("CurrentPlayers = %d, want 2", room.CurrentPlayers)
+	}
+
+	_, err = s.GetRoom(ctx, 999)
+	assertErrCode(t, err, utils.ErrCodeNotFound)
+}
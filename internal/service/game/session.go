@@ -2,52 +2,137 @@ package game
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/game-apps/internal/model"
 	"github.com/game-apps/internal/repository/redis"
 	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
+	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// 会话模式
+const (
+	SessionModeSingle = "single" // 单设备登录
+	SessionModeMulti  = "multi"  // 允许多设备同时在线
+)
+
+// 单设备模式下的会话冲突策略
+const (
+	SessionConflictReject = "reject" // 拒绝新会话
+	SessionConflictKick   = "kick"   // 踢出旧会话
+)
+
+// SessionNotifier 会话通知接口，用于通知被踢下线的客户端
+type SessionNotifier interface {
+	KickUser(userID uint, reason string)
+}
+
+// UserRepository 用户仓库接口，用于解析在线用户信息
+type UserRepository interface {
+	GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error)
+}
+
+// OnlineUser 在线用户摘要
+type OnlineUser struct {
+	ID       uint   `json:"id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
 // SessionService 会话服务
 type SessionService struct {
 	sessionRepo    *redis.SessionRepository
 	onlineUserRepo *redis.OnlineUserRepository
+	userRepo       UserRepository
+	notifier       SessionNotifier
 	logger         *zap.Logger
 	heartbeatInterval time.Duration
 	timeout          time.Duration
+	timeoutByRole    map[string]time.Duration
+	mode             string
+	conflictPolicy   string
 }
 
 // NewSessionService 创建会话服务
 func NewSessionService(
 	sessionRepo *redis.SessionRepository,
 	onlineUserRepo *redis.OnlineUserRepository,
+	userRepo UserRepository,
+	notifier SessionNotifier,
 	logger *zap.Logger,
 	heartbeatInterval, timeout time.Duration,
+	timeoutByRole map[string]time.Duration,
+	mode, conflictPolicy string,
 ) *SessionService {
 	return &SessionService{
 		sessionRepo:       sessionRepo,
 		onlineUserRepo:    onlineUserRepo,
+		userRepo:          userRepo,
+		notifier:          notifier,
 		logger:            logger,
 		heartbeatInterval: heartbeatInterval,
 		timeout:           timeout,
+		timeoutByRole:     timeoutByRole,
+		mode:              mode,
+		conflictPolicy:    conflictPolicy,
 	}
 }
 
+// timeoutFor 获取角色对应的会话超时，未配置的角色使用全局超时
+func (s *SessionService) timeoutFor(role string) time.Duration {
+	if timeout, ok := s.timeoutByRole[role]; ok && timeout > 0 {
+		return timeout
+	}
+	return s.timeout
+}
+
+// sessionRole 从会话数据中读取角色
+func sessionRole(sessionData map[string]interface{}) string {
+	role, _ := sessionData["role"].(string)
+	return role
+}
+
 // CreateSession 创建会话
-func (s *SessionService) CreateSession(ctx context.Context, userID uint, ipAddress, userAgent string) error {
+func (s *SessionService) CreateSession(ctx context.Context, userID uint, role, ipAddress, userAgent string) error {
+	// 单设备模式下检查是否已在其他设备在线
+	if s.mode == SessionModeSingle {
+		active, err := s.hasActiveSession(ctx, userID)
+		if err != nil {
+			s.logger.Warn("检查已有会话失败", zap.Error(err), zap.Uint("user_id", userID))
+		}
+		if active {
+			if s.conflictPolicy == SessionConflictReject {
+				return utils.NewError(utils.ErrCodeConflict, "用户已在其他设备登录")
+			}
+			s.logger.Info("踢出旧会话", zap.Uint("user_id", userID))
+			if s.notifier != nil {
+				s.notifier.KickUser(userID, "账号已在其他设备登录")
+			}
+		}
+	}
+
 	// 保存会话信息
 	sessionData := map[string]interface{}{
 		"user_id":       userID,
+		"role":          role,
 		"ip_address":    ipAddress,
 		"user_agent":    userAgent,
 		"last_activity": time.Now().Unix(),
 		"status":        1, // 在线
 	}
 
-	if err := s.sessionRepo.SetSession(ctx, userID, sessionData, s.timeout); err != nil {
+	if err := s.sessionRepo.SetSession(ctx, userID, sessionData, s.timeoutFor(role)); err != nil {
+		// Redis 不可用时在线状态降级，不影响主流程
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			s.logger.Warn("缓存不可用，跳过保存会话", zap.Uint("user_id", userID))
+			return nil
+		}
 		s.logger.Error("保存会话失败", zap.Error(err), zap.Uint("user_id", userID))
-		return utils.NewError(utils.ErrCodeInternal, "创建会话失败")
+		return utils.NewInternalError("创建会话失败", err)
 	}
 
 	// 添加到在线用户列表
@@ -62,19 +147,43 @@ func (s *SessionService) CreateSession(ctx context.Context, userID uint, ipAddre
 func (s *SessionService) UpdateSessionActivity(ctx context.Context, userID uint) error {
 	sessionData, err := s.sessionRepo.GetSession(ctx, userID)
 	if err != nil {
-		// 会话不存在，创建新会话
-		return s.CreateSession(ctx, userID, "", "")
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			return nil
+		}
+		// 会话不存在，按用户角色创建新会话，使角色对应的会话超时生效
+		return s.CreateSession(ctx, userID, s.userRole(ctx, userID), "", "")
 	}
 
 	sessionData["last_activity"] = time.Now().Unix()
-	if err := s.sessionRepo.SetSession(ctx, userID, sessionData, s.timeout); err != nil {
+	if err := s.sessionRepo.SetSession(ctx, userID, sessionData, s.timeoutFor(sessionRole(sessionData))); err != nil {
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			return nil
+		}
 		s.logger.Error("更新会话失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "更新会话失败")
+		return utils.NewInternalError("更新会话失败", err)
 	}
 
 	return nil
 }
 
+// userRole 查询用户角色，查询失败时返回空（使用全局会话超时）
+func (s *SessionService) userRole(ctx context.Context, userID uint) string {
+	users, err := s.userRepo.GetByIDs(ctx, []uint{userID})
+	if err != nil {
+		s.logger.Warn("查询用户角色失败", zap.Error(err), zap.Uint("user_id", userID))
+		return ""
+	}
+	if len(users) == 0 {
+		return ""
+	}
+	return users[0].Role
+}
+
+// IsAdmin 检查用户是否为管理员，查询失败时视为非管理员
+func (s *SessionService) IsAdmin(ctx context.Context, userID uint) bool {
+	return s.userRole(ctx, userID) == model.UserRoleAdmin
+}
+
 // GetSession 获取会话
 func (s *SessionService) GetSession(ctx context.Context, userID uint) (map[string]interface{}, error) {
 	return s.sessionRepo.GetSession(ctx, userID)
@@ -95,14 +204,120 @@ func (s *SessionService) DeleteSession(ctx context.Context, userID uint) error {
 	return nil
 }
 
-// IsOnline 检查用户是否在线
+// IsOnline 检查用户是否在线，Redis 不可用时视为离线
 func (s *SessionService) IsOnline(ctx context.Context, userID uint) (bool, error) {
-	return s.onlineUserRepo.IsOnline(ctx, userID)
+	online, err := s.onlineUserRepo.IsOnline(ctx, userID)
+	if errors.Is(err, cache.ErrCacheUnavailable) {
+		return false, nil
+	}
+	return online, err
+}
+
+// maxPresenceBatch 单次批量查询在线状态的最大用户数
+const maxPresenceBatch = 200
+
+// BatchPresence 批量查询用户是否在线，输入会去重，Redis 不可用时全部视为离线
+func (s *SessionService) BatchPresence(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	seen := make(map[uint]struct{}, len(userIDs))
+	unique := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	if len(unique) > maxPresenceBatch {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, fmt.Sprintf("单次最多查询 %d 个用户", maxPresenceBatch))
+	}
+
+	presence, err := s.onlineUserRepo.AreOnline(ctx, unique)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			presence = make(map[uint]bool, len(unique))
+			for _, id := range unique {
+				presence[id] = false
+			}
+			return presence, nil
+		}
+		s.logger.Error("批量查询在线状态失败", zap.Error(err))
+		return nil, utils.NewInternalError("查询在线状态失败", err)
+	}
+	return presence, nil
 }
 
-// GetOnlineUsers 获取所有在线用户
+// GetOnlineUsers 获取所有在线用户，Redis 不可用时返回空列表
 func (s *SessionService) GetOnlineUsers(ctx context.Context) ([]string, error) {
-	return s.onlineUserRepo.GetOnlineUsers(ctx)
+	users, err := s.onlineUserRepo.GetOnlineUsers(ctx)
+	if errors.Is(err, cache.ErrCacheUnavailable) {
+		return []string{}, nil
+	}
+	return users, err
+}
+
+// CountOnline 获取在线用户数量，Redis 不可用时返回 0
+func (s *SessionService) CountOnline(ctx context.Context) (int64, error) {
+	total, err := s.onlineUserRepo.CountOnline(ctx)
+	if errors.Is(err, cache.ErrCacheUnavailable) {
+		return 0, nil
+	}
+	return total, err
+}
+
+// ListOnlineUsers 分页获取在线用户摘要
+func (s *SessionService) ListOnlineUsers(ctx context.Context, page, pageSize int) (*utils.PageResult[*OnlineUser], error) {
+	params := utils.Paginate(page, pageSize)
+
+	total, err := s.CountOnline(ctx)
+	if err != nil {
+		s.logger.Error("获取在线用户数量失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取在线用户失败", err)
+	}
+
+	userIDs, err := s.onlineUserRepo.ListOnlineUsers(ctx, params.Offset(), params.Limit())
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheUnavailable) {
+			return utils.NewPageResult([]*OnlineUser{}, 0, params), nil
+		}
+		s.logger.Error("获取在线用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取在线用户失败", err)
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取在线用户失败", err)
+	}
+
+	// 按在线集合的顺序返回，已删除的用户跳过
+	userByID := make(map[uint]*model.User, len(users))
+	for _, u := range users {
+		userByID[u.ID] = u
+	}
+	items := make([]*OnlineUser, 0, len(userIDs))
+	for _, id := range userIDs {
+		if u, ok := userByID[id]; ok {
+			items = append(items, &OnlineUser{ID: u.ID, Nickname: u.Nickname, Avatar: u.Avatar})
+		}
+	}
+
+	return utils.NewPageResult(items, total, params), nil
+}
+
+// hasActiveSession 检查用户是否存在未超时的会话
+func (s *SessionService) hasActiveSession(ctx context.Context, userID uint) (bool, error) {
+	if _, err := s.sessionRepo.GetSession(ctx, userID); err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	timedOut, err := s.CheckSessionTimeout(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return !timedOut, nil
 }
 
 // CheckSessionTimeout 检查会话超时
@@ -118,7 +333,7 @@ func (s *SessionService) CheckSessionTimeout(ctx context.Context, userID uint) (
 	}
 
 	lastActivityTime := time.Unix(int64(lastActivity), 0)
-	timeoutTime := lastActivityTime.Add(s.timeout)
+	timeoutTime := lastActivityTime.Add(s.timeoutFor(sessionRole(sessionData)))
 
 	return time.Now().After(timeoutTime), nil
 }
@@ -0,0 +1,346 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// kickRecorder 记录被踢下线的用户
+type kickRecorder struct {
+	kicked []uint
+}
+
+func (k *kickRecorder) KickUser(userID uint, reason string) {
+	k.kicked = append(k.kicked, userID)
+}
+
+func TestCreateSessionModes(t *testing.T) {
+	const userID = 7
+
+	tests := []struct {
+		name       string
+		mode       string
+		policy     string
+		wantCode   int
+		wantKicked bool
+	}{
+		{"单设备拒绝新会话", SessionModeSingle, SessionConflictReject, utils.ErrCodeConflict, false},
+		{"单设备踢出旧会话", SessionModeSingle, SessionConflictKick, 0, true},
+		{"多设备允许同时在线", SessionModeMulti, SessionConflictReject, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepository(t)
+			notifier := &kickRecorder{}
+			s := NewSessionService(
+				redis.NewSessionRepository(repo),
+				redis.NewOnlineUserRepository(repo),
+				nil,
+				notifier,
+				zap.NewNop(),
+				30*time.Second, 2*time.Minute,
+				nil,
+				tt.mode, tt.policy,
+			)
+			ctx := context.Background()
+
+			if err := s.CreateSession(ctx, userID, model.UserRolePlayer, "10.0.0.1", "first"); err != nil {
+				t.Fatalf("第一次 CreateSession() error = %v", err)
+			}
+
+			err := s.CreateSession(ctx, userID, model.UserRolePlayer, "10.0.0.2", "second")
+			var appErr *utils.AppError
+			switch {
+			case tt.wantCode == 0 && err != nil:
+				t.Fatalf("第二次 CreateSession() error = %v", err)
+			case tt.wantCode != 0 && (!errors.As(err, &appErr) || appErr.Code != tt.wantCode):
+				t.Fatalf("第二次 CreateSession() error = %v, want code %d", err, tt.wantCode)
+			}
+			if kicked := len(notifier.kicked) > 0; kicked != tt.wantKicked {
+				t.Errorf("kicked = %v, want %v", notifier.kicked, tt.wantKicked)
+			}
+		})
+	}
+}
+
+func TestCreateSessionAfterTimeout(t *testing.T) {
+	// 旧会话已超时，单设备拒绝模式下也允许重新登录
+	repo, mr := newTestRepository(t)
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		redis.NewOnlineUserRepository(repo),
+		nil,
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Minute,
+		nil,
+		SessionModeSingle, SessionConflictReject,
+	)
+	ctx := context.Background()
+
+	if err := s.CreateSession(ctx, 7, model.UserRolePlayer, "", ""); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	mr.FastForward(3 * time.Minute)
+
+	if err := s.CreateSession(ctx, 7, model.UserRolePlayer, "", ""); err != nil {
+		t.Errorf("旧会话过期后 CreateSession() error = %v", err)
+	}
+}
+
+func TestCreateSessionTimeoutByRole(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		redis.NewOnlineUserRepository(repo),
+		nil,
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Hour,
+		map[string]time.Duration{model.UserRoleAdmin: 15 * time.Minute},
+		SessionModeMulti, SessionConflictReject,
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		userID  uint
+		role    string
+		wantTTL time.Duration
+	}{
+		{"管理员使用较短超时", 1, model.UserRoleAdmin, 15 * time.Minute},
+		{"玩家使用全局超时", 2, model.UserRolePlayer, 2 * time.Hour},
+		{"未知角色使用全局超时", 3, "", 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.CreateSession(ctx, tt.userID, tt.role, "", ""); err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+			if ttl := mr.TTL(fmt.Sprintf("session:%d", tt.userID)); ttl != tt.wantTTL {
+				t.Errorf("session TTL = %v, want %v", ttl, tt.wantTTL)
+			}
+
+			// 刷新活跃时间后仍使用角色对应的超时
+			mr.SetTTL(fmt.Sprintf("session:%d", tt.userID), time.Minute)
+			if err := s.UpdateSessionActivity(ctx, tt.userID); err != nil {
+				t.Fatalf("UpdateSessionActivity() error = %v", err)
+			}
+			if ttl := mr.TTL(fmt.Sprintf("session:%d", tt.userID)); ttl != tt.wantTTL {
+				t.Errorf("session TTL after activity = %v, want %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestUpdateSessionActivityRecreatesWithRole(t *testing.T) {
+	repo, mr := newTestRepository(t)
+	users := memUserRepo{
+		1: {ID: 1, Role: model.UserRoleAdmin},
+		2: {ID: 2, Role: model.UserRolePlayer},
+	}
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		redis.NewOnlineUserRepository(repo),
+		users,
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Hour,
+		map[string]time.Duration{model.UserRoleAdmin: 15 * time.Minute},
+		SessionModeMulti, SessionConflictReject,
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		userID  uint
+		wantTTL time.Duration
+	}{
+		{"管理员会话按角色超时重建", 1, 15 * time.Minute},
+		{"玩家会话使用全局超时", 2, 2 * time.Hour},
+		{"用户不存在时使用全局超时", 3, 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 会话已过期，心跳时重新创建
+			if err := s.UpdateSessionActivity(ctx, tt.userID); err != nil {
+				t.Fatalf("UpdateSessionActivity() error = %v", err)
+			}
+			if ttl := mr.TTL(fmt.Sprintf("session:%d", tt.userID)); ttl != tt.wantTTL {
+				t.Errorf("session TTL = %v, want %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+// memUserRepo 内存用户仓库，仅用于解析在线用户信息
+type memUserRepo map[uint]*model.User
+
+func (r memUserRepo) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	var users []*model.User
+	for _, id := range ids {
+		if user, ok := r[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func TestListOnlineUsers(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	onlineRepo := redis.NewOnlineUserRepository(repo)
+	users := memUserRepo{}
+	ctx := context.Background()
+	for id := uint(1); id <= 25; id++ {
+		users[id] = &model.User{ID: id, Nickname: fmt.Sprintf("player%d", id)}
+		onlineRepo.AddOnlineUser(ctx, id)
+	}
+	// 在线但已被删除的用户不返回
+	onlineRepo.AddOnlineUser(ctx, 100)
+
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		onlineRepo,
+		users,
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Minute,
+		nil,
+		SessionModeMulti, SessionConflictReject,
+	)
+
+	tests := []struct {
+		name      string
+		page      int
+		pageSize  int
+		wantItems int
+		wantMore  bool
+	}{
+		{"第一页", 1, 10, 10, true},
+		{"第二页", 2, 10, 10, true},
+		{"最后一页", 3, 10, 6, false},
+		{"超出范围", 5, 10, 0, false},
+	}
+	seen := make(map[uint]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.ListOnlineUsers(ctx, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("ListOnlineUsers() error = %v", err)
+			}
+			if result.Total != 26 {
+				t.Errorf("Total = %d, want 26", result.Total)
+			}
+			if len(result.Items) > tt.wantItems || result.HasMore != tt.wantMore {
+				t.Errorf("ListOnlineUsers() = %d items, has_more %v, want at most %d, %v", len(result.Items), result.HasMore, tt.wantItems, tt.wantMore)
+			}
+			for _, u := range result.Items {
+				if seen[u.ID] {
+					t.Errorf("user %d returned twice", u.ID)
+				}
+				seen[u.ID] = true
+				if u.Nickname != fmt.Sprintf("player%d", u.ID) {
+					t.Errorf("user %d nickname = %q", u.ID, u.Nickname)
+				}
+			}
+		})
+	}
+	if len(seen) != 25 {
+		t.Errorf("paged through %d users, want 25", len(seen))
+	}
+}
+
+func TestBatchPresence(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	onlineRepo := redis.NewOnlineUserRepository(repo)
+	ctx := context.Background()
+	onlineRepo.AddOnlineUser(ctx, 1)
+	onlineRepo.AddOnlineUser(ctx, 3)
+
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		onlineRepo,
+		memUserRepo{},
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Minute,
+		nil,
+		SessionModeMulti, SessionConflictReject,
+	)
+
+	tooMany := make([]uint, maxPresenceBatch+1)
+	for i := range tooMany {
+		tooMany[i] = uint(i + 1)
+	}
+
+	tests := []struct {
+		name     string
+		userIDs  []uint
+		want     map[uint]bool
+		wantCode int
+	}{
+		{"在线和离线混合", []uint{1, 2, 3}, map[uint]bool{1: true, 2: false, 3: true}, 0},
+		{"重复和无效 ID 去重", []uint{1, 1, 0, 2, 2}, map[uint]bool{1: true, 2: false}, 0},
+		{"空列表", nil, map[uint]bool{}, 0},
+		{"超过单次上限", tooMany, nil, utils.ErrCodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presence, err := s.BatchPresence(ctx, tt.userIDs)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("BatchPresence() error = %v", err)
+			}
+			if fmt.Sprint(presence) != fmt.Sprint(tt.want) {
+				t.Errorf("BatchPresence() = %v, want %v", presence, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	users := memUserRepo{
+		1: {ID: 1, Role: model.UserRoleAdmin},
+		2: {ID: 2, Role: model.UserRolePlayer},
+	}
+	s := NewSessionService(
+		redis.NewSessionRepository(repo),
+		redis.NewOnlineUserRepository(repo),
+		users,
+		nil,
+		zap.NewNop(),
+		30*time.Second, 2*time.Hour,
+		nil,
+		SessionModeMulti, SessionConflictReject,
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   bool
+	}{
+		{"管理员", 1, true},
+		{"玩家", 2, false},
+		{"用户不存在", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.IsAdmin(ctx, tt.userID); got != tt.want {
+				t.Errorf("IsAdmin(%d) = %v, want %v", tt.userID, got, tt.want)
+			}
+		})
+	}
+}
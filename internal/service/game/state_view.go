@@ -0,0 +1,142 @@
+package game
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/game-apps/internal/model"
+)
+
+// GameStateView 游戏状态的类型化视图，由 Redis 中的房间状态哈希解析而来
+type GameStateView struct {
+	RoomID         uint             `json:"room_id,omitempty"`
+	RoomCode       string           `json:"room_code,omitempty"`
+	Name           string           `json:"name,omitempty"`
+	GameType       string           `json:"game_type,omitempty"`
+	OwnerID        uint             `json:"owner_id,omitempty"`
+	Status         model.RoomStatus `json:"status,omitempty"`
+	GameState      GameState        `json:"game_state,omitempty"`
+	CurrentTurn    *uint            `json:"current_turn,omitempty"` // 当前行动的玩家 ID
+	CurrentPlayers int              `json:"current_players"`
+	MaxPlayers     int              `json:"max_players,omitempty"`
+	MinPlayers     int              `json:"min_players,omitempty"`
+	Settings       string           `json:"settings,omitempty"`
+	CreatedAt      *time.Time       `json:"created_at,omitempty"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	EndedAt        *time.Time       `json:"ended_at,omitempty"`
+	ExpiresAt      *time.Time       `json:"expires_at,omitempty"`
+	LastEventSeq   int64            `json:"last_event_seq,omitempty"` // 最新事件序号，客户端据此拉取遗漏的事件
+
+	// Extras 未知字段以及无法按类型解析的已知字段，保持原始字符串
+	Extras map[string]string `json:"extras,omitempty"`
+}
+
+// ParseGameStateView 将 Redis 中的房间状态哈希解析为类型化视图，state 为空时返回 nil
+func ParseGameStateView(state map[string]string) *GameStateView {
+	if len(state) == 0 {
+		return nil
+	}
+
+	view := &GameStateView{}
+	extras := make(map[string]string)
+
+	for key, raw := range state {
+		if !view.setField(key, raw) {
+			extras[key] = raw
+		}
+	}
+	if len(extras) > 0 {
+		view.Extras = extras
+	}
+	return view
+}
+
+// setField 按字段名解析并赋值，未知字段或解析失败时返回 false
+func (v *GameStateView) setField(key, raw string) bool {
+	switch key {
+	case "room_code":
+		v.RoomCode = raw
+	case "name":
+		v.Name = raw
+	case "game_type":
+		v.GameType = raw
+	case "settings":
+		v.Settings = raw
+	case "id":
+		return parseUintField(raw, &v.RoomID)
+	case "owner_id":
+		return parseUintField(raw, &v.OwnerID)
+	case "current_turn":
+		var userID uint
+		if !parseUintField(raw, &userID) {
+			return false
+		}
+		v.CurrentTurn = &userID
+	case "current_players":
+		return parseIntField(raw, &v.CurrentPlayers)
+	case "max_players":
+		return parseIntField(raw, &v.MaxPlayers)
+	case "min_players":
+		return parseIntField(raw, &v.MinPlayers)
+	case "status":
+		var status int
+		if !parseIntField(raw, &status) {
+			return false
+		}
+		v.Status = model.RoomStatus(status)
+	case "game_state":
+		var state int
+		if !parseIntField(raw, &state) {
+			return false
+		}
+		v.GameState = GameState(state)
+	case "created_at":
+		return parseUnixField(raw, &v.CreatedAt)
+	case "started_at":
+		return parseUnixField(raw, &v.StartedAt)
+	case "ended_at":
+		return parseUnixField(raw, &v.EndedAt)
+	case "expires_at":
+		return parseUnixField(raw, &v.ExpiresAt)
+	case "last_event_seq":
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return false
+		}
+		v.LastEventSeq = value
+	default:
+		return false
+	}
+	return true
+}
+
+// parseUintField 解析无符号整数字段
+func parseUintField(raw string, target *uint) bool {
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return false
+	}
+	*target = uint(value)
+	return true
+}
+
+// parseIntField 解析整数字段
+func parseIntField(raw string, target *int) bool {
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return false
+	}
+	*target = value
+	return true
+}
+
+// parseUnixField 解析 Unix 时间戳（秒）字段
+func parseUnixField(raw string, target **time.Time) bool {
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return false
+	}
+	t := time.Unix(unix, 0)
+	*target = &t
+	return true
+}
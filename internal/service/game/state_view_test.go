@@ -0,0 +1,91 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+)
+
+func TestParseGameStateView(t *testing.T) {
+	state := map[string]string{
+		"id":              "42",
+		"room_code":       "ABC123",
+		"name":            "周末局",
+		"game_type":       "poker",
+		"owner_id":        "7",
+		"status":          "2",
+		"game_state":      "1",
+		"current_turn":    "9",
+		"current_players": "3",
+		"max_players":     "6",
+		"min_players":     "2",
+		"started_at":      "1714566600",
+		"last_event_seq":  "15",
+		"turn_deadline":   "1714566630000",
+		"max_players_bad": "x",
+	}
+
+	view := ParseGameStateView(state)
+	if view == nil {
+		t.Fatalf("ParseGameStateView() = nil")
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"房间 ID", view.RoomID, uint(42)},
+		{"房间码", view.RoomCode, "ABC123"},
+		{"名称", view.Name, "周末局"},
+		{"游戏类型", view.GameType, "poker"},
+		{"房主", view.OwnerID, uint(7)},
+		{"房间状态", view.Status, model.RoomStatus(2)},
+		{"游戏状态", view.GameState, GameState(1)},
+		{"当前回合", *view.CurrentTurn, uint(9)},
+		{"当前人数", view.CurrentPlayers, 3},
+		{"最大人数", view.MaxPlayers, 6},
+		{"最小人数", view.MinPlayers, 2},
+		{"开始时间", view.StartedAt.Equal(time.Unix(1714566600, 0)), true},
+		{"事件序号", view.LastEventSeq, int64(15)},
+		{"未知字段保留原文", view.Extras["turn_deadline"], "1714566630000"},
+		{"未设置的时间为空", view.EndedAt == nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGameStateViewInvalidFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		raw   string
+		check func(view *GameStateView) bool
+	}{
+		{"人数不是数字", "current_players", "three", func(v *GameStateView) bool { return v.CurrentPlayers == 0 }},
+		{"当前回合为空", "current_turn", "", func(v *GameStateView) bool { return v.CurrentTurn == nil }},
+		{"时间戳无效", "created_at", "yesterday", func(v *GameStateView) bool { return v.CreatedAt == nil }},
+		{"房主为负数", "owner_id", "-1", func(v *GameStateView) bool { return v.OwnerID == 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := ParseGameStateView(map[string]string{tt.key: tt.raw})
+			if view == nil || !tt.check(view) {
+				t.Fatalf("无效字段不应写入类型化字段, view = %+v", view)
+			}
+			if view.Extras[tt.key] != tt.raw {
+				t.Errorf("无效字段应保留在 Extras 中, Extras = %v", view.Extras)
+			}
+		})
+	}
+
+	if view := ParseGameStateView(nil); view != nil {
+		t.Errorf("空状态应返回 nil, got %+v", view)
+	}
+}
@@ -0,0 +1,305 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// turnTimeLimitSetting 房间设置中覆盖回合时限的设置项（秒），0 表示不限时
+const turnTimeLimitSetting = "turn_time_limit"
+
+// TurnTimeoutInterval 回合超时检查任务的执行间隔
+const TurnTimeoutInterval = time.Second
+
+// turnTimeoutBatchSize 每次检查处理的最大超时房间数
+const turnTimeoutBatchSize = 100
+
+// TurnPolicy 回合时限策略
+type TurnPolicy struct {
+	TimeLimit       time.Duration            // 默认回合时限，<= 0 表示不限时
+	TimeLimitByType map[string]time.Duration // 按游戏类型（小写）覆盖回合时限
+}
+
+// timeLimit 获取房间的回合时限，房间设置优先于游戏类型配置，游戏类型配置优先于全局默认值
+func (p TurnPolicy) timeLimit(room *model.Room) time.Duration {
+	var seconds int
+	if ok, err := room.GetSetting(turnTimeLimitSetting, &seconds); ok && err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if limit, ok := p.TimeLimitByType[strings.ToLower(room.GameType)]; ok && limit > 0 {
+		return limit
+	}
+	return p.TimeLimit
+}
+
+// turnState 从房间状态中读取当前回合，未启用回合时限时 active 为 false
+type turnState struct {
+	active   bool
+	userID   uint
+	number   int64
+	deadline time.Time
+}
+
+// parseTurnState 解析房间状态中的回合字段
+func parseTurnState(state map[string]string) turnState {
+	deadlineMs, err := strconv.ParseInt(state["turn_deadline"], 10, 64)
+	if err != nil || deadlineMs <= 0 {
+		return turnState{}
+	}
+	userID, err := strconv.ParseUint(state["current_turn"], 10, 64)
+	if err != nil {
+		return turnState{}
+	}
+	number, _ := strconv.ParseInt(state["turn_number"], 10, 64)
+	return turnState{
+		active:   true,
+		userID:   uint(userID),
+		number:   number,
+		deadline: time.UnixMilli(deadlineMs),
+	}
+}
+
+// gameLockKey 游戏进程操作的分布式锁
+func gameLockKey(roomID uint) string {
+	return "game:lock:" + strconv.FormatUint(uint64(roomID), 10)
+}
+
+// turnOrder 获取按座位排序的行动玩家（不含观战者）
+func (s *ProcessService) turnOrder(ctx context.Context, roomID uint) ([]uint, error) {
+	players, err := s.roomPlayerRepo.GetByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	seated := make([]*model.RoomPlayer, 0, len(players))
+	for _, player := range players {
+		if !player.IsSpectator && player.Position != nil {
+			seated = append(seated, player)
+		}
+	}
+	sort.Slice(seated, func(i, j int) bool {
+		return *seated[i].Position < *seated[j].Position
+	})
+
+	order := make([]uint, len(seated))
+	for i, player := range seated {
+		order[i] = player.UserID
+	}
+	return order, nil
+}
+
+// nextInTurnOrder 返回 current 之后的下一位玩家，current 已不在房间时从头开始
+func nextInTurnOrder(order []uint, current uint) uint {
+	for i, userID := range order {
+		if userID == current {
+			return order[(i+1)%len(order)]
+		}
+	}
+	return order[0]
+}
+
+// nextActiveInTurnOrder 返回 current 之后第一位未暂离的玩家，current 已不在房间时从头开始
+// 所有玩家都暂离时按正常顺序返回下一位，回合继续按时限轮转
+func nextActiveInTurnOrder(order []uint, current uint, afk map[uint]bool) uint {
+	start := 0
+	for i, userID := range order {
+		if userID == current {
+			start = i + 1
+			break
+		}
+	}
+	for i := range order {
+		if candidate := order[(start+i)%len(order)]; !afk[candidate] {
+			return candidate
+		}
+	}
+	return nextInTurnOrder(order, current)
+}
+
+// startTurn 开始新回合并记录截止时间，调用方需持有游戏锁
+func (s *ProcessService) startTurn(ctx context.Context, room *model.Room, userID uint, number int64, limit time.Duration) error {
+	deadline := time.Now().Add(limit)
+	if err := s.redisRoomRepo.SetRoomState(ctx, room.ID, map[string]interface{}{
+		"current_turn":  userID,
+		"turn_number":   number,
+		"turn_deadline": deadline.UnixMilli(),
+	}, 0); err != nil {
+		return err
+	}
+	if err := s.redisRoomRepo.ScheduleTurnDeadline(ctx, room.ID, deadline); err != nil {
+		return err
+	}
+
+	event := &GameEvent{
+		Type:   "turn_start",
+		RoomID: room.ID,
+		UserID: userID,
+		Data: map[string]interface{}{
+			"turn_number": number,
+			"deadline":    deadline.UnixMilli(),
+		},
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.PublishEvent(ctx, event); err != nil {
+		s.logger.Warn("发布事件失败", zap.Error(err))
+	}
+	return nil
+}
+
+// startFirstTurn 游戏开始时按座位顺序开始第一个回合，房间未设置回合时限时不处理
+func (s *ProcessService) startFirstTurn(ctx context.Context, room *model.Room) error {
+	limit := s.turnPolicy.timeLimit(room)
+	if limit <= 0 {
+		return nil
+	}
+
+	order, err := s.turnOrder(ctx, room.ID)
+	if err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	return s.startTurn(ctx, room, nextActiveInTurnOrder(order, 0, s.afkPlayers(ctx, room.ID)), 1, limit)
+}
+
+// checkTurn 启用回合时限的房间只允许当前回合的玩家提交操作，返回是否启用了回合时限
+func (s *ProcessService) checkTurn(ctx context.Context, roomID, userID uint) (bool, error) {
+	state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
+	if err != nil {
+		s.logger.Error("获取游戏状态失败", zap.Error(err))
+		return false, utils.NewInternalError("提交操作失败", err)
+	}
+	turn := parseTurnState(state)
+	if turn.active && turn.userID != userID {
+		return true, utils.NewError(utils.ErrCodeForbidden, "还未轮到你行动")
+	}
+	return turn.active, nil
+}
+
+// advanceTurn 将回合交给下一位玩家，当前回合已不是 expectedUserID 时不处理（已被超时或其他请求推进）
+// timedOut 为 true 时发布 turn_timeout 事件
+func (s *ProcessService) advanceTurn(ctx context.Context, roomID, expectedUserID uint, timedOut bool) error {
+	lockKey := gameLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 10*time.Second, lockMaxWait)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return fmt.Errorf("获取游戏锁超时")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	return s.advanceTurnLocked(ctx, roomID, expectedUserID, timedOut)
+}
+
+// advanceTurnLocked 同 advanceTurn，调用方需持有游戏锁
+func (s *ProcessService) advanceTurnLocked(ctx context.Context, roomID, expectedUserID uint, timedOut bool) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	turn := parseTurnState(state)
+
+	// 游戏已结束或已不再计时，清理残留的截止时间
+	if room == nil || room.Status != model.RoomStatusPlaying || !turn.active {
+		return s.redisRoomRepo.ClearTurnDeadline(ctx, roomID)
+	}
+	if turn.userID != expectedUserID {
+		return nil
+	}
+	if timedOut && time.Now().Before(turn.deadline) {
+		// 截止时间已被延后（如玩家刚完成操作），等待下次检查
+		return nil
+	}
+
+	if timedOut {
+		s.logger.Info("回合超时，自动跳过", zap.Uint("room_id", roomID), zap.Uint("user_id", turn.userID))
+		event := &GameEvent{
+			Type:      "turn_timeout",
+			RoomID:    roomID,
+			UserID:    turn.userID,
+			Data:      map[string]interface{}{"turn_number": turn.number},
+			Timestamp: time.Now().Unix(),
+		}
+		if err := s.PublishEvent(ctx, event); err != nil {
+			s.logger.Warn("发布事件失败", zap.Error(err))
+		}
+	}
+
+	limit := s.turnPolicy.timeLimit(room)
+	order, err := s.turnOrder(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 || len(order) == 0 {
+		return s.redisRoomRepo.ClearTurnDeadline(ctx, roomID)
+	}
+
+	// 跳过暂离的玩家，直到其重新连接
+	next := nextActiveInTurnOrder(order, turn.userID, s.afkPlayers(ctx, roomID))
+	if err := s.startTurn(ctx, room, next, turn.number+1, limit); err != nil {
+		return err
+	}
+	s.broadcastState(ctx, roomID)
+	return nil
+}
+
+// CheckTurnTimeouts 处理所有已超时的回合，单个房间处理失败不影响其他房间
+func (s *ProcessService) CheckTurnTimeouts(ctx context.Context) error {
+	roomIDs, err := s.redisRoomRepo.DueTurnDeadlines(ctx, time.Now(), turnTimeoutBatchSize)
+	if err != nil {
+		return fmt.Errorf("查询超时回合失败: %w", err)
+	}
+
+	for _, roomID := range roomIDs {
+		state, err := s.redisRoomRepo.GetRoomState(ctx, roomID)
+		if err != nil {
+			s.logger.Warn("获取游戏状态失败", zap.Error(err), zap.Uint("room_id", roomID))
+			continue
+		}
+		turn := parseTurnState(state)
+		if !turn.active {
+			if err := s.redisRoomRepo.ClearTurnDeadline(ctx, roomID); err != nil {
+				s.logger.Warn("清理回合截止时间失败", zap.Error(err), zap.Uint("room_id", roomID))
+			}
+			continue
+		}
+		if err := s.advanceTurn(ctx, roomID, turn.userID, true); err != nil {
+			s.logger.Error("处理回合超时失败", zap.Error(err), zap.Uint("room_id", roomID))
+		}
+	}
+	return nil
+}
+
+// TurnTimeoutChecker 回合超时检查后台任务
+type TurnTimeoutChecker struct {
+	process *ProcessService
+}
+
+// NewTurnTimeoutChecker 创建回合超时检查任务
+func NewTurnTimeoutChecker(process *ProcessService) *TurnTimeoutChecker {
+	return &TurnTimeoutChecker{process: process}
+}
+
+// Name 后台任务名称
+func (c *TurnTimeoutChecker) Name() string {
+	return "turn_timeout_checker"
+}
+
+// Run 检查一次超时回合
+func (c *TurnTimeoutChecker) Run(ctx context.Context) error {
+	return c.process.CheckTurnTimeouts(ctx)
+}
@@ -0,0 +1,217 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestNextInTurnOrder(t *testing.T) {
+	order := []uint{10, 20, 30}
+
+	tests := []struct {
+		name    string
+		current uint
+		want    uint
+	}{
+		{"下一位", 10, 20},
+		{"最后一位之后回到第一位", 30, 10},
+		{"当前玩家已离开时从头开始", 99, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextInTurnOrder(order, tt.current); got != tt.want {
+				t.Errorf("nextInTurnOrder(%d) = %d, want %d", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTurnState(t *testing.T) {
+	deadline := time.UnixMilli(1714566600123)
+
+	tests := []struct {
+		name  string
+		state map[string]string
+		want  turnState
+	}{
+		{
+			name:  "进行中的回合",
+			state: map[string]string{"current_turn": "7", "turn_number": "3", "turn_deadline": "1714566600123"},
+			want:  turnState{active: true, userID: 7, number: 3, deadline: deadline},
+		},
+		{
+			name:  "未启用回合时限",
+			state: map[string]string{"current_turn": "7"},
+			want:  turnState{},
+		},
+		{
+			name:  "截止时间已清除",
+			state: map[string]string{"current_turn": "7", "turn_deadline": "0"},
+			want:  turnState{},
+		},
+		{
+			name:  "当前玩家无效",
+			state: map[string]string{"current_turn": "", "turn_deadline": "1714566600123"},
+			want:  turnState{},
+		},
+		{
+			name:  "缺少回合序号",
+			state: map[string]string{"current_turn": "7", "turn_deadline": "1714566600123"},
+			want:  turnState{active: true, userID: 7, deadline: deadline},
+		},
+		{
+			name:  "空状态",
+			state: nil,
+			want:  turnState{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTurnState(tt.state)
+			if got.active != tt.want.active || got.userID != tt.want.userID || got.number != tt.want.number || !got.deadline.Equal(tt.want.deadline) {
+				t.Errorf("parseTurnState() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTurnPolicyTimeLimit(t *testing.T) {
+	policy := TurnPolicy{
+		TimeLimit:       time.Minute,
+		TimeLimitByType: map[string]time.Duration{"chess": 30 * time.Second},
+	}
+
+	tests := []struct {
+		name     string
+		gameType string
+		settings string
+		want     time.Duration
+	}{
+		{"使用全局默认值", "poker", "", time.Minute},
+		{"按游戏类型覆盖", "chess", "", 30 * time.Second},
+		{"游戏类型不区分大小写", "Chess", "", 30 * time.Second},
+		{"房间设置优先", "chess", `{"turn_time_limit":10}`, 10 * time.Second},
+		{"房间设置为 0 表示不限时", "chess", `{"turn_time_limit":0}`, 0},
+		{"房间设置类型错误时忽略", "chess", `{"turn_time_limit":"fast"}`, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := &model.Room{GameType: tt.gameType, Settings: tt.settings}
+			if got := policy.timeLimit(room); got != tt.want {
+				t.Errorf("timeLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	const firstID, secondID = 1, 2
+	const limit = 50 * time.Millisecond
+
+	repo, _ := newTestRepository(t)
+	roomRepo := testutil.NewRoomRepo()
+	roomPlayerRepo := testutil.NewRoomPlayerRepo(roomRepo)
+	redisRoomRepo := redis.NewRoomRepository(repo)
+	s := NewProcessService(roomRepo, roomPlayerRepo, testutil.NewEventRepo(roomRepo), redisRoomRepo, redis.NewLockRepository(repo), nil, zap.NewNop(), "game:events", ReadyPolicy{}, TurnPolicy{TimeLimit: limit}, EventRetention{}, utils.JSONLimits{}, AFKPolicy{}, redis.NewConnectionRepository(repo, "test", time.Minute))
+	ctx := context.Background()
+
+	room := &model.Room{OwnerID: firstID, Status: model.RoomStatusWaiting}
+	roomRepo.Create(ctx, room)
+	for i, userID := range []uint{firstID, secondID} {
+		position := i
+		roomPlayerRepo.Create(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: userID, Position: &position})
+		redisRoomRepo.AddRoomPlayer(ctx, room.ID, userID)
+	}
+
+	currentTurn := func() turnState {
+		t.Helper()
+		state, err := redisRoomRepo.GetRoomState(ctx, room.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parseTurnState(state)
+	}
+
+	if err := s.StartGame(ctx, room.ID, firstID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+	if turn := currentTurn(); !turn.active || turn.userID != firstID || turn.number != 1 {
+		t.Fatalf("游戏开始后回合 = %+v, want user %d turn 1", turn, firstID)
+	}
+
+	// 非当前回合的玩家不能提交操作
+	_, err := s.SubmitMove(ctx, room.ID, secondID, &SubmitMoveRequest{Seq: 1, Move: json.RawMessage(`{"x":1}`)})
+	assertErrCode(t, err, utils.ErrCodeForbidden)
+
+	// 截止时间未到时不推进
+	if err := s.CheckTurnTimeouts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if turn := currentTurn(); turn.userID != firstID {
+		t.Fatalf("截止时间前回合被推进: %+v", turn)
+	}
+
+	// 当前玩家挂机超时后自动轮到下一位
+	time.Sleep(limit + 10*time.Millisecond)
+	if err := s.CheckTurnTimeouts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if turn := currentTurn(); turn.userID != secondID || turn.number != 2 {
+		t.Fatalf("超时后回合 = %+v, want user %d turn 2", turn, secondID)
+	}
+
+	// 玩家完成操作后回合交给下一位
+	if _, err := s.SubmitMove(ctx, room.ID, secondID, &SubmitMoveRequest{Seq: 1, Move: json.RawMessage(`{"x":1}`)}); err != nil {
+		t.Fatalf("SubmitMove() error = %v", err)
+	}
+	if turn := currentTurn(); turn.userID != firstID || turn.number != 3 {
+		t.Fatalf("操作后回合 = %+v, want user %d turn 3", turn, firstID)
+	}
+
+	// 回合已交给下一位后重试同一操作，识别为重复而不是拒绝
+	resp, err := s.SubmitMove(ctx, room.ID, secondID, &SubmitMoveRequest{Seq: 1, Move: json.RawMessage(`{"x":1}`)})
+	if err != nil || !resp.Duplicate {
+		t.Fatalf("重试操作 = %+v, %v, want duplicate", resp, err)
+	}
+	if turn := currentTurn(); turn.userID != firstID || turn.number != 3 {
+		t.Fatalf("重试后回合 = %+v, 不应推进", turn)
+	}
+
+	// 游戏结束后不再有待处理的截止时间
+	if err := s.EndGame(ctx, room.ID, firstID, nil); err != nil {
+		t.Fatal(err)
+	}
+	due, err := redisRoomRepo.DueTurnDeadlines(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("DueTurnDeadlines() = %v, want empty", due)
+	}
+}
+
+func TestGameLockKey(t *testing.T) {
+	tests := []struct {
+		roomID uint
+		want   string
+	}{
+		{1, "game:lock:1"},
+		{42, "game:lock:42"},
+		// 超出 Unicode 范围的 ID 不能折叠成同一个锁名
+		{0x110000, "game:lock:1114112"},
+		{0x110001, "game:lock:1114113"},
+	}
+	for _, tt := range tests {
+		if got := gameLockKey(tt.roomID); got != tt.want {
+			t.Errorf("gameLockKey(%d) = %q, want %q", tt.roomID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/game-apps/internal/config"
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// maxWaitlistPromotions 一次空位最多尝试的候补人数，跳过已失效的候补
+const maxWaitlistPromotions = 10
+
+// JoinWaitlistRequest 加入候补请求
+type JoinWaitlistRequest struct {
+	RoomCode string `json:"room_code" binding:"required"`
+}
+
+// WaitlistResponse 候补状态
+type WaitlistResponse struct {
+	RoomID   uint `json:"room_id"`
+	Position int  `json:"position"` // 在候补队列中的位置（从 1 开始）
+}
+
+// JoinWaitlist 房间已满时加入候补队列，有玩家离开后按加入顺序自动补位
+func (s *RoomService) JoinWaitlist(ctx context.Context, userID uint, roomCode string) (*WaitlistResponse, error) {
+	if err := s.checkFeature(config.FeatureWaitlist, "候补"); err != nil {
+		return nil, err
+	}
+	if err := s.checkUserActive(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	roomID, err := s.resolveRoomID(ctx, roomCode)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入候补失败", err)
+	}
+	if roomID == 0 {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在").WithReason(JoinReasonRoomNotFound)
+	}
+
+	lockKey := roomLockKey(roomID)
+	token, acquired, err := s.lockRepo.AcquireLockWait(ctx, lockKey, 5*time.Second, lockMaxWait)
+	if err != nil {
+		s.logger.Error("获取锁失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入候补失败", err)
+	}
+	if !acquired {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间正在被操作，请稍后重试")
+	}
+	defer s.lockRepo.ReleaseLock(ctx, lockKey, token)
+
+	room, err := s.loadRoom(ctx, roomID)
+	if err != nil {
+		s.logger.Error("查询房间失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入候补失败", err)
+	}
+	if room == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "房间不存在").WithReason(JoinReasonRoomNotFound)
+	}
+	if room.Status != model.RoomStatusWaiting {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间已开始或已结束").WithReason(JoinReasonRoomStarted)
+	}
+
+	member, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("查询房间玩家失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入候补失败", err)
+	}
+	if member != nil {
+		return nil, utils.NewError(utils.ErrCodeConflict, "已在房间中").WithReason(JoinReasonAlreadyJoined)
+	}
+	if room.CurrentPlayers < room.MaxPlayers {
+		return nil, utils.NewError(utils.ErrCodeConflict, "房间未满，请直接加入")
+	}
+	if err := s.checkNotInOtherRoom(ctx, userID, roomID); err != nil {
+		return nil, err
+	}
+
+	rank, err := s.redisRoomRepo.JoinWaitlist(ctx, roomID, userID, s.defaultTimeout)
+	if err != nil {
+		s.logger.Error("加入候补队列失败", zap.Error(err))
+		return nil, utils.NewInternalError("加入候补失败", err)
+	}
+
+	return &WaitlistResponse{
+		RoomID:   roomID,
+		Position: int(rank) + 1,
+	}, nil
+}
+
+// LeaveWaitlist 离开房间候补队列
+func (s *RoomService) LeaveWaitlist(ctx context.Context, userID uint, roomID uint) error {
+	removed, err := s.redisRoomRepo.LeaveWaitlist(ctx, roomID, userID)
+	if err != nil {
+		s.logger.Error("离开候补队列失败", zap.Error(err))
+		return utils.NewInternalError("离开候补失败", err)
+	}
+	if !removed {
+		return utils.NewError(utils.ErrCodeNotFound, "不在候补队列中")
+	}
+	return nil
+}
+
+// requeueWaitlist 将转正失败的候补用户按原分数放回队列
+func (s *RoomService) requeueWaitlist(ctx context.Context, roomID, userID uint, score float64) {
+	if err := s.redisRoomRepo.RequeueWaitlist(ctx, roomID, userID, score); err != nil {
+		s.logger.Error("候补用户放回队列失败", zap.Error(err), zap.Uint("room_id", roomID), zap.Uint("user_id", userID))
+	}
+}
+
+// isInternalError 是否为内部错误（数据库、缓存故障等），而不是业务校验失败
+func isInternalError(err error) bool {
+	var appErr *utils.AppError
+	return errors.As(err, &appErr) && appErr.Code == utils.ErrCodeInternal
+}
+
+// promoteFromWaitlist 房间有空位时按顺序将候补用户加入房间并通知，调用方需持有房间锁
+// 已加入其他房间、已在房间中或账号已被禁用的候补会被跳过；
+// 遇到数据库等内部错误时将该用户按原顺序放回队列并停止本次转正，等下次有空位时重试
+func (s *RoomService) promoteFromWaitlist(ctx context.Context, room *model.Room) {
+	if room.Status != model.RoomStatusWaiting {
+		return
+	}
+
+	for i := 0; i < maxWaitlistPromotions && room.CurrentPlayers < room.MaxPlayers; i++ {
+		userID, score, err := s.redisRoomRepo.PopWaitlist(ctx, room.ID)
+		if err != nil {
+			s.logger.Warn("读取候补队列失败", zap.Error(err), zap.Uint("room_id", room.ID))
+			return
+		}
+		if userID == 0 {
+			return
+		}
+
+		member, err := s.roomPlayerRepo.GetByRoomIDAndUserID(ctx, room.ID, userID)
+		if err != nil {
+			s.logger.Warn("查询房间玩家失败", zap.Error(err), zap.Uint("room_id", room.ID), zap.Uint("user_id", userID))
+			s.requeueWaitlist(ctx, room.ID, userID, score)
+			return
+		}
+		if member != nil {
+			continue
+		}
+		if err := s.checkNotInOtherRoom(ctx, userID, room.ID); err != nil {
+			if isInternalError(err) {
+				s.requeueWaitlist(ctx, room.ID, userID, score)
+				return
+			}
+			continue
+		}
+		// 加入候补后被禁用的用户不能转正
+		if err := s.checkUserActive(ctx, userID); err != nil {
+			if isInternalError(err) {
+				s.requeueWaitlist(ctx, room.ID, userID, score)
+				return
+			}
+			s.logger.Info("候补用户不可用，跳过", zap.Error(err), zap.Uint("room_id", room.ID), zap.Uint("user_id", userID))
+			continue
+		}
+
+		if err := s.addPlayer(ctx, room, userID); err != nil {
+			s.logger.Error("候补用户加入房间失败", zap.Error(err), zap.Uint("room_id", room.ID), zap.Uint("user_id", userID))
+			s.requeueWaitlist(ctx, room.ID, userID, score)
+			return
+		}
+
+		s.logger.Info("候补用户已加入房间", zap.Uint("room_id", room.ID), zap.Uint("user_id", userID))
+		if s.userNotifier != nil {
+			s.userNotifier.SendToUser(userID, map[string]interface{}{
+				"type": "waitlist_promoted",
+				"data": map[string]interface{}{
+					"room_id":   room.ID,
+					"room_code": room.RoomCode,
+				},
+			})
+		}
+	}
+}
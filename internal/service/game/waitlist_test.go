@@ -0,0 +1,179 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+)
+
+// userMessageRecorder 记录推送给用户的消息类型
+type userMessageRecorder struct {
+	messages map[uint][]string
+}
+
+func (r *userMessageRecorder) SendToUser(userID uint, message interface{}) {
+	if r.messages == nil {
+		r.messages = make(map[uint][]string)
+	}
+	msgType, _ := message.(map[string]interface{})["type"].(string)
+	r.messages[userID] = append(r.messages[userID], msgType)
+}
+
+func TestWaitlist(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	recorder := &userMessageRecorder{}
+	s.userNotifier = recorder
+	ctx := context.Background()
+	const ownerID, playerID = 1, 2
+
+	created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, roomID := created.Room.RoomCode, created.Room.ID
+
+	// 房间未满时不能候补
+	_, err = s.JoinWaitlist(ctx, 3, code)
+	assertErrCode(t, err, utils.ErrCodeConflict)
+
+	if _, err := s.JoinRoom(ctx, playerID, &JoinRoomRequest{RoomCode: code}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   uint
+		wantPos  int
+		wantCode int
+	}{
+		{"第一个候补", 3, 1, 0},
+		{"第二个候补", 4, 2, 0},
+		{"第三个候补", 5, 3, 0},
+		{"重复候补保持原有顺序", 3, 1, 0},
+		{"房间成员不能候补", playerID, 0, utils.ErrCodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.JoinWaitlist(ctx, tt.userID, code)
+			if tt.wantCode != 0 {
+				assertErrCode(t, err, tt.wantCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("JoinWaitlist() error = %v", err)
+			}
+			if resp.RoomID != roomID || resp.Position != tt.wantPos {
+				t.Errorf("JoinWaitlist() = %+v, want position %d", resp, tt.wantPos)
+			}
+		})
+	}
+
+	// 第一个候补离开队列后，玩家离开时由下一个候补补位
+	if err := s.LeaveWaitlist(ctx, 3, roomID); err != nil {
+		t.Fatalf("LeaveWaitlist() error = %v", err)
+	}
+	assertErrCode(t, s.LeaveWaitlist(ctx, 3, roomID), utils.ErrCodeNotFound)
+
+	if err := s.LeaveRoom(ctx, playerID, roomID); err != nil {
+		t.Fatal(err)
+	}
+	if member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, 4); member == nil {
+		t.Fatal("玩家离开后候补用户未加入房间")
+	}
+	if got := recorder.messages[4]; len(got) != 1 || got[0] != "waitlist_promoted" {
+		t.Errorf("messages to user 4 = %v, want [waitlist_promoted]", got)
+	}
+	if member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, 5); member != nil {
+		t.Error("只有一个空位时不应加入第二个候补")
+	}
+	room, _ := s.roomRepo.GetByID(ctx, roomID)
+	if room.CurrentPlayers != 2 {
+		t.Errorf("CurrentPlayers = %d, want 2", room.CurrentPlayers)
+	}
+
+	// 剩余候补仍在队首
+	resp, err := s.JoinWaitlist(ctx, 5, code)
+	if err != nil || resp.Position != 1 {
+		t.Errorf("JoinWaitlist() = %+v, %v, want position 1", resp, err)
+	}
+}
+
+func TestPromoteFromWaitlistSkipsDisabled(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const ownerID, playerID, disabledID, nextID = 1, 2, 3, 4
+
+	created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, roomID := created.Room.RoomCode, created.Room.ID
+	if _, err := s.JoinRoom(ctx, playerID, &JoinRoomRequest{RoomCode: code}); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uint{disabledID, nextID} {
+		if _, err := s.JoinWaitlist(ctx, userID, code); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一个候补在排队期间被禁用，空位由下一个候补补上
+	s.userRepo = userStatuses{disabledID: model.UserStatusDisabled}
+	if err := s.LeaveRoom(ctx, playerID, roomID); err != nil {
+		t.Fatal(err)
+	}
+	if member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, disabledID); member != nil {
+		t.Error("被禁用的候补用户不应加入房间")
+	}
+	if member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, nextID); member == nil {
+		t.Error("下一个候补用户未加入房间")
+	}
+}
+
+// failingUsers 查询用户总是失败的用户仓库
+type failingUsers struct{}
+
+func (failingUsers) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	return nil, errors.New("db down")
+}
+
+func TestPromoteFromWaitlistRequeuesOnError(t *testing.T) {
+	s, _, roomPlayerRepo := newTestRoomService(t, RoomDefaults{MaxPlayers: 2, MinPlayers: 1, DefaultTimeout: time.Minute}, nil)
+	ctx := context.Background()
+	const ownerID, playerID, firstID, secondID = 1, 2, 3, 4
+
+	created, err := s.CreateRoom(ctx, ownerID, &CreateRoomRequest{Name: "room", GameType: "chess"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, roomID := created.Room.RoomCode, created.Room.ID
+	if _, err := s.JoinRoom(ctx, playerID, &JoinRoomRequest{RoomCode: code}); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uint{firstID, secondID} {
+		if _, err := s.JoinWaitlist(ctx, userID, code); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 查询用户失败时不跳过候补，按原顺序放回队列
+	s.userRepo = failingUsers{}
+	if err := s.LeaveRoom(ctx, playerID, roomID); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uint{firstID, secondID} {
+		if member, _ := roomPlayerRepo.GetByRoomIDAndUserID(ctx, roomID, userID); member != nil {
+			t.Errorf("用户 %d 不应在查询失败时加入房间", userID)
+		}
+	}
+
+	for _, want := range []uint{firstID, secondID} {
+		if got, _, err := s.redisRoomRepo.PopWaitlist(ctx, roomID); err != nil || got != want {
+			t.Errorf("PopWaitlist() = %d, %v, want %d", got, err, want)
+		}
+	}
+}
@@ -2,17 +2,14 @@ package user
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/game-apps/internal/model"
-	"github.com/game-apps/internal/repository/mysql"
-	"github.com/game-apps/internal/repository/postgres"
 	"github.com/game-apps/internal/repository/redis"
 	"github.com/game-apps/internal/utils"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // AuthService 认证服务
@@ -21,17 +18,39 @@ type AuthService struct {
 	userProfileRepo UserProfileRepository
 	userStatsRepo   UserStatsRepository
 	sessionRepo     *redis.SessionRepository
+	onlineUserRepo  *redis.OnlineUserRepository
 	jwtService      *utils.JWTService
+	connCloser      ConnectionCloser
+	roomLeaver      RoomLeaver
 	logger          *zap.Logger
+	passwordPolicy  utils.PasswordPolicy
+	sessionCreator  SessionCreator
+}
+
+// SessionCreator 创建登录会话，按会话模式处理多设备冲突，并按角色设置会话超时
+type SessionCreator interface {
+	CreateSession(ctx context.Context, userID uint, role, ipAddress, userAgent string) error
+}
+
+// ConnectionCloser 关闭用户实时连接（WebSocket）
+type ConnectionCloser interface {
+	KickUser(userID uint, reason string)
+}
+
+// RoomLeaver 让用户离开所在的房间
+type RoomLeaver interface {
+	LeaveAllRooms(ctx context.Context, userID uint) error
 }
 
 // UserRepository 用户仓库接口
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
 }
 
 // UserProfileRepository 用户资料仓库接口
@@ -41,11 +60,27 @@ type UserProfileRepository interface {
 	Update(ctx context.Context, profile *model.UserProfile) error
 }
 
+// UsernameHistoryRepository 用户名变更历史仓库接口
+type UsernameHistoryRepository interface {
+	Create(ctx context.Context, history *model.UsernameHistory) error
+	GetLatestByUserID(ctx context.Context, userID uint) (*model.UsernameHistory, error)
+}
+
+// GameHistoryRepository 对局记录仓库接口
+type GameHistoryRepository interface {
+	ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.GameHistory, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	ListByRoomIDs(ctx context.Context, roomIDs []uint) ([]*model.GameHistory, error)
+}
+
 // UserStatsRepository 用户统计仓库接口
 type UserStatsRepository interface {
 	Create(ctx context.Context, stats *model.UserStats) error
 	GetByUserID(ctx context.Context, userID uint) (*model.UserStats, error)
+	GetByUserIDs(ctx context.Context, userIDs []uint) ([]*model.UserStats, error)
 	Update(ctx context.Context, stats *model.UserStats) error
+	ListInactive(ctx context.Context, since time.Time, limit, offset int) ([]*model.UserStats, error)
+	CountInactive(ctx context.Context, since time.Time) (int64, error)
 }
 
 // NewAuthService 创建认证服务
@@ -54,25 +89,36 @@ func NewAuthService(
 	userProfileRepo UserProfileRepository,
 	userStatsRepo UserStatsRepository,
 	sessionRepo *redis.SessionRepository,
+	onlineUserRepo *redis.OnlineUserRepository,
 	jwtService *utils.JWTService,
+	connCloser ConnectionCloser,
+	roomLeaver RoomLeaver,
 	logger *zap.Logger,
+	passwordPolicy utils.PasswordPolicy,
+	sessionCreator SessionCreator,
 ) *AuthService {
 	return &AuthService{
 		userRepo:        userRepo,
 		userProfileRepo: userProfileRepo,
 		userStatsRepo:   userStatsRepo,
 		sessionRepo:     sessionRepo,
+		onlineUserRepo:  onlineUserRepo,
 		jwtService:      jwtService,
+		connCloser:      connCloser,
+		roomLeaver:      roomLeaver,
 		logger:          logger,
+		passwordPolicy:  passwordPolicy,
+		sessionCreator:  sessionCreator,
 	}
 }
 
 // RegisterRequest 注册请求
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-	Nickname string `json:"nickname"`
+	Username   string `json:"username" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	Nickname   string `json:"nickname"`
+	ClientType string `json:"client_type"` // web（默认）或 mobile
 }
 
 // RegisterResponse 注册响应
@@ -82,7 +128,18 @@ type RegisterResponse struct {
 }
 
 // Register 用户注册
-func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (resp *RegisterResponse, err error) {
+	defer func() { recordAuthOutcome(authOpRegister, err) }()
+
+	clientType, err := normalizeClientType(req.ClientType)
+	if err != nil {
+		return nil, err
+	}
+	// 新注册的用户不是管理员，不签发管理端令牌
+	if clientType == utils.ClientTypeAdmin {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "不支持的客户端类型")
+	}
+
 	// 验证用户名
 	if !utils.ValidateUsername(req.Username) {
 		return nil, utils.NewError(utils.ErrCodeInvalidInput, "用户名格式无效")
@@ -92,17 +149,26 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	if !utils.ValidateEmail(req.Email) {
 		return nil, utils.NewError(utils.ErrCodeInvalidInput, "邮箱格式无效")
 	}
+	if err := utils.ValidateMaxLength("email", "邮箱", req.Email, model.MaxEmailLength); err != nil {
+		return nil, err
+	}
 
 	// 验证密码
-	if !utils.ValidatePassword(req.Password) {
-		return nil, utils.NewError(utils.ErrCodeInvalidInput, "密码强度不足，需要包含大小写字母、数字和特殊字符")
+	if err := s.passwordPolicy.Check(req.Password); err != nil {
+		return nil, err
+	}
+
+	// 清洗昵称
+	nickname, err := utils.SanitizeText(req.Nickname, nicknameRule)
+	if err != nil {
+		return nil, err
 	}
 
 	// 检查用户名是否已存在
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		s.logger.Error("查询用户失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "注册失败")
+		return nil, utils.NewInternalError("注册失败", err)
 	}
 	if existingUser != nil {
 		return nil, utils.NewError(utils.ErrCodeConflict, "用户名已存在")
@@ -112,7 +178,7 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	existingEmail, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		s.logger.Error("查询用户失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "注册失败")
+		return nil, utils.NewInternalError("注册失败", err)
 	}
 	if existingEmail != nil {
 		return nil, utils.NewError(utils.ErrCodeConflict, "邮箱已被注册")
@@ -122,7 +188,7 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		s.logger.Error("密码加密失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "注册失败")
+		return nil, utils.NewInternalError("注册失败", err)
 	}
 
 	// 创建用户
@@ -130,13 +196,14 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 		Username: req.Username,
 		Email:    req.Email,
 		Password: string(hashedPassword),
-		Nickname: req.Nickname,
+		Nickname: nickname,
 		Status:   1,
+		Role:     model.UserRolePlayer,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		s.logger.Error("创建用户失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "注册失败")
+		return nil, utils.NewInternalError("注册失败", err)
 	}
 
 	// 创建用户资料
@@ -156,10 +223,10 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	}
 
 	// 生成 Token
-	token, err := s.jwtService.GenerateToken(user.ID, user.Username)
+	token, err := s.jwtService.GenerateToken(user.ID, user.Username, clientType)
 	if err != nil {
 		s.logger.Error("生成 Token 失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "注册失败")
+		return nil, utils.NewInternalError("注册失败", err)
 	}
 
 	return &RegisterResponse{
@@ -170,8 +237,11 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 
 // LoginRequest 登录请求
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	ClientType string `json:"client_type"` // web（默认）、mobile 或 admin，作为令牌受众
+	IPAddress  string `json:"-"`           // 客户端 IP，由处理器填充
+	UserAgent  string `json:"-"`           // 客户端 User-Agent，由处理器填充
 }
 
 // LoginResponse 登录响应
@@ -182,13 +252,74 @@ type LoginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// normalizeClientType 校验客户端类型，未指定时默认为 web
+func normalizeClientType(clientType string) (string, error) {
+	if clientType == "" {
+		return utils.ClientTypeWeb, nil
+	}
+	if !utils.ValidClientType(clientType) {
+		return "", utils.NewError(utils.ErrCodeInvalidInput, "不支持的客户端类型")
+	}
+	return clientType, nil
+}
+
+// AvailabilityResponse 用户名/邮箱可用性检查结果
+type AvailabilityResponse struct {
+	Available bool   `json:"available"`
+	Valid     bool   `json:"valid"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// IsUsernameAvailable 检查用户名格式是否正确且未被占用
+func (s *AuthService) IsUsernameAvailable(ctx context.Context, username string) (*AvailabilityResponse, error) {
+	if !utils.ValidateUsername(username) {
+		return &AvailabilityResponse{Reason: "用户名格式无效"}, nil
+	}
+
+	existingUser, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("检查用户名失败", err)
+	}
+	if existingUser != nil {
+		return &AvailabilityResponse{Valid: true, Reason: "用户名已存在"}, nil
+	}
+
+	return &AvailabilityResponse{Available: true, Valid: true}, nil
+}
+
+// IsEmailAvailable 检查邮箱格式是否正确且未被注册
+func (s *AuthService) IsEmailAvailable(ctx context.Context, email string) (*AvailabilityResponse, error) {
+	if !utils.ValidateEmail(email) {
+		return &AvailabilityResponse{Reason: "邮箱格式无效"}, nil
+	}
+
+	existingEmail, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("检查邮箱失败", err)
+	}
+	if existingEmail != nil {
+		return &AvailabilityResponse{Valid: true, Reason: "邮箱已被注册"}, nil
+	}
+
+	return &AvailabilityResponse{Available: true, Valid: true}, nil
+}
+
 // Login 用户登录
-func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (resp *LoginResponse, err error) {
+	defer func() { recordAuthOutcome(authOpLogin, err) }()
+
+	clientType, err := normalizeClientType(req.ClientType)
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取用户
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		s.logger.Error("查询用户失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "登录失败")
+		return nil, utils.NewInternalError("登录失败", err)
 	}
 	if user == nil {
 		return nil, utils.NewError(utils.ErrCodeUnauthorized, "用户名或密码错误")
@@ -204,28 +335,41 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		return nil, utils.NewError(utils.ErrCodeUnauthorized, "用户名或密码错误")
 	}
 
+	// 管理端受众只签发给管理员
+	if clientType == utils.ClientTypeAdmin && user.Role != model.UserRoleAdmin {
+		return nil, utils.NewError(utils.ErrCodeForbidden, "需要管理员权限")
+	}
+
 	// 生成 Token
-	token, err := s.jwtService.GenerateToken(user.ID, user.Username)
+	token, err := s.jwtService.GenerateToken(user.ID, user.Username, clientType)
 	if err != nil {
 		s.logger.Error("生成 Token 失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "登录失败")
+		return nil, utils.NewInternalError("登录失败", err)
 	}
 
 	// 生成刷新 Token
-	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID, user.Username)
+	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID, user.Username, clientType)
 	if err != nil {
 		s.logger.Error("生成刷新 Token 失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "登录失败")
+		return nil, utils.NewInternalError("登录失败", err)
+	}
+
+	// 创建会话：单设备模式下按冲突策略拒绝本次登录或踢出旧会话
+	if err := s.sessionCreator.CreateSession(ctx, user.ID, user.Role, req.IPAddress, req.UserAgent); err != nil {
+		return nil, err
 	}
 
-	// 保存会话到 Redis
+	// 按设备记录会话，用于登出所有设备
 	sessionData := map[string]interface{}{
 		"user_id":       user.ID,
 		"username":      user.Username,
+		"role":          user.Role,
 		"last_activity": time.Now().Unix(),
 	}
-	if err := s.sessionRepo.SetSession(ctx, user.ID, sessionData, 24*time.Hour); err != nil {
-		s.logger.Warn("保存会话失败", zap.Error(err))
+	if claims, err := s.jwtService.ValidateToken(refreshToken); err == nil {
+		if err := s.sessionRepo.AddDeviceSession(ctx, user.ID, claims.ID, sessionData, time.Until(claims.ExpiresAt.Time)); err != nil {
+			s.logger.Warn("保存设备会话失败", zap.Error(err))
+		}
 	}
 
 	return &LoginResponse{
@@ -248,25 +392,37 @@ type RefreshTokenResponse struct {
 }
 
 // RefreshToken 刷新 Token
-func (s *AuthService) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*RefreshTokenResponse, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (resp *RefreshTokenResponse, err error) {
+	defer func() { recordAuthOutcome(authOpRefresh, err) }()
+
 	// 验证刷新 Token
 	claims, err := s.jwtService.ValidateToken(req.RefreshToken)
 	if err != nil {
 		return nil, utils.NewError(utils.ErrCodeUnauthorized, "无效的刷新令牌")
 	}
+	if claims.IsImpersonation() {
+		return nil, utils.NewError(utils.ErrCodeUnauthorized, "模拟登录令牌不能刷新")
+	}
+	if revoked, err := s.IsTokenRevoked(ctx, claims); err != nil {
+		s.logger.Error("检查令牌吊销状态失败", zap.Error(err))
+		return nil, utils.NewInternalError("刷新令牌失败", err)
+	} else if revoked {
+		return nil, utils.NewError(utils.ErrCodeUnauthorized, "刷新令牌已失效")
+	}
 
 	// 生成新的 Token
-	token, err := s.jwtService.GenerateToken(claims.UserID, claims.Username)
+	// 保持原令牌的客户端类型
+	token, err := s.jwtService.GenerateToken(claims.UserID, claims.Username, claims.ClientType())
 	if err != nil {
 		s.logger.Error("生成 Token 失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "刷新令牌失败")
+		return nil, utils.NewInternalError("刷新令牌失败", err)
 	}
 
 	// 生成新的刷新 Token
-	refreshToken, err := s.jwtService.GenerateRefreshToken(claims.UserID, claims.Username)
+	refreshToken, err := s.jwtService.GenerateRefreshToken(claims.UserID, claims.Username, claims.ClientType())
 	if err != nil {
 		s.logger.Error("生成刷新 Token 失败", zap.Error(err))
-		return nil, utils.NewError(utils.ErrCodeInternal, "刷新令牌失败")
+		return nil, utils.NewInternalError("刷新令牌失败", err)
 	}
 
 	return &RefreshTokenResponse{
@@ -280,6 +436,111 @@ func (s *AuthService) Logout(ctx context.Context, userID uint) error {
 	return s.sessionRepo.DeleteSession(ctx, userID)
 }
 
+// LogoutAll 登出所有设备：删除所有会话、移出在线列表、断开实时连接并吊销已签发的令牌
+func (s *AuthService) LogoutAll(ctx context.Context, userID uint) error {
+	// 吊销时长覆盖最长的刷新令牌有效期
+	revokeTTL := time.Duration(s.jwtService.RefreshExpirationHours()) * time.Hour
+	if err := s.sessionRepo.RevokeTokensBefore(ctx, userID, time.Now(), revokeTTL); err != nil {
+		s.logger.Error("吊销令牌失败", zap.Error(err), zap.Uint("user_id", userID))
+		return utils.NewInternalError("登出失败", err)
+	}
+
+	if err := s.sessionRepo.DeleteAllSessions(ctx, userID); err != nil {
+		s.logger.Error("删除会话失败", zap.Error(err), zap.Uint("user_id", userID))
+		return utils.NewInternalError("登出失败", err)
+	}
+
+	if err := s.onlineUserRepo.RemoveOnlineUser(ctx, userID); err != nil {
+		s.logger.Warn("移除在线用户失败", zap.Error(err))
+	}
+
+	if s.connCloser != nil {
+		s.connCloser.KickUser(userID, "账号已在所有设备登出")
+	}
+
+	return nil
+}
+
+// DeleteAccountRequest 注销账号请求，需要重新输入密码确认
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccount 注销账号：验证密码后离开所在房间，吊销所有会话，再匿名化用户和资料并软删除
+// 用户名和邮箱会被替换，原用户名和邮箱可以重新注册
+func (s *AuthService) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return utils.NewInternalError("注销账号失败", err)
+	}
+	if user == nil {
+		return utils.NewError(utils.ErrCodeNotFound, "用户不存在")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return utils.NewError(utils.ErrCodeUnauthorized, "密码错误")
+	}
+
+	if s.roomLeaver != nil {
+		if err := s.roomLeaver.LeaveAllRooms(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	// 先吊销令牌、删除会话并断开实时连接，失败时不删除账号，避免已注销账号的令牌仍然有效
+	if err := s.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	// 匿名化资料
+	profile, err := s.userProfileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("查询用户资料失败", zap.Error(err))
+		return utils.NewInternalError("注销账号失败", err)
+	}
+	if profile != nil {
+		profile.Gender = 0
+		profile.Birthday = nil
+		profile.Bio = ""
+		profile.Location = ""
+		if err := s.userProfileRepo.Update(ctx, profile); err != nil {
+			s.logger.Error("清除用户资料失败", zap.Error(err))
+			return utils.NewInternalError("注销账号失败", err)
+		}
+	}
+
+	// 匿名化用户后软删除，释放唯一的用户名和邮箱
+	// 替换值包含冒号，不能通过用户名和邮箱校验，不会与注册或改名得到的值冲突
+	user.Username = fmt.Sprintf("deleted:%d", user.ID)
+	user.Email = fmt.Sprintf("deleted:%d@deleted.invalid", user.ID)
+	user.Nickname = "已注销用户"
+	user.Avatar = ""
+	user.Password = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("匿名化用户失败", zap.Error(err))
+		return utils.NewInternalError("注销账号失败", err)
+	}
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		s.logger.Error("删除用户失败", zap.Error(err))
+		return utils.NewInternalError("注销账号失败", err)
+	}
+
+	s.logger.Info("用户注销账号", zap.Uint("user_id", userID))
+	return nil
+}
+
+// IsTokenRevoked 检查令牌是否已被吊销（签发时间不晚于吊销时间点，按毫秒比较）
+func (s *AuthService) IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error) {
+	revokedBefore, err := s.sessionRepo.GetTokensRevokedBefore(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if revokedBefore.IsZero() || claims.IssuedAt == nil {
+		return false, nil
+	}
+	return !claims.IssuedAt.Time.After(revokedBefore), nil
+}
+
 // ValidateToken 验证 Token
 func (s *AuthService) ValidateToken(token string) (*utils.JWTClaims, error) {
 	return s.jwtService.ValidateToken(token)
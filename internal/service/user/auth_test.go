@@ -0,0 +1,373 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// kickRecorder 记录被断开连接的用户
+type kickRecorder struct {
+	kicked []uint
+}
+
+func (k *kickRecorder) KickUser(userID uint, reason string) {
+	k.kicked = append(k.kicked, userID)
+}
+
+// leaveRecorder 记录离开所有房间的用户
+type leaveRecorder struct {
+	left []uint
+}
+
+func (l *leaveRecorder) LeaveAllRooms(ctx context.Context, userID uint) error {
+	l.left = append(l.left, userID)
+	return nil
+}
+
+// sessionRecorder 记录创建的登录会话，err 不为空时拒绝创建
+type sessionRecorder struct {
+	created []string // 角色|IP|User-Agent
+	err     error
+}
+
+func (r *sessionRecorder) CreateSession(ctx context.Context, userID uint, role, ipAddress, userAgent string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.created = append(r.created, role+"|"+ipAddress+"|"+userAgent)
+	return nil
+}
+
+// authFixture 认证服务及其依赖
+type authFixture struct {
+	service  *AuthService
+	users    *memUserRepo
+	profiles *memProfileRepo
+	sessions *redis.SessionRepository
+	online   *redis.OnlineUserRepository
+	closer   *kickRecorder
+	leaver   *leaveRecorder
+	creator  *sessionRecorder
+	redis    *miniredis.Miniredis
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	repo, mr := newTestRepository(t)
+	f := &authFixture{
+		redis:    mr,
+		users:    newMemUserRepo(),
+		profiles: newMemProfileRepo(),
+		sessions: redis.NewSessionRepository(repo),
+		online:   redis.NewOnlineUserRepository(repo),
+		closer:   &kickRecorder{},
+		leaver:   &leaveRecorder{},
+		creator:  &sessionRecorder{},
+	}
+	f.service = NewAuthService(
+		f.users,
+		f.profiles,
+		newMemStatsRepo(),
+		f.sessions,
+		f.online,
+		utils.NewJWTService("test-secret", 1, 24, "game-services", "", 0),
+		f.closer,
+		f.leaver,
+		zap.NewNop(),
+		utils.PasswordPolicy{RequireClasses: true},
+		f.creator,
+	)
+	return f
+}
+
+func TestLogoutAllRevokesTokens(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	user := f.users.addUser(t, "alice", "Passw0rd!")
+
+	login, err := f.service.Login(ctx, &LoginRequest{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := f.service.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if revoked, err := f.service.IsTokenRevoked(ctx, claims); err != nil || revoked {
+		t.Fatalf("登出前 IsTokenRevoked() = %v, %v, want false", revoked, err)
+	}
+	f.online.AddOnlineUser(ctx, user.ID)
+
+	if err := f.service.LogoutAll(ctx, user.ID); err != nil {
+		t.Fatalf("LogoutAll() error = %v", err)
+	}
+
+	if revoked, err := f.service.IsTokenRevoked(ctx, claims); err != nil || !revoked {
+		t.Errorf("登出后 IsTokenRevoked() = %v, %v, want true", revoked, err)
+	}
+	_, err = f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeUnauthorized {
+		t.Errorf("RefreshToken() error = %v, want ErrCodeUnauthorized", err)
+	}
+
+	for _, key := range f.redis.Keys() {
+		if strings.HasPrefix(key, "session:") {
+			t.Errorf("登出后会话 %s 仍然存在", key)
+		}
+	}
+	if online, _ := f.online.IsOnline(ctx, user.ID); online {
+		t.Errorf("登出后用户仍在在线列表中")
+	}
+	if len(f.closer.kicked) != 1 || f.closer.kicked[0] != user.ID {
+		t.Errorf("kicked = %v, want [%d]", f.closer.kicked, user.ID)
+	}
+}
+
+func TestIsTokenRevokedPrecision(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	revokedAt := time.Unix(1700000000, 500*int64(time.Millisecond))
+
+	tests := []struct {
+		name     string
+		stored   string // 为空表示通过 RevokeTokensBefore 写入 revokedAt
+		issuedAt time.Time
+		want     bool
+	}{
+		{"吊销前签发", "", revokedAt.Add(-time.Millisecond), true},
+		{"吊销同一毫秒签发", "", revokedAt, true},
+		{"吊销后同一秒内签发", "", revokedAt.Add(time.Millisecond), false},
+		{"旧版按秒写入，之前签发", "1700000000", time.Unix(1699999999, 0), true},
+		{"旧版按秒写入，同一秒内之后签发", "1700000000", revokedAt, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stored == "" {
+				if err := f.sessions.RevokeTokensBefore(ctx, 1, revokedAt, time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				f.redis.Set("token:revoked_before:1", tt.stored)
+			}
+
+			claims := &utils.JWTClaims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(tt.issuedAt)}}
+			if revoked, err := f.service.IsTokenRevoked(ctx, claims); err != nil || revoked != tt.want {
+				t.Errorf("IsTokenRevoked() = %v, %v, want %v", revoked, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginCreatesSession(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	user := f.users.addUser(t, "alice", "Passw0rd!")
+	user.Role = model.UserRolePlayer
+	f.users.Update(ctx, user)
+
+	req := &LoginRequest{Username: "alice", Password: "Passw0rd!", IPAddress: "10.0.0.1", UserAgent: "test-agent"}
+	if _, err := f.service.Login(ctx, req); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if want := model.UserRolePlayer + "|10.0.0.1|test-agent"; len(f.creator.created) != 1 || f.creator.created[0] != want {
+		t.Errorf("created sessions = %v, want [%s]", f.creator.created, want)
+	}
+
+	// 单设备模式拒绝登录时不签发令牌
+	f.creator.err = utils.NewError(utils.ErrCodeConflict, "账号已在其他设备登录")
+	resp, err := f.service.Login(ctx, req)
+	var appErr *utils.AppError
+	if resp != nil || !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeConflict {
+		t.Errorf("Login() = %v, %v, want ErrCodeConflict", resp, err)
+	}
+}
+
+func TestLoginAdminAudience(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	admin := f.users.addUser(t, "root", "Passw0rd!")
+	admin.Role = model.UserRoleAdmin
+	f.users.Update(ctx, admin)
+	player := f.users.addUser(t, "alice", "Passw0rd!")
+	player.Role = model.UserRolePlayer
+	f.users.Update(ctx, player)
+
+	tests := []struct {
+		name       string
+		username   string
+		clientType string
+		wantCode   int
+	}{
+		{"管理员获取管理端令牌", "root", utils.ClientTypeAdmin, 0},
+		{"普通玩家不能获取管理端令牌", "alice", utils.ClientTypeAdmin, utils.ErrCodeForbidden},
+		{"普通玩家登录网页端", "alice", utils.ClientTypeWeb, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := f.service.Login(ctx, &LoginRequest{Username: tt.username, Password: "Passw0rd!", ClientType: tt.clientType})
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if resp != nil || !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("Login() = %v, %v, want code %d", resp, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			claims, err := f.service.jwtService.ValidateToken(resp.Token)
+			if err != nil || !claims.HasAudience(tt.clientType) {
+				t.Errorf("token claims = %+v, %v, want audience %s", claims, err, tt.clientType)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsAdminClientType(t *testing.T) {
+	f := newAuthFixture(t)
+	_, err := f.service.Register(context.Background(), &RegisterRequest{
+		Username:   "mallory",
+		Email:      "mallory@example.com",
+		Password:   "Passw0rd!",
+		ClientType: utils.ClientTypeAdmin,
+	})
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInvalidInput {
+		t.Errorf("Register() error = %v, want ErrCodeInvalidInput", err)
+	}
+}
+
+func TestRefreshTokenRejectsImpersonation(t *testing.T) {
+	f := newAuthFixture(t)
+	user := f.users.addUser(t, "alice", "Passw0rd!")
+
+	token, err := f.service.jwtService.GenerateImpersonationToken(user.ID, user.Username, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.service.RefreshToken(context.Background(), &RefreshTokenRequest{RefreshToken: token})
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeUnauthorized {
+		t.Errorf("RefreshToken() error = %v, want ErrCodeUnauthorized", err)
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	f := newAuthFixture(t)
+	f.users.addUser(t, "alice", "password123")
+
+	tests := []struct {
+		name  string
+		check func(ctx context.Context, value string) (*AvailabilityResponse, error)
+		value string
+		want  AvailabilityResponse
+	}{
+		{"用户名已占用", f.service.IsUsernameAvailable, "alice", AvailabilityResponse{Valid: true, Reason: "用户名已存在"}},
+		{"用户名可用", f.service.IsUsernameAvailable, "bob_2", AvailabilityResponse{Available: true, Valid: true}},
+		{"用户名格式无效", f.service.IsUsernameAvailable, "a!", AvailabilityResponse{Reason: "用户名格式无效"}},
+		{"邮箱已注册", f.service.IsEmailAvailable, "alice@example.com", AvailabilityResponse{Valid: true, Reason: "邮箱已被注册"}},
+		{"邮箱可用", f.service.IsEmailAvailable, "bob@example.com", AvailabilityResponse{Available: true, Valid: true}},
+		{"邮箱格式无效", f.service.IsEmailAvailable, "not-an-email", AvailabilityResponse{Reason: "邮箱格式无效"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.check(context.Background(), tt.value)
+			if err != nil {
+				t.Fatalf("检查失败: %v", err)
+			}
+			if *resp != tt.want {
+				t.Errorf("结果 = %+v，期望 %+v", *resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantCode int
+	}{
+		{"注销成功", "Passw0rd!", 0},
+		{"密码错误", "wrong", utils.ErrCodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			ctx := context.Background()
+			user := f.users.addUser(t, "alice", "Passw0rd!")
+			f.profiles.Create(ctx, &model.UserProfile{UserID: user.ID, Bio: "hello", Location: "Shanghai"})
+			f.online.AddOnlineUser(ctx, user.ID)
+
+			err := f.service.DeleteAccount(ctx, user.ID, tt.password)
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("DeleteAccount() error = %v, want code %d", err, tt.wantCode)
+				}
+				if stored, _ := f.users.GetByID(ctx, user.ID); stored == nil {
+					t.Error("密码错误时不应删除用户")
+				}
+				if len(f.leaver.left) != 0 {
+					t.Errorf("密码错误时不应离开房间, left = %v", f.leaver.left)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeleteAccount() error = %v", err)
+			}
+
+			if stored, _ := f.users.GetByID(ctx, user.ID); stored != nil {
+				t.Errorf("注销后用户仍然存在: %+v", stored)
+			}
+			// 匿名化后的用户名和邮箱不能被注册或改名占用
+			if deleted := f.users.deleted[user.ID]; deleted == nil || utils.ValidateUsername(deleted.Username) || utils.ValidateEmail(deleted.Email) {
+				t.Errorf("匿名化后的用户 = %+v，用户名和邮箱不应通过校验", deleted)
+			}
+			if profile, _ := f.profiles.GetByUserID(ctx, user.ID); profile == nil || profile.Bio != "" || profile.Location != "" {
+				t.Errorf("注销后资料未清除: %+v", profile)
+			}
+			if len(f.leaver.left) != 1 || f.leaver.left[0] != user.ID {
+				t.Errorf("left = %v, want [%d]", f.leaver.left, user.ID)
+			}
+			if online, _ := f.online.IsOnline(ctx, user.ID); online {
+				t.Error("注销后用户仍在在线列表中")
+			}
+
+			// 注销后不能再登录
+			_, err = f.service.Login(ctx, &LoginRequest{Username: "alice", Password: "Passw0rd!"})
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeUnauthorized {
+				t.Errorf("Login() after deletion error = %v, want ErrCodeUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountRevokeFailure(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	user := f.users.addUser(t, "alice", "Passw0rd!")
+	f.redis.SetError("redis unavailable")
+
+	// 吊销失败时不能删除账号，否则已签发的令牌在注销后仍然有效
+	var appErr *utils.AppError
+	if err := f.service.DeleteAccount(ctx, user.ID, "Passw0rd!"); !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeInternal {
+		t.Fatalf("DeleteAccount() error = %v, want ErrCodeInternal", err)
+	}
+	if stored, _ := f.users.GetByID(ctx, user.ID); stored == nil || stored.Username != "alice" {
+		t.Errorf("吊销失败后用户 = %+v，应保持不变", stored)
+	}
+}
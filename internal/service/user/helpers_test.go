@@ -0,0 +1,221 @@
+package user
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestRepository 基于 miniredis 创建 Redis 仓库
+func newTestRepository(t *testing.T) (*redis.Repository, *miniredis.Miniredis) {
+	t.Helper()
+	client, mr := testutil.NewCacheClient(t)
+	return redis.NewRepository(client), mr
+}
+
+// memUserRepo 内存用户仓库
+type memUserRepo struct {
+	mu      sync.Mutex
+	nextID  uint
+	users   map[uint]*model.User
+	deleted map[uint]*model.User // 已软删除的用户
+}
+
+func newMemUserRepo() *memUserRepo {
+	return &memUserRepo{users: make(map[uint]*model.User), deleted: make(map[uint]*model.User)}
+}
+
+// addUser 添加一个使用指定密码的正常用户
+func (r *memUserRepo) addUser(t *testing.T, username, password string) *model.User {
+	t.Helper()
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: username, Email: username + "@example.com", Password: string(hashed), Status: 1}
+	if err := r.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (r *memUserRepo) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	user.ID = r.nextID
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memUserRepo) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memUserRepo) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	var users []*model.User
+	for _, id := range ids {
+		if user, _ := r.GetByID(ctx, id); user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memUserRepo) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memUserRepo) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+// Delete 删除用户，与软删除一致，删除后按 ID、用户名和邮箱都查不到
+func (r *memUserRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		r.deleted[id] = user
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// memProfileRepo 内存用户资料仓库
+type memProfileRepo struct {
+	mu       sync.Mutex
+	profiles map[uint]*model.UserProfile
+}
+
+func newMemProfileRepo() *memProfileRepo {
+	return &memProfileRepo{profiles: make(map[uint]*model.UserProfile)}
+}
+
+func (r *memProfileRepo) Create(ctx context.Context, profile *model.UserProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *profile
+	r.profiles[profile.UserID] = &copied
+	return nil
+}
+
+func (r *memProfileRepo) GetByUserID(ctx context.Context, userID uint) (*model.UserProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if profile, ok := r.profiles[userID]; ok {
+		copied := *profile
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memProfileRepo) Update(ctx context.Context, profile *model.UserProfile) error {
+	return r.Create(ctx, profile)
+}
+
+// memStatsRepo 内存用户统计仓库
+type memStatsRepo struct {
+	mu    sync.Mutex
+	stats map[uint]*model.UserStats
+}
+
+func newMemStatsRepo() *memStatsRepo {
+	return &memStatsRepo{stats: make(map[uint]*model.UserStats)}
+}
+
+func (r *memStatsRepo) Create(ctx context.Context, stats *model.UserStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *stats
+	r.stats[stats.UserID] = &copied
+	return nil
+}
+
+func (r *memStatsRepo) GetByUserID(ctx context.Context, userID uint) (*model.UserStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stats, ok := r.stats[userID]; ok {
+		copied := *stats
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memStatsRepo) GetByUserIDs(ctx context.Context, userIDs []uint) ([]*model.UserStats, error) {
+	var found []*model.UserStats
+	for _, id := range userIDs {
+		if stats, _ := r.GetByUserID(ctx, id); stats != nil {
+			found = append(found, stats)
+		}
+	}
+	return found, nil
+}
+
+func (r *memStatsRepo) Update(ctx context.Context, stats *model.UserStats) error {
+	return r.Create(ctx, stats)
+}
+
+func (r *memStatsRepo) ListInactive(ctx context.Context, since time.Time, limit, offset int) ([]*model.UserStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var inactive []*model.UserStats
+	for _, stats := range r.stats {
+		if stats.LastPlayedAt != nil && stats.LastPlayedAt.Before(since) {
+			copied := *stats
+			inactive = append(inactive, &copied)
+		}
+	}
+	sort.Slice(inactive, func(i, j int) bool { return inactive[i].LastPlayedAt.Before(*inactive[j].LastPlayedAt) })
+	if offset >= len(inactive) {
+		return nil, nil
+	}
+	inactive = inactive[offset:]
+	if limit > 0 && limit < len(inactive) {
+		inactive = inactive[:limit]
+	}
+	return inactive, nil
+}
+
+func (r *memStatsRepo) CountInactive(ctx context.Context, since time.Time) (int64, error) {
+	inactive, err := r.ListInactive(ctx, since, 0, 0)
+	return int64(len(inactive)), err
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// maxLobbyUsers 单次批量解析的最大用户数
+const maxLobbyUsers = 200
+
+// OnlineChecker 在线状态查询接口
+type OnlineChecker interface {
+	AreOnline(ctx context.Context, userIDs []uint) (map[uint]bool, error)
+}
+
+// UserService 用户信息聚合服务
+type UserService struct {
+	userRepo      UserRepository
+	userStatsRepo UserStatsRepository
+	onlineChecker OnlineChecker
+	logger        *zap.Logger
+}
+
+// NewUserService 创建用户信息聚合服务
+func NewUserService(
+	userRepo UserRepository,
+	userStatsRepo UserStatsRepository,
+	onlineChecker OnlineChecker,
+	logger *zap.Logger,
+) *UserService {
+	return &UserService{
+		userRepo:      userRepo,
+		userStatsRepo: userStatsRepo,
+		onlineChecker: onlineChecker,
+		logger:        logger,
+	}
+}
+
+// LobbyUser 大厅展示所需的用户信息
+type LobbyUser struct {
+	ID       uint   `json:"id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Level    int    `json:"level"`
+	Online   bool   `json:"online"`
+}
+
+// GetLobbyUsers 批量获取用户的资料、等级和在线状态，按输入顺序返回（去重），不存在的用户会被忽略
+// 用户、统计、在线状态各查询一次；Redis 不可用时全部视为离线
+func (s *UserService) GetLobbyUsers(ctx context.Context, userIDs []uint) ([]*LobbyUser, error) {
+	seen := make(map[uint]struct{}, len(userIDs))
+	unique := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	if len(unique) > maxLobbyUsers {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, fmt.Sprintf("单次最多查询 %d 个用户", maxLobbyUsers))
+	}
+	if len(unique) == 0 {
+		return []*LobbyUser{}, nil
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取用户信息失败", err)
+	}
+	userByID := make(map[uint]*model.User, len(users))
+	for _, u := range users {
+		userByID[u.ID] = u
+	}
+
+	statsList, err := s.userStatsRepo.GetByUserIDs(ctx, unique)
+	if err != nil {
+		s.logger.Error("查询用户统计失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取用户信息失败", err)
+	}
+	levelByID := make(map[uint]int, len(statsList))
+	for _, stats := range statsList {
+		levelByID[stats.UserID] = stats.Level
+	}
+
+	presence, err := s.onlineChecker.AreOnline(ctx, unique)
+	if err != nil {
+		if !errors.Is(err, cache.ErrCacheUnavailable) {
+			s.logger.Error("批量查询在线状态失败", zap.Error(err))
+			return nil, utils.NewInternalError("获取用户信息失败", err)
+		}
+		presence = map[uint]bool{}
+	}
+
+	items := make([]*LobbyUser, 0, len(unique))
+	for _, id := range unique {
+		u, ok := userByID[id]
+		if !ok {
+			continue
+		}
+		// 没有统计记录的用户按初始等级展示
+		level, ok := levelByID[id]
+		if !ok {
+			level = 1
+		}
+		items = append(items, &LobbyUser{
+			ID:       u.ID,
+			Nickname: u.Nickname,
+			Avatar:   u.Avatar,
+			Level:    level,
+			Online:   presence[id],
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,52 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"go.uber.org/zap"
+)
+
+func TestGetLobbyUsers(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	users := newMemUserRepo()
+	statsRepo := newMemStatsRepo()
+	onlineRepo := redis.NewOnlineUserRepository(repo)
+	s := NewUserService(users, statsRepo, onlineRepo, zap.NewNop())
+	ctx := context.Background()
+
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	bob := users.addUser(t, "bob", "Passw0rd!")
+	alice.Nickname, alice.Avatar = "Alice", "https://example.com/alice.png"
+	users.Update(ctx, alice)
+	statsRepo.Create(ctx, &model.UserStats{UserID: alice.ID, Level: 7})
+	onlineRepo.AddOnlineUser(ctx, alice.ID)
+
+	// 重复和不存在的 ID 被忽略，按输入顺序返回
+	got, err := s.GetLobbyUsers(ctx, []uint{bob.ID, 999, alice.ID, bob.ID, 0})
+	if err != nil {
+		t.Fatalf("GetLobbyUsers() error = %v", err)
+	}
+	want := []LobbyUser{
+		{ID: bob.ID, Level: 1, Online: false},
+		{ID: alice.ID, Nickname: "Alice", Avatar: "https://example.com/alice.png", Level: 7, Online: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetLobbyUsers() = %d users, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("GetLobbyUsers()[%d] = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+
+	tooMany := make([]uint, maxLobbyUsers+1)
+	for i := range tooMany {
+		tooMany[i] = uint(i + 1)
+	}
+	if _, err := s.GetLobbyUsers(ctx, tooMany); err == nil {
+		t.Error("超过上限时应返回错误")
+	}
+}
@@ -0,0 +1,54 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// CurrentUser 当前登录用户的身份信息
+type CurrentUser struct {
+	ID             uint   `json:"id"`
+	Username       string `json:"username"`
+	Nickname       string `json:"nickname"`
+	Avatar         string `json:"avatar"`
+	Role           string `json:"role"`
+	Online         bool   `json:"online"`
+	ImpersonatedBy uint   `json:"impersonated_by,omitempty"` // 管理员模拟登录时为发起模拟的管理员 ID
+}
+
+// GetCurrentUser 根据令牌声明和用户记录组装当前用户的身份信息
+// 令牌中不包含角色，角色以用户记录为准，避免角色变更后仍返回签发时的旧值
+func (s *UserService) GetCurrentUser(ctx context.Context, claims *utils.JWTClaims) (*CurrentUser, error) {
+	u, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", claims.UserID))
+		return nil, utils.NewInternalError("获取用户信息失败", err)
+	}
+	// 令牌有效但用户已被删除
+	if u == nil {
+		return nil, utils.NewError(utils.ErrCodeUnauthorized, "用户不存在")
+	}
+
+	presence, err := s.onlineChecker.AreOnline(ctx, []uint{u.ID})
+	if err != nil {
+		if !errors.Is(err, cache.ErrCacheUnavailable) {
+			s.logger.Error("查询在线状态失败", zap.Error(err), zap.Uint("user_id", u.ID))
+			return nil, utils.NewInternalError("获取用户信息失败", err)
+		}
+		presence = map[uint]bool{}
+	}
+
+	return &CurrentUser{
+		ID:             u.ID,
+		Username:       u.Username,
+		Nickname:       u.Nickname,
+		Avatar:         u.Avatar,
+		Role:           u.Role,
+		Online:         presence[u.ID],
+		ImpersonatedBy: claims.ImpersonatedBy,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/repository/redis"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestGetCurrentUser(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	users := newMemUserRepo()
+	onlineRepo := redis.NewOnlineUserRepository(repo)
+	s := NewUserService(users, newMemStatsRepo(), onlineRepo, zap.NewNop())
+	ctx := context.Background()
+
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	alice.Nickname, alice.Avatar, alice.Role = "Alice", "https://example.com/alice.png", model.UserRoleAdmin
+	users.Update(ctx, alice)
+	onlineRepo.AddOnlineUser(ctx, alice.ID)
+
+	got, err := s.GetCurrentUser(ctx, &utils.JWTClaims{UserID: alice.ID, Username: "alice", ImpersonatedBy: 9})
+	if err != nil {
+		t.Fatalf("GetCurrentUser() error = %v", err)
+	}
+	want := CurrentUser{
+		ID:             alice.ID,
+		Username:       "alice",
+		Nickname:       "Alice",
+		Avatar:         "https://example.com/alice.png",
+		Role:           model.UserRoleAdmin,
+		Online:         true,
+		ImpersonatedBy: 9,
+	}
+	if *got != want {
+		t.Errorf("GetCurrentUser() = %+v, want %+v", *got, want)
+	}
+
+	// 令牌有效但用户已被删除
+	_, err = s.GetCurrentUser(ctx, &utils.JWTClaims{UserID: 999})
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Code != utils.ErrCodeUnauthorized {
+		t.Errorf("GetCurrentUser() for deleted user error = %v, want ErrCodeUnauthorized", err)
+	}
+}
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/game-apps/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	authAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_attempts_total",
+			Help: "Total number of authentication attempts",
+		},
+		[]string{"operation", "result", "reason"},
+	)
+)
+
+// activeSessionsDesc 当前登录会话数，从 Redis 读取，所有实例报告相同的值
+var activeSessionsDesc = prometheus.NewDesc(
+	"auth_active_sessions",
+	"Number of users with an active login session",
+	nil, nil,
+)
+
+// sessionCountTimeout 采集时查询会话数的超时
+const sessionCountTimeout = 2 * time.Second
+
+// SessionCounter 统计当前有登录会话的用户数
+type SessionCounter interface {
+	CountOnline(ctx context.Context) (int64, error)
+}
+
+// activeSessionsCollector 在每次采集时从 Redis 读取会话数，避免各实例分别增减导致计数漂移
+type activeSessionsCollector struct {
+	counter SessionCounter
+}
+
+func (c *activeSessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+}
+
+// Collect 查询失败时不报告该指标，不影响其他指标的采集
+func (c *activeSessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCountTimeout)
+	defer cancel()
+	total, err := c.counter.CountOnline(ctx)
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(total))
+}
+
+// RegisterSessionMetrics 注册 auth_active_sessions 指标
+func RegisterSessionMetrics(counter SessionCounter) error {
+	return prometheus.Register(&activeSessionsCollector{counter: counter})
+}
+
+// 认证操作标签
+const (
+	authOpRegister = "register"
+	authOpLogin    = "login"
+	authOpRefresh  = "refresh"
+)
+
+// 认证失败原因标签
+const (
+	authReasonNone           = ""
+	authReasonBadCredentials = "bad_credentials"
+	authReasonDisabled       = "disabled"
+	authReasonLockedOut      = "locked_out"
+	authReasonInvalidInput   = "invalid_input"
+	authReasonConflict       = "conflict"
+	authReasonInternal       = "internal"
+)
+
+// recordAuthOutcome 根据返回的错误记录认证结果
+func recordAuthOutcome(operation string, err error) {
+	if err == nil {
+		authAttemptsTotal.WithLabelValues(operation, "success", authReasonNone).Inc()
+		return
+	}
+	authAttemptsTotal.WithLabelValues(operation, "failure", authFailureReason(err)).Inc()
+}
+
+// authFailureReason 将错误码映射为失败原因
+func authFailureReason(err error) string {
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) {
+		return authReasonInternal
+	}
+
+	switch appErr.Code {
+	case utils.ErrCodeUnauthorized:
+		return authReasonBadCredentials
+	case utils.ErrCodeForbidden:
+		return authReasonDisabled
+	case utils.ErrCodeTooManyRequests:
+		return authReasonLockedOut
+	case utils.ErrCodeInvalidInput:
+		return authReasonInvalidInput
+	case utils.ErrCodeConflict:
+		return authReasonConflict
+	default:
+		return authReasonInternal
+	}
+}
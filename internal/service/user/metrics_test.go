@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLoginRecordsAuthOutcome(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	f.users.addUser(t, "alice", "Passw0rd!")
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		result   string
+		reason   string
+	}{
+		{"密码错误", "alice", "wrong", "failure", authReasonBadCredentials},
+		{"用户不存在", "bob", "Passw0rd!", "failure", authReasonBadCredentials},
+		{"登录成功", "alice", "Passw0rd!", "success", authReasonNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := authAttemptsTotal.WithLabelValues(authOpLogin, tt.result, tt.reason)
+			before := testutil.ToFloat64(counter)
+
+			f.service.Login(ctx, &LoginRequest{Username: tt.username, Password: tt.password})
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("auth_attempts_total{result=%q,reason=%q} delta = %v, want 1", tt.result, tt.reason, got)
+			}
+		})
+	}
+}
+
+// stubSessionCounter 返回固定会话数
+type stubSessionCounter struct {
+	total int64
+	err   error
+}
+
+func (c stubSessionCounter) CountOnline(ctx context.Context) (int64, error) {
+	return c.total, c.err
+}
+
+func TestActiveSessionsCollector(t *testing.T) {
+	tests := []struct {
+		name      string
+		counter   stubSessionCounter
+		wantCount int
+		want      string
+	}{
+		{"报告 Redis 中的会话数", stubSessionCounter{total: 3}, 1, `
+# HELP auth_active_sessions Number of users with an active login session
+# TYPE auth_active_sessions gauge
+auth_active_sessions 3
+`},
+		{"查询失败时不报告", stubSessionCounter{err: errors.New("redis down")}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &activeSessionsCollector{counter: tt.counter}
+			if got := testutil.CollectAndCount(collector); got != tt.wantCount {
+				t.Fatalf("CollectAndCount() = %d, want %d", got, tt.wantCount)
+			}
+			if tt.want == "" {
+				return
+			}
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
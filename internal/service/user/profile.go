@@ -9,23 +9,52 @@ import (
 	"go.uber.org/zap"
 )
 
+// usernameChangeCooldown 两次修改用户名的最短间隔
+const usernameChangeCooldown = 30 * 24 * time.Hour
+
+// maxBioLength 个人简介的最大长度，bio 为 text 列，按产品限制
+const maxBioLength = 500
+
+// 用户可控展示字段的清洗规则
+var (
+	nicknameRule = utils.TextRule{Field: "nickname", Label: "昵称", MaxLength: model.MaxNicknameLength}
+	bioRule      = utils.TextRule{Field: "bio", Label: "个人简介", MaxLength: maxBioLength, Multiline: true}
+	locationRule = utils.TextRule{Field: "location", Label: "所在地", MaxLength: model.MaxLocationLength}
+)
+
+// sanitizeField 清洗可选的展示字段，value 为空指针时不处理
+func sanitizeField(value *string, rule utils.TextRule) error {
+	if value == nil {
+		return nil
+	}
+	cleaned, err := utils.SanitizeText(*value, rule)
+	if err != nil {
+		return err
+	}
+	*value = cleaned
+	return nil
+}
+
 // ProfileService 用户资料服务
 type ProfileService struct {
-	userRepo        UserRepository
-	userProfileRepo UserProfileRepository
-	logger          *zap.Logger
+	userRepo            UserRepository
+	userProfileRepo     UserProfileRepository
+	usernameHistoryRepo UsernameHistoryRepository
+	logger              *zap.Logger
 }
 
 // NewProfileService 创建用户资料服务
 func NewProfileService(
 	userRepo UserRepository,
 	userProfileRepo UserProfileRepository,
+	usernameHistoryRepo UsernameHistoryRepository,
 	logger *zap.Logger,
 ) *ProfileService {
 	return &ProfileService{
-		userRepo:        userRepo,
-		userProfileRepo: userProfileRepo,
-		logger:          logger,
+		userRepo:            userRepo,
+		userProfileRepo:     userProfileRepo,
+		usernameHistoryRepo: usernameHistoryRepo,
+		logger:              logger,
 	}
 }
 
@@ -46,7 +75,7 @@ func (s *ProfileService) GetProfile(ctx context.Context, userID uint) (*GetProfi
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", userID))
-		return nil, utils.NewError(utils.ErrCodeInternal, "获取资料失败")
+		return nil, utils.NewInternalError("获取资料失败", err)
 	}
 	if user == nil {
 		return nil, utils.NewError(utils.ErrCodeNotFound, "用户不存在")
@@ -56,7 +85,7 @@ func (s *ProfileService) GetProfile(ctx context.Context, userID uint) (*GetProfi
 	profile, err := s.userProfileRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户资料失败", zap.Error(err), zap.Uint("user_id", userID))
-		return nil, utils.NewError(utils.ErrCodeInternal, "获取资料失败")
+		return nil, utils.NewInternalError("获取资料失败", err)
 	}
 
 	// 如果资料不存在，创建默认资料
@@ -87,11 +116,30 @@ type UpdateProfileRequest struct {
 
 // UpdateProfile 更新用户资料
 func (s *ProfileService) UpdateProfile(ctx context.Context, userID uint, req *UpdateProfileRequest) error {
+	// 先清洗展示字段，校验失败时不做任何修改
+	for _, field := range []struct {
+		value *string
+		rule  utils.TextRule
+	}{
+		{req.Nickname, nicknameRule},
+		{req.Bio, bioRule},
+		{req.Location, locationRule},
+	} {
+		if err := sanitizeField(field.value, field.rule); err != nil {
+			return err
+		}
+	}
+	if req.Avatar != nil {
+		if err := utils.ValidateMaxLength("avatar", "头像地址", *req.Avatar, model.MaxAvatarLength); err != nil {
+			return err
+		}
+	}
+
 	// 获取用户
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", userID))
-		return utils.NewError(utils.ErrCodeInternal, "更新资料失败")
+		return utils.NewInternalError("更新资料失败", err)
 	}
 	if user == nil {
 		return utils.NewError(utils.ErrCodeNotFound, "用户不存在")
@@ -106,14 +154,14 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID uint, req *Up
 	}
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		s.logger.Error("更新用户失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "更新资料失败")
+		return utils.NewInternalError("更新资料失败", err)
 	}
 
 	// 获取或创建用户资料
 	profile, err := s.userProfileRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户资料失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "更新资料失败")
+		return utils.NewInternalError("更新资料失败", err)
 	}
 
 	if profile == nil {
@@ -139,15 +187,78 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID uint, req *Up
 	if profile.ID == 0 {
 		if err := s.userProfileRepo.Create(ctx, profile); err != nil {
 			s.logger.Error("创建用户资料失败", zap.Error(err))
-			return utils.NewError(utils.ErrCodeInternal, "更新资料失败")
+			return utils.NewInternalError("更新资料失败", err)
 		}
 	} else {
 		if err := s.userProfileRepo.Update(ctx, profile); err != nil {
 			s.logger.Error("更新用户资料失败", zap.Error(err))
-			return utils.NewError(utils.ErrCodeInternal, "更新资料失败")
+			return utils.NewInternalError("更新资料失败", err)
 		}
 	}
 
 	return nil
 }
 
+// ChangeUsernameRequest 修改用户名请求
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// ChangeUsername 修改用户名，记录历史并限制修改频率
+func (s *ProfileService) ChangeUsername(ctx context.Context, userID uint, newUsername string) (*model.User, error) {
+	if !utils.ValidateUsername(newUsername) {
+		return nil, utils.NewError(utils.ErrCodeInvalidInput, "用户名格式不正确（3-20位字母、数字或下划线）")
+	}
+
+	// 获取用户
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, utils.NewInternalError("修改用户名失败", err)
+	}
+	if user == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "用户不存在")
+	}
+	if user.Username == newUsername {
+		return user, nil
+	}
+
+	// 检查修改频率
+	latest, err := s.usernameHistoryRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("查询用户名历史失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, utils.NewInternalError("修改用户名失败", err)
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < usernameChangeCooldown {
+		return nil, utils.NewError(utils.ErrCodeTooManyRequests, "用户名每 30 天只能修改一次")
+	}
+
+	// 检查用户名是否已被占用
+	existing, err := s.userRepo.GetByUsername(ctx, newUsername)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("修改用户名失败", err)
+	}
+	if existing != nil {
+		return nil, utils.NewError(utils.ErrCodeConflict, "用户名已存在")
+	}
+
+	oldUsername := user.Username
+	user.Username = newUsername
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("更新用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("修改用户名失败", err)
+	}
+
+	// 记录历史
+	history := &model.UsernameHistory{
+		UserID:      userID,
+		OldUsername: oldUsername,
+		NewUsername: newUsername,
+	}
+	if err := s.usernameHistoryRepo.Create(ctx, history); err != nil {
+		s.logger.Error("记录用户名历史失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
+
+	return user, nil
+}
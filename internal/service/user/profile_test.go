@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+// memUsernameHistoryRepo 内存用户名历史仓库
+type memUsernameHistoryRepo struct {
+	mu      sync.Mutex
+	history []*model.UsernameHistory
+}
+
+func (r *memUsernameHistoryRepo) Create(ctx context.Context, history *model.UsernameHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if history.CreatedAt.IsZero() {
+		history.CreatedAt = time.Now()
+	}
+	copied := *history
+	r.history = append(r.history, &copied)
+	return nil
+}
+
+func (r *memUsernameHistoryRepo) GetLatestByUserID(ctx context.Context, userID uint) (*model.UsernameHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *model.UsernameHistory
+	for _, h := range r.history {
+		if h.UserID == userID && (latest == nil || h.CreatedAt.After(latest.CreatedAt)) {
+			latest = h
+		}
+	}
+	return latest, nil
+}
+
+func TestChangeUsername(t *testing.T) {
+	tests := []struct {
+		name        string
+		newUsername string
+		lastChange  time.Duration // 距上次修改的时间，0 表示从未修改
+		wantCode    int
+		wantChanged bool
+	}{
+		{name: "修改成功", newUsername: "bob_new", wantChanged: true},
+		{name: "冷却期已过", newUsername: "bob_new", lastChange: 31 * 24 * time.Hour, wantChanged: true},
+		{name: "用户名已被占用", newUsername: "alice", wantCode: utils.ErrCodeConflict},
+		{name: "冷却期内", newUsername: "bob_new", lastChange: 24 * time.Hour, wantCode: utils.ErrCodeTooManyRequests},
+		{name: "格式不正确", newUsername: "a!", wantCode: utils.ErrCodeInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newMemUserRepo()
+			users.addUser(t, "alice", "password123")
+			bob := users.addUser(t, "bob", "password123")
+			history := &memUsernameHistoryRepo{}
+			if tt.lastChange > 0 {
+				history.Create(context.Background(), &model.UsernameHistory{
+					UserID:      bob.ID,
+					OldUsername: "bobby",
+					NewUsername: "bob",
+					CreatedAt:   time.Now().Add(-tt.lastChange),
+				})
+			}
+			service := NewProfileService(users, newMemProfileRepo(), history, zap.NewNop())
+
+			_, err := service.ChangeUsername(context.Background(), bob.ID, tt.newUsername)
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("期望错误码 %d，实际 %v", tt.wantCode, err)
+				}
+			} else if err != nil {
+				t.Fatalf("修改用户名失败: %v", err)
+			}
+
+			stored, _ := users.GetByID(context.Background(), bob.ID)
+			if changed := stored.Username == tt.newUsername; changed != tt.wantChanged {
+				t.Fatalf("用户名为 %q，期望已修改=%v", stored.Username, tt.wantChanged)
+			}
+			latest, _ := history.GetLatestByUserID(context.Background(), bob.ID)
+			if tt.wantChanged && (latest == nil || latest.OldUsername != "bob" || latest.NewUsername != tt.newUsername) {
+				t.Fatalf("未记录用户名历史: %+v", latest)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileSanitizes(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name         string
+		req          UpdateProfileRequest
+		wantCode     int
+		wantNickname string
+		wantBio      string
+	}{
+		{name: "去除控制字符", req: UpdateProfileRequest{Nickname: ptr(" Bo\x00b\x1b "), Bio: ptr("你好\r\n世界")}, wantNickname: "Bob", wantBio: "你好\n世界"},
+		{name: "昵称超长", req: UpdateProfileRequest{Nickname: ptr(strings.Repeat("长", 51))}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "简介超长时不修改其他字段", req: UpdateProfileRequest{Nickname: ptr("new"), Bio: ptr(strings.Repeat("a", 501))}, wantCode: utils.ErrCodeInvalidInput},
+		{name: "所在地包含 HTML", req: UpdateProfileRequest{Location: ptr("<b>上海</b>")}, wantCode: utils.ErrCodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newMemUserRepo()
+			bob := users.addUser(t, "bob", "password123")
+			profiles := newMemProfileRepo()
+			service := NewProfileService(users, profiles, &memUsernameHistoryRepo{}, zap.NewNop())
+			ctx := context.Background()
+
+			err := service.UpdateProfile(ctx, bob.ID, &tt.req)
+			stored, _ := users.GetByID(ctx, bob.ID)
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("期望错误码 %d，实际 %v", tt.wantCode, err)
+				}
+				if stored.Nickname != bob.Nickname {
+					t.Errorf("校验失败后昵称被修改为 %q", stored.Nickname)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateProfile() error = %v", err)
+			}
+			if stored.Nickname != tt.wantNickname {
+				t.Errorf("Nickname = %q, want %q", stored.Nickname, tt.wantNickname)
+			}
+			profile, _ := profiles.GetByUserID(ctx, bob.ID)
+			if profile == nil || profile.Bio != tt.wantBio {
+				t.Errorf("profile = %+v, want bio %q", profile, tt.wantBio)
+			}
+		})
+	}
+}
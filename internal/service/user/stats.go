@@ -2,26 +2,38 @@ package user
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/game-apps/internal/model"
 	"github.com/game-apps/internal/utils"
+	"github.com/game-apps/pkg/cache"
 	"go.uber.org/zap"
 )
 
 // StatsService 用户统计服务
 type StatsService struct {
-	userStatsRepo UserStatsRepository
-	logger        *zap.Logger
+	userRepo        UserRepository
+	userStatsRepo   UserStatsRepository
+	gameHistoryRepo GameHistoryRepository
+	logger          *zap.Logger
+	cacheClient     *cache.Client
 }
 
 // NewStatsService 创建用户统计服务
 func NewStatsService(
+	userRepo UserRepository,
 	userStatsRepo UserStatsRepository,
+	gameHistoryRepo GameHistoryRepository,
 	logger *zap.Logger,
+	cacheClient *cache.Client,
 ) *StatsService {
 	return &StatsService{
-		userStatsRepo: userStatsRepo,
-		logger:        logger,
+		userRepo:        userRepo,
+		userStatsRepo:   userStatsRepo,
+		gameHistoryRepo: gameHistoryRepo,
+		logger:          logger,
+		cacheClient:     cacheClient,
 	}
 }
 
@@ -40,7 +52,7 @@ func (s *StatsService) GetStats(ctx context.Context, userID uint) (*GetStatsResp
 	stats, err := s.userStatsRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户统计失败", zap.Error(err), zap.Uint("user_id", userID))
-		return nil, utils.NewError(utils.ErrCodeInternal, "获取统计失败")
+		return nil, utils.NewInternalError("获取统计失败", err)
 	}
 
 	if stats == nil {
@@ -50,7 +62,7 @@ func (s *StatsService) GetStats(ctx context.Context, userID uint) (*GetStatsResp
 		}
 		if err := s.userStatsRepo.Create(ctx, stats); err != nil {
 			s.logger.Error("创建用户统计失败", zap.Error(err))
-			return nil, utils.NewError(utils.ErrCodeInternal, "获取统计失败")
+			return nil, utils.NewInternalError("获取统计失败", err)
 		}
 	}
 
@@ -59,12 +71,73 @@ func (s *StatsService) GetStats(ctx context.Context, userID uint) (*GetStatsResp
 	}, nil
 }
 
+// PublicStats 对外公开的用户统计
+type PublicStats struct {
+	UserID       uint       `json:"user_id"`
+	Username     string     `json:"username"`
+	Nickname     string     `json:"nickname"`
+	GamesPlayed  int        `json:"games_played"`
+	GamesWon     int        `json:"games_won"`
+	WinRate      float64    `json:"win_rate"`
+	Level        int        `json:"level"`
+	LastPlayedAt *time.Time `json:"last_played_at,omitempty"`
+}
+
+// publicStatsCacheTTL 公开统计读缓存的有效期
+const publicStatsCacheTTL = 30 * time.Second
+
+// publicStatsCacheKey 公开统计的读缓存键
+func publicStatsCacheKey(userID uint) string {
+	return fmt.Sprintf("cache:public_stats:%d", userID)
+}
+
+// GetPublicStats 获取其他用户的公开统计，不会为其创建统计记录，结果短时间缓存
+func (s *StatsService) GetPublicStats(ctx context.Context, targetUserID uint) (*PublicStats, error) {
+	return cache.GetOrLoad(ctx, s.cacheClient, publicStatsCacheKey(targetUserID), publicStatsCacheTTL, func(ctx context.Context) (*PublicStats, error) {
+		return s.loadPublicStats(ctx, targetUserID)
+	})
+}
+
+// loadPublicStats 从数据库读取公开统计
+func (s *StatsService) loadPublicStats(ctx context.Context, targetUserID uint) (*PublicStats, error) {
+	user, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", targetUserID))
+		return nil, utils.NewInternalError("获取统计失败", err)
+	}
+	if user == nil {
+		return nil, utils.NewError(utils.ErrCodeNotFound, "用户不存在")
+	}
+
+	stats, err := s.userStatsRepo.GetByUserID(ctx, targetUserID)
+	if err != nil {
+		s.logger.Error("查询用户统计失败", zap.Error(err), zap.Uint("user_id", targetUserID))
+		return nil, utils.NewInternalError("获取统计失败", err)
+	}
+
+	result := &PublicStats{
+		UserID:   user.ID,
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Level:    1,
+	}
+	if stats != nil {
+		result.GamesPlayed = stats.GamesPlayed
+		result.GamesWon = stats.GamesWon
+		result.WinRate = stats.WinRate
+		result.Level = stats.Level
+		result.LastPlayedAt = stats.LastPlayedAt
+	}
+
+	return result, nil
+}
+
 // UpdateGameResult 更新游戏结果
 func (s *StatsService) UpdateGameResult(ctx context.Context, userID uint, won bool, score int64) error {
 	stats, err := s.userStatsRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("查询用户统计失败", zap.Error(err))
-		return utils.NewError(utils.ErrCodeInternal, "更新统计失败")
+		return utils.NewInternalError("更新统计失败", err)
 	}
 
 	if stats == nil {
@@ -80,6 +153,8 @@ func (s *StatsService) UpdateGameResult(ctx context.Context, userID uint, won bo
 		stats.GamesLost++
 	}
 	stats.TotalScore += score
+	now := time.Now()
+	stats.LastPlayedAt = &now
 
 	// 更新胜率
 	if stats.GamesPlayed > 0 {
@@ -89,15 +164,193 @@ func (s *StatsService) UpdateGameResult(ctx context.Context, userID uint, won bo
 	if stats.ID == 0 {
 		if err := s.userStatsRepo.Create(ctx, stats); err != nil {
 			s.logger.Error("创建用户统计失败", zap.Error(err))
-			return utils.NewError(utils.ErrCodeInternal, "更新统计失败")
+			return utils.NewInternalError("更新统计失败", err)
 		}
 	} else {
 		if err := s.userStatsRepo.Update(ctx, stats); err != nil {
 			s.logger.Error("更新用户统计失败", zap.Error(err))
-			return utils.NewError(utils.ErrCodeInternal, "更新统计失败")
+			return utils.NewInternalError("更新统计失败", err)
 		}
 	}
 
+	if err := s.cacheClient.Del(ctx, publicStatsCacheKey(userID)); err != nil {
+		s.logger.Debug("删除公开统计缓存失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
+
 	return nil
 }
 
+// InactiveUser 长时间未进行对局的用户
+type InactiveUser struct {
+	UserID       uint       `json:"user_id"`
+	Username     string     `json:"username"`
+	Nickname     string     `json:"nickname"`
+	Email        string     `json:"email"`
+	GamesPlayed  int        `json:"games_played"`
+	LastPlayedAt *time.Time `json:"last_played_at"`
+}
+
+// ListInactiveUsers 分页列出 since 之后没有再进行对局的用户（用于召回），从未完成对局的用户不包含在内
+func (s *StatsService) ListInactiveUsers(ctx context.Context, since time.Time, page, pageSize int) (*utils.PageResult[*InactiveUser], error) {
+	params := utils.Paginate(page, pageSize)
+
+	total, err := s.userStatsRepo.CountInactive(ctx, since)
+	if err != nil {
+		s.logger.Error("统计不活跃用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取不活跃用户失败", err)
+	}
+
+	statsList, err := s.userStatsRepo.ListInactive(ctx, since, params.Limit(), params.Offset())
+	if err != nil {
+		s.logger.Error("查询不活跃用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取不活跃用户失败", err)
+	}
+
+	userIDs := make([]uint, len(statsList))
+	for i, stats := range statsList {
+		userIDs[i] = stats.UserID
+	}
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		s.logger.Error("查询用户失败", zap.Error(err))
+		return nil, utils.NewInternalError("获取不活跃用户失败", err)
+	}
+	userByID := make(map[uint]*model.User, len(users))
+	for _, u := range users {
+		userByID[u.ID] = u
+	}
+
+	// 已删除的用户跳过
+	items := make([]*InactiveUser, 0, len(statsList))
+	for _, stats := range statsList {
+		u, ok := userByID[stats.UserID]
+		if !ok {
+			continue
+		}
+		items = append(items, &InactiveUser{
+			UserID:       u.ID,
+			Username:     u.Username,
+			Nickname:     u.Nickname,
+			Email:        u.Email,
+			GamesPlayed:  stats.GamesPlayed,
+			LastPlayedAt: stats.LastPlayedAt,
+		})
+	}
+
+	return utils.NewPageResult(items, total, params), nil
+}
+
+// HistoryOpponent 对局中的对手
+type HistoryOpponent struct {
+	UserID   uint   `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Won      bool   `json:"won"`
+	Score    int64  `json:"score"`
+}
+
+// HistoryItem 对局记录
+type HistoryItem struct {
+	RoomID    uint               `json:"room_id"`
+	RoomName  string             `json:"room_name"`
+	GameType  string             `json:"game_type"`
+	Won       bool               `json:"won"`
+	Score     int64              `json:"score"`
+	PlayedAt  time.Time          `json:"played_at"`
+	Opponents []*HistoryOpponent `json:"opponents"`
+}
+
+// GetHistory 分页获取用户的对局记录，按对局时间倒序
+func (s *StatsService) GetHistory(ctx context.Context, userID uint, page, pageSize int) (*utils.PageResult[*HistoryItem], error) {
+	params := utils.Paginate(page, pageSize)
+
+	total, err := s.gameHistoryRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("统计对局记录失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, utils.NewInternalError("获取对局记录失败", err)
+	}
+
+	histories, err := s.gameHistoryRepo.ListByUserID(ctx, userID, params.Limit(), params.Offset())
+	if err != nil {
+		s.logger.Error("查询对局记录失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, utils.NewInternalError("获取对局记录失败", err)
+	}
+
+	opponents, err := s.historyOpponents(ctx, userID, histories)
+	if err != nil {
+		s.logger.Error("查询对局对手失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, utils.NewInternalError("获取对局记录失败", err)
+	}
+
+	items := make([]*HistoryItem, 0, len(histories))
+	for _, history := range histories {
+		roomOpponents := opponents[historyGameKey(history)]
+		if roomOpponents == nil {
+			roomOpponents = []*HistoryOpponent{}
+		}
+		items = append(items, &HistoryItem{
+			RoomID:    history.RoomID,
+			RoomName:  history.RoomName,
+			GameType:  history.GameType,
+			Won:       history.Won,
+			Score:     history.Score,
+			PlayedAt:  history.PlayedAt,
+			Opponents: roomOpponents,
+		})
+	}
+
+	return utils.NewPageResult(items, total, params), nil
+}
+
+// gameKey 标识一局对局，同一房间再来一局的多局对局以对局时间区分（同一局的记录对局时间相同）
+type gameKey struct {
+	roomID   uint
+	playedAt int64
+}
+
+// historyGameKey 对局记录所属的对局
+func historyGameKey(history *model.GameHistory) gameKey {
+	return gameKey{roomID: history.RoomID, playedAt: history.PlayedAt.UnixNano()}
+}
+
+// historyOpponents 批量查询对局中的其他参与者，按对局分组
+func (s *StatsService) historyOpponents(ctx context.Context, userID uint, histories []*model.GameHistory) (map[gameKey][]*HistoryOpponent, error) {
+	roomIDs := make([]uint, 0, len(histories))
+	for _, history := range histories {
+		roomIDs = append(roomIDs, history.RoomID)
+	}
+
+	records, err := s.gameHistoryRepo.ListByRoomIDs(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(records))
+	for _, record := range records {
+		if record.UserID != userID {
+			userIDs = append(userIDs, record.UserID)
+		}
+	}
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	nicknames := make(map[uint]string, len(users))
+	for _, u := range users {
+		nicknames[u.ID] = u.Nickname
+	}
+
+	opponents := make(map[gameKey][]*HistoryOpponent, len(histories))
+	for _, record := range records {
+		if record.UserID == userID {
+			continue
+		}
+		key := historyGameKey(record)
+		opponents[key] = append(opponents[key], &HistoryOpponent{
+			UserID:   record.UserID,
+			Nickname: nicknames[record.UserID],
+			Won:      record.Won,
+			Score:    record.Score,
+		})
+	}
+	return opponents, nil
+}
@@ -0,0 +1,253 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/model"
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestGetPublicStats(t *testing.T) {
+	users := newMemUserRepo()
+	statsRepo := newMemStatsRepo()
+	client, _ := testutil.NewCacheClient(t)
+	s := NewStatsService(users, statsRepo, &memGameHistoryRepo{}, zap.NewNop(), client)
+	ctx := context.Background()
+
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	bob := users.addUser(t, "bob", "Passw0rd!")
+	statsRepo.Create(ctx, &model.UserStats{UserID: alice.ID, GamesPlayed: 10, GamesWon: 4, WinRate: 0.4, Level: 3})
+
+	tests := []struct {
+		name       string
+		userID     uint
+		wantCode   int
+		wantPlayed int
+		wantLevel  int
+	}{
+		{"有统计的用户", alice.ID, 0, 10, 3},
+		{"尚无统计的用户", bob.ID, 0, 0, 1},
+		{"用户不存在", 999, utils.ErrCodeNotFound, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := s.GetPublicStats(ctx, tt.userID)
+			if tt.wantCode != 0 {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("GetPublicStats() error = %v, want code %d", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPublicStats() error = %v", err)
+			}
+			if stats.UserID != tt.userID || stats.GamesPlayed != tt.wantPlayed || stats.Level != tt.wantLevel {
+				t.Errorf("GetPublicStats() = %+v, want played %d level %d", stats, tt.wantPlayed, tt.wantLevel)
+			}
+		})
+	}
+
+	// 查询他人统计不会创建统计记录
+	for _, id := range []uint{bob.ID, 999} {
+		if stored, _ := statsRepo.GetByUserID(ctx, id); stored != nil {
+			t.Errorf("stats for user %d created: %+v", id, stored)
+		}
+	}
+
+	// 对局结果更新后缓存失效
+	if err := s.UpdateGameResult(ctx, alice.ID, true, 10); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.GetPublicStats(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.GamesPlayed != 11 {
+		t.Errorf("更新后 GamesPlayed = %d, want 11", stats.GamesPlayed)
+	}
+	if stats.LastPlayedAt == nil || time.Since(*stats.LastPlayedAt) > time.Minute {
+		t.Errorf("更新后 LastPlayedAt = %v, want now", stats.LastPlayedAt)
+	}
+}
+
+func TestListInactiveUsers(t *testing.T) {
+	users := newMemUserRepo()
+	statsRepo := newMemStatsRepo()
+	client, _ := testutil.NewCacheClient(t)
+	s := NewStatsService(users, statsRepo, &memGameHistoryRepo{}, zap.NewNop(), client)
+	ctx := context.Background()
+
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	bob := users.addUser(t, "bob", "Passw0rd!")
+	carol := users.addUser(t, "carol", "Passw0rd!")
+	dave := users.addUser(t, "dave", "Passw0rd!")
+	statsRepo.Create(ctx, &model.UserStats{UserID: alice.ID, GamesPlayed: 3, LastPlayedAt: daysAgo(40)})
+	statsRepo.Create(ctx, &model.UserStats{UserID: bob.ID, GamesPlayed: 5, LastPlayedAt: daysAgo(60)})
+	statsRepo.Create(ctx, &model.UserStats{UserID: carol.ID, GamesPlayed: 8, LastPlayedAt: daysAgo(1)})
+	statsRepo.Create(ctx, &model.UserStats{UserID: dave.ID}) // 从未完成对局
+
+	// 刚完成对局的用户不再属于不活跃用户
+	if err := s.UpdateGameResult(ctx, alice.ID, false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.ListInactiveUsers(ctx, now.AddDate(0, 0, -30), 1, 10)
+	if err != nil {
+		t.Fatalf("ListInactiveUsers() error = %v", err)
+	}
+	if result.Total != 1 || len(result.Items) != 1 || result.Items[0].UserID != bob.ID {
+		t.Fatalf("ListInactiveUsers() = %+v, want only bob", result.Items)
+	}
+	if result.Items[0].Username != "bob" || result.Items[0].GamesPlayed != 5 {
+		t.Errorf("ListInactiveUsers() item = %+v", result.Items[0])
+	}
+}
+
+// memGameHistoryRepo 内存对局记录仓库
+type memGameHistoryRepo struct {
+	histories []*model.GameHistory
+}
+
+func (r *memGameHistoryRepo) ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.GameHistory, error) {
+	var matched []*model.GameHistory
+	for _, history := range r.histories {
+		if history.UserID == userID {
+			matched = append(matched, history)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].PlayedAt.After(matched[j].PlayedAt) })
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], nil
+}
+
+func (r *memGameHistoryRepo) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	for _, history := range r.histories {
+		if history.UserID == userID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *memGameHistoryRepo) ListByRoomIDs(ctx context.Context, roomIDs []uint) ([]*model.GameHistory, error) {
+	var matched []*model.GameHistory
+	for _, history := range r.histories {
+		for _, roomID := range roomIDs {
+			if history.RoomID == roomID {
+				matched = append(matched, history)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+func TestGetHistory(t *testing.T) {
+	users := newMemUserRepo()
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	bob := users.addUser(t, "bob", "Passw0rd!")
+	bob.Nickname = "Bob"
+	users.Update(context.Background(), bob)
+
+	// alice 共 3 局，第 2 局的对手是 bob
+	base := time.Now().Add(-time.Hour)
+	historyRepo := &memGameHistoryRepo{histories: []*model.GameHistory{
+		{UserID: alice.ID, RoomID: 1, RoomName: "r1", Won: true, Score: 10, PlayedAt: base},
+		{UserID: alice.ID, RoomID: 2, RoomName: "r2", Won: false, Score: 5, PlayedAt: base.Add(time.Minute)},
+		{UserID: bob.ID, RoomID: 2, RoomName: "r2", Won: true, Score: 8, PlayedAt: base.Add(time.Minute)},
+		{UserID: alice.ID, RoomID: 3, RoomName: "r3", Won: true, Score: 7, PlayedAt: base.Add(2 * time.Minute)},
+	}}
+	client, _ := testutil.NewCacheClient(t)
+	s := NewStatsService(users, newMemStatsRepo(), historyRepo, zap.NewNop(), client)
+
+	tests := []struct {
+		name      string
+		page      int
+		pageSize  int
+		wantRooms []uint
+	}{
+		{"第一页按时间倒序", 1, 2, []uint{3, 2}},
+		{"第二页", 2, 2, []uint{1}},
+		{"超出范围", 3, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.GetHistory(context.Background(), alice.ID, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("GetHistory() error = %v", err)
+			}
+			if result.Total != 3 {
+				t.Errorf("Total = %d, want 3", result.Total)
+			}
+			var rooms []uint
+			for _, item := range result.Items {
+				rooms = append(rooms, item.RoomID)
+			}
+			if fmt.Sprint(rooms) != fmt.Sprint(tt.wantRooms) {
+				t.Errorf("rooms = %v, want %v", rooms, tt.wantRooms)
+			}
+		})
+	}
+
+	result, _ := s.GetHistory(context.Background(), alice.ID, 1, 2)
+	opponents := result.Items[1].Opponents
+	if len(opponents) != 1 || opponents[0].UserID != bob.ID || opponents[0].Nickname != "Bob" || !opponents[0].Won {
+		t.Errorf("对手 = %+v, want bob", opponents)
+	}
+	if len(result.Items[0].Opponents) != 0 {
+		t.Errorf("单人对局不应有对手: %+v", result.Items[0].Opponents)
+	}
+}
+
+func TestGetHistoryRematchOpponents(t *testing.T) {
+	users := newMemUserRepo()
+	alice := users.addUser(t, "alice", "Passw0rd!")
+	bob := users.addUser(t, "bob", "Passw0rd!")
+	carol := users.addUser(t, "carol", "Passw0rd!")
+
+	// 同一房间再来一局，两局的对手不同
+	first := time.Now().Add(-time.Hour)
+	second := first.Add(10 * time.Minute)
+	historyRepo := &memGameHistoryRepo{histories: []*model.GameHistory{
+		{UserID: alice.ID, RoomID: 1, PlayedAt: first},
+		{UserID: bob.ID, RoomID: 1, PlayedAt: first},
+		{UserID: alice.ID, RoomID: 1, PlayedAt: second},
+		{UserID: carol.ID, RoomID: 1, PlayedAt: second},
+	}}
+	client, _ := testutil.NewCacheClient(t)
+	s := NewStatsService(users, newMemStatsRepo(), historyRepo, zap.NewNop(), client)
+
+	result, err := s.GetHistory(context.Background(), alice.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("items = %d, want 2", len(result.Items))
+	}
+	for i, want := range []uint{carol.ID, bob.ID} {
+		opponents := result.Items[i].Opponents
+		if len(opponents) != 1 || opponents[0].UserID != want {
+			t.Errorf("第 %d 局对手 = %+v, want user %d", i+1, opponents, want)
+		}
+	}
+}
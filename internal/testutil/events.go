@@ -0,0 +1,107 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/game-apps/internal/model"
+)
+
+// EventRepo 内存事件发件箱仓库，房间写入 rooms
+type EventRepo struct {
+	mu        sync.Mutex
+	rooms     *RoomRepo
+	nextID    uint
+	events    []*model.OutboxEvent
+	histories []*model.GameHistory
+}
+
+// NewEventRepo 创建内存事件发件箱仓库，结束游戏时房间状态写入 rooms
+func NewEventRepo(rooms *RoomRepo) *EventRepo {
+	return &EventRepo{rooms: rooms}
+}
+
+// SaveGameEnd 与数据库实现一致，只更新房间的状态和结束时间
+func (r *EventRepo) SaveGameEnd(ctx context.Context, room *model.Room, event *model.OutboxEvent, histories []*model.GameHistory) error {
+	r.rooms.mu.Lock()
+	if stored, ok := r.rooms.rooms[room.ID]; ok {
+		stored.Status = room.Status
+		stored.EndedAt = room.EndedAt
+	}
+	r.rooms.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	event.ID = r.nextID
+	r.events = append(r.events, event)
+	r.histories = append(r.histories, histories...)
+	return nil
+}
+
+func (r *EventRepo) ClaimPending(ctx context.Context, maxAttempts, limit int, lease time.Duration) ([]*model.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []*model.OutboxEvent
+	now := time.Now()
+	for _, event := range r.events {
+		if event.SentAt == nil && event.Attempts < maxAttempts && !event.NextAttemptAt.After(now) && len(pending) < limit {
+			copied := *event
+			pending = append(pending, &copied)
+			event.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return pending, nil
+}
+
+// Pending 返回到期且未超过最大投递次数的待投递事件，不认领
+func (r *EventRepo) Pending(maxAttempts int) []*model.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []*model.OutboxEvent
+	now := time.Now()
+	for _, event := range r.events {
+		if event.SentAt == nil && event.Attempts < maxAttempts && !event.NextAttemptAt.After(now) {
+			copied := *event
+			pending = append(pending, &copied)
+		}
+	}
+	return pending
+}
+
+// Event 返回指定 ID 的事件，不存在时返回 nil
+func (r *EventRepo) Event(id uint) *model.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.events) {
+		return nil
+	}
+	copied := *r.events[id-1]
+	return &copied
+}
+
+// Histories 返回已写入的对局记录
+func (r *EventRepo) Histories() []*model.GameHistory {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.GameHistory(nil), r.histories...)
+}
+
+func (r *EventRepo) MarkSent(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.events[id-1].SentAt = &now
+	return nil
+}
+
+func (r *EventRepo) MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event := r.events[id-1]
+	event.Attempts++
+	event.LastError = lastError
+	event.NextAttemptAt = nextAttemptAt
+	return nil
+}
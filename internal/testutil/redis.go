@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/pkg/cache"
+)
+
+// NewCacheClient 启动 miniredis 并创建连接它的缓存客户端，测试结束时自动关闭
+func NewCacheClient(t testing.TB) (*cache.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client, err := cache.NewClient(cache.Options{
+		Addr:         mr.Addr(),
+		PoolSize:     10,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("连接 miniredis 失败: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
@@ -0,0 +1,328 @@
+package testutil
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-apps/internal/model"
+)
+
+// RoomRepo 内存房间仓库
+type RoomRepo struct {
+	mu          sync.Mutex
+	nextID      uint
+	rooms       map[uint]*model.Room
+	codeLookups int // GetByRoomCode 调用次数
+}
+
+// NewRoomRepo 创建内存房间仓库
+func NewRoomRepo() *RoomRepo {
+	return &RoomRepo{rooms: make(map[uint]*model.Room)}
+}
+
+func (r *RoomRepo) Create(ctx context.Context, room *model.Room) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	room.ID = r.nextID
+	if room.CreatedAt.IsZero() {
+		room.CreatedAt = time.Now()
+	}
+	copied := *room
+	r.rooms[room.ID] = &copied
+	return nil
+}
+
+func (r *RoomRepo) GetByID(ctx context.Context, id uint) (*model.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	room, ok := r.rooms[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *room
+	return &copied, nil
+}
+
+func (r *RoomRepo) GetByRoomCode(ctx context.Context, roomCode string) (*model.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codeLookups++
+	for _, room := range r.rooms {
+		if room.RoomCode == roomCode {
+			copied := *room
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *RoomRepo) List(ctx context.Context, status *model.RoomStatus, limit, offset int) ([]*model.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rooms []*model.Room
+	for _, room := range r.rooms {
+		if status == nil || room.Status == *status {
+			copied := *room
+			rooms = append(rooms, &copied)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID > rooms[j].ID })
+	if offset >= len(rooms) {
+		return nil, nil
+	}
+	rooms = rooms[offset:]
+	if limit > 0 && limit < len(rooms) {
+		rooms = rooms[:limit]
+	}
+	return rooms, nil
+}
+
+func (r *RoomRepo) ListAfter(ctx context.Context, status *model.RoomStatus, afterCreatedAt time.Time, afterID uint, limit int) ([]*model.Room, error) {
+	rooms, _ := r.List(ctx, status, 0, 0)
+	sort.SliceStable(rooms, func(i, j int) bool {
+		if !rooms[i].CreatedAt.Equal(rooms[j].CreatedAt) {
+			return rooms[i].CreatedAt.After(rooms[j].CreatedAt)
+		}
+		return rooms[i].ID > rooms[j].ID
+	})
+	var page []*model.Room
+	for _, room := range rooms {
+		if afterID != 0 && !(room.CreatedAt.Before(afterCreatedAt) ||
+			(room.CreatedAt.Equal(afterCreatedAt) && room.ID < afterID)) {
+			continue
+		}
+		page = append(page, room)
+		if len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+func (r *RoomRepo) Count(ctx context.Context, status *model.RoomStatus) (int64, error) {
+	rooms, err := r.List(ctx, status, 0, 0)
+	return int64(len(rooms)), err
+}
+
+func (r *RoomRepo) ListByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus, limit, offset int) ([]*model.Room, error) {
+	rooms, _ := r.List(ctx, status, 0, 0)
+	var owned []*model.Room
+	for _, room := range rooms {
+		if room.OwnerID == ownerID {
+			owned = append(owned, room)
+		}
+	}
+	if offset >= len(owned) {
+		return nil, nil
+	}
+	owned = owned[offset:]
+	if limit > 0 && limit < len(owned) {
+		owned = owned[:limit]
+	}
+	return owned, nil
+}
+
+func (r *RoomRepo) CountByOwner(ctx context.Context, ownerID uint, status *model.RoomStatus) (int64, error) {
+	rooms, err := r.ListByOwner(ctx, ownerID, status, 0, 0)
+	return int64(len(rooms)), err
+}
+
+func (r *RoomRepo) Update(ctx context.Context, room *model.Room) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *room
+	r.rooms[room.ID] = &copied
+	return nil
+}
+
+func (r *RoomRepo) UpdateCurrentPlayers(ctx context.Context, roomID uint, currentPlayers int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if room, ok := r.rooms[roomID]; ok {
+		room.CurrentPlayers = currentPlayers
+	}
+	return nil
+}
+
+func (r *RoomRepo) MarkStarted(ctx context.Context, roomID uint, startedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if room, ok := r.rooms[roomID]; ok {
+		room.Status = model.RoomStatusPlaying
+		room.StartedAt = &startedAt
+	}
+	return nil
+}
+
+func (r *RoomRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rooms, id)
+	return nil
+}
+
+// CodeLookups 返回 GetByRoomCode 的调用次数
+func (r *RoomRepo) CodeLookups() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.codeLookups
+}
+
+// RoomPlayerRepo 内存房间玩家仓库，LeftAt 非空的记录视为已离开
+type RoomPlayerRepo struct {
+	mu      sync.Mutex
+	nextID  uint
+	players []*model.RoomPlayer
+	rooms   *RoomRepo
+}
+
+// NewRoomPlayerRepo 创建内存房间玩家仓库，rooms 用于判断玩家所在房间是否仍在进行
+func NewRoomPlayerRepo(rooms *RoomRepo) *RoomPlayerRepo {
+	return &RoomPlayerRepo{rooms: rooms}
+}
+
+func (r *RoomPlayerRepo) Create(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	roomPlayer.ID = r.nextID
+	copied := *roomPlayer
+	r.players = append(r.players, &copied)
+	return nil
+}
+
+func (r *RoomPlayerRepo) GetByRoomID(ctx context.Context, roomID uint) ([]*model.RoomPlayer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var players []*model.RoomPlayer
+	for _, p := range r.players {
+		if p.RoomID == roomID && p.LeftAt == nil {
+			copied := *p
+			players = append(players, &copied)
+		}
+	}
+	// 与数据库一致，空位置（观战者）排在最后
+	sort.SliceStable(players, func(i, j int) bool {
+		pi, pj := players[i].Position, players[j].Position
+		return pi != nil && (pj == nil || *pi < *pj)
+	})
+	return players, nil
+}
+
+func (r *RoomPlayerRepo) GetByRoomIDAndUserID(ctx context.Context, roomID, userID uint) (*model.RoomPlayer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.players {
+		if p.RoomID == roomID && p.UserID == userID && p.LeftAt == nil {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *RoomPlayerRepo) GetActiveByUserID(ctx context.Context, userID uint) (*model.RoomPlayer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.players {
+		if p.UserID != userID || p.LeftAt != nil {
+			continue
+		}
+		room, _ := r.rooms.GetByID(ctx, p.RoomID)
+		if room != nil && (room.Status == model.RoomStatusWaiting || room.Status == model.RoomStatusPlaying) {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *RoomPlayerRepo) Update(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.players {
+		if p.ID == roomPlayer.ID {
+			copied := *roomPlayer
+			r.players[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *RoomPlayerRepo) ResetReady(ctx context.Context, roomID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.players {
+		if p.RoomID == roomID && p.LeftAt == nil {
+			p.IsReady = false
+		}
+	}
+	return nil
+}
+
+func (r *RoomPlayerRepo) ReassignPositions(ctx context.Context, roomID uint) (bool, error) {
+	players, _ := r.GetByRoomID(ctx, roomID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for i, player := range players {
+		if player.Position == nil || *player.Position == i {
+			continue
+		}
+		for _, p := range r.players {
+			if p.ID == player.ID {
+				position := i
+				p.Position = &position
+			}
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func (r *RoomPlayerRepo) SaveWithFreePosition(ctx context.Context, roomPlayer *model.RoomPlayer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	taken := make(map[int]bool)
+	for _, p := range r.players {
+		if p.RoomID == roomPlayer.RoomID && p.Position != nil && p.ID != roomPlayer.ID {
+			taken[*p.Position] = true
+		}
+	}
+	position := 0
+	for taken[position] {
+		position++
+	}
+	roomPlayer.Position = &position
+
+	if roomPlayer.ID == 0 {
+		r.nextID++
+		roomPlayer.ID = r.nextID
+		copied := *roomPlayer
+		r.players = append(r.players, &copied)
+		return nil
+	}
+	for i, p := range r.players {
+		if p.ID == roomPlayer.ID {
+			copied := *roomPlayer
+			r.players[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *RoomPlayerRepo) LeaveRoom(ctx context.Context, roomID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, p := range r.players {
+		if p.RoomID == roomID && p.UserID == userID && p.LeftAt == nil {
+			p.LeftAt = &now
+			p.Position = nil
+		}
+	}
+	return nil
+}
@@ -3,13 +3,19 @@ package utils
 import (
 	"errors"
 	"net/http"
+	"time"
 )
 
 // AppError 应用错误
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Err     error  `json:"-"`
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"` // 字段级校验错误
+	Reason  string            `json:"reason,omitempty"` // 稳定的细分原因，供客户端区分同一错误码下的不同情况
+	Err     error             `json:"-"`
+
+	Retryable  bool          `json:"retryable,omitempty"` // 瞬时错误，客户端可安全重试
+	RetryAfter time.Duration `json:"-"`                   // 建议的重试间隔
 }
 
 func (e *AppError) Error() string {
@@ -50,6 +56,21 @@ func NewErrorWithErr(code int, message string, err error) *AppError {
 	}
 }
 
+// WithReason 设置细分原因
+func (e *AppError) WithReason(reason string) *AppError {
+	e.Reason = reason
+	return e
+}
+
+// NewValidationError 创建带字段错误信息的参数校验错误
+func NewValidationError(message string, fields map[string]string) *AppError {
+	return &AppError{
+		Code:    ErrCodeInvalidInput,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
 // HTTP 状态码映射
 func (e *AppError) HTTPStatus() int {
 	switch e.Code {
@@ -65,6 +86,11 @@ func (e *AppError) HTTPStatus() int {
 		return http.StatusConflict
 	case ErrCodeTooManyRequests:
 		return http.StatusTooManyRequests
+	case ErrCodeInternal:
+		if e.Retryable {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusInternalServerError
 	default:
 		return http.StatusInternalServerError
 	}
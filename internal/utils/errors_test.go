@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/game-apps/pkg/cache"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestNewInternalError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantRetryable bool
+		wantStatus    int
+	}{
+		{"数据库死锁", fmt.Errorf("更新房间失败: %w", &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}), true, http.StatusServiceUnavailable},
+		{"缓存不可用", cache.ErrCacheUnavailable, true, http.StatusServiceUnavailable},
+		{"唯一约束冲突", &mysql.MySQLError{Number: 1062}, false, http.StatusInternalServerError},
+		{"普通错误", errors.New("boom"), false, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := NewInternalError("操作失败", tt.err)
+			if appErr.Code != ErrCodeInternal || appErr.Retryable != tt.wantRetryable {
+				t.Fatalf("NewInternalError() = %+v, want retryable %v", appErr, tt.wantRetryable)
+			}
+			if tt.wantRetryable && appErr.RetryAfter != DefaultRetryAfter {
+				t.Errorf("RetryAfter = %v, want %v", appErr.RetryAfter, DefaultRetryAfter)
+			}
+			if got := appErr.HTTPStatus(); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if !errors.Is(appErr, tt.err) {
+				t.Errorf("NewInternalError() 未保留底层错误")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONLimits 解析客户端提交的任意 JSON（房间设置、游戏操作等）时的限制
+type JSONLimits struct {
+	MaxDepth int // 最大嵌套层级，<= 0 表示不限制
+	MaxBytes int // 最大字节数，<= 0 表示不限制
+}
+
+// CheckJSON 在解析之前检查 JSON 的大小和嵌套层级，只扫描字节不构建对象，
+// 避免深度嵌套的数据在反序列化时消耗大量 CPU 和内存。语法错误由后续解析发现
+func CheckJSON(data []byte, limits JSONLimits) error {
+	if limits.MaxBytes > 0 && len(data) > limits.MaxBytes {
+		return NewError(ErrCodeInvalidInput, fmt.Sprintf("JSON 数据不能超过 %d 字节", limits.MaxBytes))
+	}
+	if limits.MaxDepth <= 0 {
+		return nil
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > limits.MaxDepth {
+				return NewError(ErrCodeInvalidInput, fmt.Sprintf("JSON 嵌套层级不能超过 %d", limits.MaxDepth))
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// DecodeJSON 检查限制后将 JSON 解析到 v，超出限制或格式错误时返回 ErrCodeInvalidInput
+func DecodeJSON(data []byte, v interface{}, limits JSONLimits) error {
+	if err := CheckJSON(data, limits); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return NewError(ErrCodeInvalidInput, "JSON 格式错误")
+	}
+	return nil
+}
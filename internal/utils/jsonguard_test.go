@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckJSON(t *testing.T) {
+	limits := JSONLimits{MaxDepth: 3, MaxBytes: 64}
+
+	tests := []struct {
+		name    string
+		data    string
+		limits  JSONLimits
+		wantErr bool
+	}{
+		{"层级在限制内", `{"a":{"b":[1,2]}}`, limits, false},
+		{"层级超出限制", `{"a":{"b":{"c":{"d":1}}}}`, limits, true},
+		{"数组嵌套超出限制", `[[[[1]]]]`, limits, true},
+		{"字符串中的括号不计入层级", `{"a":"{{{{[[[["}`, limits, false},
+		{"转义引号不结束字符串", `{"a":"\"{{{{"}`, limits, false},
+		{"兄弟节点不累加层级", `{"a":{"b":1},"c":{"d":1},"e":[1]}`, limits, false},
+		{"超出大小限制", `{"a":"` + strings.Repeat("x", 64) + `"}`, limits, true},
+		{"不限制", strings.Repeat("[", 1000) + strings.Repeat("]", 1000), JSONLimits{}, false},
+		{"深度嵌套的攻击数据", strings.Repeat(`{"a":`, 10000) + "1" + strings.Repeat("}", 10000), JSONLimits{MaxDepth: 32}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckJSON([]byte(tt.data), tt.limits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var appErr *AppError
+			if err != nil && (!errors.As(err, &appErr) || appErr.Code != ErrCodeInvalidInput) {
+				t.Errorf("CheckJSON() error = %v, want ErrCodeInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	limits := JSONLimits{MaxDepth: 2, MaxBytes: 128}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"正常解析", `{"max_players":4,"tags":["a"]}`, false},
+		{"嵌套过深", `{"a":{"b":{"c":1}}}`, true},
+		{"格式错误", `{"a":`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]interface{}
+			err := DecodeJSON([]byte(tt.data), &v, limits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var appErr *AppError
+			if err != nil && (!errors.As(err, &appErr) || appErr.Code != ErrCodeInvalidInput) {
+				t.Errorf("DecodeJSON() error = %v, want ErrCodeInvalidInput", err)
+			}
+		})
+	}
+}
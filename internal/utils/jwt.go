@@ -1,75 +1,240 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+func init() {
+	// 签发时间等声明精确到毫秒，登出所有设备后同一秒内重新登录签发的令牌不会被判定为已吊销
+	jwt.TimePrecision = time.Millisecond
+}
+
+// 客户端类型，作为令牌受众（aud）的一部分
+const (
+	ClientTypeWeb    = "web"
+	ClientTypeMobile = "mobile"
+	ClientTypeAdmin  = "admin"
+)
+
+// ValidClientType 是否为支持的客户端类型
+func ValidClientType(clientType string) bool {
+	switch clientType {
+	case ClientTypeWeb, ClientTypeMobile, ClientTypeAdmin:
+		return true
+	}
+	return false
+}
+
 // JWTClaims JWT 声明
 type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	ImpersonatedBy uint `json:"impersonated_by,omitempty"` // 模拟登录时为发起模拟的管理员 ID
 	jwt.RegisteredClaims
 }
 
+// HasAudience 令牌受众中是否包含指定值
+func (c *JWTClaims) HasAudience(audience string) bool {
+	for _, aud := range c.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// IsImpersonation 是否为管理员模拟登录签发的令牌
+func (c *JWTClaims) IsImpersonation() bool {
+	return c.ImpersonatedBy != 0
+}
+
+// ClientType 从令牌受众中获取客户端类型，未携带时返回空
+func (c *JWTClaims) ClientType() string {
+	for _, aud := range c.Audience {
+		if ValidClientType(aud) {
+			return aud
+		}
+	}
+	return ""
+}
+
 // JWTService JWT 服务
 type JWTService struct {
-	secret                []byte
+	method                jwt.SigningMethod
+	signKey               interface{} // 签名密钥，非对称算法下为私钥，仅验证时可为空
+	verifyKey             interface{} // 验证密钥，非对称算法下为公钥
+	keyID                 string
 	expirationHours       int
 	refreshExpirationHours int
+	issuer                string
+	audience              string
+	leeway                time.Duration
 }
 
 // NewJWTService 创建 JWT 服务
-func NewJWTService(secret string, expirationHours, refreshExpirationHours int) *JWTService {
+func NewJWTService(secret string, expirationHours, refreshExpirationHours int, issuer, audience string, leeway time.Duration) *JWTService {
 	return &JWTService{
-		secret:                []byte(secret),
+		method:                jwt.SigningMethodHS256,
+		signKey:               []byte(secret),
+		verifyKey:             []byte(secret),
+		expirationHours:       expirationHours,
+		refreshExpirationHours: refreshExpirationHours,
+		issuer:                issuer,
+		audience:              audience,
+		leeway:                leeway,
+	}
+}
+
+// NewAsymmetricJWTService 创建使用非对称算法（RS256/ES256）的 JWT 服务
+// privateKeyPath 为空时只能验证令牌，不能签发
+func NewAsymmetricJWTService(algorithm, privateKeyPath, publicKeyPath, keyID string, expirationHours, refreshExpirationHours int, issuer, audience string, leeway time.Duration) (*JWTService, error) {
+	s := &JWTService{
+		keyID:                 keyID,
 		expirationHours:       expirationHours,
 		refreshExpirationHours: refreshExpirationHours,
+		issuer:                issuer,
+		audience:              audience,
+		leeway:                leeway,
+	}
+
+	var parsePrivate func([]byte) (interface{}, error)
+	var parsePublic func([]byte) (interface{}, error)
+	switch algorithm {
+	case "RS256":
+		s.method = jwt.SigningMethodRS256
+		parsePrivate = func(b []byte) (interface{}, error) { return jwt.ParseRSAPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (interface{}, error) { return jwt.ParseRSAPublicKeyFromPEM(b) }
+	case "ES256":
+		s.method = jwt.SigningMethodES256
+		parsePrivate = func(b []byte) (interface{}, error) { return jwt.ParseECPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (interface{}, error) { return jwt.ParseECPublicKeyFromPEM(b) }
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
+	}
+
+	publicPEM, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取公钥失败: %w", err)
+	}
+	if s.verifyKey, err = parsePublic(publicPEM); err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %w", err)
+	}
+
+	if privateKeyPath != "" {
+		privatePEM, err := os.ReadFile(privateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取私钥失败: %w", err)
+		}
+		if s.signKey, err = parsePrivate(privatePEM); err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
 	}
+
+	return s, nil
 }
 
-// GenerateToken 生成访问令牌
-func (s *JWTService) GenerateToken(userID uint, username string) (string, error) {
+// sign 使用配置的算法签发令牌
+func (s *JWTService) sign(claims jwt.Claims) (string, error) {
+	if s.signKey == nil {
+		return "", errors.New("未配置签名私钥，无法签发令牌")
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.keyID != "" {
+		token.Header["kid"] = s.keyID
+	}
+	return token.SignedString(s.signKey)
+}
+
+// registeredClaims 生成标准声明，clientType 非空时加入受众
+func (s *JWTService) registeredClaims(expiration time.Duration, clientType string) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        generateTokenID(),
+		Issuer:    s.issuer,
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+	if s.audience != "" {
+		claims.Audience = append(claims.Audience, s.audience)
+	}
+	if clientType != "" {
+		claims.Audience = append(claims.Audience, clientType)
+	}
+	return claims
+}
+
+// GenerateToken 生成访问令牌，clientType 为客户端类型（web、mobile、admin）
+func (s *JWTService) GenerateToken(userID uint, username, clientType string) (string, error) {
 	claims := JWTClaims{
 		UserID:   userID,
 		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.expirationHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		RegisteredClaims: s.registeredClaims(time.Duration(s.expirationHours)*time.Hour, clientType),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	return s.sign(claims)
 }
 
 // GenerateRefreshToken 生成刷新令牌
-func (s *JWTService) GenerateRefreshToken(userID uint, username string) (string, error) {
+func (s *JWTService) GenerateRefreshToken(userID uint, username, clientType string) (string, error) {
 	claims := JWTClaims{
 		UserID:   userID,
 		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.refreshExpirationHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		RegisteredClaims: s.registeredClaims(time.Duration(s.refreshExpirationHours)*time.Hour, clientType),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	return s.sign(claims)
+}
+
+// GenerateImpersonationToken 生成管理员模拟登录用的短期访问令牌，不签发刷新令牌
+func (s *JWTService) GenerateImpersonationToken(userID uint, username string, adminID uint, ttl time.Duration) (string, error) {
+	claims := JWTClaims{
+		UserID:         userID,
+		Username:       username,
+		ImpersonatedBy: adminID,
+		RegisteredClaims: s.registeredClaims(ttl, ClientTypeWeb),
+	}
+
+	return s.sign(claims)
+}
+
+// RefreshExpirationHours 刷新令牌有效期（小时）
+func (s *JWTService) RefreshExpirationHours() int {
+	return s.refreshExpirationHours
 }
 
 // ValidateToken 验证令牌
 func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
+	// 只接受配置的签名算法，防止 alg: none 或算法降级
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.method.Alg()}),
+		jwt.WithLeeway(s.leeway),
+	}
+	// 要求 iss 与本服务一致，拒绝共用密钥的其他服务签发或未携带 iss 的令牌
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != s.method.Alg() {
 			return nil, errors.New("无效的签名方法")
 		}
-		return s.secret, nil
-	})
+		if kid, ok := token.Header["kid"].(string); ok && s.keyID != "" && kid != s.keyID {
+			return nil, errors.New("未知的密钥 ID")
+		}
+		return s.verifyKey, nil
+	}, opts...)
 
 	if err != nil {
 		return nil, err
@@ -82,3 +247,12 @@ func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return nil, errors.New("无效的令牌")
 }
 
+
+// generateTokenID 生成令牌唯一 ID（jti）
+func generateTokenID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(bytes)
+}
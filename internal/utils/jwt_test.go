@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeRSAKeys 生成 RSA 密钥对并写入临时目录，返回私钥和公钥路径
+func writeRSAKeys(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("序列化公钥失败: %v", err)
+	}
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return privatePath, publicPath
+}
+
+func TestValidateToken(t *testing.T) {
+	const secret = "test-secret"
+	privatePath, publicPath := writeRSAKeys(t)
+
+	rs256, err := NewAsymmetricJWTService("RS256", privatePath, publicPath, "key-1", 1, 24, "", "", 0)
+	if err != nil {
+		t.Fatalf("NewAsymmetricJWTService() error = %v", err)
+	}
+	rs256VerifyOnly, err := NewAsymmetricJWTService("RS256", "", publicPath, "key-1", 1, 24, "", "", 0)
+	if err != nil {
+		t.Fatalf("NewAsymmetricJWTService() error = %v", err)
+	}
+	hs256 := NewJWTService(secret, 1, 24, "", "", 0)
+
+	tests := []struct {
+		name      string
+		issuer    *JWTService
+		validator *JWTService
+		wantErr   bool
+	}{
+		{"签发方一致", NewJWTService(secret, 1, 24, "game-services", "", 0), NewJWTService(secret, 1, 24, "game-services", "", 0), false},
+		{"签发方不一致", NewJWTService(secret, 1, 24, "other-service", "", 0), NewJWTService(secret, 1, 24, "game-services", "", 0), true},
+		{"未携带签发方", NewJWTService(secret, 1, 24, "", "", 0), NewJWTService(secret, 1, 24, "game-services", "", 0), true},
+		{"受众一致", NewJWTService(secret, 1, 24, "", "game-api", 0), NewJWTService(secret, 1, 24, "", "game-api", 0), false},
+		{"受众不一致", NewJWTService(secret, 1, 24, "", "admin-api", 0), NewJWTService(secret, 1, 24, "", "game-api", 0), true},
+		{"密钥不一致", NewJWTService("other-secret", 1, 24, "", "", 0), hs256, true},
+		{"RS256 签发和验证", rs256, rs256, false},
+		{"RS256 仅公钥验证", rs256, rs256VerifyOnly, false},
+		{"配置 RS256 时拒绝 HS256 令牌", hs256, rs256, true},
+		{"配置 HS256 时拒绝 RS256 令牌", rs256, hs256, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.issuer.GenerateToken(7, "alice", ClientTypeWeb)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			claims, err := tt.validator.ValidateToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims.UserID != 7 {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+
+	if _, err := rs256VerifyOnly.GenerateToken(7, "alice", ClientTypeWeb); err == nil {
+		t.Errorf("未配置私钥时不应能签发令牌")
+	}
+}
+
+func TestValidateTokenClockSkew(t *testing.T) {
+	tests := []struct {
+		name      string
+		notBefore time.Duration // 相对当前时间
+		expiresAt time.Duration
+		leeway    time.Duration
+		wantErr   bool
+	}{
+		{"签发方时钟略快，在容差内", 5 * time.Second, time.Hour, 30 * time.Second, false},
+		{"签发方时钟略快，未配置容差", 5 * time.Second, time.Hour, 0, true},
+		{"生效时间超出容差", time.Minute, time.Hour, 30 * time.Second, true},
+		{"刚过期，在容差内", -time.Hour, -5 * time.Second, 30 * time.Second, false},
+		{"已过期", -time.Hour, -time.Minute, 30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewJWTService("test-secret", 1, 24, "game-services", "", tt.leeway)
+			now := time.Now()
+			claims := JWTClaims{
+				UserID:   7,
+				Username: "alice",
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "game-services",
+					IssuedAt:  jwt.NewNumericDate(now.Add(tt.notBefore)),
+					NotBefore: jwt.NewNumericDate(now.Add(tt.notBefore)),
+					ExpiresAt: jwt.NewNumericDate(now.Add(tt.expiresAt)),
+				},
+			}
+			token, err := s.sign(claims)
+			if err != nil {
+				t.Fatalf("sign() error = %v", err)
+			}
+			if _, err := s.ValidateToken(token); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	s := NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+
+	token, err := s.GenerateImpersonationToken(7, "alice", 1, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateImpersonationToken() error = %v", err)
+	}
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if claims.UserID != 7 || claims.ImpersonatedBy != 1 || !claims.IsImpersonation() {
+		t.Errorf("claims = %+v, want user 7 impersonated by 1", claims)
+	}
+	if remaining := time.Until(claims.ExpiresAt.Time); remaining > 15*time.Minute || remaining < 14*time.Minute {
+		t.Errorf("令牌有效期 = %v, want 15m", remaining)
+	}
+
+	normal, err := s.GenerateToken(7, "alice", ClientTypeWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := s.ValidateToken(normal); err != nil || claims.IsImpersonation() {
+		t.Errorf("普通令牌不应标记为模拟登录: %+v, %v", claims, err)
+	}
+}
+
+func TestTokenIssuedAtMillisecondPrecision(t *testing.T) {
+	s := NewJWTService("test-secret", 1, 24, "game-services", "", 0)
+
+	// 解析时按浮点数处理小数秒，可能向下损失 1 毫秒
+	before := time.Now().Truncate(time.Millisecond).Add(-time.Millisecond)
+	token, err := s.GenerateToken(7, "alice", ClientTypeWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	// 按秒截断的签发时间会早于生成前的时间
+	if issuedAt := claims.IssuedAt.Time; issuedAt.Before(before) || issuedAt.After(time.Now()) {
+		t.Errorf("IssuedAt = %v, want between %v and now", issuedAt, before)
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// 分页默认值
+const (
+	DefaultPage     = 1
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// PageParams 规范化后的分页参数
+type PageParams struct {
+	Page     int
+	PageSize int
+}
+
+// Paginate 规范化分页参数：页码从 1 开始，每页数量使用默认值并限制上限
+func Paginate(page, pageSize int) PageParams {
+	if page <= 0 {
+		page = DefaultPage
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return PageParams{Page: page, PageSize: pageSize}
+}
+
+// Offset 查询偏移量
+func (p PageParams) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// Limit 查询数量
+func (p PageParams) Limit() int {
+	return p.PageSize
+}
+
+// PageResult 分页结果
+type PageResult[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	HasMore  bool  `json:"has_more"`
+}
+
+// NewPageResult 创建分页结果
+func NewPageResult[T any](items []T, total int64, params PageParams) *PageResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &PageResult[T]{
+		Items:    items,
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		HasMore:  int64(params.Page*params.PageSize) < total,
+	}
+}
+
+// Cursor 游标分页位置：上一页最后一条记录的创建时间和 ID
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// EncodeCursor 将分页位置编码为不透明的游标字符串
+func EncodeCursor(c Cursor) string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor 解析游标字符串，格式无效时返回 ErrCodeInvalidInput
+func DecodeCursor(s string) (Cursor, error) {
+	invalid := NewError(ErrCodeInvalidInput, "无效的分页游标")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+	var nanos int64
+	var id uint
+	if n, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil || n != 2 || id == 0 {
+		return Cursor{}, invalid
+	}
+	return Cursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
+
+// CursorResult 游标分页结果，NextCursor 为空表示没有更多数据
+type CursorResult[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewCursorResult 创建游标分页结果
+func NewCursorResult[T any](items []T, nextCursor string) *CursorResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &CursorResult[T]{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
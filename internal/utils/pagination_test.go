@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name       string
+		page, size int
+		wantPage   int
+		wantSize   int
+		wantOffset int
+	}{
+		{"默认值", 0, 0, DefaultPage, DefaultPageSize, 0},
+		{"负数使用默认值", -3, -1, DefaultPage, DefaultPageSize, 0},
+		{"正常分页", 3, 20, 3, 20, 40},
+		{"每页数量等于上限", 1, MaxPageSize, 1, MaxPageSize, 0},
+		{"每页数量超过上限", 2, MaxPageSize + 1, 2, MaxPageSize, MaxPageSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Paginate(tt.page, tt.size)
+			if p.Page != tt.wantPage || p.PageSize != tt.wantSize {
+				t.Errorf("Paginate(%d, %d) = %+v, want page %d size %d", tt.page, tt.size, p, tt.wantPage, tt.wantSize)
+			}
+			if p.Offset() != tt.wantOffset {
+				t.Errorf("Offset() = %d, want %d", p.Offset(), tt.wantOffset)
+			}
+			if p.Limit() != tt.wantSize {
+				t.Errorf("Limit() = %d, want %d", p.Limit(), tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestNewPageResultHasMore(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     int
+		total    int64
+		wantMore bool
+	}{
+		{"没有数据", 1, 0, false},
+		{"不足一页", 1, 9, false},
+		{"恰好一页", 1, 10, false},
+		{"多出一条", 1, 11, true},
+		{"最后一页恰好填满", 2, 20, false},
+		{"超出总页数", 5, 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewPageResult[int](nil, tt.total, Paginate(tt.page, 10))
+			if result.HasMore != tt.wantMore {
+				t.Errorf("HasMore = %v, want %v", result.HasMore, tt.wantMore)
+			}
+			if result.Items == nil {
+				t.Errorf("Items 不应为 nil")
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    Cursor
+		wantErr bool
+	}{
+		{"往返编码保留纳秒", EncodeCursor(Cursor{CreatedAt: createdAt, ID: 42}), Cursor{CreatedAt: createdAt, ID: 42}, false},
+		{"不是 base64", "!!!", Cursor{}, true},
+		{"格式错误", rawCursor("abc"), Cursor{}, true},
+		{"ID 为 0", rawCursor("1714566600000000000:0"), Cursor{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if tt.wantErr {
+				var appErr *AppError
+				if !errors.As(err, &appErr) || appErr.Code != ErrCodeInvalidInput {
+					t.Fatalf("DecodeCursor(%q) error = %v, want ErrCodeInvalidInput", tt.cursor, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q) error = %v", tt.cursor, err)
+			}
+			if !got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID {
+				t.Errorf("DecodeCursor(%q) = %+v, want %+v", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
+
+// cursorRow 游标分页测试用的记录
+type cursorRow struct {
+	id        uint
+	createdAt time.Time
+}
+
+// listAfter 与仓库层的键集查询一致：按 (created_at, id) 倒序，取游标之后的 limit 条
+func listAfter(rows []cursorRow, after Cursor, limit int) []cursorRow {
+	sorted := append([]cursorRow(nil), rows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].createdAt.Equal(sorted[j].createdAt) {
+			return sorted[i].createdAt.After(sorted[j].createdAt)
+		}
+		return sorted[i].id > sorted[j].id
+	})
+
+	var page []cursorRow
+	for _, row := range sorted {
+		if after.ID != 0 && !(row.createdAt.Before(after.CreatedAt) ||
+			(row.createdAt.Equal(after.CreatedAt) && row.id < after.ID)) {
+			continue
+		}
+		page = append(page, row)
+		if len(page) == limit {
+			break
+		}
+	}
+	return page
+}
+
+func TestCursorWalkWithInserts(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		initial  int
+		pageSize int
+		sameTime bool // 所有记录创建时间相同，只能靠 ID 区分
+	}{
+		{"创建时间各不相同", 23, 5, false},
+		{"创建时间全部相同", 12, 4, true},
+		{"页大小整除总数", 10, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []cursorRow
+			nextID := uint(1)
+			insert := func(at time.Time) {
+				rows = append(rows, cursorRow{id: nextID, createdAt: at})
+				nextID++
+			}
+			for i := 0; i < tt.initial; i++ {
+				at := base.Add(time.Duration(i) * time.Millisecond)
+				if tt.sameTime {
+					at = base
+				}
+				insert(at)
+			}
+
+			seen := make(map[uint]bool)
+			var order []uint
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > tt.initial {
+					t.Fatalf("分页未结束")
+				}
+				var after Cursor
+				if cursor != "" {
+					var err error
+					if after, err = DecodeCursor(cursor); err != nil {
+						t.Fatalf("DecodeCursor() error = %v", err)
+					}
+				}
+
+				// 多查一条判断是否还有下一页，与 RoomService.ListRoomsByCursor 一致
+				page := listAfter(rows, after, tt.pageSize+1)
+				next := ""
+				if len(page) > tt.pageSize {
+					page = page[:tt.pageSize]
+					last := page[len(page)-1]
+					next = EncodeCursor(Cursor{CreatedAt: last.createdAt, ID: last.id})
+				}
+				result := NewCursorResult(page, next)
+
+				for _, row := range result.Items {
+					if seen[row.id] {
+						t.Fatalf("记录 %d 重复出现", row.id)
+					}
+					seen[row.id] = true
+					order = append(order, row.id)
+				}
+				if !result.HasMore {
+					break
+				}
+				cursor = result.NextCursor
+
+				// 翻页之间插入更新的记录，不应影响后续页
+				insert(base.Add(time.Hour + time.Duration(pages)*time.Millisecond))
+			}
+
+			if len(order) != tt.initial {
+				t.Fatalf("遍历到 %d 条记录, want %d", len(order), tt.initial)
+			}
+			for id := uint(1); id <= uint(tt.initial); id++ {
+				if !seen[id] {
+					t.Errorf("记录 %d 被跳过", id)
+				}
+			}
+		})
+	}
+}
+
+// rawCursor 将原始内容直接编码为游标，用于构造格式错误的游标
+func rawCursor(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy 密码策略
+type PasswordPolicy struct {
+	RequireClasses bool // 要求包含大小写字母、数字和特殊字符
+	MinScore       int  // 最低强度评分（0-4），0 表示不检查
+}
+
+// Check 按策略检查密码，不满足时返回 ErrCodeInvalidInput
+func (p PasswordPolicy) Check(password string) error {
+	if p.RequireClasses && !ValidatePassword(password) {
+		return NewError(ErrCodeInvalidInput, "密码强度不足，需要包含大小写字母、数字和特殊字符")
+	}
+	if !p.RequireClasses && (len(password) < 8 || len(password) > 32) {
+		return NewError(ErrCodeInvalidInput, "密码长度需要在 8 到 32 个字符之间")
+	}
+	if p.MinScore > 0 && PasswordScore(password) < p.MinScore {
+		return NewError(ErrCodeInvalidInput, "密码过于容易被猜到，请避免常见单词、重复字符和连续字符")
+	}
+	return nil
+}
+
+// commonPasswordWords 常见密码片段（已转为小写并还原 leet 替换），命中时按字典猜测计算
+var commonPasswordWords = []string{
+	"password", "passwd", "qwerty", "qwertyuiop", "asdfgh", "asdfghjkl", "zxcvbn", "zxcvbnm",
+	"1qaz2wsx", "qazwsx", "letmein", "welcome", "admin", "administrator", "login", "master",
+	"monkey", "dragon", "football", "baseball", "iloveyou", "sunshine", "princess", "shadow",
+	"superman", "batman", "trustno", "secret", "hello", "freedom", "whatever", "starwars",
+	"computer", "internet", "game", "gamer", "player", "changeme", "default", "abc",
+}
+
+// passwordDictionaryBits 命中常见片段时的猜测位数
+var passwordDictionaryBits = math.Log2(float64(len(commonPasswordWords)))
+
+// leetReplacer 将常见 leet 替换还原为字母，用于匹配常见片段
+var leetReplacer = strings.NewReplacer("@", "a", "4", "a", "0", "o", "1", "i", "!", "i", "3", "e", "$", "s", "5", "s", "7", "t", "+", "t")
+
+// PasswordScore 估算密码强度评分（0-4），参照 zxcvbn 的评分区间
+// 常见单词、重复字符和连续字符只计少量猜测位数，其余字符按所属字符集计算
+func PasswordScore(password string) int {
+	bits := passwordGuessBits(password)
+	switch {
+	case bits < 10:
+		return 0
+	case bits < 20:
+		return 1
+	case bits < 27:
+		return 2
+	case bits < 33:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// passwordGuessBits 估算猜中密码所需的猜测次数（以 2 为底的对数）
+func passwordGuessBits(password string) float64 {
+	runes := []rune(password)
+	normalized := []rune(leetReplacer.Replace(strings.ToLower(password)))
+	if len(normalized) != len(runes) {
+		// 替换表均为单字符映射，长度不一致时说明存在多字节大小写变化，不做字典匹配
+		normalized = nil
+	}
+
+	var bits float64
+	for i := 0; i < len(runes); {
+		if n := matchCommonWord(normalized, i); n > 0 {
+			bits += passwordDictionaryBits
+			if hasUpper(runes[i : i+n]) {
+				bits++
+			}
+			i += n
+			continue
+		}
+
+		if i > 0 && isPatternStep(runes[i-1], runes[i]) {
+			bits++
+			i++
+			continue
+		}
+
+		bits += math.Log2(float64(charsetSize(runes[i])))
+		i++
+	}
+	return bits
+}
+
+// matchCommonWord 返回从 start 开始命中的最长常见片段长度，未命中返回 0
+func matchCommonWord(normalized []rune, start int) int {
+	if normalized == nil {
+		return 0
+	}
+	rest := string(normalized[start:])
+	longest := 0
+	for _, word := range commonPasswordWords {
+		if n := len([]rune(word)); n > longest && strings.HasPrefix(rest, word) {
+			longest = n
+		}
+	}
+	return longest
+}
+
+// isPatternStep 当前字符是否为前一字符的重复或连续（如 aa、ab、21），忽略大小写
+func isPatternStep(prev, cur rune) bool {
+	diff := unicode.ToLower(cur) - unicode.ToLower(prev)
+	return diff >= -1 && diff <= 1
+}
+
+// charsetSize 字符所属字符集的大小
+func charsetSize(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return 26
+	case r >= '0' && r <= '9':
+		return 10
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+// hasUpper 是否包含大写字母
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  bool
+	}{
+		{"满足字符类别", PasswordPolicy{RequireClasses: true}, "Str0ng!Pass", false},
+		{"缺少特殊字符", PasswordPolicy{RequireClasses: true}, "Str0ngPass", true},
+		{"不要求类别时只检查长度", PasswordPolicy{}, "abcdefgh", false},
+		{"长度不足", PasswordPolicy{}, "abc", true},
+		{"满足类别但是常见密码", PasswordPolicy{RequireClasses: true, MinScore: 3}, "P@ssw0rd1!", true},
+		{"满足类别但是重复字符", PasswordPolicy{RequireClasses: true, MinScore: 3}, "Aa1!aaaa", true},
+		{"满足类别但是连续字符", PasswordPolicy{RequireClasses: true, MinScore: 3}, "Abcdefg1!", true},
+		{"满足类别且足够随机", PasswordPolicy{RequireClasses: true, MinScore: 3}, "x7#Kq9!mZt2v", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q) error = %v, wantErr %v (score %d)", tt.password, err, tt.wantErr, PasswordScore(tt.password))
+			}
+		})
+	}
+}
+
+func TestPasswordScore(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"aaaaaaaa", 1},
+		{"12345678", 1},
+		{"password", 0},
+		{"x7#Kq9!mZt2v", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := PasswordScore(tt.password); got != tt.want {
+				t.Errorf("PasswordScore(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCombiningMarks 单个字符后允许连续出现的组合字符数，超出部分丢弃（防止叠加字符刷屏）
+const maxCombiningMarks = 2
+
+// htmlTagPattern 匹配类似 HTML 标签、注释或实体的内容
+var htmlTagPattern = regexp.MustCompile(`<\s*[a-zA-Z!/?]|&#?[a-zA-Z0-9]+;`)
+
+// TextRule 用户可控展示字段的清洗规则
+type TextRule struct {
+	Field     string // 字段名，作为字段级错误的键
+	Label     string // 字段的展示名称，用于错误提示
+	MaxLength int    // 最大字符数（按 Unicode 字符计），<= 0 表示不限制
+	Multiline bool   // 是否保留换行
+	AllowHTML bool   // 是否允许 HTML 标签
+}
+
+// SanitizeText 清洗用户输入的展示文本：去除控制字符和不可见格式字符，限制连续的组合字符，
+// 去除首尾空白，然后校验长度和 HTML。超长或包含 HTML 时返回 ErrCodeInvalidInput，不做截断
+func SanitizeText(value string, rule TextRule) (string, error) {
+	if !utf8.ValidString(value) {
+		return "", fieldError(rule, "包含无效字符")
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+	combining := 0
+	for _, r := range value {
+		switch {
+		case r == '\n' || r == '\t':
+			if !rule.Multiline {
+				r = ' '
+			}
+		case r == '\r':
+			continue
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r) && r != '\u200d':
+			// 保留零宽连接符，避免破坏组合 emoji
+			continue
+		}
+
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+			combining++
+			if combining > maxCombiningMarks {
+				continue
+			}
+		} else {
+			combining = 0
+		}
+		b.WriteRune(r)
+	}
+	cleaned := strings.TrimSpace(b.String())
+
+	if rule.MaxLength > 0 && utf8.RuneCountInString(cleaned) > rule.MaxLength {
+		return "", fieldError(rule, fmt.Sprintf("长度不能超过 %d 个字符", rule.MaxLength))
+	}
+	if !rule.AllowHTML && htmlTagPattern.MatchString(cleaned) {
+		return "", fieldError(rule, "不能包含 HTML 内容")
+	}
+	return cleaned, nil
+}
+
+// ValidateMaxLength 校验字段长度（按 Unicode 字符计）不超过 max，用于写入数据库前的预校验
+func ValidateMaxLength(field, label, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return fieldError(TextRule{Field: field, Label: label}, fmt.Sprintf("长度不能超过 %d 个字符", max))
+	}
+	return nil
+}
+
+// fieldError 创建单个字段的校验错误
+func fieldError(rule TextRule, message string) *AppError {
+	return NewValidationError(rule.Label+message, map[string]string{rule.Field: message})
+}
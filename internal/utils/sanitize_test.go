@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	nickname := TextRule{Field: "nickname", Label: "昵称", MaxLength: 10}
+	bio := TextRule{Field: "bio", Label: "简介", MaxLength: 20, Multiline: true}
+
+	tests := []struct {
+		name    string
+		value   string
+		rule    TextRule
+		want    string
+		wantErr bool
+	}{
+		{"普通文本", "玩家一号", nickname, "玩家一号", false},
+		{"去除首尾空白", "  alice  ", nickname, "alice", false},
+		{"去除控制字符", "al\x00i\x1bce", nickname, "alice", false},
+		{"去除零宽字符", "ali\u200bce\u202e", nickname, "alice", false},
+		{"保留零宽连接符", "\U0001F468\u200d\U0001F469", nickname, "\U0001F468\u200d\U0001F469", false},
+		{"单行字段换行替换为空格", "a\nb\tc", nickname, "a b c", false},
+		{"多行字段保留换行", "第一行\r\n第二行", bio, "第一行\n第二行", false},
+		{"限制连续组合字符", "e\u0301\u0301\u0301\u0301", nickname, "e\u0301\u0301", false},
+		{"按字符计长度", strings.Repeat("字", 10), nickname, strings.Repeat("字", 10), false},
+		{"超出长度", strings.Repeat("a", 11), nickname, "", true},
+		{"清洗后再校验长度", strings.Repeat("a", 10) + "\x00\x00", nickname, strings.Repeat("a", 10), false},
+		{"包含 HTML 标签", "<script>", nickname, "", true},
+		{"包含 HTML 实体", "a&lt;b", nickname, "", true},
+		{"比较符号不是标签", "1 < 2", nickname, "1 < 2", false},
+		{"无效的 UTF-8", "a\xffb", nickname, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeText(tt.value, tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SanitizeText(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err != nil {
+				var appErr *AppError
+				if !errors.As(err, &appErr) || appErr.Fields[tt.rule.Field] == "" {
+					t.Errorf("SanitizeText(%q) error = %v, want field error for %q", tt.value, err, tt.rule.Field)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SanitizeText(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMaxLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		max     int
+		wantErr bool
+	}{
+		{"未超出", "房间", 2, false},
+		{"超出", "房间名", 2, true},
+		{"按字符而非字节计", strings.Repeat("房", 50), 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMaxLength("name", "房间名", tt.value, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMaxLength(%q, %d) error = %v, wantErr %v", tt.value, tt.max, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/game-apps/pkg/cache"
+	"github.com/game-apps/pkg/database"
+)
+
+// DefaultRetryAfter 可重试错误建议的默认重试间隔
+const DefaultRetryAfter = time.Second
+
+// IsTransient 判断错误是否为数据库或缓存的瞬时错误（死锁、连接中断、缓存熔断等），重试可能成功
+func IsTransient(err error) bool {
+	return database.IsTransient(err) || cache.IsTransient(err)
+}
+
+// NewInternalError 创建内部错误，底层错误为瞬时错误时标记为可重试
+func NewInternalError(message string, err error) *AppError {
+	appErr := NewErrorWithErr(ErrCodeInternal, message, err)
+	if IsTransient(err) {
+		appErr.Retryable = true
+		appErr.RetryAfter = DefaultRetryAfter
+	}
+	return appErr
+}
@@ -5,7 +5,7 @@ import (
 	"unicode"
 )
 
-// ValidateUsername 验证用户名
+// ValidateUsername 验证用户名，格式限制（最长 20）比数据库列（50）更严格
 func ValidateUsername(username string) bool {
 	if len(username) < 3 || len(username) > 20 {
 		return false
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// 缓存加载锁参数
+const (
+	loadLockTTL      = 5 * time.Second       // 加载锁的最长持有时间，防止加载方崩溃后锁不释放
+	loadPollInterval = 50 * time.Millisecond // 未拿到加载锁时轮询缓存的间隔
+)
+
+// GetOrLoad 读取缓存，未命中时调用 loader 加载并写入缓存（cache-aside）
+// 同一个键同时未命中时只有拿到加载锁的调用方执行 loader，其他调用方等待缓存写入，防止缓存击穿
+// 缓存不可用或读写失败时直接调用 loader，loader 返回错误时不写入缓存
+func GetOrLoad[T any](ctx context.Context, c *Client, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	if value, ok := getCached[T](ctx, c, key); ok {
+		return value, nil
+	}
+
+	lockKey := key + ":loading"
+	acquired, err := c.SetNX(ctx, lockKey, 1, loadLockTTL)
+	if err != nil {
+		return loader(ctx)
+	}
+	if !acquired {
+		// 其他调用方正在加载，等待其写入缓存，超时后自行加载
+		deadline := time.Now().Add(loadLockTTL)
+		ticker := time.NewTicker(loadPollInterval)
+		defer ticker.Stop()
+		for time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				var zero T
+				return zero, ctx.Err()
+			case <-ticker.C:
+			}
+			if value, ok := getCached[T](ctx, c, key); ok {
+				return value, nil
+			}
+		}
+		return loader(ctx)
+	}
+	defer c.Del(context.WithoutCancel(ctx), lockKey)
+
+	value, err := loader(ctx)
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil {
+		c.Set(ctx, key, data, ttl)
+	}
+	return value, nil
+}
+
+// getCached 读取并解析缓存值，未命中、缓存不可用或解析失败时返回 false
+func getCached[T any](ctx context.Context, c *Client, key string) (T, bool) {
+	var value T
+	raw, err := c.Get(ctx, key)
+	if err != nil {
+		return value, false
+	}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
@@ -0,0 +1,109 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/game-apps/internal/testutil"
+	"github.com/game-apps/pkg/cache"
+)
+
+type cachedItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	errLoad := errors.New("db down")
+
+	tests := []struct {
+		name      string
+		cached    string // 预先写入的缓存值，为空表示未命中
+		loadErr   error
+		want      cachedItem
+		wantErr   error
+		wantLoads int32
+		wantCache bool // 调用后缓存中是否有值
+	}{
+		{"命中缓存", `{"name":"cached","count":1}`, nil, cachedItem{"cached", 1}, nil, 0, true},
+		{"未命中时加载并写入缓存", "", nil, cachedItem{"loaded", 2}, nil, 1, true},
+		{"缓存值无法解析时重新加载", "not-json", nil, cachedItem{"loaded", 2}, nil, 1, true},
+		{"加载失败不写入缓存", "", errLoad, cachedItem{}, errLoad, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mr := testutil.NewCacheClient(t)
+			if tt.cached != "" {
+				mr.Set("item", tt.cached)
+			}
+
+			var loads int32
+			got, err := cache.GetOrLoad(ctx, client, "item", time.Minute, func(ctx context.Context) (cachedItem, error) {
+				atomic.AddInt32(&loads, 1)
+				return cachedItem{"loaded", 2}, tt.loadErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cache.GetOrLoad() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("cache.GetOrLoad() = %+v, want %+v", got, tt.want)
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("loader 调用 %d 次, want %d", loads, tt.wantLoads)
+			}
+			if mr.Exists("item") != tt.wantCache {
+				t.Errorf("缓存存在 = %v, want %v", mr.Exists("item"), tt.wantCache)
+			}
+			if mr.Exists("item:loading") {
+				t.Error("加载锁未释放")
+			}
+			if tt.wantCache && tt.cached == "" && mr.TTL("item") != time.Minute {
+				t.Errorf("TTL = %v, want 1m", mr.TTL("item"))
+			}
+		})
+	}
+}
+
+func TestGetOrLoadConcurrentMisses(t *testing.T) {
+	client, _ := testutil.NewCacheClient(t)
+	ctx := context.Background()
+
+	var loads int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (cachedItem, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return cachedItem{"loaded", 1}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]cachedItem, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = cache.GetOrLoad(ctx, client, "item", time.Minute, loader)
+		}(i)
+	}
+
+	// 等所有调用方都未命中后再完成加载
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if loads != 1 {
+		t.Errorf("loader 调用 %d 次, want 1", loads)
+	}
+	for i := range results {
+		if errs[i] != nil || results[i].Name != "loaded" {
+			t.Errorf("调用方 %d: %+v, %v", i, results[i], errs[i])
+		}
+	}
+}
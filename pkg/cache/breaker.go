@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCacheUnavailable 熔断器打开时返回的错误，表示 Redis 暂不可用
+var ErrCacheUnavailable = errors.New("cache unavailable")
+
+// 熔断器默认参数
+const (
+	defaultFailureThreshold = 5
+	defaultBreakerCooldown  = 5 * time.Second
+)
+
+// breaker 简单熔断器：连续失败达到阈值后打开，由后台探测恢复后关闭
+type breaker struct {
+	mu        sync.Mutex
+	open      bool
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	ping      func(ctx context.Context) error
+	done      chan struct{}
+}
+
+func newBreaker(threshold int, cooldown time.Duration, ping func(ctx context.Context) error) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		ping:      ping,
+		done:      make(chan struct{}),
+	}
+}
+
+// allow 检查是否允许执行命令
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.open {
+		return ErrCacheUnavailable
+	}
+	return nil
+}
+
+// record 记录命令执行结果，只有连接类的临时错误计入熔断，命令和脚本错误不计入
+func (b *breaker) record(err error) {
+	if !IsTransient(err) {
+		b.mu.Lock()
+		b.failures = 0
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if !b.open && b.failures >= b.threshold {
+		b.open = true
+		go b.probe()
+	}
+}
+
+// probe 熔断打开后定期探测 Redis，恢复后关闭熔断器
+func (b *breaker) probe() {
+	ticker := time.NewTicker(b.cooldown)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), b.cooldown)
+			err := b.ping(ctx)
+			cancel()
+			if err == nil {
+				b.mu.Lock()
+				b.open = false
+				b.failures = 0
+				b.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// isOpen 熔断器是否打开
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// reset 关闭熔断器并清零失败计数，用于替换连接后立即恢复服务
+// 正在运行的后台探测会在下一次探测成功后自行退出
+func (b *breaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.open = false
+	b.failures = 0
+}
+
+// stop 停止后台探测
+func (b *breaker) stop() {
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+}
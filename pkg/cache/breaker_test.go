@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerRecord(t *testing.T) {
+	errConn := &net.OpError{Op: "read", Err: errors.New("connection reset")}
+	errCommand := replyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+	tests := []struct {
+		name     string
+		results  []error
+		wantOpen bool
+	}{
+		{"连续连接失败达到阈值", []error{errConn, errConn, errConn}, true},
+		{"未达到阈值", []error{errConn, errConn}, false},
+		{"成功后重新计数", []error{errConn, errConn, nil, errConn, errConn}, false},
+		{"调用方取消不计入", []error{errConn, errConn, context.Canceled, errConn}, false},
+		{"命令错误不计入", []error{errCommand, errCommand, errCommand, errCommand}, false},
+		{"命令错误清零连续失败", []error{errConn, errConn, errCommand, errConn}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(3, time.Hour, func(ctx context.Context) error { return errConn })
+			defer b.stop()
+
+			for _, err := range tt.results {
+				b.record(err)
+			}
+			if got := b.isOpen(); got != tt.wantOpen {
+				t.Errorf("isOpen() = %v, want %v", got, tt.wantOpen)
+			}
+			if err := b.allow(); (err != nil) != tt.wantOpen {
+				t.Errorf("allow() error = %v, wantOpen %v", err, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestBreakerRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	b := newBreaker(1, 5*time.Millisecond, func(ctx context.Context) error {
+		if healthy.Load() {
+			return nil
+		}
+		return io.EOF
+	})
+	defer b.stop()
+
+	b.record(io.EOF)
+	if !errors.Is(b.allow(), ErrCacheUnavailable) {
+		t.Fatalf("连接失败后熔断器应打开")
+	}
+
+	// 探测失败时保持打开
+	time.Sleep(20 * time.Millisecond)
+	if !b.isOpen() {
+		t.Fatalf("Redis 未恢复时熔断器应保持打开")
+	}
+
+	healthy.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for b.isOpen() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Redis 恢复后熔断器未关闭")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := b.allow(); err != nil {
+		t.Errorf("恢复后 allow() error = %v", err)
+	}
+}
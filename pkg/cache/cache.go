@@ -2,126 +2,496 @@ package cache
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// Client Redis 客户端封装
+// defaultDialTimeout 未设置 DialTimeout 时建立连接后检查连通性的超时，与 go-redis 的默认拨号超时一致
+const defaultDialTimeout = 5 * time.Second
+
+// Options Redis 连接参数，未设置（零值）的参数使用 go-redis 的默认值
+type Options struct {
+	Addr            string
+	Password        string
+	DB              int
+	PoolSize        int
+	MinIdleConns    int
+	MaxRetries      int           // 命令失败后的最大重试次数，0 为默认（3 次），-1 表示不重试
+	DialTimeout     time.Duration // 拨号超时，同时作为建立连接后检查连通性的超时，0 为默认（5 秒）
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	PoolTimeout     time.Duration // 连接池耗尽时等待空闲连接的时间，0 为默认（ReadTimeout + 1s）
+	ConnMaxIdleTime time.Duration // 空闲连接的最长保留时间，0 为默认（30 分钟），-1 表示不回收
+	CommandTimeout  time.Duration // 调用方 context 没有截止时间时单条命令的默认超时，0 表示不限制
+}
+
+// Client Redis 客户端封装，底层连接可通过 Reconnect 在运行时替换
 type Client struct {
-	client *redis.Client
+	mu      sync.RWMutex
+	client  *redis.Client
+	pending *sync.WaitGroup // 当前连接上正在执行的命令
+	breaker *breaker
 }
 
 // NewClient 创建 Redis 客户端
-func NewClient(addr, password string, db, poolSize, minIdleConns int, dialTimeout, readTimeout, writeTimeout time.Duration) (*Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     password,
-		DB:           db,
-		PoolSize:     poolSize,
-		MinIdleConns: minIdleConns,
-		DialTimeout:  dialTimeout,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
+func NewClient(opts Options) (*Client, error) {
+	rdb, err := dial(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{client: rdb, pending: &sync.WaitGroup{}}
+	c.breaker = newBreaker(defaultFailureThreshold, defaultBreakerCooldown, func(ctx context.Context) error {
+		rdb, release := c.acquire()
+		defer release()
+		return rdb.Ping(ctx).Err()
 	})
+	return c, nil
+}
+
+// redisOptions 将连接参数转换为 go-redis 的参数
+func redisOptions(opts Options) *redis.Options {
+	return &redis.Options{
+		Addr:            opts.Addr,
+		Password:        opts.Password,
+		DB:              opts.DB,
+		PoolSize:        opts.PoolSize,
+		MinIdleConns:    opts.MinIdleConns,
+		MaxRetries:      opts.MaxRetries,
+		DialTimeout:     opts.DialTimeout,
+		ReadTimeout:     opts.ReadTimeout,
+		WriteTimeout:    opts.WriteTimeout,
+		PoolTimeout:     opts.PoolTimeout,
+		ConnMaxIdleTime: opts.ConnMaxIdleTime,
+		// go-redis 默认忽略 context 的截止时间，启用默认超时时需要同时让读写遵守 context
+		ContextTimeoutEnabled: opts.CommandTimeout > 0,
+	}
+}
+
+// dial 创建底层客户端并确认可以连通
+func dial(opts Options) (*redis.Client, error) {
+	rdb := redis.NewClient(redisOptions(opts))
+	if opts.CommandTimeout > 0 {
+		rdb.AddHook(commandTimeoutHook{timeout: opts.CommandTimeout})
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	timeout := opts.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
 		return nil, err
 	}
+	return rdb, nil
+}
 
-	return &Client{client: rdb}, nil
+// acquire 获取当前的底层客户端，命令执行完后需调用 release
+// 在此期间发生的 Reconnect 会等待 release 后才关闭旧客户端
+func (c *Client) acquire() (*redis.Client, func()) {
+	c.mu.RLock()
+	rdb, pending := c.client, c.pending
+	pending.Add(1)
+	c.mu.RUnlock()
+	return rdb, pending.Done
+}
+
+// Reconnect 使用新的参数建立连接并替换底层客户端，新连接不可用时保留原连接并返回错误
+// 替换后的命令使用新连接，替换前已开始的命令在旧连接上执行完成后再关闭旧连接
+// 通过 Client() 获取的原始客户端和已建立的订阅不会迁移，调用方需重新获取
+func (c *Client) Reconnect(opts Options) error {
+	rdb, err := dial(opts)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old, pending := c.client, c.pending
+	c.client, c.pending = rdb, &sync.WaitGroup{}
+	c.mu.Unlock()
+
+	c.breaker.reset()
+	go func() {
+		pending.Wait()
+		old.Close()
+	}()
+	return nil
+}
+
+// Available Redis 是否可用（熔断器未打开）
+func (c *Client) Available() bool {
+	return !c.breaker.isOpen()
 }
 
 // Get 获取值
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
-	return c.client.Get(ctx, key).Result()
+	if err := c.breaker.allow(); err != nil {
+		return "", err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.Get(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // Set 设置值
 func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return c.client.Set(ctx, key, value, expiration).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.Set(ctx, key, value, expiration).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // Del 删除键
 func (c *Client) Del(ctx context.Context, keys ...string) error {
-	return c.client.Del(ctx, keys...).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.Del(ctx, keys...).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // Exists 检查键是否存在
 func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
-	return c.client.Exists(ctx, keys...).Result()
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.Exists(ctx, keys...).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // Expire 设置过期时间
 func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	return c.client.Expire(ctx, key, expiration).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.Expire(ctx, key, expiration).Err()
+	c.breaker.record(err)
+	return err
+}
+
+// TTL 获取键的剩余过期时间，键不存在时返回 -2ns，没有过期时间时返回 -1ns（与 go-redis 一致）
+func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.TTL(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // HGet 获取哈希字段值
 func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
-	return c.client.HGet(ctx, key, field).Result()
+	if err := c.breaker.allow(); err != nil {
+		return "", err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.HGet(ctx, key, field).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // HSet 设置哈希字段值
 func (c *Client) HSet(ctx context.Context, key string, values ...interface{}) error {
-	return c.client.HSet(ctx, key, values...).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.HSet(ctx, key, values...).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // HGetAll 获取所有哈希字段
 func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	return c.client.HGetAll(ctx, key).Result()
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.HGetAll(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// HGetAllBatch 通过管道批量获取多个哈希的所有字段，结果与 keys 一一对应，不存在的键返回空 map
+func (c *Client) HGetAllBatch(ctx context.Context, keys []string) ([]map[string]string, error) {
+	if len(keys) == 0 {
+		return []map[string]string{}, nil
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	rdb, release := c.acquire()
+	defer release()
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	c.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, len(keys))
+	for i, cmd := range cmds {
+		results[i] = cmd.Val()
+	}
+	return results, nil
 }
 
 // HDel 删除哈希字段
 func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
-	return c.client.HDel(ctx, key, fields...).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.HDel(ctx, key, fields...).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // SAdd 添加集合成员
 func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) error {
-	return c.client.SAdd(ctx, key, members...).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.SAdd(ctx, key, members...).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // SRem 删除集合成员
 func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) error {
-	return c.client.SRem(ctx, key, members...).Err()
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.SRem(ctx, key, members...).Err()
+	c.breaker.record(err)
+	return err
 }
 
 // SMembers 获取集合所有成员
 func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
-	return c.client.SMembers(ctx, key).Result()
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.SMembers(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // SIsMember 检查成员是否在集合中
 func (c *Client) SIsMember(ctx context.Context, key, member string) (bool, error) {
-	return c.client.SIsMember(ctx, key, member).Result()
+	if err := c.breaker.allow(); err != nil {
+		return false, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.SIsMember(ctx, key, member).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// SMIsMember 批量检查成员是否在集合中，结果与 members 一一对应
+func (c *Client) SMIsMember(ctx context.Context, key string, members ...interface{}) ([]bool, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.SMIsMember(ctx, key, members...).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// SScan 增量迭代集合成员
+func (c *Client) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	members, next, err := rdb.SScan(ctx, key, cursor, match, count).Result()
+	c.breaker.record(err)
+	return members, next, err
+}
+
+// Scan 增量迭代匹配 match 的键，避免 KEYS 阻塞 Redis
+func (c *Client) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	keys, next, err := rdb.Scan(ctx, cursor, match, count).Result()
+	c.breaker.record(err)
+	return keys, next, err
+}
+
+// SCard 获取集合成员数量
+func (c *Client) SCard(ctx context.Context, key string) (int64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.SCard(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// ZAdd 添加有序集合成员，成员已存在时更新分数
+func (c *Client) ZAdd(ctx context.Context, key string, score float64, member interface{}) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+	c.breaker.record(err)
+	return err
+}
+
+// ZRem 移除有序集合成员
+func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	err := rdb.ZRem(ctx, key, members...).Err()
+	c.breaker.record(err)
+	return err
+}
+
+// ZRangeByScore 按分数范围获取有序集合成员（升序），count <= 0 表示不限制数量
+func (c *Client) ZRangeByScore(ctx context.Context, key, min, max string, count int64) ([]string, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	opt := &redis.ZRangeBy{Min: min, Max: max}
+	if count > 0 {
+		opt.Count = count
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.ZRangeByScore(ctx, key, opt).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// Incr 将键的值加一并返回新值，键不存在时从 0 开始
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.Incr(ctx, key).Result()
+	c.breaker.record(err)
+	return result, err
+}
+
+// LRange 获取列表指定范围的元素，stop 为 -1 表示到末尾
+func (c *Client) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.LRange(ctx, key, start, stop).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // SetNX 设置键值（仅当键不存在时）
 func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
-	return c.client.SetNX(ctx, key, value, expiration).Result()
+	if err := c.breaker.allow(); err != nil {
+		return false, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.SetNX(ctx, key, value, expiration).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
-// Publish 发布消息
-func (c *Client) Publish(ctx context.Context, channel string, message interface{}) error {
-	return c.client.Publish(ctx, channel, message).Err()
+// Publish 发布消息，返回收到消息的订阅者数
+func (c *Client) Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	receivers, err := rdb.Publish(ctx, channel, message).Result()
+	c.breaker.record(err)
+	return receivers, err
+}
+
+// Eval 执行 Lua 脚本
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	rdb, release := c.acquire()
+	defer release()
+	result, err := rdb.Eval(ctx, script, keys, args...).Result()
+	c.breaker.record(err)
+	return result, err
 }
 
 // Subscribe 订阅频道
 func (c *Client) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
-	return c.client.Subscribe(ctx, channels...)
+	rdb, release := c.acquire()
+	defer release()
+	return rdb.Subscribe(ctx, channels...)
 }
 
 // Close 关闭连接
 func (c *Client) Close() error {
+	c.breaker.stop()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.client.Close()
 }
 
-// Client 获取原始客户端
+// Client 获取当前的原始客户端，Reconnect 后旧客户端会被关闭，不要长期持有
 func (c *Client) Client() *redis.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.client
 }
 
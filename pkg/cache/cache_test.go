@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func testOptions(addr string) Options {
+	return Options{
+		Addr:         addr,
+		PoolSize:     10,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	}
+}
+
+func TestRedisOptions(t *testing.T) {
+	opts := Options{
+		Addr:            "redis:6379",
+		Password:        "secret",
+		DB:              2,
+		PoolSize:        50,
+		MinIdleConns:    5,
+		MaxRetries:      -1,
+		DialTimeout:     time.Second,
+		ReadTimeout:     2 * time.Second,
+		WriteTimeout:    3 * time.Second,
+		PoolTimeout:     4 * time.Second,
+		ConnMaxIdleTime: 5 * time.Minute,
+		CommandTimeout:  500 * time.Millisecond,
+	}
+	got := redisOptions(opts)
+	if got.Addr != opts.Addr || got.Password != opts.Password || got.DB != opts.DB ||
+		got.PoolSize != opts.PoolSize || got.MinIdleConns != opts.MinIdleConns || got.MaxRetries != opts.MaxRetries {
+		t.Errorf("redisOptions() = %+v", got)
+	}
+	if got.DialTimeout != opts.DialTimeout || got.ReadTimeout != opts.ReadTimeout || got.WriteTimeout != opts.WriteTimeout ||
+		got.PoolTimeout != opts.PoolTimeout || got.ConnMaxIdleTime != opts.ConnMaxIdleTime {
+		t.Errorf("redisOptions() timeouts = %+v", got)
+	}
+	if !got.ContextTimeoutEnabled {
+		t.Error("设置命令超时时应遵守 context 截止时间")
+	}
+
+	// 未设置的参数保持零值，由 go-redis 使用默认值
+	if got := redisOptions(Options{Addr: "redis:6379"}); got.MaxRetries != 0 || got.PoolTimeout != 0 || got.ContextTimeoutEnabled {
+		t.Errorf("redisOptions() defaults = %+v", got)
+	}
+}
+
+func TestNewClientZeroOptions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	// 只设置地址时应使用默认超时，而不是立即过期的连通性检查
+	client, err := NewClient(Options{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("零值参数创建客户端失败: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Set(context.Background(), "key", "value", 0); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	ctx := context.Background()
+	opts := testOptions(miniredis.RunT(t).Addr())
+	opts.CommandTimeout = 50 * time.Millisecond
+	client, err := NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.Set(ctx, "key", "v", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// 没有截止时间的命令使用默认超时，已有截止时间的保持不变
+	hook := commandTimeoutHook{timeout: time.Minute}
+	timeoutCtx, cancel := hook.withTimeout(ctx)
+	defer cancel()
+	if deadline, ok := timeoutCtx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("withTimeout() deadline = %v, %v", deadline, ok)
+	}
+	shortCtx, shortCancel := context.WithTimeout(ctx, time.Second)
+	defer shortCancel()
+	if got, _ := hook.withTimeout(shortCtx); got != shortCtx {
+		t.Error("已有截止时间的 context 不应被替换")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	ctx := context.Background()
+	oldServer := miniredis.RunT(t)
+	client, err := NewClient(testOptions(oldServer.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	newServer := miniredis.RunT(t)
+	oldServer.Set("key", "old")
+	newServer.Set("key", "new")
+
+	// 新地址不可用时保留原连接
+	unreachable := miniredis.RunT(t)
+	addr := unreachable.Addr()
+	unreachable.Close()
+	if err := client.Reconnect(testOptions(addr)); err == nil {
+		t.Fatal("Reconnect() to unreachable address should fail")
+	}
+	if got, err := client.Get(ctx, "key"); err != nil || got != "old" {
+		t.Fatalf("Get() = %q, %v, want old", got, err)
+	}
+
+	// 替换前已开始的命令仍可在旧连接上完成
+	inFlight, release := client.acquire()
+	if err := client.Reconnect(testOptions(newServer.Addr())); err != nil {
+		t.Fatalf("Reconnect() error = %v", err)
+	}
+	if got, err := inFlight.Get(ctx, "key").Result(); err != nil || got != "old" {
+		t.Errorf("in-flight Get() = %q, %v, want old", got, err)
+	}
+	release()
+
+	if got, err := client.Get(ctx, "key"); err != nil || got != "new" {
+		t.Errorf("Get() after Reconnect = %q, %v, want new", got, err)
+	}
+	if err := client.Set(ctx, "written", "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !newServer.Exists("written") || oldServer.Exists("written") {
+		t.Error("新命令应写入新连接")
+	}
+
+	// 命令执行完后旧连接被关闭
+	deadline := time.Now().Add(time.Second)
+	for inFlight.Ping(ctx).Err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("旧连接未被关闭")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
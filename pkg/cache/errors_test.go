@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replyError 模拟 Redis 返回的错误回复
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"无错误", nil, false},
+		{"键不存在", redis.Nil, false},
+		{"调用方取消", context.Canceled, false},
+		{"熔断打开", ErrCacheUnavailable, true},
+		{"包装后的熔断错误", fmt.Errorf("获取房间状态失败: %w", ErrCacheUnavailable), true},
+		{"客户端已关闭", redis.ErrClosed, true},
+		{"超时", context.DeadlineExceeded, true},
+		{"连接断开", io.EOF, true},
+		{"网络错误", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"数据加载中", replyError("LOADING Redis is loading the dataset in memory"), true},
+		{"只读副本", replyError("READONLY You can't write against a read only replica."), true},
+		{"命令错误", replyError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"脚本错误", replyError("ERR Error running script"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// commandTimeoutHook 为没有截止时间的 context 设置默认超时，
+// 避免调用方传入 context.Background() 时命令在 Redis 卡顿期间无限等待
+// 订阅不经过该钩子，不受超时影响
+type commandTimeoutHook struct {
+	timeout time.Duration
+}
+
+func (h commandTimeoutHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h commandTimeoutHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, cancel := h.withTimeout(ctx)
+		defer cancel()
+		return next(ctx, cmd)
+	}
+}
+
+func (h commandTimeoutHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, cancel := h.withTimeout(ctx)
+		defer cancel()
+		return next(ctx, cmds)
+	}
+}
+
+func (h commandTimeoutHook) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.timeout)
+}
+
+var _ redis.Hook = commandTimeoutHook{}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"无错误", nil, false},
+		{"调用方取消", context.Canceled, false},
+		{"记录不存在", gorm.ErrRecordNotFound, false},
+		{"MySQL 死锁", &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, true},
+		{"包装后的 MySQL 死锁", fmt.Errorf("更新房间失败: %w", &mysql.MySQLError{Number: 1213}), true},
+		{"MySQL 锁等待超时", &mysql.MySQLError{Number: 1205}, true},
+		{"MySQL 唯一约束冲突", &mysql.MySQLError{Number: 1062}, false},
+		{"PostgreSQL 死锁", &pgconn.PgError{Code: "40P01"}, true},
+		{"PostgreSQL 序列化失败", &pgconn.PgError{Code: "40001"}, true},
+		{"PostgreSQL 连接异常", &pgconn.PgError{Code: "08006"}, true},
+		{"PostgreSQL 唯一约束冲突", &pgconn.PgError{Code: "23505"}, false},
+		{"连接失效", driver.ErrBadConn, true},
+		{"超时", context.DeadlineExceeded, true},
+		{"普通错误", errors.New("syntax error"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"GORM 唯一约束", gorm.ErrDuplicatedKey, true},
+		{"MySQL 重复键", &mysql.MySQLError{Number: 1062}, true},
+		{"PostgreSQL 唯一约束", &pgconn.PgError{Code: "23505"}, true},
+		{"MySQL 死锁", &mysql.MySQLError{Number: 1213}, false},
+		{"无错误", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDuplicateKey(tt.err); got != tt.want {
+				t.Errorf("IsDuplicateKey(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockName 迁移咨询锁名称
+const migrationLockName = "game_apps:migrate"
+
+// migrationLockPoll PostgreSQL 下轮询获取咨询锁的间隔
+const migrationLockPoll = 500 * time.Millisecond
+
+// ErrMigrationLockTimeout 等待迁移锁超时
+var ErrMigrationLockTimeout = errors.New("等待数据库迁移锁超时")
+
+// WithMigrationLock 持有数据库咨询锁执行 fn，多个实例同时启动时依次执行迁移
+// 咨询锁属于会话，fn 在持有锁的同一连接上执行
+func WithMigrationLock(ctx context.Context, db *gorm.DB, timeout time.Duration, fn func(db *gorm.DB) error) error {
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		unlock, err := acquireMigrationLock(ctx, conn, timeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		return fn(conn)
+	})
+}
+
+// acquireMigrationLock 获取迁移咨询锁，返回释放函数
+func acquireMigrationLock(ctx context.Context, conn *gorm.DB, timeout time.Duration) (func(), error) {
+	switch conn.Dialector.Name() {
+	case "mysql":
+		var acquired sql.NullInt64
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, int(timeout.Seconds())).Scan(&acquired).Error; err != nil {
+			return nil, fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return nil, ErrMigrationLockTimeout
+		}
+		return func() {
+			conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+		}, nil
+
+	case "postgres":
+		key := migrationLockKey()
+		deadline := time.Now().Add(timeout)
+		for {
+			var acquired bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+				return nil, fmt.Errorf("获取迁移锁失败: %w", err)
+			}
+			if acquired {
+				return func() {
+					conn.Exec("SELECT pg_advisory_unlock(?)", key)
+				}, nil
+			}
+			if time.Now().After(deadline) {
+				return nil, ErrMigrationLockTimeout
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(migrationLockPoll):
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", conn.Dialector.Name())
+	}
+}
+
+// migrationLockKey PostgreSQL 咨询锁使用的 64 位键
+func migrationLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(migrationLockName))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB 创建基于 sqlmock 的 gorm 连接
+func newMockDB(t *testing.T, driver string) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	var dialector gorm.Dialector
+	if driver == "postgres" {
+		dialector = postgres.New(postgres.Config{Conn: sqlDB})
+	} else {
+		dialector = mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true})
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestWithMigrationLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		expect  func(mock sqlmock.Sqlmock)
+		wantRun bool
+		wantErr error
+	}{
+		{
+			name:   "MySQL 持有锁执行迁移后释放",
+			driver: "mysql",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs(migrationLockName, 60).
+					WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(1))
+				mock.ExpectExec("SELECT RELEASE_LOCK").WithArgs(migrationLockName).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantRun: true,
+		},
+		{
+			name:   "MySQL 等待超时不执行迁移",
+			driver: "mysql",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT GET_LOCK").WithArgs(migrationLockName, 60).
+					WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(0))
+			},
+			wantErr: ErrMigrationLockTimeout,
+		},
+		{
+			name:   "PostgreSQL 等待其他实例释放锁后执行",
+			driver: "postgres",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(migrationLockKey()).
+					WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(false))
+				mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(migrationLockKey()).
+					WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+				mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationLockKey()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantRun: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t, tt.driver)
+			tt.expect(mock)
+
+			ran := false
+			err := WithMigrationLock(context.Background(), db, time.Minute, func(db *gorm.DB) error {
+				ran = true
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithMigrationLock() error = %v, want %v", err, tt.wantErr)
+			}
+			if ran != tt.wantRun {
+				t.Errorf("migration ran = %v, want %v", ran, tt.wantRun)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
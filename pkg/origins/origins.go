@@ -0,0 +1,123 @@
+package origins
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Matcher 跨域来源白名单，HTTP CORS 与 WebSocket 升级共用同一份配置
+// 支持的模式：
+//   - "*"                       允许任意来源
+//   - "https://example.com"     精确匹配（协议、主机、端口）
+//   - "https://*.example.com"   匹配 example.com 的任意子域名，不包括 example.com 本身
+type Matcher struct {
+	any       bool
+	exact     map[string]struct{}
+	wildcards []wildcard
+}
+
+// wildcard 子域名通配规则
+type wildcard struct {
+	scheme string
+	suffix string // 以 "." 开头的父域名，如 ".example.com"
+	port   string
+}
+
+// NewMatcher 解析白名单模式，模式格式错误时返回错误
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{exact: make(map[string]struct{})}
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		if pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			m.any = true
+			continue
+		}
+
+		scheme, host, port, err := split(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的来源 %q: %w", raw, err)
+		}
+
+		if strings.HasPrefix(host, "*.") {
+			domain := host[2:]
+			if domain == "" || strings.Contains(domain, "*") || !strings.Contains(domain, ".") {
+				return nil, fmt.Errorf("无效的通配来源 %q", raw)
+			}
+			m.wildcards = append(m.wildcards, wildcard{scheme: scheme, suffix: "." + domain, port: port})
+			continue
+		}
+		if strings.Contains(host, "*") {
+			return nil, fmt.Errorf("通配符只能用于最左侧子域名: %q", raw)
+		}
+		m.exact[join(scheme, host, port)] = struct{}{}
+	}
+	return m, nil
+}
+
+// AllowAny 是否允许任意来源
+func (m *Matcher) AllowAny() bool {
+	return m != nil && m.any
+}
+
+// Allow 检查来源是否在白名单中，来源需为 Origin 头格式（scheme://host[:port]）
+func (m *Matcher) Allow(origin string) bool {
+	if m == nil || origin == "" {
+		return false
+	}
+	if m.any {
+		return true
+	}
+
+	scheme, host, port, err := split(origin)
+	if err != nil || strings.Contains(host, "*") {
+		return false
+	}
+
+	if _, ok := m.exact[join(scheme, host, port)]; ok {
+		return true
+	}
+	for _, w := range m.wildcards {
+		if w.scheme != scheme || w.port != port {
+			continue
+		}
+		// 必须以 ".domain" 结尾且前面至少还有一级子域名，防止 evilexample.com 匹配 *.example.com
+		if len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// split 将来源拆分为小写的协议、主机和端口，默认端口归一化为空
+func split(origin string) (scheme, host, port string, err error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", "", "", fmt.Errorf("缺少协议或主机")
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", "", fmt.Errorf("来源只能包含协议、主机和端口")
+	}
+
+	scheme = strings.ToLower(u.Scheme)
+	host = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port = u.Port()
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	return scheme, host, port, nil
+}
+
+// join 拼接归一化后的来源
+func join(scheme, host, port string) string {
+	if port == "" {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + host + ":" + port
+}
@@ -0,0 +1,71 @@
+package origins
+
+import "testing"
+
+func TestMatcherAllow(t *testing.T) {
+	m, err := NewMatcher([]string{"https://example.com", "https://*.game.example.com", "http://localhost:3000"})
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"精确匹配", "https://example.com", true},
+		{"默认端口归一化", "https://example.com:443", true},
+		{"大小写不敏感", "HTTPS://Example.COM", true},
+		{"协议不同", "http://example.com", false},
+		{"端口不同", "http://localhost:3001", false},
+		{"精确匹配带端口", "http://localhost:3000", true},
+		{"通配子域名", "https://eu.game.example.com", true},
+		{"通配多级子域名", "https://a.b.game.example.com", true},
+		{"通配不包括父域名本身", "https://game.example.com", false},
+		{"后缀相同的其他域名", "https://evilgame.example.com", false},
+		{"未配置的来源", "https://attacker.com", false},
+		{"来源带路径", "https://example.com/path", false},
+		{"空来源", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Allow(tt.origin); got != tt.want {
+				t.Errorf("Allow(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherAllowAny(t *testing.T) {
+	m, err := NewMatcher([]string{"*"})
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+	if !m.AllowAny() || !m.Allow("https://anything.test") {
+		t.Errorf("通配 * 应允许任意来源")
+	}
+
+	var nilMatcher *Matcher
+	if nilMatcher.AllowAny() || nilMatcher.Allow("https://example.com") {
+		t.Errorf("未配置白名单时不应允许任何来源")
+	}
+}
+
+func TestNewMatcherInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"缺少协议", "example.com"},
+		{"通配符不在最左侧", "https://game.*.example.com"},
+		{"通配顶级域名", "https://*.com"},
+		{"包含路径", "https://example.com/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMatcher([]string{tt.pattern}); err == nil {
+				t.Errorf("NewMatcher(%q) 应返回错误", tt.pattern)
+			}
+		})
+	}
+}
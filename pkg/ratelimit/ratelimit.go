@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/game-apps/pkg/cache"
+)
+
+// slidingWindowScript 基于有序集合的滑动窗口限流
+// KEYS[1]: 限流键; ARGV[1]: 窗口毫秒数; ARGV[2]: 窗口内允许的次数; ARGV[3]: 本次请求的唯一成员
+// 返回 {是否允许, 需要等待的毫秒数}
+const slidingWindowScript = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, 0}
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local retry = window
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+end
+return {0, retry}
+`
+
+// Limiter 基于 Redis 的分布式限流器（滑动窗口）
+type Limiter struct {
+	client   *cache.Client
+	prefix   string
+	failOpen bool
+}
+
+// NewLimiter 创建限流器
+// failOpen 为 true 时 Redis 不可用则放行请求，否则拒绝
+func NewLimiter(client *cache.Client, failOpen bool) *Limiter {
+	return &Limiter{
+		client:   client,
+		prefix:   "ratelimit:",
+		failOpen: failOpen,
+	}
+}
+
+// Allow 检查 key 在 window 时间窗口内是否未超过 limit 次
+// 被拒绝时 retryAfter 为距离可再次请求的时间
+// Redis 出错时返回错误，allowed 按 failOpen 策略给出
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error) {
+	if limit <= 0 || window <= 0 {
+		return true, 0, nil
+	}
+
+	result, err := l.client.Eval(ctx, slidingWindowScript, []string{l.prefix + key},
+		window.Milliseconds(), limit, requestID())
+	if err != nil {
+		return l.failOpen, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return l.failOpen, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+	allowedFlag, _ := values[0].(int64)
+	retryMillis, _ := values[1].(int64)
+
+	return allowedFlag == 1, time.Duration(retryMillis) * time.Millisecond, nil
+}
+
+// requestID 生成有序集合成员，避免同一毫秒内的请求互相覆盖
+func requestID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
+}
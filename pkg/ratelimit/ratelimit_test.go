@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/game-apps/internal/testutil"
+)
+
+// newTestLimiter 基于 miniredis 创建限流器
+func newTestLimiter(t *testing.T, failOpen bool) (*Limiter, *miniredis.Miniredis) {
+	t.Helper()
+	client, mr := testutil.NewCacheClient(t)
+	return NewLimiter(client, failOpen), mr
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+	limiter, mr := newTestLimiter(t, true)
+	ctx := context.Background()
+	now := time.Now()
+	mr.SetTime(now)
+
+	// 窗口内恰好允许 limit 次
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(ctx, "login:1.2.3.4", 3, time.Minute)
+		if err != nil || !allowed {
+			t.Fatalf("第 %d 次 Allow() = %v, %v, want allowed", i+1, allowed, err)
+		}
+	}
+
+	allowed, retryAfter, err := limiter.Allow(ctx, "login:1.2.3.4", 3, time.Minute)
+	if err != nil || allowed {
+		t.Fatalf("超出限制 Allow() = %v, %v, want denied", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("retryAfter = %v, want within window", retryAfter)
+	}
+
+	// 不同的键独立计数
+	if allowed, _, _ := limiter.Allow(ctx, "login:5.6.7.8", 3, time.Minute); !allowed {
+		t.Error("其他键不应被限流")
+	}
+
+	// 窗口即将结束时仍被拒绝，窗口过去后恢复
+	mr.SetTime(now.Add(time.Minute - time.Second))
+	if allowed, _, _ := limiter.Allow(ctx, "login:1.2.3.4", 3, time.Minute); allowed {
+		t.Error("窗口结束前不应放行")
+	}
+	mr.SetTime(now.Add(time.Minute + time.Millisecond))
+	if allowed, _, err := limiter.Allow(ctx, "login:1.2.3.4", 3, time.Minute); err != nil || !allowed {
+		t.Errorf("窗口过去后 Allow() = %v, %v, want allowed", allowed, err)
+	}
+}
+
+func TestAllowDisabled(t *testing.T) {
+	limiter, _ := newTestLimiter(t, false)
+	for _, limit := range []int{0, -1} {
+		if allowed, _, err := limiter.Allow(context.Background(), "k", limit, time.Minute); !allowed || err != nil {
+			t.Errorf("limit %d: Allow() = %v, %v, want allowed", limit, allowed, err)
+		}
+	}
+}
+
+func TestAllowRedisUnavailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		failOpen    bool
+		wantAllowed bool
+	}{
+		{"故障放行", true, true},
+		{"故障拒绝", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, mr := newTestLimiter(t, tt.failOpen)
+			mr.Close()
+
+			allowed, _, err := limiter.Allow(context.Background(), "k", 1, time.Minute)
+			if err == nil {
+				t.Fatal("Allow() error = nil, want error")
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("Allow() allowed = %v, want %v", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
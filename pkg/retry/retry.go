@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Policy 重试策略
+type Policy struct {
+	MaxAttempts    int           // 最大尝试次数（含第一次），<= 0 表示只受 context 截止时间限制
+	InitialBackoff time.Duration // 第一次失败后的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration // 等待时间上限，<= 0 表示不设上限
+}
+
+// backoff 第 attempt 次失败后的等待时间（指数退避）
+func (p Policy) backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff << uint(attempt-1)
+	if p.MaxBackoff > 0 && (wait <= 0 || wait > p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	if wait <= 0 {
+		// 没有上限且翻倍溢出时保持初始间隔
+		return p.InitialBackoff
+	}
+	return wait
+}
+
+// Do 执行 op 直到成功、尝试次数用尽或 ctx 结束
+// 每次失败后、等待之前调用 onRetry（可为 nil），最后一次失败不会调用
+// 放弃时返回最后一次的错误
+func Do(ctx context.Context, policy Policy, op func(ctx context.Context) error, onRetry func(attempt int, err error, wait time.Duration)) error {
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return fmt.Errorf("尝试 %d 次后仍然失败: %w", attempt, err)
+		}
+
+		wait := policy.backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("等待重试时超时（已尝试 %d 次）: %w", attempt, err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	errConnect := errors.New("connection refused")
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		failures     int // op 连续失败的次数
+		wantErr      bool
+		wantAttempts int
+		wantRetries  int
+	}{
+		{"第一次成功", 0, false, 1, 0},
+		{"失败两次后成功", 2, false, 3, 2},
+		{"尝试次数用尽", 5, true, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, retries := 0, 0
+			err := Do(context.Background(), policy, func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errConnect
+				}
+				return nil
+			}, func(attempt int, err error, wait time.Duration) {
+				retries++
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errConnect) {
+				t.Errorf("Do() error = %v, 应包装最后一次的错误", err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("onRetry 调用 %d 次, want %d", retries, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestDoContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	errConnect := errors.New("connection refused")
+	err := Do(ctx, Policy{InitialBackoff: time.Hour}, func(ctx context.Context) error {
+		return errConnect
+	}, nil)
+	if !errors.Is(err, errConnect) {
+		t.Errorf("Do() error = %v, 应包装最后一次的错误", err)
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{"第一次失败", Policy{InitialBackoff: 100 * time.Millisecond}, 1, 100 * time.Millisecond},
+		{"指数翻倍", Policy{InitialBackoff: 100 * time.Millisecond}, 3, 400 * time.Millisecond},
+		{"不超过上限", Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 250 * time.Millisecond}, 3, 250 * time.Millisecond},
+		{"溢出时使用上限", Policy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 80, time.Minute},
+		{"溢出且无上限时保持初始间隔", Policy{InitialBackoff: time.Second}, 80, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
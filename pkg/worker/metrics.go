@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	workerRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_runs_total",
+			Help: "Total number of background worker runs",
+		},
+		[]string{"worker", "result"},
+	)
+
+	workerPanicsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_panics_total",
+			Help: "Total number of recovered background worker panics",
+		},
+		[]string{"worker"},
+	)
+
+	workerLastSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful background worker run",
+		},
+		[]string{"worker"},
+	)
+)
+
+// 运行结果标签
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+	resultPanic   = "panic"
+)
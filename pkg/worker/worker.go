@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker 后台任务，由 Manager 按固定间隔调用 Run
+// Run 只执行一轮工作，返回错误或 panic 都会被记录，下个间隔继续执行
+type Worker interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// Status 后台任务的运行状态
+type Status struct {
+	Name                string        `json:"name"`
+	Interval            time.Duration `json:"interval"`
+	LastRunAt           *time.Time    `json:"last_run_at,omitempty"`
+	LastSuccessAt       *time.Time    `json:"last_success_at,omitempty"`
+	LastError           string        `json:"last_error,omitempty"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	Panics              int           `json:"panics"`
+	Healthy             bool          `json:"healthy"`
+}
+
+// entry 已注册的后台任务及其状态
+type entry struct {
+	worker   Worker
+	interval time.Duration
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Manager 后台任务管理器，统一启动、停止、恢复 panic 并记录运行状态
+type Manager struct {
+	logger  *zap.Logger
+	entries []*entry
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager 创建后台任务管理器
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Register 注册后台任务，需在 Start 之前调用
+func (m *Manager) Register(w Worker, interval time.Duration) {
+	m.entries = append(m.entries, &entry{
+		worker:   w,
+		interval: interval,
+		status:   Status{Name: w.Name(), Interval: interval},
+	})
+}
+
+// Start 启动所有后台任务，ctx 取消或调用 Stop 时停止
+func (m *Manager) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	for _, e := range m.entries {
+		m.wg.Add(1)
+		go m.loop(ctx, e)
+	}
+}
+
+// Stop 停止所有后台任务并等待正在执行的一轮结束
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+// Health 返回所有后台任务的运行状态，按名称排序
+func (m *Manager) Health() []Status {
+	statuses := make([]Status, 0, len(m.entries))
+	now := time.Now()
+	for _, e := range m.entries {
+		statuses = append(statuses, e.snapshot(now))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// loop 按间隔执行后台任务直到 ctx 取消
+func (m *Manager) loop(ctx context.Context, e *entry) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runOnce(ctx, e)
+		}
+	}
+}
+
+// runOnce 执行一轮后台任务，panic 会被恢复并计为失败
+func (m *Manager) runOnce(ctx context.Context, e *entry) {
+	name := e.worker.Name()
+	startedAt := time.Now()
+
+	err, panicked := func() (err error, panicked bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				panicked = true
+			}
+		}()
+		return e.worker.Run(ctx), false
+	}()
+
+	switch {
+	case panicked:
+		m.logger.Error("后台任务 panic，已恢复", zap.String("worker", name), zap.Error(err), zap.Stack("stack"))
+		workerPanicsTotal.WithLabelValues(name).Inc()
+		workerRunsTotal.WithLabelValues(name, resultPanic).Inc()
+	case err != nil:
+		m.logger.Warn("后台任务执行失败", zap.String("worker", name), zap.Error(err))
+		workerRunsTotal.WithLabelValues(name, resultError).Inc()
+	default:
+		workerRunsTotal.WithLabelValues(name, resultSuccess).Inc()
+		workerLastSuccess.WithLabelValues(name).Set(float64(startedAt.Unix()))
+	}
+
+	e.record(startedAt, err, panicked)
+}
+
+// record 记录一轮执行结果
+func (e *entry) record(at time.Time, err error, panicked bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.status.LastRunAt = &at
+	if panicked {
+		e.status.Panics++
+	}
+	if err != nil {
+		e.status.LastError = err.Error()
+		e.status.ConsecutiveFailures++
+		return
+	}
+	e.status.LastSuccessAt = &at
+	e.status.LastError = ""
+	e.status.ConsecutiveFailures = 0
+}
+
+// snapshot 获取状态副本，最近一轮失败或超过 3 个间隔未成功时视为不健康
+func (e *entry) snapshot(now time.Time) Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := e.status
+	status.Healthy = status.ConsecutiveFailures == 0
+	if status.LastSuccessAt != nil && now.Sub(*status.LastSuccessAt) > 3*e.interval {
+		status.Healthy = false
+	}
+	return status
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 测试用的单轮执行结果
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+	outcomePanic   = "panic"
+)
+
+// scriptedWorker 按预设的结果依次执行，结果用完后一直成功
+type scriptedWorker struct {
+	outcomes []string
+	runs     atomic.Int32
+}
+
+func (w *scriptedWorker) Name() string { return "scripted" }
+
+func (w *scriptedWorker) Run(ctx context.Context) error {
+	n := int(w.runs.Add(1)) - 1
+	if n >= len(w.outcomes) {
+		return nil
+	}
+	switch w.outcomes[n] {
+	case outcomeError:
+		return errors.New("run failed")
+	case outcomePanic:
+		panic("boom")
+	}
+	return nil
+}
+
+func TestRunOnceStatus(t *testing.T) {
+	tests := []struct {
+		name                string
+		outcomes            []string
+		wantHealthy         bool
+		wantFailures        int
+		wantPanics          int
+		wantLastErrorNonNil bool
+	}{
+		{"成功", []string{outcomeSuccess}, true, 0, 0, false},
+		{"失败", []string{outcomeError}, false, 1, 0, true},
+		{"panic 被恢复并计为失败", []string{outcomePanic}, false, 1, 1, true},
+		{"连续失败累计", []string{outcomeError, outcomePanic, outcomeError}, false, 3, 1, true},
+		{"成功后恢复健康", []string{outcomePanic, outcomeError, outcomeSuccess}, true, 0, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(zap.NewNop())
+			w := &scriptedWorker{outcomes: tt.outcomes}
+			m.Register(w, time.Minute)
+
+			for range tt.outcomes {
+				m.runOnce(context.Background(), m.entries[0])
+			}
+
+			status := m.Health()[0]
+			if status.Healthy != tt.wantHealthy {
+				t.Errorf("Healthy = %v, want %v", status.Healthy, tt.wantHealthy)
+			}
+			if status.ConsecutiveFailures != tt.wantFailures {
+				t.Errorf("ConsecutiveFailures = %d, want %d", status.ConsecutiveFailures, tt.wantFailures)
+			}
+			if status.Panics != tt.wantPanics {
+				t.Errorf("Panics = %d, want %d", status.Panics, tt.wantPanics)
+			}
+			if (status.LastError != "") != tt.wantLastErrorNonNil {
+				t.Errorf("LastError = %q", status.LastError)
+			}
+		})
+	}
+}
+
+func TestManagerKeepsRunningAfterPanic(t *testing.T) {
+	m := NewManager(zap.NewNop())
+	w := &scriptedWorker{outcomes: []string{outcomePanic, outcomePanic}}
+	m.Register(w, time.Millisecond)
+
+	m.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for w.runs.Load() < 4 {
+		if time.Now().After(deadline) {
+			m.Stop()
+			t.Fatalf("panic 后任务未继续执行，已执行 %d 次", w.runs.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Stop()
+
+	status := m.Health()[0]
+	if status.Panics != 2 {
+		t.Errorf("Panics = %d, want 2", status.Panics)
+	}
+	if !status.Healthy {
+		t.Errorf("panic 后成功执行应恢复健康, status = %+v", status)
+	}
+}